@@ -0,0 +1,416 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+)
+
+// Each command handles one unit, emits one record for it and reports whether the
+// unit was processed cleanly. A per-unit failure is written as a record with an
+// "error" field and does not stop the run; the returned error aborts it.
+
+type digestRecord struct {
+	Source     string               `json:"source"`
+	Digest     string               `json:"digest,omitempty"`
+	SQLType    []string             `json:"sql_type,omitempty"`
+	Complexity []sqlglot.Complexity `json:"complexity,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+func doDigest(c *config, em *emitter, u unit) (bool, error) {
+	res, err := sqlglot.ResultFor(u.SQL, c.opt)
+	rec := digestRecord{Source: u.Source, Digest: res.Digest, SQLType: res.SQLType, Complexity: res.Complexity}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return err == nil, em.emit(rec, func(w io.Writer) {
+		if err != nil {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+			return
+		}
+		fmt.Fprintln(w, res.Digest)
+		printComplexity(w, res)
+	})
+}
+
+// printComplexity writes one "-- #n TYPE metric=value ..." line per statement,
+// leaving out metrics that are zero.
+func printComplexity(w io.Writer, res sqlglot.Result) {
+	for i, c := range res.Complexity {
+		var b strings.Builder
+		fmt.Fprintf(&b, "-- #%d %s", i+1, res.SQLType[i])
+		for _, m := range []struct {
+			name string
+			v    int
+		}{
+			{"joins", c.Joins}, {"subqueries", c.Subqueries}, {"subquery_depth", c.SubqueryDepth},
+			{"ctes", c.CTEs}, {"recursive_ctes", c.RecursiveCTEs}, {"union_branches", c.UnionBranches},
+			{"window_functions", c.WindowFunctions}, {"aggregate_functions", c.AggregateFunctions},
+			{"max_in_list", c.MaxInList}, {"predicates", c.Predicates}, {"tables", len(c.Tables)},
+		} {
+			if m.v > 0 {
+				fmt.Fprintf(&b, " %s=%d", m.name, m.v)
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
+}
+
+type paramsRecord struct {
+	Source string              `json:"source"`
+	Digest string              `json:"digest,omitempty"`
+	Params []sqlglot.ExParam   `json:"params"`
+	Nested []sqlglot.NestedSQL `json:"nested,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+func doParams(c *config, em *emitter, u unit) (bool, error) {
+	res, err := sqlglot.ResultFor(u.SQL, c.opt)
+	rec := paramsRecord{Source: u.Source, Digest: res.Digest, Params: res.Params, Nested: res.Nested}
+	if rec.Params == nil {
+		rec.Params = []sqlglot.ExParam{}
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return err == nil, em.emit(rec, func(w io.Writer) {
+		if err != nil {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+			return
+		}
+		fmt.Fprintf(w, "-- %s\n%s\n", u.Source, res.Digest)
+		for _, p := range res.Params {
+			fmt.Fprintf(w, "P#%d %-10s [%d,%d): %q\n", p.Index, p.Type, p.Start, p.End, p.Value)
+		}
+		printNested(w, res.Nested, "  ")
+	})
+}
+
+// printNested lists dynamic SQL found in string params, indented one level per depth.
+func printNested(w io.Writer, nested []sqlglot.NestedSQL, indent string) {
+	for _, n := range nested {
+		fmt.Fprintf(w, "%s-- P#%d %s [%d,%d)\n%s%s\n", indent, n.ParamIndex, n.Wrapper, n.Start, n.End, indent, n.Digest)
+		for _, p := range n.Params {
+			fmt.Fprintf(w, "%sP#%d %-10s [%d,%d): %q\n", indent, p.Index, p.Type, p.Start, p.End, p.Value)
+		}
+		printNested(w, n.Nested, indent+"  ")
+	}
+}
+
+type splitRecord struct {
+	Source string `json:"source"`
+	Index  int    `json:"index"`
+	Type   string `json:"type,omitempty"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	SQL    string `json:"sql"`
+}
+
+func doSplit(c *config, em *emitter, u unit) (bool, error) {
+	stmts, err := sqlglot.Split(u.SQL, c.opt)
+	if err != nil {
+		return false, em.emit(digestRecord{Source: u.Source, Error: err.Error()}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+		})
+	}
+	for i, st := range stmts {
+		rec := splitRecord{
+			Source: u.Source,
+			Index:  i + 1,
+			Type:   st.Type,
+			Start:  st.StartByte,
+			End:    st.EndByte,
+			SQL:    strings.TrimSpace(u.SQL[st.StartByte:st.EndByte]),
+		}
+		if err := em.emit(rec, func(w io.Writer) {
+			fmt.Fprintf(w, "-- %s #%d %s [%d,%d)\n%s;\n", rec.Source, rec.Index, rec.Type, rec.Start, rec.End, rec.SQL)
+		}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+type validateRecord struct {
+	Source string          `json:"source"`
+	Valid  bool            `json:"valid"`
+	Issues []sqlglot.Issue `json:"issues,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+func doValidate(c *config, em *emitter, u unit) (bool, error) {
+	issues, err := sqlglot.Validate(u.SQL, c.opt)
+	rec := validateRecord{Source: u.Source, Valid: err == nil && len(issues) == 0, Issues: issues}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return rec.Valid, em.emit(rec, func(w io.Writer) {
+		switch {
+		case err != nil:
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+		case rec.Valid:
+			fmt.Fprintf(w, "%s: ok\n", u.Source)
+		}
+		for _, is := range issues {
+			fmt.Fprintf(w, "%s: %s\n", u.Source, is)
+		}
+	})
+}
+
+// sqlRecord is one unit of rewritten SQL (transpile, format, qualify, ...).
+type sqlRecord struct {
+	Source string `json:"source"`
+	SQL    string `json:"sql,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// doTranspile reports sqlglot.ErrNotImplemented for every unit until
+// Transpile lands, so the command exits with status 1.
+func doTranspile(c *config, em *emitter, u unit) (bool, error) {
+	out, err := sqlglot.Transpile(u.SQL, c.opt.Dialect, c.to, c.opt)
+	rec := sqlRecord{Source: u.Source, SQL: out}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return err == nil, em.emit(rec, func(w io.Writer) {
+		if err != nil {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+			return
+		}
+		fmt.Fprintln(w, out)
+	})
+}
+
+// doFormat emits the formatted unit.
+func doFormat(c *config, em *emitter, u unit) (bool, error) {
+	out, err := sqlglot.Format(u.SQL, c.opt, c.fo)
+	rec := sqlRecord{Source: u.Source, SQL: out}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return err == nil, em.emit(rec, func(w io.Writer) {
+		if err != nil {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+			return
+		}
+		fmt.Fprintln(w, out)
+	})
+}
+
+func doQualify(c *config, em *emitter, u unit) (bool, error) {
+	out, err := sqlglot.Qualify(u.SQL, c.opt)
+	rec := sqlRecord{Source: u.Source, SQL: out}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return err == nil, em.emit(rec, func(w io.Writer) {
+		if err != nil {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+			return
+		}
+		fmt.Fprintln(w, out)
+	})
+}
+
+type policyRecord struct {
+	Source string `json:"source"`
+	sqlglot.PolicyViolation
+	SQL   string `json:"sql,omitempty"` // the violating span
+	Error string `json:"error,omitempty"`
+}
+
+// doPolicy emits one record per violation; a clean unit produces no output.
+func doPolicy(c *config, em *emitter, u unit) (bool, error) {
+	vs, err := sqlglot.CheckPolicy(u.SQL, c.policy, c.opt)
+	if err != nil {
+		return false, em.emit(policyRecord{Source: u.Source, Error: err.Error()}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+		})
+	}
+	for _, v := range vs {
+		rec := policyRecord{Source: u.Source, PolicyViolation: v, SQL: u.SQL[v.Start:v.End]}
+		if err := em.emit(rec, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: #%d [%s] %s: %s\n    %s\n", rec.Source, v.Statement, v.Severity, v.Rule, v.Message, rec.SQL)
+		}); err != nil {
+			return false, err
+		}
+	}
+	return len(vs) == 0, nil
+}
+
+type lineageRecord struct {
+	Source string `json:"source"`
+	sqlglot.ColumnLineage
+	Error string `json:"error,omitempty"`
+}
+
+// doLineage emits one record per target column, e.g.
+//
+//	q.sql: #1 report.total <- orders.amount, orders.tax
+func doLineage(c *config, em *emitter, u unit) (bool, error) {
+	ls, err := sqlglot.Lineage(u.SQL, c.opt)
+	if err != nil {
+		return false, em.emit(lineageRecord{Source: u.Source, Error: err.Error()}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+		})
+	}
+	for _, l := range ls {
+		rec := lineageRecord{Source: u.Source, ColumnLineage: l}
+		if err := em.emit(rec, func(w io.Writer) {
+			target := l.Target.Column
+			if target == "" {
+				target = fmt.Sprintf("#%d", l.Position)
+			}
+			src := make([]string, len(l.Sources))
+			for i, s := range l.Sources {
+				src[i] = s.Column
+				if s.Table != "" {
+					src[i] = s.Table + "." + s.Column
+				}
+			}
+			if len(src) == 0 {
+				src = []string{"-"} // constants only
+			}
+			fmt.Fprintf(w, "%s: #%d %s.%s <- %s\n", u.Source, l.Statement, l.Target.Table, target, strings.Join(src, ", "))
+		}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+type ddlRecord struct {
+	Source string `json:"source"`
+	sqlglot.DDLStatement
+	Error string `json:"error,omitempty"`
+}
+
+// doDDL emits one record per CREATE TABLE / ALTER TABLE / CREATE INDEX / DROP
+// statement; the text form lists columns, keys and indexes, or the ALTER actions.
+func doDDL(c *config, em *emitter, u unit) (bool, error) {
+	stmts, err := sqlglot.ParseDDL(u.SQL, c.opt)
+	if err != nil {
+		return false, em.emit(ddlRecord{Source: u.Source, Error: err.Error()}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+		})
+	}
+	for _, st := range stmts {
+		if err := em.emit(ddlRecord{Source: u.Source, DDLStatement: st}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: #%d %s %s\n", u.Source, st.Statement, st.Kind, strings.Join(append([]string{st.Name}, st.Names[min(1, len(st.Names)):]...), ", "))
+			if t := st.Table; t != nil {
+				for _, col := range t.Columns {
+					line := strings.TrimSpace(col.Name + " " + col.Type)
+					if !col.Nullable {
+						line += " NOT NULL"
+					}
+					if col.Default != "" {
+						line += " DEFAULT " + col.Default
+					}
+					if col.AutoIncrement {
+						line += " AUTO_INCREMENT"
+					}
+					fmt.Fprintf(w, "    %s\n", line)
+				}
+				for _, k := range t.Constraints {
+					fmt.Fprintf(w, "    %s %s(%s)\n", k.Kind, nameSpace(k.Name), strings.Join(k.Columns, ", "))
+				}
+				for _, ix := range t.Indexes {
+					fmt.Fprintf(w, "    INDEX %s(%s)\n", nameSpace(ix.Name), strings.Join(ix.Columns, ", "))
+				}
+			}
+			if ix := st.Index; ix != nil && st.Kind == "CREATE INDEX" {
+				fmt.Fprintf(w, "    ON %s (%s)\n", ix.Table, strings.Join(ix.Columns, ", "))
+			}
+			for _, a := range st.Actions {
+				fmt.Fprintf(w, "    %s: %s\n", a.Kind, a.Text)
+			}
+		}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func nameSpace(name string) string {
+	if name == "" {
+		return ""
+	}
+	return name + " "
+}
+
+type migrationRecord struct {
+	Source string `json:"source"`
+	sqlglot.MigrationCheck
+	SQL   string `json:"sql,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// doMigration emits one record per DDL statement with its lock level, rewrite
+// risk and notes; the unit fails when a statement is high risk.
+func doMigration(c *config, em *emitter, u unit) (bool, error) {
+	checks, err := sqlglot.AnalyzeMigration(u.SQL, c.opt, c.mo)
+	if err != nil {
+		return false, em.emit(migrationRecord{Source: u.Source, Error: err.Error()}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+		})
+	}
+	ok := true
+	for _, mc := range checks {
+		ok = ok && mc.Risk != sqlglot.RiskHigh
+		rec := migrationRecord{Source: u.Source, MigrationCheck: mc, SQL: u.SQL[mc.Start:mc.End]}
+		if err := em.emit(rec, func(w io.Writer) {
+			line := fmt.Sprintf("%s: #%d [%s] %s %s: lock=%s", rec.Source, mc.Statement, mc.Risk, mc.Kind, mc.Name, mc.Lock)
+			if mc.Rewrite {
+				line += " rewrite"
+			}
+			if mc.Algorithm != "" {
+				line += " algorithm=" + mc.Algorithm
+			}
+			if mc.Fails {
+				line += " fails"
+			}
+			fmt.Fprintln(w, line)
+			for _, n := range mc.Notes {
+				if n.Action != "" {
+					fmt.Fprintf(w, "    %s\n", n.Action)
+				}
+				fmt.Fprintf(w, "      %s\n", n.Reason)
+				if n.Suggestion != "" {
+					fmt.Fprintf(w, "      instead: %s\n", n.Suggestion)
+				}
+			}
+		}); err != nil {
+			return false, err
+		}
+	}
+	return ok, nil
+}
+
+type lintRecord struct {
+	Source string `json:"source"`
+	sqlglot.LintFinding
+	SQL   string `json:"sql,omitempty"` // the flagged span
+	Error string `json:"error,omitempty"`
+}
+
+// doLint emits one record per finding; a clean unit produces no output.
+func doLint(c *config, em *emitter, u unit) (bool, error) {
+	fs, err := sqlglot.Lint(u.SQL, nil, c.opt)
+	if err != nil {
+		return false, em.emit(lintRecord{Source: u.Source, Error: err.Error()}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: error: %v\n", u.Source, err)
+		})
+	}
+	for _, f := range fs {
+		rec := lintRecord{Source: u.Source, LintFinding: f, SQL: u.SQL[f.Start:f.End]}
+		if err := em.emit(rec, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: #%d [%s] %s: %s\n    %s\n", rec.Source, f.Statement, f.Severity, f.Rule, f.Message, rec.SQL)
+		}); err != nil {
+			return false, err
+		}
+	}
+	return len(fs) == 0, nil
+}
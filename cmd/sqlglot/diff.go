@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+)
+
+// diff compares the schemas built from two DDL files and prints the changes
+// as a migration script (text) or as change records. The exit status is 1
+// when a change is destructive.
+func diff(args []string, stdout io.Writer) error {
+	var opt sqlglot.Options
+	fs := flag.NewFlagSet("sqlglot diff", flag.ContinueOnError)
+	dialect := fs.String("dialect", "mysql", "SQL dialect of both files: mysql, postgres, sqlserver, oracle")
+	to := fs.String("to", "", "render the ALTER statements for this dialect (default: --dialect)")
+	version := fs.String("server-version", "", "server version the ALTER statements are for, e.g. 16 for postgres (default: latest)")
+	format := fs.String("format", "text", "output format: text, json, ndjson")
+	var files []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		files = append(files, args[0])
+		args = args[1:]
+	}
+	if len(files) != 2 {
+		return errors.New("diff needs two files: OLD.sql NEW.sql")
+	}
+	var err error
+	if opt.Dialect, err = sqlglot.ParseDialect(*dialect); err != nil {
+		return err
+	}
+	target := opt.Dialect
+	if *to != "" {
+		if target, err = sqlglot.ParseDialect(*to); err != nil {
+			return err
+		}
+	}
+	var schemas [2]*sqlglot.Schema
+	for i, name := range files {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if schemas[i], err = sqlglot.SchemaFromDDL(string(b), opt); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	schemas[1].Version = *version
+
+	em, err := newEmitter(stdout, *format)
+	if err != nil {
+		return err
+	}
+	destructive := false
+	for _, ch := range sqlglot.DiffSchemas(schemas[0], schemas[1]) {
+		if target != opt.Dialect {
+			ch.SQL = ch.RenderVersion(target, *version)
+		}
+		destructive = destructive || ch.Destructive
+		if err = em.emit(ch, func(w io.Writer) {
+			if ch.Destructive {
+				fmt.Fprintf(w, "-- DESTRUCTIVE: %s\n", ch.Reason)
+			}
+			for _, s := range ch.SQL {
+				if strings.HasPrefix(s, "--") {
+					fmt.Fprintln(w, s)
+				} else {
+					fmt.Fprintf(w, "%s;\n", s)
+				}
+			}
+		}); err != nil {
+			break
+		}
+	}
+	if cerr := em.close(); err == nil {
+		err = cerr
+	}
+	if err == nil && destructive {
+		err = errReported
+	}
+	return err
+}
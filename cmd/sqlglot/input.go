@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+)
+
+// unit is one piece of SQL to process, with a human-readable origin.
+type unit struct {
+	Source string
+	SQL    string
+}
+
+// inputs yields units from -e arguments, files, or stdin (when neither is given),
+// cutting each input according to mode:
+//
+//	stmt  split at top-level ';' and at lone GO / slash lines (quotes, comments and dollar tags respected)
+//	line  one query per line (query logs)
+//	all   the whole input is one unit
+//
+// Files and stdin are read incrementally so large logs stream through.
+func inputs(exprs, files []string, mode string, dialect sqlglot.Dialect, fn func(unit) error) error {
+	for i, e := range exprs {
+		if err := cutUnits(strings.NewReader(e), fmt.Sprintf("arg#%d", i+1), mode, dialect, fn); err != nil {
+			return err
+		}
+	}
+	for _, name := range files {
+		if err := cutFile(name, mode, dialect, fn); err != nil {
+			return err
+		}
+	}
+	if len(exprs) == 0 && len(files) == 0 {
+		return cutUnits(os.Stdin, "stdin", mode, dialect, fn)
+	}
+	return nil
+}
+
+// cutFile cuts one file ("-" is stdin), closing it before the next one is opened.
+func cutFile(name, mode string, dialect sqlglot.Dialect, fn func(unit) error) error {
+	if name == "-" {
+		return cutUnits(os.Stdin, name, mode, dialect, fn)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return cutUnits(f, name, mode, dialect, fn)
+}
+
+func cutUnits(r io.Reader, name, mode string, dialect sqlglot.Dialect, fn func(unit) error) error {
+	switch mode {
+	case "all":
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(b)) == "" {
+			return nil
+		}
+		return fn(unit{Source: name, SQL: string(b)})
+	case "line":
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for ln := 1; sc.Scan(); ln++ {
+			if strings.TrimSpace(sc.Text()) == "" {
+				continue
+			}
+			if err := fn(unit{Source: fmt.Sprintf("%s:%d", name, ln), SQL: sc.Text()}); err != nil {
+				return err
+			}
+		}
+		return sc.Err()
+	case "stmt":
+		sc := newStmtScanner(bufio.NewReaderSize(r, 64*1024), dialect)
+		for {
+			sql, line, err := sc.next()
+			if sql != "" {
+				if ferr := fn(unit{Source: fmt.Sprintf("%s:%d", name, line), SQL: sql}); ferr != nil {
+					return ferr
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("unknown --unit %q (want stmt, line or all)", mode)
+}
+
+// stmtScanner cuts a character stream into statements at top-level ';', and
+// at a line holding only GO (SQL Server) or / (Oracle).
+// It is a small state machine rather than the ANTLR lexer so that input can be
+// consumed incrementally; each statement is then lexed properly by the library.
+type stmtScanner struct {
+	r       *bufio.Reader
+	dialect sqlglot.Dialect
+	line    int
+	midLine bool // the last statement ended before the end of its line
+}
+
+func newStmtScanner(r *bufio.Reader, d sqlglot.Dialect) *stmtScanner {
+	return &stmtScanner{r: r, dialect: d, line: 1}
+}
+
+// next returns the next non-blank statement (without the ';') and the line it starts on.
+func (s *stmtScanner) next() (string, int, error) {
+	var sb strings.Builder
+	startLine := 0
+	var (
+		quote     rune   // ' " ` ]
+		escapes   bool   // backslash escapes inside quote (MySQL strings, PG E'..')
+		dollarTag string // PG $tag$
+		dollarAt  int    // sb length right after the opening $tag$
+		qClose    rune   // Oracle q'<d>...<d>'
+		lineCmt   bool
+		blockCmt  bool
+		prev      rune // previous rune, 0 after a construct closed (so "*/*" or "''" do not chain)
+		before    rune // the rune before prev
+		lineAt    = 0  // sb length at the start of the current line; -1 when the line began in the last statement
+	)
+	if s.midLine {
+		lineAt, s.midLine = -1, false
+	}
+	// separator cuts the statement before the current line when that line is a
+	// lone GO or /, at top level.
+	separator := func() (string, bool) {
+		if lineAt < 0 || quote != 0 || dollarTag != "" || qClose != 0 || blockCmt {
+			return "", false
+		}
+		if !s.isSeparator(sb.String()[lineAt:]) {
+			return "", false
+		}
+		out := strings.TrimSpace(sb.String()[:lineAt])
+		sb.Reset()
+		lineAt = 0
+		return out, true
+	}
+	for {
+		c, _, err := s.r.ReadRune()
+		if err != nil {
+			if out, ok := separator(); ok {
+				return out, startLine, err
+			}
+			return strings.TrimSpace(sb.String()), startLine, err
+		}
+		if c == '\n' {
+			s.line++
+		}
+		if startLine == 0 && !isSpace(c) {
+			startLine = s.line
+		}
+		sb.WriteRune(c)
+		closed := false
+		switch {
+		case lineCmt:
+			lineCmt = c != '\n'
+		case blockCmt:
+			if prev == '*' && c == '/' {
+				blockCmt, closed = false, true
+			}
+		case dollarTag != "":
+			if c == '$' && sb.Len()-len(dollarTag) >= dollarAt && strings.HasSuffix(sb.String(), dollarTag) {
+				dollarTag, closed = "", true
+			}
+		case qClose != 0:
+			if prev == qClose && c == '\'' {
+				qClose, closed = 0, true
+			}
+		case quote != 0:
+			if c == '\\' && escapes {
+				if n, _, err := s.r.ReadRune(); err == nil {
+					sb.WriteRune(n)
+				}
+				closed = true
+			} else if c == quote {
+				quote, closed = 0, true
+			}
+		case c == ';':
+			if out := strings.TrimSpace(strings.TrimSuffix(sb.String(), ";")); out != "" {
+				s.midLine = true
+				return out, startLine, nil
+			}
+			sb.Reset()
+			startLine, lineAt = 0, -1
+			closed = true
+		case c == '\'' && (prev == 'q' || prev == 'Q') && !isIdentRune(before) && s.dialect == sqlglot.Oracle:
+			if d, _, err := s.r.ReadRune(); err == nil {
+				sb.WriteRune(d)
+				qClose = closingDelim(d)
+			}
+			closed = true
+		case c == '\'' || c == '"':
+			quote = c
+			escapes = s.dialect == sqlglot.MySQL ||
+				c == '\'' && (prev == 'E' || prev == 'e') && !isIdentRune(before) && s.dialect == sqlglot.Postgres
+		case c == '`' && s.dialect == sqlglot.MySQL:
+			quote, escapes = '`', false
+		case c == '[' && s.dialect == sqlglot.SQLServer:
+			quote, escapes = ']', false
+		case c == '-' && prev == '-':
+			lineCmt = true
+		case c == '#' && s.dialect == sqlglot.MySQL:
+			lineCmt = true
+		case c == '*' && prev == '/':
+			blockCmt, closed = true, true
+		case c == '$' && s.dialect == sqlglot.Postgres:
+			if tag := s.peekDollarTag(); tag != "" {
+				sb.WriteString(tag[1:])
+				dollarTag, dollarAt, closed = tag, sb.Len(), true
+			}
+		}
+		if c == '\n' {
+			if out, ok := separator(); ok {
+				if out != "" {
+					return out, startLine, nil
+				}
+				startLine = 0
+			}
+			lineAt = sb.Len()
+		}
+		if closed {
+			prev, before = 0, 0
+		} else {
+			prev, before = c, prev
+		}
+	}
+}
+
+// isSeparator reports whether line is a batch separator of the dialect.
+func (s *stmtScanner) isSeparator(line string) bool {
+	line = strings.TrimSpace(line)
+	switch s.dialect {
+	case sqlglot.SQLServer:
+		return strings.EqualFold(line, "GO")
+	case sqlglot.Oracle:
+		return line == "/"
+	}
+	return false
+}
+
+// peekDollarTag is called after a '$' was read; it consumes and returns the full
+// opening tag ("$$" or "$name$") or returns "" when this '$' starts no tag ($1 etc.).
+func (s *stmtScanner) peekDollarTag() string {
+	b, _ := s.r.Peek(64)
+	for i, c := range b {
+		if c == '$' {
+			tag := "$" + string(b[:i+1])
+			if i > 0 && b[0] >= '0' && b[0] <= '9' {
+				return ""
+			}
+			s.r.Discard(i + 1)
+			return tag
+		}
+		if !(c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')) {
+			return ""
+		}
+	}
+	return ""
+}
+
+func closingDelim(d rune) rune {
+	switch d {
+	case '[':
+		return ']'
+	case '{':
+		return '}'
+	case '(':
+		return ')'
+	case '<':
+		return '>'
+	}
+	return d
+}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || c == '$' || c == '#' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c >= 0x80
+}
+
+func isSpace(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
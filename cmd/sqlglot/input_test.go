@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+)
+
+// go test -v -count=1 ./cmd/sqlglot
+
+// scan 返回每条语句及其起始行，形如 "2:SELECT 1"
+func scan(t *testing.T, d sqlglot.Dialect, in string) []string {
+	t.Helper()
+	sc := newStmtScanner(bufio.NewReader(strings.NewReader(in)), d)
+	var out []string
+	for {
+		sql, line, err := sc.next()
+		if sql != "" {
+			out = append(out, strconv.Itoa(line)+":"+sql)
+		}
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+	}
+}
+
+func Test_StmtScanner(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect sqlglot.Dialect
+		in      string
+		want    []string
+	}{
+		{"plain", sqlglot.MySQL, "SELECT 1; SELECT 2;\n\nSELECT 3", []string{"1:SELECT 1", "1:SELECT 2", "3:SELECT 3"}},
+		{"empty statements", sqlglot.MySQL, ";; \n ;SELECT 1;;", []string{"2:SELECT 1"}},
+		{"single quotes", sqlglot.Postgres, "SELECT 'a;b', 'it''s;' FROM t; SELECT 2", []string{"1:SELECT 'a;b', 'it''s;' FROM t", "1:SELECT 2"}},
+		{"double quotes", sqlglot.Postgres, `SELECT "a;b" FROM t;SELECT 2`, []string{`1:SELECT "a;b" FROM t`, "1:SELECT 2"}},
+		{"mysql backslash", sqlglot.MySQL, `SELECT 'it\';s', "a\";" FROM t; SELECT 2`, []string{`1:SELECT 'it\';s', "a\";" FROM t`, "1:SELECT 2"}},
+		{"pg plain backslash", sqlglot.Postgres, `SELECT 'a\'; SELECT 2`, []string{`1:SELECT 'a\'`, "1:SELECT 2"}},
+		{"pg E string", sqlglot.Postgres, `SELECT E'it\'s;x', e'\\'; SELECT 2`, []string{`1:SELECT E'it\'s;x', e'\\'`, "1:SELECT 2"}},
+		{"pg E after identifier", sqlglot.Postgres, `SELECT a FROM t WHERE name LIKE'x\'; SELECT 2`, []string{`1:SELECT a FROM t WHERE name LIKE'x\'`, "1:SELECT 2"}},
+		{"backticks", sqlglot.MySQL, "SELECT `a;b` FROM t; SELECT 2", []string{"1:SELECT `a;b` FROM t", "1:SELECT 2"}},
+		{"brackets", sqlglot.SQLServer, "SELECT [a;b] FROM t; SELECT 2", []string{"1:SELECT [a;b] FROM t", "1:SELECT 2"}},
+		{"dollar body", sqlglot.Postgres,
+			"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql;\nSELECT $1, $tag$ ; $$ ; $tag$",
+			[]string{"1:CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql", "2:SELECT $1, $tag$ ; $$ ; $tag$"}},
+		{"line comments", sqlglot.MySQL, "-- a;b\nSELECT 1 # c;d\n; SELECT 2",
+			[]string{"1:-- a;b\nSELECT 1 # c;d", "3:SELECT 2"}},
+		{"block comments", sqlglot.Postgres, "/* a; */ SELECT 1 /* b;*/; SELECT /**/2", []string{"1:/* a; */ SELECT 1 /* b;*/", "1:SELECT /**/2"}},
+		{"oracle q-quote", sqlglot.Oracle, "SELECT q'[a;']' FROM dual; SELECT 2 FROM dual", []string{"1:SELECT q'[a;']' FROM dual", "1:SELECT 2 FROM dual"}},
+		{"GO", sqlglot.SQLServer, "SELECT 1\nGO\nselect 2\n  go  \nSELECT 3;\nGO",
+			[]string{"1:SELECT 1", "3:select 2", "5:SELECT 3"}},
+		{"GO inside text", sqlglot.SQLServer, "SELECT 'a\nGO\nb'\nGO\nSELECT 1 GO", []string{"1:SELECT 'a\nGO\nb'", "5:SELECT 1 GO"}},
+		{"GO after statement on same line", sqlglot.SQLServer, "SELECT 1; GO\nSELECT 2", []string{"1:SELECT 1", "1:GO\nSELECT 2"}},
+		{"GO in other dialects", sqlglot.MySQL, "SELECT 1\nGO\nSELECT 2", []string{"1:SELECT 1\nGO\nSELECT 2"}},
+		{"slash", sqlglot.Oracle, "SELECT 1 FROM dual\n/\nSELECT 2 FROM dual\n/", []string{"1:SELECT 1 FROM dual", "3:SELECT 2 FROM dual"}},
+		{"slash is division", sqlglot.Oracle, "SELECT 4\n/ 2 FROM dual", []string{"1:SELECT 4\n/ 2 FROM dual"}},
+	}
+	for _, c := range cases {
+		got := scan(t, c.dialect, c.in)
+		if strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("[%s]\n got=%q\nwant=%q", c.name, got, c.want)
+		}
+	}
+}
+
+func Test_CutUnits(t *testing.T) {
+	in := "SELECT 1;\n\nSELECT 2\n"
+	cases := []struct {
+		mode string
+		want []string
+	}{
+		{"stmt", []string{"f:1 SELECT 1", "f:3 SELECT 2"}},
+		{"line", []string{"f:1 SELECT 1;", "f:3 SELECT 2"}},
+		{"all", []string{"f " + in}},
+	}
+	for _, c := range cases {
+		var got []string
+		err := cutUnits(strings.NewReader(in), "f", c.mode, sqlglot.MySQL, func(u unit) error {
+			got = append(got, u.Source+" "+u.SQL)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("[%s] %v", c.mode, err)
+		}
+		if strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("[%s]\n got=%q\nwant=%q", c.mode, got, c.want)
+		}
+	}
+	if err := cutUnits(strings.NewReader(in), "f", "word", sqlglot.MySQL, func(unit) error { return nil }); err == nil {
+		t.Errorf("unknown unit accepted")
+	}
+}
+
+func Test_Emitter(t *testing.T) {
+	type rec struct {
+		N int `json:"n"`
+	}
+	cases := []struct {
+		format string
+		recs   int
+		want   string
+	}{
+		{"text", 2, "n=0\nn=1\n"},
+		{"ndjson", 2, "{\"n\":0}\n{\"n\":1}\n"},
+		{"json", 2, "[\n  {\n    \"n\": 0\n  },\n  {\n    \"n\": 1\n  }\n]\n"},
+		{"json", 0, "[\n]\n"},
+	}
+	for _, c := range cases {
+		var b bytes.Buffer
+		em, err := newEmitter(&b, c.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < c.recs; i++ {
+			if err := em.emit(rec{i}, func(w io.Writer) { io.WriteString(w, "n="+strconv.Itoa(i)+"\n") }); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := em.close(); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != c.want {
+			t.Errorf("[%s/%d]\n got=%q\nwant=%q", c.format, c.recs, b.String(), c.want)
+		}
+	}
+	if _, err := newEmitter(io.Discard, "xml"); err == nil {
+		t.Errorf("unknown format accepted")
+	}
+}
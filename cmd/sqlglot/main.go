@@ -0,0 +1,280 @@
+// Command sqlglot digests, inspects and splits SQL from the shell.
+//
+//	sqlglot digest   [flags] [FILE...]   normalized digest per statement
+//	sqlglot params   [flags] [FILE...]   digest plus extracted parameters
+//	sqlglot split    [flags] [FILE...]   statements with type and byte range
+//	sqlglot validate [flags] [FILE...]   lexer-level checks; exit status 1 on problems
+//	sqlglot transpile --to DIALECT [flags] [FILE...]
+//	sqlglot policy   [--rules FILE] [flags] [FILE...]  dangerous-statement checks; exit status 1 on violations
+//	sqlglot lint     [--catalog FILE] [flags] [FILE...]   built-in lint rules; exit status 1 on findings
+//	sqlglot format   [--keyword-case upper|lower|preserve] [--indent N] [--width N] [flags] [FILE...]
+//	sqlglot lineage  [--catalog FILE] [flags] [FILE...]   target column <- source columns of INSERT ... SELECT, CTAS, UPDATE, MERGE
+//	sqlglot qualify  [--catalog FILE] [flags] [FILE...]   qualify column references and expand SELECT *
+//	sqlglot ddl      [flags] [FILE...]   tables, columns, keys and ALTER actions of DDL statements
+//	sqlglot diff     [--to DIALECT] [--server-version V] [flags] OLD.sql NEW.sql   migration from one schema to the other; exit status 1 on destructive changes
+//	sqlglot migration [--server-version V] [--schema FILE] [flags] [FILE...]   lock level and rewrite risk of DDL; exit status 1 on high risk
+//	sqlglot serve    [--addr :8080] [flags]  HTTP/JSON service (see package httpapi)
+//
+// SQL comes from -e arguments, from files ("-" is stdin), or from stdin when
+// neither is given. Output is text, json or ndjson and is written as each
+// statement is processed, e.g.
+//
+//	tail -f query.log | sqlglot digest --unit line --dialect mysql --format ndjson
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+)
+
+const usage = `usage: sqlglot <command> [flags] [FILE...]
+
+commands:
+  digest     print the normalized digest of each statement
+  params     print the digest and the extracted parameters
+  split      print each statement with its type and byte range
+  validate   report lexer-level problems (exit status 1 if any)
+  transpile  convert between dialects (--to; not implemented yet, exit status 1)
+  policy     check statements against a rule set (--rules; exit status 1 on violations)
+  lint       run the built-in lint rules (exit status 1 on findings)
+  format     pretty-print SQL, keeping literals and comments
+  lineage    print which source columns feed each written column
+  qualify    qualify column references and expand SELECT * (--catalog)
+  ddl        print the tables, columns, keys and ALTER actions of DDL statements
+  diff       print the ALTER statements that turn OLD.sql into NEW.sql (exit status 1 if destructive)
+  migration  estimate the lock level and rewrite risk of DDL (exit status 1 on high risk)
+  serve      run the HTTP/JSON digest service (--addr)
+
+run 'sqlglot <command> -h' for flags
+`
+
+// errReported marks a failure that was already written to the output.
+var errReported = errors.New("errors reported")
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	err := run(os.Args[1], os.Args[2:], os.Stdout)
+	switch {
+	case err == nil:
+	case errors.Is(err, errReported):
+		os.Exit(1)
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(0)
+	default:
+		fmt.Fprintln(os.Stderr, "sqlglot:", err)
+		os.Exit(2)
+	}
+}
+
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, "; ") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// config holds the flags shared by every command.
+type config struct {
+	opt    sqlglot.Options
+	to     sqlglot.Dialect
+	policy sqlglot.Policy
+	fo     sqlglot.FormatOptions
+	mo     sqlglot.MigrationOptions
+	format string
+	unit   string
+	exprs  stringList
+	files  []string
+}
+
+func parseFlags(cmd string, args []string) (*config, error) {
+	c := &config{}
+	fs := flag.NewFlagSet("sqlglot "+cmd, flag.ContinueOnError)
+	dialect := optionFlags(fs, &c.opt)
+	fs.StringVar(&c.format, "format", "text", "output format: text, json, ndjson")
+	unitDefault := "stmt"
+	if cmd == "format" || cmd == "migration" {
+		// format: keep comments and blank lines between statements with the statements;
+		// migration: later statements see the tables created and altered by earlier ones
+		unitDefault = "all"
+	}
+	fs.StringVar(&c.unit, "unit", unitDefault, "how input is cut: stmt (at ';'), line (one query per line), all")
+	fs.Var(&c.exprs, "e", "SQL text to process (repeatable)")
+	to, rules, catalog := "", "", ""
+	if cmd == "transpile" {
+		fs.StringVar(&to, "to", "", "target dialect")
+	}
+	if cmd == "policy" {
+		fs.StringVar(&rules, "rules", "", "JSON rule set file (default: the built-in rules)")
+	}
+	if cmd == "lint" || cmd == "lineage" || cmd == "qualify" {
+		fs.StringVar(&catalog, "catalog", "", "schema catalog: JSON file, or a .sql file of CREATE TABLE statements")
+	}
+	schema := ""
+	if cmd == "migration" {
+		fs.StringVar(&c.mo.Version, "server-version", "", "server version, e.g. 8.0.28, 11, 2016, 12c (default: current release)")
+		fs.StringVar(&schema, "schema", "", "CREATE TABLE statements of the existing tables (.sql)")
+	}
+	indent := 2
+	if cmd == "format" {
+		fs.StringVar(&c.fo.KeywordCase, "keyword-case", sqlglot.KeywordUpper, "keyword case: upper, lower, preserve")
+		fs.IntVar(&indent, "indent", 2, "spaces per indentation level (0 = tab)")
+		fs.IntVar(&c.fo.LineWidth, "width", 80, "target line width (-1 = unlimited)")
+	}
+	// flags may follow file names: keep parsing after each positional argument
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		c.files = append(c.files, args[0])
+		args = args[1:]
+	}
+	var err error
+	if c.opt.Dialect, err = sqlglot.ParseDialect(*dialect); err != nil {
+		return nil, err
+	}
+	if cmd == "transpile" {
+		if to == "" {
+			return nil, errors.New("transpile needs --to")
+		}
+		if c.to, err = sqlglot.ParseDialect(to); err != nil {
+			return nil, err
+		}
+	}
+	if cmd == "format" {
+		if indent > 0 {
+			c.fo.Indent = strings.Repeat(" ", indent)
+		} else {
+			c.fo.Indent = "\t"
+		}
+	}
+	if cmd == "policy" {
+		c.policy = sqlglot.DefaultPolicy()
+		if rules != "" {
+			b, err := os.ReadFile(rules)
+			if err != nil {
+				return nil, err
+			}
+			if c.policy, err = sqlglot.ParsePolicy(b); err != nil {
+				return nil, fmt.Errorf("%s: %w", rules, err)
+			}
+		}
+	}
+	if schema != "" {
+		b, err := os.ReadFile(schema)
+		if err != nil {
+			return nil, err
+		}
+		if c.mo.Schema, err = sqlglot.SchemaFromDDL(string(b), c.opt); err != nil {
+			return nil, fmt.Errorf("%s: %w", schema, err)
+		}
+	}
+	if catalog != "" {
+		if c.opt.Catalog, err = loadCatalog(catalog, c.opt); err != nil {
+			return nil, fmt.Errorf("%s: %w", catalog, err)
+		}
+	}
+	return c, nil
+}
+
+// loadCatalog reads a JSON catalog, or builds one from the CREATE TABLE
+// statements of a .sql file.
+func loadCatalog(path string, opt sqlglot.Options) (*sqlglot.Catalog, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".sql") {
+		return sqlglot.CatalogFromDDL(string(b), opt)
+	}
+	return sqlglot.ParseCatalog(b)
+}
+
+// optionFlags registers the digest option flags on fs and returns the
+// --dialect value, which the caller resolves after parsing.
+func optionFlags(fs *flag.FlagSet, opt *sqlglot.Options) *string {
+	dialect := fs.String("dialect", "mysql", "SQL dialect: mysql, postgres, sqlserver, oracle")
+	fs.BoolVar(&opt.CollapseValuesInDigest, "collapse-values", false, "collapse multi-row INSERT ... VALUES in the digest")
+	fs.BoolVar(&opt.ParamizeTimeFuncs, "paramize-time", false, "parameterize NOW()/SYSDATE/CURRENT_DATE ...")
+	fs.BoolVar(&opt.NormalizeBinds, "normalize-binds", false, "treat :name / :1 / @p1 forms as binds in every dialect")
+	fs.BoolVar(&opt.NoBackslashEscapes, "no-backslash-escapes", false, "MySQL NO_BACKSLASH_ESCAPES when decoding strings")
+	fs.BoolVar(&opt.PGStatStatements, "pg-stat-statements", false, "postgres: print digests exactly as pg_stat_statements.query")
+	fs.IntVar(&opt.PGVersion, "pg-version", 0, "postgres: server major version for --pg-stat-statements (13-18; default 18)")
+	fs.BoolVar(&opt.MySQLDigestText, "mysql-digest-text", false, "mysql: print digests in performance_schema DIGEST_TEXT form")
+	fs.BoolVar(&opt.UnwrapDynamicSQL, "unwrap-dynamic", false, "also digest SQL embedded in EXEC('...'), sp_executesql, EXECUTE IMMEDIATE, EXECUTE format(...), PREPARE ... FROM")
+	fs.BoolVar(&opt.Complexity, "complexity", false, "also report per-statement complexity metrics (joins, subqueries, CTEs, IN-list sizes ...)")
+	return dialect
+}
+
+func run(cmd string, args []string, stdout io.Writer) error {
+	var handle func(*config, *emitter, unit) (bool, error)
+	switch cmd {
+	case "digest":
+		handle = doDigest
+	case "params":
+		handle = doParams
+	case "split":
+		handle = doSplit
+	case "validate":
+		handle = doValidate
+	case "transpile":
+		handle = doTranspile
+	case "policy":
+		handle = doPolicy
+	case "lint":
+		handle = doLint
+	case "format":
+		handle = doFormat
+	case "lineage":
+		handle = doLineage
+	case "qualify":
+		handle = doQualify
+	case "ddl":
+		handle = doDDL
+	case "migration":
+		handle = doMigration
+	case "diff":
+		return diff(args, stdout)
+	case "serve":
+		return serve(args, stdout)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", cmd, usage)
+	}
+	c, err := parseFlags(cmd, args)
+	if err != nil {
+		return err
+	}
+	em, err := newEmitter(stdout, c.format)
+	if err != nil {
+		return err
+	}
+	failed := false
+	err = inputs(c.exprs, c.files, c.unit, c.opt.Dialect, func(u unit) error {
+		ok, err := handle(c, em, u)
+		if !ok {
+			failed = true
+		}
+		return err
+	})
+	if cerr := em.close(); err == nil {
+		err = cerr
+	}
+	if err == nil && failed {
+		err = errReported
+	}
+	return err
+}
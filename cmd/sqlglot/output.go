@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// emitter writes one record per unit in the selected format and flushes after
+// each record, so output streams when the tool sits in a shell pipeline.
+//
+//	text    human-readable, produced by the per-command text func
+//	json    a single JSON array (opened on the first record, closed by close)
+//	ndjson  one JSON object per line
+type emitter struct {
+	w      *bufio.Writer
+	format string
+	n      int
+}
+
+func newEmitter(w io.Writer, format string) (*emitter, error) {
+	switch format {
+	case "text", "json", "ndjson":
+	default:
+		return nil, fmt.Errorf("unknown --format %q (want text, json or ndjson)", format)
+	}
+	return &emitter{w: bufio.NewWriter(w), format: format}, nil
+}
+
+func (e *emitter) emit(rec any, text func(w io.Writer)) error {
+	switch e.format {
+	case "text":
+		text(e.w)
+	case "ndjson":
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		e.w.Write(b)
+		e.w.WriteByte('\n')
+	case "json":
+		if e.n == 0 {
+			e.w.WriteString("[\n")
+		} else {
+			e.w.WriteString(",\n")
+		}
+		b, err := json.MarshalIndent(rec, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		e.w.WriteString("  ")
+		e.w.Write(b)
+	}
+	e.n++
+	return e.w.Flush()
+}
+
+func (e *emitter) close() error {
+	if e.format == "json" {
+		if e.n == 0 {
+			e.w.WriteString("[")
+		}
+		e.w.WriteString("\n]\n")
+	}
+	return e.w.Flush()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+	"github.com/tensafe/sqlglot-go/sqlglot/httpapi"
+)
+
+// serve runs the HTTP/JSON service until SIGINT/SIGTERM. The option flags set
+// the defaults for requests that do not carry their own.
+func serve(args []string, stdout io.Writer) error {
+	var cfg httpapi.Config
+	fs := flag.NewFlagSet("sqlglot serve", flag.ContinueOnError)
+	dialect := optionFlags(fs, &cfg.Defaults)
+	addr := fs.String("addr", ":8080", "listen address")
+	fs.Int64Var(&cfg.MaxBodyBytes, "max-body", 1<<20, "request body limit in bytes")
+	fs.IntVar(&cfg.MaxBatch, "max-batch", 1000, "items per batch request")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("serve takes no arguments, got %q", fs.Args())
+	}
+	var err error
+	if cfg.Defaults.Dialect, err = sqlglot.ParseDialect(*dialect); err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           httpapi.NewHandler(cfg),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Serve returns as soon as Shutdown starts; done reports when the
+	// in-flight requests have been drained.
+	done := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(shutdown)
+	}()
+	fmt.Fprintf(stdout, "sqlglot: listening on %s\n", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-done
+}
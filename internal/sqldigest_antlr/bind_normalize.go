@@ -0,0 +1,96 @@
+package sqldigest_antlr
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// splitBindEnd：NormalizeBinds=true 时识别被 lexer 拆成两个 token 的绑定占位符，
+// 例如 MySQL/SQL Server 的 ":name" / ":1"，PG/Oracle 的 "@p1"。
+// 要求符号与名字紧贴（中间无空白），且符号前不紧贴标识符/数字/右括号（排除 arr[1:n] 之类切片）。
+// 命中时返回名字 token 的下标。
+func splitBindEnd(toks []antlr.Token, i int) (int, bool) {
+	t := toks[i]
+	sigil := t.GetText()
+	if sigil != ":" && sigil != "@" {
+		return 0, false
+	}
+	if i+1 >= len(toks) {
+		return 0, false
+	}
+	nt := toks[i+1]
+	if IsEOFToken(nt) || nt.GetChannel() != antlr.TokenDefaultChannel || nt.GetStart() != t.GetStop()+1 {
+		return 0, false
+	}
+	name := nt.GetText()
+	if isQuotedIdent(name) || !(looksLikeIdent(name) || isAllDigits(name)) {
+		return 0, false
+	}
+	if sigil == "@" && isAllDigits(name) {
+		return 0, false
+	}
+	if i > 0 {
+		pt := toks[i-1]
+		if !IsEOFToken(pt) && pt.GetChannel() == antlr.TokenDefaultChannel && pt.GetStop()+1 == t.GetStart() {
+			pw := pt.GetText()
+			if looksLikeIdent(pw) || isNumberLiteral(pw) || pw == ")" || pw == "]" {
+				return 0, false
+			}
+		}
+	}
+	return i + 1, true
+}
+
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// annotateBinds 给绑定参数填 BindName/BindOrdinal：
+//   - ?          → 按出现顺序递增
+//   - $n / :n    → 直接取 n
+//   - :name/@name → 同名（大小写不敏感）共享同一个逻辑序号，首次出现时分配
+func annotateBinds(params []ExParam) {
+	next := 1
+	named := map[string]int{}
+	for i := range params {
+		p := &params[i]
+		if p.Type != "Bind" && p.Type != "NamedBind" {
+			continue
+		}
+		v := p.Value
+		switch {
+		case v == "?":
+			p.BindOrdinal = next
+			next++
+		case len(v) > 1 && (v[0] == '$' || v[0] == ':') && isAllDigits(v[1:]):
+			n, _ := strconv.Atoi(v[1:])
+			if v[0] == ':' {
+				p.BindName = v[1:]
+			}
+			p.BindOrdinal = n
+			if n >= next {
+				next = n + 1
+			}
+		case len(v) > 1 && (v[0] == ':' || v[0] == '@'):
+			p.BindName = v[1:]
+			key := strings.ToLower(p.BindName)
+			if ord, ok := named[key]; ok {
+				p.BindOrdinal = ord
+				continue
+			}
+			named[key] = next
+			p.BindOrdinal = next
+			next++
+		}
+	}
+}
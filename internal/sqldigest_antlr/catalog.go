@@ -0,0 +1,184 @@
+package sqldigest_antlr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema 目录：库 → schema → 表 → 列（带类型）。可从 JSON 读入，或由 CREATE TABLE 语句构建。
+// 放进 Options.Catalog 后，Lineage / Qualify / lint 的 schema 规则按它解析列；为 nil 时行为与无目录相同。
+// 名字比较不区分大小写；MySQL 的库在这里写作 schema（Database 留空）。
+
+// Catalog 表的集合；未限定的表名按 DefaultDatabase / DefaultSchema 查找，二者为空表示任意
+type Catalog struct {
+	DefaultDatabase string            `json:"default_database,omitempty"`
+	DefaultSchema   string            `json:"default_schema,omitempty"`
+	Databases       []CatalogDatabase `json:"databases"`
+}
+
+type CatalogDatabase struct {
+	Name    string          `json:"name,omitempty"`
+	Schemas []CatalogSchema `json:"schemas"`
+}
+
+type CatalogSchema struct {
+	Name   string         `json:"name,omitempty"`
+	Tables []CatalogTable `json:"tables"`
+}
+
+type CatalogTable struct {
+	Name    string          `json:"name"`
+	Columns []CatalogColumn `json:"columns"`
+}
+
+type CatalogColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"` // 类型原文，如 varchar(64)、numeric(10,2)
+}
+
+// ParseCatalog 解析 JSON 目录（未知字段报错）
+func ParseCatalog(data []byte) (*Catalog, error) {
+	var c Catalog
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("catalog: %w", err)
+	}
+	for _, db := range c.Databases {
+		for _, s := range db.Schemas {
+			for _, t := range s.Tables {
+				if t.Name == "" {
+					return nil, fmt.Errorf("catalog: table without name in %q.%q", db.Name, s.Name)
+				}
+			}
+		}
+	}
+	return &c, nil
+}
+
+// AddTable 加入（或替换同名的）表
+func (c *Catalog) AddTable(database, schema string, t CatalogTable) {
+	di := -1
+	for i := range c.Databases {
+		if strings.EqualFold(c.Databases[i].Name, database) {
+			di = i
+			break
+		}
+	}
+	if di < 0 {
+		c.Databases = append(c.Databases, CatalogDatabase{Name: database})
+		di = len(c.Databases) - 1
+	}
+	db := &c.Databases[di]
+	si := -1
+	for i := range db.Schemas {
+		if strings.EqualFold(db.Schemas[i].Name, schema) {
+			si = i
+			break
+		}
+	}
+	if si < 0 {
+		db.Schemas = append(db.Schemas, CatalogSchema{Name: schema})
+		si = len(db.Schemas) - 1
+	}
+	s := &db.Schemas[si]
+	for i := range s.Tables {
+		if strings.EqualFold(s.Tables[i].Name, t.Name) {
+			s.Tables[i] = t
+			return
+		}
+	}
+	s.Tables = append(s.Tables, t)
+}
+
+// Table 按 "db.schema.t" / "schema.t" / "t" 查表（各段可带引号），找不到返回 nil
+func (c *Catalog) Table(name string) *CatalogTable {
+	return c.lookup(splitQualified(name))
+}
+
+// Column 按名字查列，找不到返回 nil
+func (t *CatalogTable) Column(name string) *CatalogColumn {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// lookup parts 为去引号的名字各段；两段名先当 schema.t，再当 MySQL 式的 db.t
+func (c *Catalog) lookup(parts []string) *CatalogTable {
+	if c == nil || len(parts) == 0 || len(parts) > 3 {
+		return nil
+	}
+	name := parts[len(parts)-1]
+	db, schema := c.DefaultDatabase, c.DefaultSchema
+	switch len(parts) {
+	case 3:
+		db, schema = parts[0], parts[1]
+	case 2:
+		schema = parts[0]
+	}
+	if t := c.find(db, schema, name); t != nil {
+		return t
+	}
+	if len(parts) == 2 {
+		return c.find(parts[0], "", name)
+	}
+	return nil
+}
+
+// find 空的 db / schema 匹配任意
+func (c *Catalog) find(db, schema, name string) *CatalogTable {
+	for di := range c.Databases {
+		d := &c.Databases[di]
+		if db != "" && !strings.EqualFold(d.Name, db) {
+			continue
+		}
+		for si := range d.Schemas {
+			s := &d.Schemas[si]
+			if schema != "" && !strings.EqualFold(s.Name, schema) {
+				continue
+			}
+			for ti := range s.Tables {
+				if strings.EqualFold(s.Tables[ti].Name, name) {
+					return &s.Tables[ti]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// splitQualified 按不在引号里的 . 切分并去引号
+func splitQualified(name string) []string {
+	var parts []string
+	start, quote := 0, byte(0)
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '.':
+			parts = append(parts, unquoteIdent(name[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, unquoteIdent(name[start:]))
+}
+
+// CatalogFromDDL 用 sql 里的 DDL（CREATE TABLE，以及之后的 ALTER TABLE / DROP TABLE）构建目录；其它语句忽略
+func CatalogFromDDL(sql string, opt Options) (*Catalog, error) {
+	s, err := SchemaFromDDL(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	return s.Catalog(), nil
+}
@@ -0,0 +1,311 @@
+package sqldigest_antlr
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Comment 原文中的一条注释（[Start,End) 为字节区间，不含行尾换行）
+type Comment struct {
+	Text  string            `json:"text"`
+	Kind  string            `json:"kind"` // line / block / hint（/*+ */、--+）/ version（MySQL /*! */）
+	Start int               `json:"start"`
+	End   int               `json:"end"`
+	Tags  map[string]string `json:"tags,omitempty"` // sqlcommenter：/*key='value',...*/ 解码后的键值
+}
+
+// Hint 一条优化器提示：来自 /*+ ... */ 注释或 SQL Server 的 OPTION (...)
+type Hint struct {
+	Name   string   `json:"name"` // 统一大写，如 INDEX / MAX_EXECUTION_TIME / MAXDOP / OPTIMIZE FOR
+	Args   []string `json:"args,omitempty"`
+	Source string   `json:"source"` // comment / option
+	Start  int      `json:"start"`
+	End    int      `json:"end"`
+}
+
+// sqlcommenter 形态：key='value'(,key='value')*
+var reSQLCommenter = regexp.MustCompile(`^\s*[A-Za-z0-9_.%-]+='(?:[^'\\]|\\.)*'(?:\s*,\s*[A-Za-z0-9_.%-]+='(?:[^'\\]|\\.)*')*\s*$`)
+var reSQLCommenterPair = regexp.MustCompile(`([A-Za-z0-9_.%-]+)='((?:[^'\\]|\\.)*)'`)
+var reHintEq = regexp.MustCompile(`\s*=\s*`)
+
+// ExtractComments 收集注释：MySQL 沿用 findMySQLCommentSpans（含 /*! */ 版本注释），其它方言取隐藏通道的注释 token
+func ExtractComments(original string, toks []antlr.Token, opt Options) []Comment {
+	var out []Comment
+	add := func(s, e int) {
+		for e > s && (original[e-1] == '\n' || original[e-1] == '\r') {
+			e--
+		}
+		if e <= s {
+			return
+		}
+		c := Comment{Text: original[s:e], Start: s, End: e, Kind: commentKind(original[s:e])}
+		if c.Kind == "block" {
+			c.Tags = parseSQLCommenter(c.Text)
+		}
+		out = append(out, c)
+	}
+	if opt.Dialect == MySQL {
+		for _, sp := range findMySQLCommentSpans(original) {
+			add(sp.S, sp.E)
+		}
+		return out
+	}
+	for _, t := range toks {
+		if IsEOFToken(t) || t.GetChannel() == antlr.TokenDefaultChannel {
+			continue
+		}
+		txt := t.GetText()
+		if !strings.HasPrefix(txt, "--") && !strings.HasPrefix(txt, "/*") {
+			continue
+		}
+		add(RuneIndexToByte(original, t.GetStart()), RuneIndexToByte(original, t.GetStop()+1))
+	}
+	return out
+}
+
+func commentKind(text string) string {
+	switch {
+	case strings.HasPrefix(text, "/*+"), strings.HasPrefix(text, "--+"):
+		return "hint"
+	case strings.HasPrefix(text, "/*!"):
+		return "version"
+	case strings.HasPrefix(text, "/*"):
+		return "block"
+	}
+	return "line"
+}
+
+// parseSQLCommenter 解析 sqlcommenter 标签：值先去掉 \' 转义，再做 URL 解码
+func parseSQLCommenter(text string) map[string]string {
+	body := strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	if !reSQLCommenter.MatchString(body) {
+		return nil
+	}
+	tags := map[string]string{}
+	for _, m := range reSQLCommenterPair.FindAllStringSubmatch(body, -1) {
+		k, err := url.QueryUnescape(m[1])
+		if err != nil {
+			k = m[1]
+		}
+		v := strings.ReplaceAll(m[2], `\'`, `'`)
+		if dv, err := url.PathUnescape(v); err == nil {
+			v = dv
+		}
+		tags[k] = v
+	}
+	return tags
+}
+
+// ExtractHints 从 hint 注释与 SQL Server OPTION (...) 中解析提示
+func ExtractHints(original string, toks []antlr.Token, comments []Comment, opt Options) []Hint {
+	var out []Hint
+	for _, c := range comments {
+		if c.Kind != "hint" {
+			continue
+		}
+		bodyStart := c.Start + 3
+		bodyEnd := c.End
+		if strings.HasSuffix(c.Text, "*/") {
+			bodyEnd -= 2
+		}
+		out = append(out, parseHintList(original, bodyStart, bodyEnd, "comment")...)
+	}
+	if opt.Dialect == SQLServer {
+		out = append(out, extractOptionClause(original, toks)...)
+	}
+	return out
+}
+
+// parseHintList 解析 "NAME(args) NAME2 NAME3(a b)" 形式（Oracle / MySQL 提示）
+func parseHintList(s string, lo, hi int, source string) []Hint {
+	var out []Hint
+	i := lo
+	for i < hi {
+		for i < hi && (isHintSpace(s[i]) || s[i] == ',') {
+			i++
+		}
+		if i >= hi {
+			break
+		}
+		start := i
+		for i < hi && isHintNameByte(s[i]) {
+			i++
+		}
+		if i == start {
+			// 非提示字符（如注释文字），跳过
+			i++
+			continue
+		}
+		h := Hint{Name: strings.ToUpper(s[start:i]), Source: source, Start: start, End: i}
+		j := i
+		for j < hi && isHintSpace(s[j]) {
+			j++
+		}
+		if j < hi && s[j] == '(' {
+			if k := matchParen(s, j, hi); k > j {
+				h.Args = splitHintArgs(s[j+1 : k])
+				h.End = k + 1
+				i = k + 1
+			}
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// extractOptionClause 解析 SQL Server 查询提示：OPTION (RECOMPILE, MAXDOP 4, OPTIMIZE FOR (@p = 1))
+func extractOptionClause(original string, toks []antlr.Token) []Hint {
+	var out []Hint
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		if IsEOFToken(t) {
+			break
+		}
+		if t.GetChannel() != antlr.TokenDefaultChannel || !strings.EqualFold(t.GetText(), "OPTION") {
+			continue
+		}
+		nv, j := nextVisibleWithComments(original, toks, i, nil)
+		if nv == nil || nv.GetText() != "(" {
+			continue
+		}
+		lo := RuneIndexToByte(original, nv.GetStart())
+		hi := matchParen(original, lo, len(original))
+		if hi < 0 {
+			continue
+		}
+		for _, it := range splitTopLevel(original, lo+1, hi, ',') {
+			if h, ok := parseOptionItem(original, it[0], it[1]); ok {
+				out = append(out, h)
+			}
+		}
+		i = j
+	}
+	return out
+}
+
+// parseOptionItem：前导单词组成名字（HASH JOIN / OPTIMIZE FOR / USE HINT），其后为参数
+func parseOptionItem(s string, lo, hi int) (Hint, bool) {
+	for lo < hi && isHintSpace(s[lo]) {
+		lo++
+	}
+	for hi > lo && isHintSpace(s[hi-1]) {
+		hi--
+	}
+	if lo >= hi {
+		return Hint{}, false
+	}
+	h := Hint{Source: "option", Start: lo, End: hi}
+	var words []string
+	i := lo
+	for i < hi {
+		for i < hi && isHintSpace(s[i]) {
+			i++
+		}
+		st := i
+		for i < hi && isHintNameByte(s[i]) && !(s[i] >= '0' && s[i] <= '9' && i == st) {
+			i++
+		}
+		if i == st {
+			break
+		}
+		words = append(words, strings.ToUpper(s[st:i]))
+	}
+	h.Name = strings.Join(words, " ")
+	rest := strings.TrimSpace(s[i:hi])
+	if strings.HasPrefix(rest, "(") && strings.HasSuffix(rest, ")") {
+		rest = rest[1 : len(rest)-1]
+		for _, it := range splitTopLevel(rest, 0, len(rest), ',') {
+			if a := strings.TrimSpace(rest[it[0]:it[1]]); a != "" {
+				h.Args = append(h.Args, a)
+			}
+		}
+	} else if rest != "" {
+		h.Args = []string{rest}
+	}
+	return h, h.Name != ""
+}
+
+// splitHintArgs：有顶层逗号按逗号切，否则按空白切；"a = 1" 收敛为 "a=1"
+func splitHintArgs(body string) []string {
+	var out []string
+	parts := splitTopLevel(body, 0, len(body), ',')
+	if len(parts) > 1 {
+		for _, p := range parts {
+			if a := strings.TrimSpace(body[p[0]:p[1]]); a != "" {
+				out = append(out, a)
+			}
+		}
+		return out
+	}
+	body = reHintEq.ReplaceAllString(body, "=")
+	return strings.Fields(body)
+}
+
+// splitTopLevel 在 [lo,hi) 内按顶层分隔符切分（忽略括号与引号内部），返回各段区间
+func splitTopLevel(s string, lo, hi int, sep byte) [][2]int {
+	var out [][2]int
+	depth := 0
+	var quote byte
+	start := lo
+	for i := lo; i < hi; i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if c == sep && depth == 0 {
+				out = append(out, [2]int{start, i})
+				start = i + 1
+			}
+		}
+	}
+	return append(out, [2]int{start, hi})
+}
+
+// matchParen 返回与 s[open]=='(' 匹配的 ')' 下标；找不到返回 -1
+func matchParen(s string, open, hi int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < hi; i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isHintSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isHintNameByte(c byte) bool {
+	return c == '_' || c == '$' || ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9')
+}
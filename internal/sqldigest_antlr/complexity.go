@@ -0,0 +1,413 @@
+package sqldigest_antlr
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// 语句复杂度指标：只看 token 流与语句切分，不做语法分析。
+// Options.Complexity 打开时由 BuildDigestANTLR 逐条语句计算，写入 Result.Complexity（与 SQLType 一一对应）。
+
+// Complexity 一条语句的复杂度指标
+type Complexity struct {
+	Joins              int            `json:"joins"`                // 显式 JOIN / APPLY 与 FROM 里的逗号连接
+	JoinTypes          map[string]int `json:"join_types,omitempty"` // INNER / LEFT / RIGHT / FULL / CROSS / NATURAL / APPLY / STRAIGHT_JOIN / COMMA
+	Subqueries         int            `json:"subqueries"`           // 括号里的 SELECT（CTE 本体不算）
+	SubqueryDepth      int            `json:"subquery_depth"`       // 子查询最大嵌套层数
+	CTEs               int            `json:"ctes"`
+	RecursiveCTEs      int            `json:"recursive_ctes"`      // 本体引用了自己名字的 CTE
+	UnionBranches      int            `json:"union_branches"`      // UNION / INTERSECT / EXCEPT / MINUS 连接的分支总数；没有集合运算为 0
+	WindowFunctions    int            `json:"window_functions"`    // 带 OVER 的函数调用
+	AggregateFunctions int            `json:"aggregate_functions"` // 不带 OVER 的聚合函数调用
+	InLists            []int          `json:"in_lists,omitempty"`  // 每个 IN (...) 值列表的元素个数（IN 子查询不算）
+	MaxInList          int            `json:"max_in_list"`
+	Predicates         int            `json:"predicates"`       // WHERE / ON / HAVING / QUALIFY / CONNECT BY / START WITH 里的比较、LIKE、IN、BETWEEN、IS、EXISTS
+	Tables             []string       `json:"tables,omitempty"` // 引用的表（原文写法，忽略大小写去重）；CTE 名与 DUAL 不算
+}
+
+// ComplexityLimits Complexity.Exceeds 的上限；为 0 的指标不检查
+type ComplexityLimits struct {
+	Joins              int `json:"joins,omitempty"`
+	Subqueries         int `json:"subqueries,omitempty"`
+	SubqueryDepth      int `json:"subquery_depth,omitempty"`
+	CTEs               int `json:"ctes,omitempty"`
+	RecursiveCTEs      int `json:"recursive_ctes,omitempty"`
+	UnionBranches      int `json:"union_branches,omitempty"`
+	WindowFunctions    int `json:"window_functions,omitempty"`
+	AggregateFunctions int `json:"aggregate_functions,omitempty"`
+	MaxInList          int `json:"max_in_list,omitempty"`
+	Predicates         int `json:"predicates,omitempty"`
+	Tables             int `json:"tables,omitempty"` // 引用表的个数
+}
+
+// Exceeds 返回超过 limit 的指标名（JSON 字段名）
+func (c Complexity) Exceeds(limit ComplexityLimits) []string {
+	var out []string
+	for _, m := range []struct {
+		name     string
+		val, max int
+	}{
+		{"joins", c.Joins, limit.Joins},
+		{"subqueries", c.Subqueries, limit.Subqueries},
+		{"subquery_depth", c.SubqueryDepth, limit.SubqueryDepth},
+		{"ctes", c.CTEs, limit.CTEs},
+		{"recursive_ctes", c.RecursiveCTEs, limit.RecursiveCTEs},
+		{"union_branches", c.UnionBranches, limit.UnionBranches},
+		{"window_functions", c.WindowFunctions, limit.WindowFunctions},
+		{"aggregate_functions", c.AggregateFunctions, limit.AggregateFunctions},
+		{"max_in_list", c.MaxInList, limit.MaxInList},
+		{"predicates", c.Predicates, limit.Predicates},
+		{"tables", len(c.Tables), limit.Tables},
+	} {
+		if m.max > 0 && m.val > m.max {
+			out = append(out, m.name)
+		}
+	}
+	return out
+}
+
+var aggregateFuncs = toSet(`COUNT COUNT_BIG SUM AVG MIN MAX GROUP_CONCAT STRING_AGG ARRAY_AGG LISTAGG JSON_AGG JSONB_AGG
+JSON_OBJECT_AGG JSONB_OBJECT_AGG JSON_ARRAYAGG JSON_OBJECTAGG XMLAGG STDDEV STDDEV_POP STDDEV_SAMP STDEV STDEVP
+VARIANCE VAR VARP VAR_POP VAR_SAMP BIT_AND BIT_OR BIT_XOR BOOL_AND BOOL_OR EVERY MEDIAN PERCENTILE_CONT
+PERCENTILE_DISC APPROX_COUNT_DISTINCT CHECKSUM_AGG COLLECT`)
+
+// JOIN 前可出现的修饰词（含 SQL Server 的联接提示）
+var joinModifiers = toSet(`LEFT RIGHT FULL INNER CROSS NATURAL OUTER HASH LOOP MERGE REMOTE`)
+
+// 结束条件区域的子句关键字
+var predicateEnd = toSet(`SELECT FROM GROUP ORDER LIMIT OFFSET FETCH SET VALUES UNION INTERSECT EXCEPT MINUS
+RETURNING WINDOW INTO JOIN USING`)
+
+// measureComplexity 逐条语句计算指标
+func measureComplexity(sql string, toks []antlr.Token, stmts []StmtInfo, opt Options) []Complexity {
+	out := make([]Complexity, 0, len(stmts))
+	for n, st := range statementTokens(lintTokens(sql, toks, opt), stmts) {
+		out = append(out, statementComplexity(st, stmts[n].Type))
+	}
+	// 与 SQLType 对齐：空输入的 SQLType 为 ["UNKNOWN"]，对应一条全零指标
+	if len(out) == 0 {
+		out = append(out, Complexity{})
+	}
+	return out
+}
+
+// statementTokens 按语句切分 lintTokens 的结果（去掉结尾的 ;），与 stmts 一一对应
+func statementTokens(all []LintToken, stmts []StmtInfo) [][]LintToken {
+	out := make([][]LintToken, 0, len(stmts))
+	k := 0
+	for _, si := range stmts {
+		for k < len(all) && all[k].Start < si.StartByte {
+			k++
+		}
+		lo := k
+		for k < len(all) && all[k].End <= si.EndByte {
+			k++
+		}
+		st := all[lo:k]
+		if n := len(st); n > 0 && st[n-1].Text == ";" {
+			st = st[:n-1]
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// lintItoks 转成 itok，供 qualifiedName 等按下标共用
+func lintItoks(toks []LintToken) []itok {
+	v := make([]itok, len(toks))
+	for i, t := range toks {
+		v[i] = itok{text: t.Text, up: t.Upper, start: t.Start, end: t.End}
+	}
+	return v
+}
+
+func statementComplexity(toks []LintToken, typ string) Complexity {
+	var c Complexity
+	ctes := findCTEs(toks)
+	cteBody := map[int]bool{}
+	cteName := map[string]bool{}
+	for _, d := range ctes {
+		cteBody[d.open] = true
+		cteName[strings.ToUpper(unquoteIdent(d.name))] = true
+		c.CTEs++
+		for k := d.open + 1; k < d.close; k++ {
+			if strings.EqualFold(unquoteIdent(toks[k].Text), unquoteIdent(d.name)) && !(k+1 < d.close && toks[k+1].Text == "(") {
+				c.RecursiveCTEs++
+				break
+			}
+		}
+	}
+
+	join := func(kind string) {
+		if c.JoinTypes == nil {
+			c.JoinTypes = map[string]int{}
+		}
+		c.Joins++
+		c.JoinTypes[kind]++
+	}
+	scope := selectScopes(toks)
+	v := lintItoks(toks)
+	seen := map[string]bool{}
+	// cols：名字后的括号是列清单（INSERT INTO t (...) / CREATE TABLE t (...)），否则视为表函数
+	table := func(i int, cols bool) {
+		for i < len(toks) {
+			switch toks[i].Upper {
+			case "ONLY", "LATERAL", "LOW_PRIORITY", "IGNORE", "QUICK":
+				i++
+				continue
+			}
+			break
+		}
+		parts, link, end, ok := qualifiedName(v, i)
+		if !ok || (!cols && end+1 < len(toks) && toks[end+1].Text == "(") {
+			return // 子查询、表函数
+		}
+		name := toks[i].Text
+		for k := i + 1; k <= end; k++ {
+			name += toks[k].Text
+		}
+		if len(parts) == 1 && link == "" && (cteName[strings.ToUpper(unquoteIdent(parts[0]))] || strings.EqualFold(parts[0], "DUAL")) {
+			return
+		}
+		if key := strings.ToUpper(name); !seen[key] {
+			seen[key] = true
+			c.Tables = append(c.Tables, name)
+		}
+	}
+
+	// 括号栈：是否子查询、所在条件区域、集合运算个数
+	type level struct {
+		sub, pred bool
+		setOps    int
+	}
+	stack := []level{{}}
+	subDepth := 0
+	closeLevel := func(l level) {
+		if l.setOps > 0 {
+			c.UnionBranches += l.setOps + 1
+		}
+		if l.sub {
+			subDepth--
+		}
+	}
+	for i, t := range toks {
+		top := &stack[len(stack)-1]
+		next := func(k int) string {
+			if i+k < len(toks) {
+				return toks[i+k].Upper
+			}
+			return ""
+		}
+		prev := ""
+		if i > 0 {
+			prev = toks[i-1].Upper
+		}
+		switch t.Text {
+		case "(":
+			l := level{pred: top.pred}
+			if u := next(1); (u == "SELECT" || u == "WITH") && !cteBody[i] {
+				l.sub = true
+				c.Subqueries++
+				subDepth++
+				c.SubqueryDepth = max(c.SubqueryDepth, subDepth)
+			}
+			stack = append(stack, l)
+			continue
+		case ")":
+			if len(stack) > 1 {
+				closeLevel(*top)
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		switch u := t.Upper; {
+		case u == "WHERE" || u == "HAVING" || u == "QUALIFY" ||
+			(u == "ON" && !joinOnExcluded(next(1))) ||
+			(u == "BY" && prev == "CONNECT") || (u == "WITH" && prev == "START"):
+			top.pred = true
+		case predicateEnd[u] && !(u == "FROM" && prev == "DISTINCT"):
+			top.pred = false
+		}
+
+		switch u := t.Upper; {
+		case u == "UNION" || u == "INTERSECT" || u == "EXCEPT" || u == "MINUS":
+			top.setOps++
+		case u == "JOIN":
+			kind, k := "INNER", i-1
+			for ; k >= 0 && joinModifiers[toks[k].Upper]; k-- {
+				switch m := toks[k].Upper; m {
+				case "LEFT", "RIGHT", "FULL", "CROSS":
+					kind = m
+				case "NATURAL":
+					if kind == "INNER" {
+						kind = m
+					}
+				}
+			}
+			join(kind)
+			table(i+1, false)
+		case u == "STRAIGHT_JOIN" && prev != "SELECT":
+			join(u)
+			table(i+1, false)
+		case u == "APPLY" && (prev == "CROSS" || prev == "OUTER"):
+			join(u)
+		case u == "FROM" && (scope[i] || (typ == "DELETE" && t.Depth == 0)) && prev != "DISTINCT":
+			table(i+1, false)
+			if !scope[i] {
+				break
+			}
+			end := clauseEnd(toks, i+1, t.Depth)
+			for j := i + 1; j+1 < end; j++ {
+				if toks[j].Text == "," && toks[j].Depth == t.Depth {
+					join("COMMA")
+					table(j+1, false)
+				}
+			}
+		case u == "UPDATE" && t.Depth == 0 && (i == 0 || prev == ")") && typ == "UPDATE":
+			k := i + 1
+			if next(1) == "TOP" && next(2) == "(" {
+				k = closeParen(toks, i+2) + 1
+			}
+			table(k, false)
+		case u == "INTO" && !scope[i] && (typ == "INSERT" || typ == "MERGE" || typ == "REPLACE"):
+			table(i+1, true)
+		case u == "USING" && typ == "MERGE" && t.Depth == 0:
+			table(i+1, false)
+		case u == "TABLE" && t.Depth == 0 && prev != ".":
+			k := i + 1
+			if next(1) == "IF" {
+				k = i + 3
+				if next(2) == "NOT" {
+					k = i + 4
+				}
+			}
+			table(k, true)
+		case u == "OVER" && prev == ")":
+			c.WindowFunctions++
+		case aggregateFuncs[u] && next(1) == "(" && prev != ".":
+			if !followedByOver(toks, closeParen(toks, i+1)+1) {
+				c.AggregateFunctions++
+			}
+		case u == "IN" && next(1) == "(" && next(2) != "SELECT" && next(2) != "WITH":
+			open := i + 1
+			n, end := 0, closeParen(toks, open)
+			if end > open+1 {
+				n = 1
+			}
+			for k := open + 1; k < end; k++ {
+				if toks[k].Text == "," && toks[k].Depth == toks[open].Depth+1 {
+					n++
+				}
+			}
+			c.InLists = append(c.InLists, n)
+			c.MaxInList = max(c.MaxInList, n)
+		}
+
+		if top.pred && isPredicateToken(toks, i) {
+			c.Predicates++
+		}
+	}
+	for len(stack) > 0 {
+		closeLevel(stack[len(stack)-1])
+		stack = stack[:len(stack)-1]
+	}
+	return c
+}
+
+// ON DUPLICATE KEY / ON CONFLICT / ON DELETE CASCADE 等不是连接条件
+func joinOnExcluded(next string) bool {
+	switch next {
+	case "DUPLICATE", "CONFLICT", "DELETE", "UPDATE", "COMMIT":
+		return true
+	}
+	return false
+}
+
+// followedByOver：跳过 WITHIN GROUP (...) / FILTER (...) / KEEP (...) 后是否是 OVER
+func followedByOver(toks []LintToken, k int) bool {
+	for k < len(toks) {
+		switch toks[k].Upper {
+		case "OVER":
+			return true
+		case "WITHIN":
+			k++
+			fallthrough
+		case "FILTER", "KEEP":
+			if k+1 < len(toks) && toks[k+1].Text == "(" {
+				k = closeParen(toks, k+1) + 1
+				continue
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// isPredicateToken：比较运算符（被 lexer 拆开的 < = 只算一次）、LIKE 族、IN、BETWEEN、IS、EXISTS
+func isPredicateToken(toks []LintToken, i int) bool {
+	t := toks[i]
+	switch t.Upper {
+	case "LIKE", "ILIKE", "RLIKE", "REGEXP", "SIMILAR", "IN", "BETWEEN", "EXISTS":
+		return true
+	case "IS":
+		return i+1 < len(toks) && toks[i+1].Upper != "OF" // Oracle IS OF (type)
+	case "=", "<>", "!=", "<", ">", "<=", ">=", "<=>", "^=":
+		if i > 0 && toks[i-1].End == t.Start {
+			switch toks[i-1].Text {
+			case "<", ">", "!", "^":
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+type cteDef struct {
+	name        string
+	open, close int // 本体括号
+}
+
+// findCTEs：WITH [RECURSIVE] name [(cols)] AS [[NOT] MATERIALIZED] ( ... ) [, ...]
+func findCTEs(toks []LintToken) []cteDef {
+	var out []cteDef
+	for i, t := range toks {
+		if t.Upper != "WITH" {
+			continue
+		}
+		k := i + 1
+		if k < len(toks) && toks[k].Upper == "RECURSIVE" {
+			k++
+		}
+		for k < len(toks) {
+			name := k
+			if !(looksLikeIdent(toks[k].Text) || isQuotedIdent(toks[k].Text)) {
+				break
+			}
+			k++
+			if k < len(toks) && toks[k].Text == "(" {
+				k = closeParen(toks, k) + 1
+			}
+			if k >= len(toks) || toks[k].Upper != "AS" {
+				break
+			}
+			k++
+			for k < len(toks) && (toks[k].Upper == "NOT" || toks[k].Upper == "MATERIALIZED") {
+				k++
+			}
+			if k >= len(toks) || toks[k].Text != "(" {
+				break
+			}
+			end := closeParen(toks, k)
+			out = append(out, cteDef{name: toks[name].Text, open: k, close: end})
+			k = end + 1
+			if k >= len(toks) || toks[k].Text != "," {
+				break
+			}
+			k++
+		}
+	}
+	return out
+}
@@ -0,0 +1,1035 @@
+package sqldigest_antlr
+
+import (
+	"strings"
+)
+
+// DDL 解析：CREATE TABLE / ALTER TABLE / CREATE INDEX / DROP 拆成结构化对象（表、列、类型、可空、默认值、键、索引），
+// 只看 token 流，不连数据库。SchemaFromDDL 按顺序把这些语句应用到一个 Schema 上，得到脚本执行后的表结构。
+// 名字一律去引号；类型、默认值、CHECK 与索引表达式保留原文。
+
+// DDLStatement 一条 DDL 语句
+type DDLStatement struct {
+	Statement   int               `json:"statement"` // 从 1 起，与 Split 的序号一致
+	Kind        string            `json:"kind"`      // CREATE TABLE / ALTER TABLE / CREATE INDEX / DROP TABLE / DROP INDEX / DROP VIEW ...
+	Start       int               `json:"start"`     // 字节区间
+	End         int               `json:"end"`
+	Name        string            `json:"name"`                    // 目标对象（表 / 索引 / 视图），限定名各段以 . 连接
+	Table       *TableDef         `json:"table,omitempty"`         // CREATE TABLE
+	Index       *IndexDef         `json:"index,omitempty"`         // CREATE INDEX；DROP INDEX ... ON t 时只有 Name / Table
+	Actions     []AlterAction     `json:"actions,omitempty"`       // ALTER TABLE
+	Names       []string          `json:"names,omitempty"`         // DROP 的全部对象
+	IfExists    bool              `json:"if_exists,omitempty"`     // DROP / ALTER ... IF EXISTS
+	IfNotExists bool              `json:"if_not_exists,omitempty"` // CREATE ... IF NOT EXISTS
+	Cascade     bool              `json:"cascade,omitempty"`       // DROP ... CASCADE [CONSTRAINTS]
+	Options     map[string]string `json:"options,omitempty"`       // ALGORITHM / LOCK / ONLINE / CONCURRENTLY 等执行选项，键大写
+	Unsupported bool              `json:"unsupported,omitempty"`
+}
+
+// TableDef 表结构
+type TableDef struct {
+	Database    string          `json:"database,omitempty"` // 三段名的首段
+	Schema      string          `json:"schema,omitempty"`   // 两段名的首段（MySQL 即库名）
+	Name        string          `json:"name"`
+	Temporary   bool            `json:"temporary,omitempty"`
+	AsQuery     bool            `json:"as_query,omitempty"` // CREATE TABLE ... AS SELECT：列来自查询，Columns 为空
+	Columns     []ColumnDef     `json:"columns"`
+	PrimaryKey  []string        `json:"primary_key,omitempty"`
+	Constraints []ConstraintDef `json:"constraints,omitempty"` // 主键、唯一、外键、CHECK（列级的也收在这里）
+	Indexes     []IndexDef      `json:"indexes,omitempty"`     // 表定义里的 KEY / INDEX，与之后 CREATE INDEX 建的索引
+}
+
+// ColumnDef 列定义
+type ColumnDef struct {
+	Name          string `json:"name"`
+	Type          string `json:"type,omitempty"` // 类型原文，如 varchar(64)、numeric(10,2)、timestamp(3) with time zone
+	Nullable      bool   `json:"nullable"`       // 没写 NOT NULL 且不是主键列
+	Default       string `json:"default,omitempty"`
+	DefaultName   string `json:"default_name,omitempty"`   // SQL Server 默认值约束名
+	AutoIncrement bool   `json:"auto_increment,omitempty"` // AUTO_INCREMENT / IDENTITY / SERIAL / GENERATED ... AS IDENTITY
+	Generated     string `json:"generated,omitempty"`      // 计算列表达式
+	OnUpdate      string `json:"on_update,omitempty"`      // MySQL ON UPDATE
+	Charset       string `json:"charset,omitempty"`        // MySQL CHARACTER SET / CHARSET
+	Collation     string `json:"collation,omitempty"`
+	Comment       string `json:"comment,omitempty"` // 注释原文（含引号）
+}
+
+// ConstraintDef 约束
+type ConstraintDef struct {
+	Name       string   `json:"name,omitempty"`
+	Kind       string   `json:"kind"` // PRIMARY KEY / UNIQUE / FOREIGN KEY / CHECK
+	Columns    []string `json:"columns,omitempty"`
+	RefTable   string   `json:"ref_table,omitempty"`
+	RefColumns []string `json:"ref_columns,omitempty"`
+	OnDelete   string   `json:"on_delete,omitempty"`
+	OnUpdate   string   `json:"on_update,omitempty"`
+	Check      string   `json:"check,omitempty"` // CHECK 括号里的原文
+}
+
+// IndexDef 索引
+type IndexDef struct {
+	Name    string   `json:"name,omitempty"`
+	Table   string   `json:"table,omitempty"`
+	Columns []string `json:"columns"` // 单列为列名，其它（表达式、前缀长度、ASC / DESC）为原文
+	Include []string `json:"include,omitempty"`
+	Unique  bool     `json:"unique,omitempty"`
+	Kind    string   `json:"kind,omitempty"`   // FULLTEXT / SPATIAL / BITMAP / CLUSTERED / NONCLUSTERED ...
+	Method  string   `json:"method,omitempty"` // USING btree / gin ...
+	Where   string   `json:"where,omitempty"`  // 部分索引的条件原文
+}
+
+// AlterAction ALTER TABLE 里的一个动作
+type AlterAction struct {
+	Kind       string         `json:"kind"`
+	Name       string         `json:"name,omitempty"`     // 被删除 / 修改 / 改名的列、约束或索引
+	NewName    string         `json:"new_name,omitempty"` // RENAME ... TO
+	Column     *ColumnDef     `json:"column,omitempty"`   // ADD COLUMN / MODIFY COLUMN 的完整定义；SET TYPE / SET DEFAULT 只填对应字段
+	Constraint *ConstraintDef `json:"constraint,omitempty"`
+	Index      *IndexDef      `json:"index,omitempty"`
+	Text       string         `json:"text"` // 动作原文
+}
+
+// AlterAction.Kind
+const (
+	AlterAddColumn      = "ADD COLUMN"
+	AlterDropColumn     = "DROP COLUMN"
+	AlterModifyColumn   = "MODIFY COLUMN" // 整列重定义：MySQL MODIFY / CHANGE，SQL Server ALTER COLUMN
+	AlterRenameColumn   = "RENAME COLUMN"
+	AlterSetType        = "SET TYPE" // PG ALTER COLUMN TYPE，Oracle MODIFY 带类型
+	AlterSetDefault     = "SET DEFAULT"
+	AlterDropDefault    = "DROP DEFAULT"
+	AlterSetNotNull     = "SET NOT NULL"
+	AlterDropNotNull    = "DROP NOT NULL"
+	AlterAddConstraint  = "ADD CONSTRAINT"
+	AlterDropConstraint = "DROP CONSTRAINT" // MySQL DROP PRIMARY KEY 的 Name 为空
+	AlterAddIndex       = "ADD INDEX"
+	AlterDropIndex      = "DROP INDEX"
+	AlterRenameTable    = "RENAME TABLE"
+	AlterOther          = "OTHER" // 其它（ENGINE=、OWNER TO、VALIDATE CONSTRAINT ...），只有 Text
+)
+
+// 列定义里类型之后的约束 / 属性起始词
+var columnAttrWords = toSet(`NOT NULL DEFAULT PRIMARY UNIQUE REFERENCES CHECK CONSTRAINT COLLATE AUTO_INCREMENT IDENTITY
+GENERATED COMMENT CHARSET ON AS ENCODE ENABLE DISABLE VISIBLE INVISIBLE SPARSE ROWGUIDCOL FILESTREAM STORAGE COMPRESSION
+COLUMN_FORMAT SRID ENCRYPTED MASKED KEY FIRST AFTER USING VIRTUAL STORED PERSISTED`)
+
+// CREATE TABLE 括号里不是列定义的项
+var tableConstraintWords = toSet(`CONSTRAINT PRIMARY UNIQUE FOREIGN KEY INDEX CHECK FULLTEXT SPATIAL EXCLUDE LIKE PERIOD`)
+
+// 隐含自增的 PG 类型
+var serialTypes = toSet(`SERIAL SERIAL2 SERIAL4 SERIAL8 SMALLSERIAL BIGSERIAL`)
+
+type ddlParser struct {
+	*lineageCtx
+	sql string
+}
+
+// ParseDDL 解析 sql 里的 CREATE TABLE / CREATE INDEX / ALTER TABLE / DROP 语句，其它语句（含 CREATE VIEW 等）跳过；
+// 对象名读不出来的标 Unsupported
+func ParseDDL(sql string, opt Options) ([]DDLStatement, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	toks, _, err := lexTokens(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	stmts := SplitStatements(sql, toks, opt)
+	all := lintTokens(sql, toks, opt)
+	if opt.Dialect == Postgres {
+		all, _ = mergeDollarQuoted(sql, all, nil)
+	}
+	var out []DDLStatement
+	for n, st := range statementTokens(all, stmts) {
+		// 前面的批分隔符：SQL Server 的 GO、Oracle 独占一行的 /
+		for len(st) > 1 && (opt.Dialect == SQLServer && st[0].Upper == "GO" || opt.Dialect == Oracle && st[0].Text == "/") &&
+			strings.Contains(sql[st[0].End:st[1].Start], "\n") {
+			st = st[1:]
+		}
+		if len(st) == 0 {
+			continue
+		}
+		p := &ddlParser{lineageCtx: &lineageCtx{toks: st, v: lintItoks(st), dialect: opt.Dialect, base: st[0].Depth}, sql: sql}
+		if s, ok := p.statement(); ok {
+			s.Statement = n + 1
+			s.Start, s.End = st[0].Start, st[len(st)-1].End
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// text toks[a..b) 的原文
+func (p *ddlParser) text(a, b int) string {
+	if a >= b || a >= len(p.toks) {
+		return ""
+	}
+	return p.sql[p.toks[a].Start:p.toks[b-1].End]
+}
+
+// name 读 toks[i] 起的限定名，返回去引号的各段与下一个 token 的下标
+func (p *ddlParser) name(i int) ([]string, int, bool) {
+	parts, _, end, ok := p.nameOf(i)
+	if !ok {
+		return nil, i, false
+	}
+	for k := range parts {
+		parts[k] = unquoteIdent(parts[k])
+	}
+	return parts, end + 1, true
+}
+
+// tok toks[i] 的原文，越界为空
+func (p *ddlParser) tok(i int) string {
+	if i >= 0 && i < len(p.toks) {
+		return p.toks[i].Text
+	}
+	return ""
+}
+
+// ident 读一个（不限定的）名字
+func (p *ddlParser) ident(i int) string {
+	if i < len(p.toks) && (looksLikeIdent(p.toks[i].Text) || isQuotedIdent(p.toks[i].Text)) {
+		return unquoteIdent(p.toks[i].Text)
+	}
+	return ""
+}
+
+// words 判断 toks[i:] 是否依次为 ws
+func (p *ddlParser) words(i int, ws ...string) bool {
+	for k, w := range ws {
+		if p.up(i+k) != w {
+			return false
+		}
+	}
+	return true
+}
+
+// ifExists 跳过 IF [NOT] EXISTS
+func (p *ddlParser) ifExists(i int, s *DDLStatement) int {
+	switch {
+	case p.words(i, "IF", "EXISTS"):
+		s.IfExists = true
+		return i + 2
+	case p.words(i, "IF", "NOT", "EXISTS"):
+		s.IfNotExists = true
+		return i + 3
+	}
+	return i
+}
+
+func (p *ddlParser) statement() (DDLStatement, bool) {
+	var s DDLStatement
+	switch p.up(0) {
+	case "CREATE":
+		k := 1
+		for k < len(p.toks) && p.toks[k].Depth == p.base {
+			switch p.up(k) {
+			case "TABLE":
+				s.Kind = "CREATE TABLE"
+				p.createTable(&s, k)
+				return s, true
+			case "INDEX":
+				s.Kind = "CREATE INDEX"
+				p.createIndex(&s, k)
+				return s, true
+			case "OR", "REPLACE", "GLOBAL", "LOCAL", "TEMPORARY", "TEMP", "UNLOGGED", "PRIVATE", "SHARDED", "DUPLICATED",
+				"UNIQUE", "CLUSTERED", "NONCLUSTERED", "FULLTEXT", "SPATIAL", "BITMAP", "MULTIVALUE", "EXTERNAL":
+				k++
+				continue
+			}
+			return s, false
+		}
+	case "ALTER":
+		if p.up(1) == "TABLE" {
+			s.Kind = "ALTER TABLE"
+			p.alterTable(&s)
+			return s, true
+		}
+	case "DROP":
+		p.drop(&s)
+		return s, s.Kind != ""
+	}
+	return s, false
+}
+
+// createTable：CREATE [...] TABLE [IF NOT EXISTS] name ( 列与约束 ) [选项] | AS SELECT ...
+func (p *ddlParser) createTable(s *DDLStatement, k int) {
+	t := &TableDef{}
+	for i := 1; i < k; i++ {
+		if p.up(i) == "TEMPORARY" || p.up(i) == "TEMP" {
+			t.Temporary = true
+		}
+	}
+	s.Table = t
+	k = p.ifExists(k+1, s)
+	parts, k, ok := p.name(k)
+	if !ok {
+		s.Unsupported = true
+		return
+	}
+	setTableName(t, parts)
+	s.Name = strings.Join(parts, ".")
+	if strings.HasPrefix(t.Name, "#") {
+		t.Temporary = true
+	}
+	if p.up(k) != "(" {
+		t.AsQuery = p.find(k, len(p.toks), p.base, is("AS", "SELECT")) < len(p.toks)
+		if !t.AsQuery {
+			s.Unsupported = true // PARTITION OF / LIKE ...
+		}
+		return
+	}
+	cl := closeParen(p.toks, k)
+	if p.find(cl+1, len(p.toks), p.base, is("AS")) < len(p.toks) {
+		t.AsQuery = true // CREATE TABLE t (a, b) AS SELECT ...
+		return
+	}
+	for _, it := range p.items(k+1, cl, p.toks[k].Depth+1) {
+		p.tableItem(t, it[0], it[1])
+	}
+	for _, c := range t.PrimaryKey {
+		if col := t.Column(c); col != nil {
+			col.Nullable = false
+		}
+	}
+}
+
+func setTableName(t *TableDef, parts []string) {
+	t.Name = parts[len(parts)-1]
+	switch len(parts) {
+	case 2:
+		t.Schema = parts[0]
+	case 3:
+		t.Database, t.Schema = parts[0], parts[1]
+	}
+}
+
+// tableItem 表定义括号里的一项：列定义、约束或索引
+func (p *ddlParser) tableItem(t *TableDef, a, b int) {
+	if p.tableConstraint(t, a, b) {
+		return
+	}
+	if tableConstraintWords[p.up(a)] || !(looksLikeIdent(p.toks[a].Text) || isQuotedIdent(p.toks[a].Text)) {
+		return // EXCLUDE / LIKE / PERIOD FOR ...
+	}
+	col, cons := p.columnDef(a, b)
+	t.Columns = append(t.Columns, col)
+	for _, c := range cons {
+		if c.Kind == "PRIMARY KEY" {
+			t.PrimaryKey = c.Columns
+		}
+		t.Constraints = append(t.Constraints, c)
+	}
+}
+
+// tableConstraint 表级约束或 MySQL / SQL Server 的内联索引；不是则返回 false
+func (p *ddlParser) tableConstraint(t *TableDef, a, b int) bool {
+	if c, ok := p.constraint(a, b); ok {
+		if c.Kind == "PRIMARY KEY" {
+			t.PrimaryKey = c.Columns
+		}
+		t.Constraints = append(t.Constraints, c)
+		return true
+	}
+	if ix, ok := p.inlineIndex(a, b); ok {
+		t.Indexes = append(t.Indexes, ix)
+		return true
+	}
+	return false
+}
+
+// constraint：[CONSTRAINT name] PRIMARY KEY (...) | UNIQUE [KEY|INDEX] [name] (...) | FOREIGN KEY [name] (...) REFERENCES ... | CHECK (...)
+func (p *ddlParser) constraint(a, b int) (ConstraintDef, bool) {
+	var c ConstraintDef
+	k := a
+	if p.up(k) == "CONSTRAINT" {
+		if p.up(k+1) != "PRIMARY" && p.up(k+1) != "UNIQUE" && p.up(k+1) != "FOREIGN" && p.up(k+1) != "CHECK" {
+			c.Name = p.ident(k + 1)
+			k++
+		}
+		k++
+	}
+	switch p.up(k) {
+	case "PRIMARY":
+		c.Kind = "PRIMARY KEY"
+		k += 2
+	case "UNIQUE":
+		c.Kind = "UNIQUE"
+		k++
+		if p.up(k) == "KEY" || p.up(k) == "INDEX" {
+			k++
+		}
+	case "FOREIGN":
+		c.Kind = "FOREIGN KEY"
+		k += 2
+	case "CHECK":
+		c.Kind = "CHECK"
+		k++
+		if p.up(k) == "(" {
+			cl := closeParen(p.toks, k)
+			c.Check = p.text(k+1, cl)
+		}
+		return c, true
+	default:
+		return c, false
+	}
+	// 列清单前的索引名、CLUSTERED、NULLS NOT DISTINCT、USING BTREE 等
+	for ; k < b && p.up(k) != "("; k++ {
+		switch p.up(k) {
+		case "CLUSTERED", "NONCLUSTERED", "NULLS", "NOT", "DISTINCT":
+		case "USING":
+			k++
+		default:
+			if c.Name == "" && c.Kind != "PRIMARY KEY" {
+				c.Name = p.ident(k)
+			}
+		}
+	}
+	if k >= b {
+		return c, true
+	}
+	c.Columns, k = p.columns(k)
+	if c.Kind == "FOREIGN KEY" {
+		p.references(&c, p.find(k, b, p.toks[a].Depth, is("REFERENCES")), b)
+	}
+	return c, true
+}
+
+// references：REFERENCES t [(cols)] [ON DELETE x] [ON UPDATE y]
+func (p *ddlParser) references(c *ConstraintDef, k, b int) {
+	if p.up(k) != "REFERENCES" {
+		return
+	}
+	parts, k, ok := p.name(k + 1)
+	if !ok {
+		return
+	}
+	c.RefTable = strings.Join(parts, ".")
+	if p.up(k) == "(" {
+		c.RefColumns, k = p.columns(k)
+	}
+	for ; k < b; k++ {
+		if p.up(k) != "ON" || (p.up(k+1) != "DELETE" && p.up(k+1) != "UPDATE") {
+			continue
+		}
+		e := k + 3
+		for e < b && (p.up(e) == "NULL" || p.up(e) == "ACTION" || p.up(e) == "DEFAULT") {
+			e++
+		}
+		action := strings.ToUpper(p.text(k+2, e))
+		if p.up(k+1) == "DELETE" {
+			c.OnDelete = action
+		} else {
+			c.OnUpdate = action
+		}
+		k = e - 1
+	}
+}
+
+// columns 读 (a, b(10), c DESC)，返回各项去引号的首个名字与 ) 之后的下标
+func (p *ddlParser) columns(open int) ([]string, int) {
+	names, cl := p.nameList(open)
+	return names, cl + 1
+}
+
+// indexColumns 读索引列：单个名字去引号，其它保留原文
+func (p *ddlParser) indexColumns(open int) ([]string, int) {
+	cl := closeParen(p.toks, open)
+	var out []string
+	for _, it := range p.items(open+1, cl, p.toks[open].Depth+1) {
+		if it[1] == it[0]+1 && p.ident(it[0]) != "" {
+			out = append(out, p.ident(it[0]))
+		} else {
+			out = append(out, p.text(it[0], it[1]))
+		}
+	}
+	return out, cl + 1
+}
+
+// inlineIndex：MySQL 的 {KEY|INDEX} [name] [USING x] (cols)、FULLTEXT / SPATIAL；SQL Server 的 INDEX name [CLUSTERED] (cols)
+func (p *ddlParser) inlineIndex(a, b int) (IndexDef, bool) {
+	var ix IndexDef
+	k := a
+	if p.up(k) == "FULLTEXT" || p.up(k) == "SPATIAL" {
+		ix.Kind = p.up(k)
+		k++
+	}
+	if p.up(k) != "KEY" && p.up(k) != "INDEX" {
+		return ix, ix.Kind != "" && p.up(k) == "("
+	}
+	for k++; k < b && p.up(k) != "("; k++ {
+		switch p.up(k) {
+		case "CLUSTERED", "NONCLUSTERED":
+			ix.Kind = p.up(k)
+		case "USING":
+			ix.Method = p.tok(k + 1)
+			k++
+		default:
+			if ix.Name == "" {
+				ix.Name = p.ident(k)
+			}
+		}
+	}
+	if k < b {
+		ix.Columns, k = p.indexColumns(k)
+	}
+	if p.up(k) == "USING" && k+1 < b {
+		ix.Method = p.tok(k + 1)
+	}
+	return ix, true
+}
+
+// columnDef：name type [属性 ...]；返回列与列级约束
+func (p *ddlParser) columnDef(a, b int) (ColumnDef, []ConstraintDef) {
+	if a >= b {
+		return ColumnDef{Nullable: true}, nil
+	}
+	col := ColumnDef{Name: unquoteIdent(p.toks[a].Text), Nullable: true}
+	depth := p.toks[a].Depth
+	e := a + 1
+	for e < b && !(p.toks[e].Depth == depth && (columnAttrWords[p.up(e)] || p.words(e, "CHARACTER", "SET"))) {
+		e++
+	}
+	col.Type = typeText(p.toks[a+1 : e])
+	if serialTypes[strings.ToUpper(col.Type)] {
+		col.AutoIncrement, col.Nullable = true, false
+	}
+	var cons []ConstraintDef
+	name := ""
+	for k := e; k < b; {
+		next := k + 1
+		switch p.up(k) {
+		case "NOT":
+			if p.up(k+1) == "NULL" {
+				col.Nullable = false
+				next = k + 2
+			}
+		case "NULL":
+			col.Nullable = true
+		case "CONSTRAINT":
+			name = p.ident(k + 1)
+			next = k + 2
+		case "DEFAULT":
+			next = p.attrEnd(k+2, b, depth)
+			col.Default, col.DefaultName = p.text(k+1, next), name
+			name = ""
+		case "ON":
+			if p.up(k+1) == "UPDATE" {
+				next = p.attrEnd(k+3, b, depth)
+				col.OnUpdate = p.text(k+2, next)
+			}
+		case "COLLATE":
+			col.Collation = unquoteIdent(p.tok(k + 1))
+			next = k + 2
+		case "COMMENT":
+			col.Comment = p.tok(k + 1)
+			next = k + 2
+		case "AUTO_INCREMENT":
+			col.AutoIncrement = true
+		case "IDENTITY":
+			col.AutoIncrement, col.Nullable = true, false
+			if p.up(k+1) == "(" {
+				next = closeParen(p.toks, k+1) + 1
+			}
+		case "GENERATED", "AS":
+			// GENERATED {ALWAYS|BY DEFAULT} AS IDENTITY [(...)] | GENERATED ALWAYS AS (expr) [STORED] | AS (expr)
+			as := k
+			for as < b && p.up(as) != "AS" {
+				as++
+			}
+			switch {
+			case p.up(as+1) == "IDENTITY":
+				col.AutoIncrement, col.Nullable = true, false
+				next = as + 2
+				if p.up(next) == "(" {
+					next = closeParen(p.toks, next) + 1
+				}
+			case p.up(as+1) == "(":
+				cl := closeParen(p.toks, as+1)
+				col.Generated = p.text(as+2, cl)
+				next = cl + 1
+			default: // SQL Server：c AS a + b
+				next = p.attrEnd(as+1, b, depth)
+				col.Generated = p.text(as+1, next)
+			}
+		case "PRIMARY":
+			cons = append(cons, ConstraintDef{Name: name, Kind: "PRIMARY KEY", Columns: []string{col.Name}})
+			col.Nullable = false
+			next = k + 2
+			name = ""
+		case "UNIQUE":
+			cons = append(cons, ConstraintDef{Name: name, Kind: "UNIQUE", Columns: []string{col.Name}})
+			if p.up(k+1) == "KEY" {
+				next = k + 2
+			}
+			name = ""
+		case "FOREIGN": // SQL Server：[CONSTRAINT n] FOREIGN KEY REFERENCES ...
+			next = k + 2
+		case "KEY": // MySQL 列级 KEY 即主键
+			cons = append(cons, ConstraintDef{Name: name, Kind: "PRIMARY KEY", Columns: []string{col.Name}})
+			col.Nullable = false
+			name = ""
+		case "REFERENCES":
+			c := ConstraintDef{Name: name, Kind: "FOREIGN KEY", Columns: []string{col.Name}}
+			_, next, _ = p.name(k + 1)
+			if p.up(next) == "(" {
+				next = closeParen(p.toks, next) + 1
+			}
+			for next < b && p.up(next) == "ON" && (p.up(next+1) == "DELETE" || p.up(next+1) == "UPDATE") {
+				switch p.up(next + 2) {
+				case "SET", "NO":
+					next += 4
+				default:
+					next += 3
+				}
+			}
+			p.references(&c, k, min(next, b))
+			cons = append(cons, c)
+			name = ""
+		case "CHECK":
+			c := ConstraintDef{Name: name, Kind: "CHECK"}
+			if p.up(k+1) == "(" {
+				cl := closeParen(p.toks, k+1)
+				c.Check = p.text(k+2, cl)
+				next = cl + 1
+			}
+			cons = append(cons, c)
+			name = ""
+		case "CHARACTER", "CHARSET":
+			v := k + 1
+			if p.up(k) == "CHARACTER" && p.up(v) == "SET" {
+				v++
+			}
+			if p.tok(v) == "=" {
+				v++
+			}
+			col.Charset = unquoteIdent(p.tok(v))
+			next = p.attrEnd(v+1, b, depth)
+		case "AFTER":
+			next = k + 2
+		}
+		k = next
+	}
+	return col, cons
+}
+
+// attrEnd 从 lo 起找下一个列属性起始词（至少跨过一个 token）
+func (p *ddlParser) attrEnd(lo, hi, depth int) int {
+	k := lo
+	for k < hi && !(p.toks[k].Depth == depth && (columnAttrWords[p.up(k)] || p.up(k) == "CHARACTER")) {
+		k++
+	}
+	return k
+}
+
+// createIndex：CREATE [UNIQUE] [...] INDEX [CONCURRENTLY] [IF NOT EXISTS] [name] ON [ONLY] t [USING m] (cols) [INCLUDE (...)] [WHERE ...] [WITH (...)] [选项]
+func (p *ddlParser) createIndex(s *DDLStatement, k int) {
+	ix := &IndexDef{}
+	s.Index = ix
+	for i := 1; i < k; i++ {
+		switch u := p.up(i); u {
+		case "UNIQUE":
+			ix.Unique = true
+		case "CLUSTERED", "NONCLUSTERED", "FULLTEXT", "SPATIAL", "BITMAP", "MULTIVALUE":
+			ix.Kind = u
+		}
+	}
+	k++
+	if p.up(k) == "CONCURRENTLY" {
+		p.option(s, "CONCURRENTLY", "")
+		k++
+	}
+	k = p.ifExists(k, s)
+	if p.up(k) != "ON" {
+		parts, next, ok := p.name(k)
+		if !ok {
+			s.Unsupported = true
+			return
+		}
+		ix.Name = parts[len(parts)-1]
+		s.Name = strings.Join(parts, ".")
+		k = next
+	}
+	if p.up(k) != "ON" {
+		s.Unsupported = true
+		return
+	}
+	k++
+	if p.up(k) == "ONLY" {
+		k++
+	}
+	parts, k, ok := p.name(k)
+	if !ok {
+		s.Unsupported = true
+		return
+	}
+	ix.Table = strings.Join(parts, ".")
+	if p.up(k) == "USING" {
+		ix.Method = p.tok(k + 1)
+		k += 2
+	}
+	if p.up(k) != "(" {
+		s.Unsupported = true
+		return
+	}
+	ix.Columns, k = p.indexColumns(k)
+	n := len(p.toks)
+	for k < n {
+		switch p.up(k) {
+		case "INCLUDE":
+			if p.up(k+1) == "(" {
+				ix.Include, k = p.columns(k + 1)
+				continue
+			}
+		case "WHERE":
+			e := p.find(k+1, n, p.base, is("WITH", "TABLESPACE", "ONLINE", "ALGORITHM", "LOCK"))
+			ix.Where = p.text(k+1, e)
+			k = e
+			continue
+		case "USING":
+			if k+1 < n && p.up(k+1) != "INDEX" {
+				ix.Method = p.tok(k + 1)
+			}
+		}
+		k = p.tailOption(s, k)
+	}
+}
+
+// tailOption 读语句尾部的执行选项（ALGORITHM=x、LOCK=x、ONLINE、WITH (ONLINE = ON, ...)），返回下一个下标
+func (p *ddlParser) tailOption(s *DDLStatement, k int) int {
+	switch u := p.up(k); {
+	case (u == "ALGORITHM" || u == "LOCK") && p.toks[k].Depth == p.base:
+		v := k + 1
+		if p.up(v) == "=" {
+			v++
+		}
+		p.option(s, u, p.up(v))
+		return v + 1
+	case u == "ONLINE" && p.toks[k].Depth == p.base:
+		p.option(s, u, "")
+	case u == "WITH" && p.up(k+1) == "(":
+		cl := closeParen(p.toks, k+1)
+		for _, it := range p.items(k+2, cl, p.toks[k+1].Depth+1) {
+			v := ""
+			if it[1]-it[0] >= 3 && p.toks[it[0]+1].Text == "=" {
+				v = strings.ToUpper(p.text(it[0]+2, it[1]))
+			}
+			p.option(s, p.up(it[0]), v)
+		}
+		return cl + 1
+	}
+	return k + 1
+}
+
+func (p *ddlParser) option(s *DDLStatement, k, v string) {
+	if s.Options == nil {
+		s.Options = map[string]string{}
+	}
+	s.Options[k] = v
+}
+
+// alterTable：ALTER TABLE [IF EXISTS] [ONLY] name 动作[, 动作 ...]
+func (p *ddlParser) alterTable(s *DDLStatement) {
+	k := p.ifExists(2, s)
+	if p.up(k) == "ONLY" {
+		k++
+	}
+	parts, k, ok := p.name(k)
+	if !ok {
+		s.Unsupported = true
+		return
+	}
+	s.Name = strings.Join(parts, ".")
+	n := len(p.toks)
+	verb := ""
+	for _, it := range p.items(k, n, p.base) {
+		a, b := it[0], it[1]
+		switch p.up(a) {
+		case "ALGORITHM", "LOCK":
+			p.tailOption(s, a)
+			continue
+		case "ADD", "DROP", "ALTER", "MODIFY", "CHANGE", "RENAME":
+			verb = p.up(a)
+		case "WITH", "NOCHECK", "CHECK":
+			// SQL Server：WITH [NO]CHECK ADD CONSTRAINT ...
+			if v := p.find(a, b, p.base, is("ADD")); v < b {
+				verb, a = "ADD", v
+			} else {
+				verb = ""
+			}
+		default:
+			if verb == "" || p.dialect != SQLServer {
+				s.Actions = append(s.Actions, AlterAction{Kind: AlterOther, Text: p.text(a, b)})
+				continue
+			}
+			a-- // 沿用上一个动作的动词：SQL Server ADD a int, b int / DROP COLUMN a, b
+		}
+		p.alterItem(s, verb, a, b, it[0])
+	}
+}
+
+// alterItem 一个动作；toks[a] 为动词（沿用时 a 指向动词之前），start 为原文起点
+func (p *ddlParser) alterItem(s *DDLStatement, verb string, a, b, start int) {
+	text := p.text(start, b)
+	add := func(act AlterAction) {
+		act.Text = text
+		s.Actions = append(s.Actions, act)
+	}
+	k := a + 1
+	if b > k && p.toks[b-1].Depth == p.base && (p.up(b-1) == "ONLINE") {
+		p.option(s, "ONLINE", "")
+		b--
+	}
+	switch verb {
+	case "ADD":
+		switch {
+		case p.up(k) == "COLUMN":
+			k++
+		case p.up(k) == "(" && closeParen(p.toks, k) == b-1:
+			// Oracle / MySQL：ADD (a int, b int) 或 ADD (CONSTRAINT ...)
+			for _, it := range p.items(k+1, b-1, p.toks[k].Depth+1) {
+				text = p.text(it[0], it[1])
+				p.addItem(add, it[0], it[1])
+			}
+			return
+		}
+		p.addItem(add, k, b)
+	case "DROP":
+		p.dropItem(add, k, b)
+	case "MODIFY":
+		if p.up(k) == "COLUMN" {
+			k++
+		}
+		if p.up(k) == "(" && closeParen(p.toks, k) == b-1 {
+			for _, it := range p.items(k+1, b-1, p.toks[k].Depth+1) {
+				text = p.text(it[0], it[1])
+				p.modifyItem(add, it[0], it[1])
+			}
+			return
+		}
+		p.modifyItem(add, k, b)
+	case "CHANGE":
+		// MySQL：CHANGE [COLUMN] old new 定义
+		if p.up(k) == "COLUMN" {
+			k++
+		}
+		col, _ := p.columnDef(k+1, b)
+		add(AlterAction{Kind: AlterModifyColumn, Name: p.ident(k), Column: &col})
+	case "ALTER":
+		if p.up(k) == "COLUMN" {
+			k++
+		}
+		p.alterColumn(add, k, b)
+	case "RENAME":
+		switch {
+		case p.up(k) == "COLUMN" || (p.up(k+1) == "TO" && p.dialect == Postgres && p.up(k) != "TO"):
+			if p.up(k) == "COLUMN" {
+				k++
+			}
+			add(AlterAction{Kind: AlterRenameColumn, Name: p.ident(k), NewName: p.ident(k + 2)})
+		case p.up(k) == "INDEX" || p.up(k) == "KEY":
+			add(AlterAction{Kind: AlterOther, Name: p.ident(k + 1), NewName: p.ident(k + 3)})
+		case p.up(k) == "CONSTRAINT":
+			add(AlterAction{Kind: AlterOther, Name: p.ident(k + 1), NewName: p.ident(k + 3)})
+		default:
+			if p.up(k) == "TO" || p.up(k) == "AS" {
+				k++
+			}
+			parts, _, _ := p.name(k)
+			add(AlterAction{Kind: AlterRenameTable, NewName: strings.Join(parts, ".")})
+		}
+	}
+}
+
+// addItem：列定义、[CONSTRAINT n] 约束或 MySQL 的索引
+func (p *ddlParser) addItem(add func(AlterAction), a, b int) {
+	if c, ok := p.constraint(a, b); ok {
+		add(AlterAction{Kind: AlterAddConstraint, Name: c.Name, Constraint: &c})
+		return
+	}
+	if ix, ok := p.inlineIndex(a, b); ok {
+		add(AlterAction{Kind: AlterAddIndex, Name: ix.Name, Index: &ix})
+		return
+	}
+	if p.up(a) == "CONSTRAINT" && p.up(a+2) == "DEFAULT" {
+		// SQL Server：ADD CONSTRAINT df DEFAULT expr FOR c
+		if f := p.find(a+3, b, p.toks[a].Depth, is("FOR")); f < b {
+			name := p.ident(f + 1)
+			add(AlterAction{Kind: AlterSetDefault, Name: name, Column: &ColumnDef{Name: name, Default: p.text(a+3, f), DefaultName: p.ident(a + 1)}})
+			return
+		}
+	}
+	if p.words(a, "IF", "NOT", "EXISTS") {
+		a += 3
+	}
+	if a >= b || tableConstraintWords[p.up(a)] {
+		add(AlterAction{Kind: AlterOther})
+		return
+	}
+	col, cons := p.columnDef(a, b)
+	add(AlterAction{Kind: AlterAddColumn, Name: col.Name, Column: &col})
+	for i := range cons {
+		add(AlterAction{Kind: AlterAddConstraint, Name: cons[i].Name, Constraint: &cons[i]})
+	}
+}
+
+// dropItem：[COLUMN] [IF EXISTS] c | (a, b) | CONSTRAINT n | PRIMARY KEY | FOREIGN KEY n | {INDEX|KEY} n | CHECK n | DEFAULT (MySQL ALTER 里) ...
+func (p *ddlParser) dropItem(add func(AlterAction), k, b int) {
+	if p.words(k, "IF", "EXISTS") {
+		k += 2
+	}
+	switch p.up(k) {
+	case "CONSTRAINT", "CHECK":
+		k++
+		if p.words(k, "IF", "EXISTS") {
+			k += 2
+		}
+		add(AlterAction{Kind: AlterDropConstraint, Name: p.ident(k)})
+	case "FOREIGN":
+		add(AlterAction{Kind: AlterDropConstraint, Name: p.ident(k + 2)})
+	case "PRIMARY":
+		add(AlterAction{Kind: AlterDropConstraint, Constraint: &ConstraintDef{Kind: "PRIMARY KEY"}})
+	case "UNIQUE":
+		add(AlterAction{Kind: AlterOther})
+	case "INDEX", "KEY":
+		add(AlterAction{Kind: AlterDropIndex, Name: p.ident(k + 1)})
+	case "PARTITION", "PERIOD", "SYSTEM":
+		add(AlterAction{Kind: AlterOther})
+	case "(":
+		// Oracle：DROP (a, b)
+		names, _ := p.columns(k)
+		for _, c := range names {
+			add(AlterAction{Kind: AlterDropColumn, Name: c})
+		}
+	case "COLUMN":
+		k++
+		if p.words(k, "IF", "EXISTS") {
+			k += 2
+		}
+		if p.up(k) == "(" {
+			p.dropItem(add, k, b)
+			return
+		}
+		add(AlterAction{Kind: AlterDropColumn, Name: p.ident(k)})
+	default:
+		add(AlterAction{Kind: AlterDropColumn, Name: p.ident(k)})
+	}
+}
+
+// modifyItem：MySQL MODIFY 为整列重定义；Oracle MODIFY 只改写出的部分
+func (p *ddlParser) modifyItem(add func(AlterAction), a, b int) {
+	if a >= b || p.up(a) == "CONSTRAINT" || p.up(a) == "PRIMARY" || p.up(a) == "UNIQUE" || p.up(a) == "PARTITION" {
+		add(AlterAction{Kind: AlterOther})
+		return
+	}
+	col, _ := p.columnDef(a, b)
+	if p.dialect != Oracle {
+		add(AlterAction{Kind: AlterModifyColumn, Name: col.Name, Column: &col})
+		return
+	}
+	if col.Type != "" {
+		add(AlterAction{Kind: AlterSetType, Name: col.Name, Column: &ColumnDef{Name: col.Name, Type: col.Type}})
+	}
+	if p.find(a, b, p.toks[a].Depth, is("DEFAULT")) < b {
+		add(AlterAction{Kind: AlterSetDefault, Name: col.Name, Column: &ColumnDef{Name: col.Name, Default: col.Default}})
+	}
+	if nn := p.find(a, b, p.toks[a].Depth, is("NOT")); nn < b && p.up(nn+1) == "NULL" {
+		add(AlterAction{Kind: AlterSetNotNull, Name: col.Name})
+	} else if p.find(a, b, p.toks[a].Depth, is("NULL")) < b {
+		add(AlterAction{Kind: AlterDropNotNull, Name: col.Name})
+	}
+}
+
+// alterColumn：PG / MySQL 的 ALTER [COLUMN] c SET DEFAULT / DROP DEFAULT / [SET DATA] TYPE / SET|DROP NOT NULL；
+// SQL Server 的 ALTER COLUMN c 类型 [NULL|NOT NULL] 是整列重定义
+func (p *ddlParser) alterColumn(add func(AlterAction), k, b int) {
+	name := p.ident(k)
+	switch {
+	case p.words(k+1, "SET", "DEFAULT"):
+		add(AlterAction{Kind: AlterSetDefault, Name: name, Column: &ColumnDef{Name: name, Default: p.text(k+3, b)}})
+	case p.words(k+1, "DROP", "DEFAULT"):
+		add(AlterAction{Kind: AlterDropDefault, Name: name})
+	case p.words(k+1, "SET", "NOT", "NULL"):
+		add(AlterAction{Kind: AlterSetNotNull, Name: name})
+	case p.words(k+1, "DROP", "NOT", "NULL"):
+		add(AlterAction{Kind: AlterDropNotNull, Name: name})
+	case p.words(k+1, "TYPE"), p.words(k+1, "SET", "DATA", "TYPE"):
+		t := k + 2
+		if p.up(k+1) == "SET" {
+			t = k + 4
+		}
+		e := t
+		for e < b && !(p.toks[e].Depth == p.base && (p.up(e) == "USING" || p.up(e) == "COLLATE")) {
+			e++
+		}
+		add(AlterAction{Kind: AlterSetType, Name: name, Column: &ColumnDef{Name: name, Type: typeText(p.toks[t:e])}})
+	case p.dialect == SQLServer && p.up(k+1) != "ADD" && p.up(k+1) != "DROP":
+		col, _ := p.columnDef(k, b)
+		add(AlterAction{Kind: AlterModifyColumn, Name: name, Column: &col})
+	default:
+		add(AlterAction{Kind: AlterOther, Name: name})
+	}
+}
+
+// drop：DROP [TEMPORARY] 对象类型 [CONCURRENTLY] [IF EXISTS] a[, b ...] [ON t] [CASCADE [CONSTRAINTS] | RESTRICT | PURGE]
+func (p *ddlParser) drop(s *DDLStatement) {
+	k := 1
+	if p.up(k) == "TEMPORARY" {
+		k++
+	}
+	obj := k
+	for k < len(p.toks) && p.toks[k].Depth == p.base && looksLikeIdent(p.toks[k].Text) &&
+		!p.words(k, "IF", "EXISTS") && (k == obj || p.up(k) == "VIEW" || p.up(k) == "TABLE" || p.up(k) == "KEY" || p.up(k) == "BODY") {
+		k++
+	}
+	if k == obj {
+		return
+	}
+	s.Kind = "DROP " + strings.ToUpper(p.text(obj, k))
+	if p.up(k) == "CONCURRENTLY" {
+		p.option(s, "CONCURRENTLY", "")
+		k++
+	}
+	k = p.ifExists(k, s)
+	n := len(p.toks)
+	end := p.find(k, n, p.base, is("ON", "CASCADE", "RESTRICT", "PURGE"))
+	for _, it := range p.items(k, end, p.base) {
+		if parts, _, ok := p.name(it[0]); ok {
+			s.Names = append(s.Names, strings.Join(parts, "."))
+		}
+	}
+	if len(s.Names) > 0 {
+		s.Name = s.Names[0]
+	}
+	for k = end; k < n; k++ {
+		switch p.up(k) {
+		case "ON":
+			if parts, next, ok := p.name(k + 1); ok && s.Kind == "DROP INDEX" {
+				s.Index = &IndexDef{Name: s.Name, Table: strings.Join(parts, ".")}
+				k = next - 1
+			}
+		case "CASCADE":
+			s.Cascade = true
+		default:
+			k = p.tailOption(s, k) - 1
+		}
+	}
+}
+
+// typeText 把类型 token 拼成 varchar(10) / numeric(10,2) / double precision 形式
+func typeText(toks []LintToken) string {
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 {
+			switch p := toks[i-1].Text; {
+			case t.Text == "(" || t.Text == ")" || t.Text == "," || t.Text == "[" || t.Text == "]":
+			case p == "(" || p == "[" || p == ",":
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(t.Text)
+	}
+	return b.String()
+}
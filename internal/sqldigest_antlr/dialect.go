@@ -30,18 +30,31 @@ type Options struct {
 	Dialect                Dialect
 	ParamizeTimeFuncs      bool // 是否把 NOW()/CURRENT_DATE 等也参数化（默认 false）
 	CollapseValuesInDigest bool
+	NormalizeBinds         bool     // 把 lexer 拆开的 :name / :1 / @p1 也识别为绑定并在 digest 中统一为 ?（不同驱动的占位风格得到同一 digest）
+	NoBackslashEscapes     bool     // MySQL sql_mode=NO_BACKSLASH_ESCAPES：字符串里的 \ 不是转义符（影响 ExParam.Decoded）
+	PGStatStatements       bool     // 仅 Postgres：digest 输出与 pg_stat_statements.query 相同的规范化文本（常量 → $n，原文其余部分不变）
+	PGVersion              int      // PGStatStatements 对应的服务端主版本（13–18）；16 起非 DML 语句的常量也规范化，18 起含 SET 的值；0 按 18
+	MySQLDigestText        bool     // 仅 MySQL：digest 输出与 performance_schema DIGEST_TEXT 相同的形式（`ident`、?、(...)）
+	UnwrapDynamicSQL       bool     // EXEC('...') / sp_executesql / EXECUTE IMMEDIATE / EXECUTE format(...) / PREPARE ... FROM 里的 SQL 文本再做一次 digest，写入 Result.Nested
+	Complexity             bool     // 逐条语句计算复杂度指标（连接、子查询、CTE、IN 列表等），写入 Result.Complexity
+	Catalog                *Catalog // 可选的 schema 目录：Lineage / Qualify / schema lint 规则按它解析列；digest 不受影响
 }
 
 type ExParam struct {
-	Index     int
-	IndexHash string
-	Type      string
-	Value     string
-	Start     int
-	End       int
+	Index     int    `json:"index"`
+	IndexHash string `json:"index_hash,omitempty"`
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Decoded   string `json:"decoded,omitempty"` // 按方言规则解码后的值（去引号/反转义；十六进制为原始字节）；绑定/函数为空
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
 	// 新增：INSERT ... VALUES (...) , (...), ... 的行/列位置（1-based）
-	Row int // 第几行 VALUES 元组
-	Col int // 该行里的第几个参数（按出现顺序）
+	Row int `json:"row,omitempty"` // 第几行 VALUES 元组
+	Col int `json:"col,omitempty"` // 该行里的第几个参数（按出现顺序）
+	// 绑定占位符（Type 为 Bind/NamedBind）：原始名字与逻辑参数序号（1-based）
+	BindName    string `json:"bind_name,omitempty"`    // :id → "id"，@p1 → "p1"，:1 → "1"；? 与 $n 为空
+	BindOrdinal int    `json:"bind_ordinal,omitempty"` // ? 按出现顺序；$n/:n 取 n；同名命名绑定（如两次 :id）共享同一序号
+	BoundValue  string `json:"bound_value,omitempty"`  // 外部来源（如 PG 日志 DETAIL: parameters）给出的绑定值，SQL 字面量原文
 }
 
 // Result 产物
@@ -49,6 +62,13 @@ type Result struct {
 	Digest  string    `json:"digest,omitempty"`
 	Params  []ExParam `json:"params,omitempty"`
 	SQLType []string  `json:"sql_type,omitempty"` // 新增：按多语句返回每条类型
+	// 注释与优化器提示（digest 中会被去掉，这里保留原文与字节区间）
+	Comments []Comment `json:"comments,omitempty"`
+	Hints    []Hint    `json:"hints,omitempty"`
+	// Options.UnwrapDynamicSQL 时：字符串参数里的动态 SQL 的 digest 与参数（位置相对外层原文）
+	Nested []NestedSQL `json:"nested,omitempty"`
+	// Options.Complexity 时：每条语句的复杂度指标，与 SQLType 按语句对齐
+	Complexity []Complexity `json:"complexity,omitempty"`
 }
 
 func MD5Prefix4(v interface{}) string {
@@ -110,7 +130,9 @@ func BuildDigestANTLR(sql string, opt Options) (Result, error) {
 
 	for i, p := range params {
 		params[i].IndexHash = MD5Prefix4(p.Index)
+		params[i].Decoded = DecodeLiteral(p.Value, p.Type, opt)
 	}
+	annotateBinds(params)
 
 	// 新增：多语句类型收集
 	stmtInfos := SplitStatements(sql, tokens.GetAllTokens(), opt)
@@ -123,10 +145,26 @@ func BuildDigestANTLR(sql string, opt Options) (Result, error) {
 		sqlTypes = []string{"UNKNOWN"}
 	}
 
+	comments := ExtractComments(sql, tokens.GetAllTokens(), opt)
+	hints := ExtractHints(sql, tokens.GetAllTokens(), comments, opt)
+
+	var nested []NestedSQL
+	if opt.UnwrapDynamicSQL {
+		nested = unwrapDynamicSQL(sql, params, opt)
+	}
+	var complexity []Complexity
+	if opt.Complexity {
+		complexity = measureComplexity(sql, tokens.GetAllTokens(), stmtInfos, opt)
+	}
+
 	return Result{
-		Digest:  digest,
-		Params:  params,
-		SQLType: sqlTypes,
+		Digest:     digest,
+		Params:     params,
+		SQLType:    sqlTypes,
+		Comments:   comments,
+		Hints:      hints,
+		Nested:     nested,
+		Complexity: complexity,
 	}, nil
 
 	//return Result{Digest: digest, Params: params}, nil
@@ -0,0 +1,256 @@
+package sqldigest_antlr
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 动态 SQL 展开（Options.UnwrapDynamicSQL）：
+//   SQL Server  EXEC('...') / sp_executesql N'...'
+//   Oracle      EXECUTE IMMEDIATE '...' [USING ...]
+//   Postgres    EXECUTE '...' / EXECUTE format('...', ...) / DO $$ ... $$
+//   MySQL       PREPARE s FROM '...'
+// 被包裹的字符串字面量照常作为外层的 String 参数；其内容按同一方言再做一次 digest，
+// 结果记入 Result.Nested（可递归）。内层参数的 Start/End 映射回外层原文的字节位置，Value 为内层文本。
+
+// NestedSQL 外层某个字符串参数里的动态 SQL
+type NestedSQL struct {
+	ParamIndex int    `json:"param_index"` // 对应外层 ExParam.Index
+	Wrapper    string `json:"wrapper"`     // EXEC / sp_executesql / EXECUTE IMMEDIATE / EXECUTE / format / DO / PREPARE
+	Start      int    `json:"start"`       // 内层文本（不含引号）在外层原文中的字节区间
+	End        int    `json:"end"`
+	Result
+}
+
+type dynWrapper struct {
+	name string
+	re   *regexp.Regexp // 匹配字面量之前的原文尾部
+}
+
+var dynWrappers = map[Dialect][]dynWrapper{
+	SQLServer: {
+		{"EXEC", regexp.MustCompile(`(?i)\bEXEC(?:UTE)?\s*\(\s*$`)},
+		{"sp_executesql", regexp.MustCompile(`(?i)\bsp_executesql\]?\s+(?:@stmt\s*=\s*)?$`)},
+	},
+	Oracle: {
+		{"EXECUTE IMMEDIATE", regexp.MustCompile(`(?i)\bEXECUTE\s+IMMEDIATE\s*$`)},
+	},
+	Postgres: {
+		{"format", regexp.MustCompile(`(?i)\bEXECUTE\s+format\s*\(\s*$`)},
+		{"EXECUTE", regexp.MustCompile(`(?i)\bEXECUTE\s*$`)},
+		{"DO", regexp.MustCompile(`(?i)\bDO\s+(?:LANGUAGE\s+plpgsql\s+)?$`)},
+	},
+	MySQL: {
+		{"PREPARE", regexp.MustCompile(`(?i)\bPREPARE\s+\S+\s+FROM\s*$`)},
+	},
+}
+
+// dynHeadWindow 判断包裹形式时只看字面量之前这么多字节（包裹关键字都很短），避免长脚本里逐个参数扫描整个前缀
+const dynHeadWindow = 256
+
+// dynHead 字面量之前的原文尾部；截断时从下一个非单词字符开始，保证 \b 不会落在单词中间
+func dynHead(original string, end int) string {
+	start := end - dynHeadWindow
+	if start <= 0 {
+		return original[:end]
+	}
+	for start < end && isWordByte(original[start]) {
+		start++
+	}
+	return original[start:end]
+}
+
+// format() 的占位符：%s / %I / %L，可带位置 %1$I 与宽度 %-10s
+var rePGFormatSpec = regexp.MustCompile(`%(?:\d+\$)?-?(?:\d+|\*(?:\d+\$)?)?[sIL]|%%`)
+
+// embeddedText 内层文本及其每个字节在外层原文中的位置（len(off) == len(s)+1）
+type embeddedText struct {
+	s   string
+	off []int
+}
+
+// unwrapDynamicSQL 为外层的每个动态 SQL 字面量生成嵌套 digest
+func unwrapDynamicSQL(original string, params []ExParam, opt Options) []NestedSQL {
+	var out []NestedSQL
+	for _, p := range params {
+		if p.Type != "String" {
+			continue
+		}
+		wrapper := ""
+		head := strings.TrimRight(dynHead(original, p.Start), " \t\r\n")
+		for _, w := range dynWrappers[opt.Dialect] {
+			if w.re.MatchString(head + " ") {
+				wrapper = w.name
+				break
+			}
+		}
+		if wrapper == "" {
+			continue
+		}
+		et, ok := unquoteEmbedded(p.Value, p.Start, opt)
+		if !ok || strings.TrimSpace(et.s) == "" {
+			continue
+		}
+		var specs map[int]string
+		if wrapper == "format" {
+			et, specs = substituteFormatSpecs(et)
+		}
+		res, err := BuildDigestANTLR(et.s, opt)
+		if err != nil {
+			continue
+		}
+		for i, q := range res.Params {
+			if spec, ok := specs[q.Start]; ok {
+				res.Params[i].Type, res.Params[i].Value, res.Params[i].Decoded = "FormatSpec", spec, ""
+				res.Params[i].BindName, res.Params[i].BindOrdinal = "", 0
+			}
+		}
+		if wrapper == "sp_executesql" {
+			if stmt, vals, ok := UnwrapSPExecuteSQL(original); ok && stmt == et.s {
+				AttachNamedBindValues(res.Params, vals)
+			}
+		}
+		mapNestedOffsets(&res, et.off)
+		out = append(out, NestedSQL{
+			ParamIndex: p.Index,
+			Wrapper:    wrapper,
+			Start:      et.off[0],
+			End:        et.off[len(et.s)],
+			Result:     res,
+		})
+	}
+	return out
+}
+
+// mapNestedOffsets 把内层结果（含更深层）里的字节位置换算到外层原文
+func mapNestedOffsets(res *Result, off []int) {
+	at := func(i int) int {
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(off) {
+			i = len(off) - 1
+		}
+		return off[i]
+	}
+	for i := range res.Params {
+		res.Params[i].Start, res.Params[i].End = at(res.Params[i].Start), at(res.Params[i].End)
+	}
+	for i := range res.Comments {
+		res.Comments[i].Start, res.Comments[i].End = at(res.Comments[i].Start), at(res.Comments[i].End)
+	}
+	for i := range res.Hints {
+		res.Hints[i].Start, res.Hints[i].End = at(res.Hints[i].Start), at(res.Hints[i].End)
+	}
+	for i := range res.Nested {
+		n := &res.Nested[i]
+		n.Start, n.End = at(n.Start), at(n.End)
+		mapNestedOffsets(&n.Result, off)
+	}
+}
+
+// unquoteEmbedded 解开字符串字面量并记录位置映射；base 为字面量在外层原文中的起点
+func unquoteEmbedded(raw string, base int, opt Options) (embeddedText, bool) {
+	var et embeddedText
+	identity := func(lo, hi int) (embeddedText, bool) {
+		et.s = raw[lo:hi]
+		for i := lo; i <= hi; i++ {
+			et.off = append(et.off, base+i)
+		}
+		return et, true
+	}
+	// PG $tag$...$tag$
+	if strings.HasPrefix(raw, "$") {
+		j := strings.IndexByte(raw[1:], '$')
+		if j < 0 {
+			return et, false
+		}
+		tag := raw[:j+2]
+		if len(raw) < 2*len(tag) || !strings.HasSuffix(raw, tag) {
+			return et, false
+		}
+		return identity(len(tag), len(raw)-len(tag))
+	}
+	i := 0
+	escapes := opt.Dialect == MySQL && !opt.NoBackslashEscapes
+	if len(raw) > 1 && raw[1] == '\'' {
+		switch raw[0] {
+		case 'N', 'n':
+			i = 1
+		case 'E', 'e':
+			i, escapes = 1, true
+		case 'Q', 'q':
+			// Oracle q'[...]'：内容原样
+			if len(raw) < 5 {
+				return et, false
+			}
+			return identity(3, len(raw)-2)
+		default:
+			return et, false
+		}
+	}
+	if len(raw) < i+2 {
+		return et, false
+	}
+	q := raw[i]
+	if (q != '\'' && q != '"') || raw[len(raw)-1] != q {
+		return et, false
+	}
+	var b strings.Builder
+	for k, end := i+1, len(raw)-1; k < end; {
+		c := raw[k]
+		n := 1
+		switch {
+		case c == q && k+1 < end && raw[k+1] == q:
+			n = 2
+		case c == '\\' && escapes && k+1 < end:
+			n = 2
+			c = raw[k+1]
+			switch c {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'r':
+				c = '\r'
+			case '0':
+				c = 0
+			case 'b':
+				c = '\b'
+			case 'Z':
+				c = 26
+			}
+		}
+		b.WriteByte(c)
+		et.off = append(et.off, base+k)
+		k += n
+	}
+	et.s = b.String()
+	et.off = append(et.off, base+len(raw)-1)
+	return et, true
+}
+
+// substituteFormatSpecs 把 format() 的 %I/%L/%s 换成 ?（按绑定参数处理），%% 换成 %；
+// 返回替换后 ? 的位置 → 原占位符
+func substituteFormatSpecs(et embeddedText) (embeddedText, map[int]string) {
+	var out embeddedText
+	specs := map[int]string{}
+	var b strings.Builder
+	last := 0
+	for _, m := range rePGFormatSpec.FindAllStringIndex(et.s, -1) {
+		b.WriteString(et.s[last:m[0]])
+		out.off = append(out.off, et.off[last:m[0]]...)
+		if et.s[m[0]:m[1]] == "%%" {
+			b.WriteByte('%')
+		} else {
+			specs[b.Len()] = et.s[m[0]:m[1]]
+			b.WriteByte('?')
+		}
+		out.off = append(out.off, et.off[m[0]])
+		last = m[1]
+	}
+	b.WriteString(et.s[last:])
+	out.off = append(out.off, et.off[last:]...)
+	out.s = b.String()
+	return out, specs
+}
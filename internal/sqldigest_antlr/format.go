@@ -0,0 +1,1019 @@
+package sqldigest_antlr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQL 格式化：保留字面量与注释，只调整空白与关键字大小写。
+// 版式只由 token 序列与注释位置决定（不看原文缩进），因此 Format 幂等；
+// 与摘要相同的 token 序列保证语义不变（Signature 前后一致）。
+
+const (
+	KeywordUpper    = "upper"
+	KeywordLower    = "lower"
+	KeywordPreserve = "preserve"
+)
+
+// FormatOptions 格式化选项；零值即默认（大写关键字、两个空格缩进、行宽 80）
+type FormatOptions struct {
+	KeywordCase string `json:"keyword_case,omitempty"` // upper（默认）/ lower / preserve
+	Indent      string `json:"indent,omitempty"`       // 每级缩进，默认两个空格
+	LineWidth   int    `json:"line_width,omitempty"`   // 目标行宽，默认 80；<0 不限
+}
+
+// formatKeywords 参与大小写转换的关键字；函数名、类型名与其它词保持原样
+var formatKeywords = toSet(`ADD ALL ALTER AND ANY APPLY AS ASC BEGIN BETWEEN BY CASCADE CASE CHECK COLUMN COMMIT CONFLICT CONNECT
+CONSTRAINT CREATE CROSS CURRENT DATABASE DECLARE DEFAULT DELETE DESC DISTINCT DISTINCTROW DO DROP DUPLICATE
+ELSE ELSIF END ESCAPE EXCEPT EXISTS EXPLAIN FALSE FETCH FIRST FOLLOWING FOR FOREIGN FROM FULL FUNCTION GRANT
+GROUP HAVING IF IGNORE ILIKE IN INDEX INNER INSERT INTERSECT INTERVAL INTO IS JOIN KEY LAST LATERAL LEFT LIKE
+LIMIT LOOP MATCHED MERGE MINUS NATURAL NEXT NOT NOTHING NULL NULLS OFFSET ON ONLY OPTION OR ORDER OUTER OVER
+PARTITION PERCENT PRECEDING PRIMARY PRIOR PROCEDURE QUALIFY RANGE RECURSIVE REFERENCES REPLACE RETURNING
+REVOKE RIGHT ROLLBACK ROLLUP ROW ROWS SELECT SET SHARE SOME START STRAIGHT_JOIN TABLE THEN TIES TO TOP TRIGGER
+TRUE TRUNCATE UNBOUNDED UNION UNIQUE UPDATE USING VALUES VIEW WHEN WHERE WHILE WINDOW WITH`)
+
+// formatNameAfter 之后的词（跳过 formatNameSkip）是表名等名字；AS 两侧的词是别名或类型。
+// 名字即使与关键字同形也保持原样：MySQL 表名区分大小写。formatReserved 不会是名字，照常转换
+var (
+	formatNameAfter = toSet(`FROM JOIN INTO UPDATE TABLE`)
+	formatNameSkip  = toSet(`IF NOT EXISTS IGNORE LATERAL`)
+	formatReserved  = toSet(`BEGIN END FALSE NULL SELECT SET TRUE VALUES WITH`)
+)
+
+func toSet(words string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(words) {
+		m[w] = true
+	}
+	return m
+}
+
+// 两侧加空格的运算符（* 另在 needSpace 里处理 t.* / COUNT(*)）
+var formatOps = toSet(`= < > <= >= <> != ^= + - * / % || := => -> ->> #> #>> @> <@ && <=> ^ | & << >> ~ !~ ~* !~* ~~`)
+
+// 其后的 ( 总留空格：IN (...)、EXISTS (...)、AS (...)
+var spaceBeforeParen = toSet(`IN EXISTS AS ON USING AND OR NOT THEN ELSE WHEN FROM JOIN WHERE OVER SELECT ALL ANY SOME`)
+
+const (
+	fWord  = iota // 标识符 / 关键字 / 字面量 / 绑定
+	fOp           // formatOps
+	fPunct        // ( ) , ; .
+	fOther        // 其它符号：按原文是否紧贴决定空格
+)
+
+type fcomment struct {
+	text string
+	line bool // -- / # 注释，其后必须换行
+}
+
+type ftok struct {
+	text, up string
+	kind     int
+	adj      bool // 原文与前一 token 紧贴
+	nl       bool // 原文与前一 token 之间有换行
+	lead     []fcomment
+	trail    []fcomment
+}
+
+const (
+	nLeaf = iota
+	nParen
+	nSub  // ( SELECT ... )
+	nCase // CASE ... END
+)
+
+type fnode struct {
+	kind  int
+	tok   *ftok // nLeaf
+	open  *ftok
+	close *ftok
+	kids  []*fnode
+}
+
+// Format 按 fo 重排 sql 的空白与关键字大小写
+func Format(sql string, opt Options, fo FormatOptions) (string, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	switch fo.KeywordCase {
+	case "":
+		fo.KeywordCase = KeywordUpper
+	case KeywordUpper, KeywordLower, KeywordPreserve:
+	default:
+		return "", fmt.Errorf("unknown keyword case %q", fo.KeywordCase)
+	}
+	if fo.Indent == "" {
+		fo.Indent = "  "
+	}
+	if fo.LineWidth == 0 {
+		fo.LineWidth = 80
+	}
+	toks, _, err := lexTokens(sql, opt)
+	if err != nil {
+		return "", err
+	}
+	ftoks, tail := formatTokens(sql, lintTokens(sql, toks, opt), ExtractComments(sql, toks, opt), fo)
+
+	p := &fprinter{fo: fo, atStart: true}
+	var prev *fstmt
+	stmts := splitFormatStatements(ftoks, opt.Dialect)
+	for i := range stmts {
+		st := &stmts[i]
+		switch {
+		case prev == nil:
+		case prev.after == 0 && st.depth == 0 && !st.sep:
+			// 顶层语句之间空一行；GO / / 紧跟在所分隔的语句之后
+			p.b.WriteString("\n")
+			p.newline(0)
+		default:
+			p.newline(st.depth)
+		}
+		prev = st
+		body, semi := st.toks, (*ftok)(nil)
+		if k := len(body); k > 0 && body[k-1].text == ";" {
+			body, semi = body[:k-1], body[k-1]
+		}
+		if nodes, ok := buildFormatTree(body); ok {
+			p.seq(nodes, st.depth)
+		} else {
+			// 括号 / CASE 不配对：整条按 token 平铺
+			p.lineIndent, p.contIndent = st.depth, st.depth+1
+			for _, t := range body {
+				p.tok(t)
+			}
+		}
+		if semi != nil {
+			p.tok(semi)
+		}
+	}
+	for _, c := range tail {
+		if !p.atStart {
+			p.newline(0)
+		}
+		p.write(c.text)
+		p.atStart = false
+	}
+	return p.b.String(), nil
+}
+
+// formatTokens 把 token 与注释合并：与前一 token 同行的注释挂在其 trail，其余挂在下一 token 的 lead；
+// 最后一个 token 之后另起行的注释作为 tail 返回
+func formatTokens(sql string, lt []LintToken, comments []Comment, fo FormatOptions) ([]*ftok, []fcomment) {
+	lt, comments = mergeDollarQuoted(sql, lt, comments)
+	out := make([]*ftok, 0, len(lt))
+	var pending []fcomment
+	ci := 0
+	prevEnd := -1
+	nameAt := false // 上一个词是 FROM/JOIN/...，当前位置是名字
+	flush := func(limit int) {
+		for ; ci < len(comments) && comments[ci].Start < limit; ci++ {
+			c := comments[ci]
+			fc := fcomment{text: c.Text, line: strings.HasPrefix(c.Text, "--") || strings.HasPrefix(c.Text, "#")}
+			if len(out) > 0 && len(pending) == 0 && !strings.ContainsAny(sql[prevEnd:c.Start], "\r\n") {
+				last := out[len(out)-1]
+				last.trail = append(last.trail, fc)
+			} else {
+				pending = append(pending, fc)
+			}
+			prevEnd = c.End
+		}
+	}
+	for i, t := range lt {
+		flush(t.Start)
+		ft := &ftok{text: t.Text, up: t.Upper, lead: pending}
+		pending = nil
+		if i > 0 {
+			gap := sql[lt[i-1].End:t.Start]
+			ft.adj = gap == ""
+			ft.nl = strings.ContainsAny(gap, "\r\n")
+		}
+		ft.kind = formatKind(t.Text)
+		prev, next := "", ""
+		if len(out) > 0 {
+			prev = out[len(out)-1].up
+		}
+		if i+1 < len(lt) {
+			next = lt[i+1].Upper
+		}
+		name := prev == "." || next == "."
+		if ft.kind == fWord && !formatReserved[ft.up] {
+			name = name || (nameAt && !formatNameSkip[ft.up]) || prev == "AS" || next == "AS"
+		}
+		if ft.kind == fWord && formatKeywords[ft.up] && !name {
+			switch fo.KeywordCase {
+			case KeywordUpper:
+				ft.text = ft.up
+			case KeywordLower:
+				ft.text = strings.ToLower(ft.text)
+			}
+		}
+		nameAt = ft.kind == fWord && !name && (formatNameAfter[ft.up] || nameAt && formatNameSkip[ft.up])
+		out = append(out, ft)
+		prevEnd = t.End
+	}
+	flush(len(sql) + 1)
+	return out, pending
+}
+
+// mergeDollarQuoted 与 RenderAndExtract 一致：PG 的 $tag$...$tag$ 整体作为一个 token，其中的“注释”丢弃
+func mergeDollarQuoted(sql string, lt []LintToken, comments []Comment) ([]LintToken, []Comment) {
+	var out []LintToken
+	var spans []span
+	for i := 0; i < len(lt); i++ {
+		t := lt[i]
+		if reDollarTag.MatchString(t.Text) {
+			for j := i + 1; j < len(lt); j++ {
+				if lt[j].Text == t.Text {
+					t.End = lt[j].End
+					t.Text = sql[t.Start:t.End]
+					t.Upper = strings.ToUpper(t.Text)
+					spans = append(spans, span{t.Start, t.End})
+					i = j
+					break
+				}
+			}
+		}
+		out = append(out, t)
+	}
+	if len(spans) == 0 {
+		return out, comments
+	}
+	var kept []Comment
+	for _, c := range comments {
+		if !inAnySpan(c.Start, c.End, spans) {
+			kept = append(kept, c)
+		}
+	}
+	return out, kept
+}
+
+func formatKind(text string) int {
+	switch text {
+	case "(", ")", ",", ";", ".":
+		return fPunct
+	case "?":
+		return fWord
+	}
+	if formatOps[text] {
+		return fOp
+	}
+	c := text[0]
+	switch {
+	case c == '_' || c == '\'' || c == '"' || c == '`' || c == '[' || c >= 0x80,
+		c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return fWord
+	case len(text) > 1 && (c == '@' || c == ':' || c == '$' || c == '#') && text != "::" && text != "@@":
+		return fWord
+	}
+	return fOther
+}
+
+type fstmt struct {
+	toks  []*ftok
+	depth int  // 所在 BEGIN ... END 块的层数，只影响缩进与语句间空行
+	after int  // 语句结束后的块层数
+	sep   bool // GO / 单独成行的 /
+}
+
+// splitFormatStatements 与 SplitStatements 一样按顶层 ; 切分；
+// SQL Server 单独成行的 GO、Oracle 单独成行的 / 自成一段
+func splitFormatStatements(toks []*ftok, dialect Dialect) []fstmt {
+	var out []fstmt
+	var cur []*ftok
+	depth, block, start := 0, 0, 0
+	cut := func() {
+		if len(cur) > 0 {
+			out = append(out, fstmt{toks: cur, depth: start, after: block})
+		}
+		cur = nil
+	}
+	alone := func(i int) bool { return (i == 0 || toks[i].nl) && (i+1 == len(toks) || toks[i+1].nl) }
+	for i, t := range toks {
+		next := ""
+		if i+1 < len(toks) {
+			next = toks[i+1].up
+		}
+		if len(cur) == 0 {
+			start = block
+			if t.up == "END" && start > 0 && !closesUncounted(next) {
+				start--
+			}
+		}
+		if depth == 0 && ((dialect == SQLServer && t.up == "GO") || (dialect == Oracle && t.text == "/")) && alone(i) {
+			cut()
+			out = append(out, fstmt{toks: []*ftok{t}, depth: block, after: block, sep: true})
+			continue
+		}
+		cur = append(cur, t)
+		switch {
+		case t.text == "(":
+			depth++
+		case t.text == ")":
+			if depth > 0 {
+				depth--
+			}
+		case t.kind != fWord:
+		case t.up == "BEGIN" && opensBlock(dialect, len(cur) == 1, next):
+			block++
+		case t.up == "CASE" && block > 0 && !(i > 0 && toks[i-1].up == "END"):
+			block++
+		case t.up == "END" && block > 0 && !closesUncounted(next):
+			block--
+		}
+		if depth == 0 && t.text == ";" {
+			cut()
+		}
+	}
+	cut()
+	return out
+}
+
+// closesUncounted：END IF / END LOOP 等结束的结构不计入块层数
+func closesUncounted(next string) bool {
+	switch next {
+	case "IF", "LOOP", "WHILE", "REPEAT", "FOR":
+		return true
+	}
+	return false
+}
+
+// opensBlock：BEGIN 是否开始一个过程块（而不是开启事务）
+func opensBlock(dialect Dialect, first bool, next string) bool {
+	switch next {
+	case "", ";", "TRAN", "TRANSACTION", "WORK", "DISTRIBUTED":
+		return false
+	}
+	switch dialect {
+	case Oracle, SQLServer:
+		return true
+	case MySQL:
+		return !first
+	}
+	return false
+}
+
+// buildFormatTree 把括号与 CASE...END 组成分组；不配对时返回 false
+func buildFormatTree(toks []*ftok) ([]*fnode, bool) {
+	root := &fnode{}
+	stack := []*fnode{root}
+	for i, t := range toks {
+		top := stack[len(stack)-1]
+		switch {
+		case t.text == "(":
+			g := &fnode{kind: nParen, open: t}
+			top.kids = append(top.kids, g)
+			stack = append(stack, g)
+		case t.text == ")":
+			if top.kind != nParen {
+				return nil, false
+			}
+			top.close = t
+			if len(top.kids) > 0 && top.kids[0].kind == nLeaf {
+				switch top.kids[0].tok.up {
+				case "SELECT", "WITH":
+					top.kind = nSub
+				}
+			}
+			stack = stack[:len(stack)-1]
+		case t.up == "CASE" && t.kind == fWord && !(i > 0 && toks[i-1].up == "END"):
+			g := &fnode{kind: nCase, open: t}
+			top.kids = append(top.kids, g)
+			stack = append(stack, g)
+		case t.up == "END" && top.kind == nCase:
+			top.close = t
+			stack = stack[:len(stack)-1]
+		default:
+			top.kids = append(top.kids, &fnode{kind: nLeaf, tok: t})
+		}
+	}
+	return root.kids, len(stack) == 1
+}
+
+type fprinter struct {
+	b          strings.Builder
+	fo         FormatOptions
+	col        int
+	lineIndent int
+	contIndent int // 注释强制换行后的缩进
+	prev       *ftok
+	prevUnary  bool
+	atStart    bool // 位于行首
+	pendingNL  bool
+	flat       bool // 只做单行测量：遇到换行即失败
+	failed     bool
+}
+
+func (p *fprinter) write(s string) {
+	p.b.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		p.col = len(s) - i - 1
+	} else {
+		p.col += len(s)
+	}
+}
+
+func (p *fprinter) newline(indent int) {
+	if p.flat {
+		p.failed = true
+		return
+	}
+	p.b.WriteString("\n")
+	p.col = 0
+	p.write(strings.Repeat(p.fo.Indent, indent))
+	p.lineIndent = indent
+	p.atStart = true
+	p.pendingNL = false
+}
+
+func (p *fprinter) tok(t *ftok) {
+	for _, c := range t.lead {
+		if !p.atStart {
+			p.newline(p.contIndent)
+		}
+		p.write(c.text)
+		p.newline(p.lineIndent)
+	}
+	if p.pendingNL || (t.nl && p.prev != nil && isStringTok(p.prev) && isStringTok(t)) {
+		// 行注释之后必须换行；相邻字符串（PG 依赖换行拼接）保持换行
+		if !p.atStart {
+			p.newline(p.contIndent)
+		}
+	}
+	if !p.atStart && (needSpace(p.prev, t, p.prevUnary) || gluesIntoComment(p.b.String(), t.text)) {
+		p.write(" ")
+	}
+	p.write(t.text)
+	p.prevUnary = isUnaryAt(p.prev, t)
+	p.prev = t
+	p.atStart = false
+	for _, c := range t.trail {
+		p.write(" " + c.text)
+		if c.line {
+			p.pendingNL = true
+			if p.flat {
+				p.failed = true
+			}
+		}
+	}
+}
+
+func isStringTok(t *ftok) bool { return t.kind == fWord && isStringLiteral(t.text) }
+
+// gluesIntoComment 紧贴书写会拼出注释起始符时必须隔开
+func gluesIntoComment(out, next string) bool {
+	if out == "" || next == "" {
+		return false
+	}
+	pair := out[len(out)-1:] + next[:1]
+	return pair == "--" || pair == "/*" || pair == "*/"
+}
+
+func needSpace(prev, cur *ftok, prevUnary bool) bool {
+	if prev == nil {
+		return false
+	}
+	if cur.kind == fOther || prev.kind == fOther || (cur.adj && prev.kind == fOp && cur.kind == fOp && formatOps[prev.text+cur.text]) {
+		// 被 lexer 拆开的 < = 等复合运算符保持紧贴
+		return !cur.adj
+	}
+	switch cur.text {
+	case ",", ")", ";", ".":
+		return false
+	}
+	switch prev.text {
+	case "(", ".":
+		return false
+	}
+	if prevUnary {
+		return false
+	}
+	if cur.text == "(" && prev.kind == fWord && !spaceBeforeParen[prev.up] {
+		return !cur.adj
+	}
+	return true
+}
+
+// isUnaryAt：cur 是出现在表达式开头位置的 + - ~
+func isUnaryAt(prev, cur *ftok) bool {
+	if cur.text != "-" && cur.text != "+" && cur.text != "~" {
+		return false
+	}
+	if prev == nil {
+		return true
+	}
+	switch {
+	case prev.kind == fOp, prev.text == "(", prev.text == ",":
+		return true
+	case prev.kind == fWord && formatKeywords[prev.up]:
+		switch prev.up {
+		case "NULL", "TRUE", "FALSE", "END":
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func (p *fprinter) node(n *fnode) {
+	switch n.kind {
+	case nLeaf:
+		p.tok(n.tok)
+	case nSub:
+		p.sub(n)
+	case nCase:
+		if p.flat || p.fits([]*fnode{n}) {
+			p.tok(n.open)
+			p.inline(n.kids)
+			p.tok(n.close)
+			return
+		}
+		p.caseBroken(n)
+	case nParen:
+		if p.flat || p.fits([]*fnode{n}) {
+			p.tok(n.open)
+			p.inline(n.kids)
+			p.tok(n.close)
+			return
+		}
+		p.parenBroken(n)
+	}
+}
+
+func (p *fprinter) inline(nodes []*fnode) {
+	for _, n := range nodes {
+		if p.failed {
+			return
+		}
+		p.node(n)
+	}
+}
+
+// measure 返回 nodes 从当前位置单行书写的宽度；无法单行时 ok=false
+func (p *fprinter) measure(nodes []*fnode) (int, bool) {
+	m := &fprinter{fo: p.fo, prev: p.prev, prevUnary: p.prevUnary, flat: true, atStart: p.atStart, pendingNL: p.pendingNL}
+	if m.pendingNL {
+		return 0, false
+	}
+	m.inline(nodes)
+	return m.b.Len(), !m.failed
+}
+
+func (p *fprinter) fits(nodes []*fnode) bool {
+	w, ok := p.measure(nodes)
+	return ok && (p.fo.LineWidth < 0 || p.col+w <= p.fo.LineWidth)
+}
+
+func (p *fprinter) sub(n *fnode) {
+	if p.flat {
+		p.failed = true
+		return
+	}
+	li, ci := p.lineIndent, p.contIndent
+	p.tok(n.open)
+	p.newline(li + 1)
+	p.seq(n.kids, li+1)
+	p.newline(li)
+	p.tok(n.close)
+	p.contIndent = ci
+}
+
+func (p *fprinter) parenBroken(n *fnode) {
+	li, ci := p.lineIndent, p.contIndent
+	p.tok(n.open)
+	items, seps := splitNodes(n.kids, isComma)
+	if len(items) == 1 {
+		if c, cs := splitConditions(n.kids); len(c) > 1 {
+			items, seps = c, cs
+			for k, it := range items {
+				p.newline(li + 1)
+				p.contIndent = li + 2
+				if k > 0 {
+					p.node(seps[k-1])
+				}
+				p.inline(it)
+			}
+			p.newline(li)
+			p.tok(n.close)
+			p.contIndent = ci
+			return
+		}
+	}
+	for k, it := range items {
+		p.newline(li + 1)
+		p.contIndent = li + 2
+		p.inline(it)
+		if k < len(seps) {
+			p.node(seps[k])
+		}
+	}
+	p.newline(li)
+	p.tok(n.close)
+	p.contIndent = ci
+}
+
+func (p *fprinter) caseBroken(n *fnode) {
+	li, ci := p.lineIndent, p.contIndent
+	p.tok(n.open)
+	start := 0
+	for k, c := range n.kids {
+		if c.kind == nLeaf && (c.tok.up == "WHEN" || c.tok.up == "ELSE") {
+			p.inline(n.kids[start:k])
+			p.newline(li + 1)
+			p.contIndent = li + 2
+			start = k
+		}
+	}
+	p.inline(n.kids[start:])
+	p.newline(li)
+	p.tok(n.close)
+	p.contIndent = ci
+}
+
+func isComma(n *fnode) bool { return n.kind == nLeaf && n.tok.text == "," }
+
+// splitNodes 按分隔节点切分，返回各段与分隔符
+func splitNodes(nodes []*fnode, sep func(*fnode) bool) ([][]*fnode, []*fnode) {
+	var items [][]*fnode
+	var seps []*fnode
+	start := 0
+	for i, n := range nodes {
+		if sep(n) {
+			items = append(items, nodes[start:i])
+			seps = append(seps, n)
+			start = i + 1
+		}
+	}
+	return append(items, nodes[start:]), seps
+}
+
+// splitConditions 在顶层 AND / OR 前切分（BETWEEN x AND y 的 AND 除外）；分隔符留在后一段开头
+func splitConditions(nodes []*fnode) ([][]*fnode, []*fnode) {
+	var items [][]*fnode
+	var seps []*fnode
+	start, between := 0, false
+	for i, n := range nodes {
+		if n.kind != nLeaf {
+			continue
+		}
+		switch n.tok.up {
+		case "BETWEEN":
+			between = true
+		case "AND", "OR":
+			if n.tok.up == "AND" && between {
+				between = false
+				continue
+			}
+			if i > start {
+				items = append(items, nodes[start:i])
+				seps = append(seps, n)
+				start = i + 1
+			}
+		}
+	}
+	return append(items, nodes[start:]), seps
+}
+
+// 子句体的排版方式
+const (
+	styleNone  = iota // 关键字独占一行（UNION 等）
+	styleList         // 逗号列表
+	styleCond         // AND / OR 条件
+	styleJoin         // JOIN 目标 + ON 条件
+	styleWith         // CTE 列表
+	stylePre          // 首个子句之前的部分（CREATE VIEW v AS ...）
+	styleBlock        // BEGIN：其后的子句缩进一级
+	styleEnd          // 块结尾的 END：回退一级
+	styleSeg          // THEN / ELSE / LOOP / DO：留在当前行，其后换行
+)
+
+type fclause struct {
+	phrase []*fnode
+	body   []*fnode
+	style  int
+}
+
+func leafUp(nodes []*fnode, i int) string {
+	if i < len(nodes) && nodes[i].kind == nLeaf && nodes[i].tok.kind == fWord {
+		return nodes[i].tok.up
+	}
+	return ""
+}
+
+// clauseAt 判断 nodes[i] 是否起一个子句，返回关键字短语长度与排版方式
+func clauseAt(nodes []*fnode, i int, head string) (int, int) {
+	w := leafUp(nodes, i)
+	next := func(k int) string { return leafUp(nodes, i+k) }
+	switch w {
+	case "SELECT":
+		n := 1
+		for {
+			switch u := next(n); {
+			case u == "DISTINCT" || u == "ALL" || u == "DISTINCTROW" || strings.HasPrefix(u, "SQL_"):
+				n++
+				continue
+			case u == "TOP":
+				n += 2
+				if next(n) == "PERCENT" {
+					n++
+				}
+				if next(n) == "WITH" && next(n+1) == "TIES" {
+					n += 2
+				}
+				continue
+			}
+			break
+		}
+		if i+n > len(nodes) {
+			n = len(nodes) - i
+		}
+		return n, styleList
+	case "VALUES":
+		// MySQL ON DUPLICATE KEY UPDATE b = VALUES(b) 里是函数
+		if i > 0 && nodes[i-1].kind == nLeaf && (nodes[i-1].tok.kind == fOp || nodes[i-1].tok.text == ",") {
+			return 0, 0
+		}
+		return 1, styleList
+	case "FROM", "LIMIT", "OFFSET", "FETCH", "RETURNING", "WINDOW", "INTO", "OPTION":
+		return 1, styleList
+	case "OUTPUT":
+		if i > 0 {
+			return 1, styleList
+		}
+	case "DO":
+		// PG ON CONFLICT ... DO UPDATE SET
+		if next(1) == "UPDATE" && next(2) == "SET" {
+			return 3, styleList
+		}
+
+	case "WHERE", "HAVING", "QUALIFY":
+		return 1, styleCond
+	case "SET":
+		if head == "UPDATE" || head == "MERGE" || i == 0 {
+			return 1, styleList
+		}
+	case "WITH":
+		if i == 0 {
+			if next(1) == "RECURSIVE" {
+				return 2, styleWith
+			}
+			return 1, styleWith
+		}
+	case "UNION", "INTERSECT", "EXCEPT", "MINUS":
+		if u := next(1); u == "ALL" || u == "DISTINCT" {
+			return 2, styleNone
+		}
+		return 1, styleNone
+	case "INSERT", "REPLACE":
+		if i == 0 || head == "WITH" {
+			n := 1
+			for next(n) == "IGNORE" || next(n) == "INTO" {
+				n++
+			}
+			return n, styleList
+		}
+	case "UPDATE", "DELETE", "MERGE":
+		if i == 0 || head == "WITH" {
+			if next(1) == "FROM" || next(1) == "INTO" {
+				return 2, styleList
+			}
+			return 1, styleList
+		}
+	case "USING":
+		if head == "MERGE" {
+			return 1, styleJoin
+		}
+	case "WHEN":
+		if head == "MERGE" {
+			return 1, styleCond
+		}
+	case "ON":
+		switch next(1) {
+		case "CONFLICT":
+			return 2, styleNone
+		case "DUPLICATE":
+			if next(2) == "KEY" && next(3) == "UPDATE" {
+				return 4, styleList
+			}
+		}
+	case "GROUP", "ORDER", "CONNECT":
+		if next(1) == "BY" {
+			if w == "CONNECT" {
+				return 2, styleCond
+			}
+			return 2, styleList
+		}
+	case "START":
+		if next(1) == "WITH" {
+			return 2, styleCond
+		}
+	case "FOR":
+		if u := next(1); u == "UPDATE" || u == "SHARE" {
+			return 2, styleList
+		}
+	case "STRAIGHT_JOIN":
+		if i > 0 {
+			return 1, styleJoin
+		}
+	case "JOIN", "NATURAL", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "OUTER":
+		n := 0
+		for {
+			switch next(n) {
+			case "NATURAL", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "OUTER":
+				n++
+				continue
+			case "JOIN", "APPLY":
+				return n + 1, styleJoin
+			}
+			return 0, 0
+		}
+	}
+	return 0, 0
+}
+
+// procAt 过程块关键字（不看方言，只影响换行与缩进）
+func procAt(nodes []*fnode, i, seg int) (int, int) {
+	switch leafUp(nodes, i) {
+	case "BEGIN":
+		switch u := leafUp(nodes, i+1); {
+		case u == "TRY" || u == "CATCH":
+			return 2, styleBlock
+		case opensBlock(Oracle, false, u):
+			return 1, styleBlock
+		}
+	case "END":
+		if i > 0 {
+			switch leafUp(nodes, i+1) {
+			case "IF", "LOOP", "WHILE", "REPEAT", "CASE", "TRY", "CATCH":
+				return 2, styleEnd
+			}
+			return 1, styleEnd
+		}
+	case "THEN", "ELSE", "LOOP":
+		if leafUp(nodes, 0) != "MERGE" {
+			return 1, styleSeg
+		}
+	case "DO":
+		if leafUp(nodes, seg) == "WHILE" {
+			return 1, styleSeg
+		}
+	}
+	return 0, 0
+}
+
+func splitClauses(nodes []*fnode) []fclause {
+	var out []fclause
+	cur := fclause{style: stylePre}
+	seg := 0 // 当前段起点：语句开头或过程块关键字之后
+	for i := 0; i < len(nodes); {
+		n, style := procAt(nodes, i, seg)
+		if n == 0 {
+			n, style = clauseAt(nodes[seg:], i-seg, leafUp(nodes, seg))
+		}
+		if n == 0 {
+			cur.body = append(cur.body, nodes[i])
+			i++
+			continue
+		}
+		if len(cur.phrase) > 0 || len(cur.body) > 0 {
+			out = append(out, cur)
+		}
+		cur = fclause{phrase: nodes[i : i+n], style: style}
+		i += n
+		switch style {
+		case styleBlock, styleEnd, styleSeg:
+			out = append(out, cur)
+			cur = fclause{style: stylePre}
+			seg = i
+		}
+	}
+	return append(out, cur)
+}
+
+// seq 以 indent 为基准排版一条语句（或子查询）的子句序列；调用时已位于行首或语句开头
+func (p *fprinter) seq(nodes []*fnode, indent int) {
+	started := false
+	for _, c := range splitClauses(nodes) {
+		if len(c.phrase) == 0 && len(c.body) == 0 {
+			continue
+		}
+		switch c.style {
+		case styleSeg:
+			p.inline(c.phrase)
+			continue
+		case styleEnd:
+			if indent > 0 && !closesUncounted(leafUp(c.phrase, 1)) {
+				indent--
+			}
+		}
+		if started || !p.atStart {
+			p.newline(indent)
+		}
+		started = true
+		p.lineIndent, p.contIndent = indent, indent+1
+		p.inline(c.phrase)
+		switch c.style {
+		case styleBlock:
+			indent++
+			continue
+		case styleEnd:
+			continue
+		}
+		if len(c.body) == 0 {
+			continue
+		}
+		switch c.style {
+		case stylePre, styleNone:
+			p.inline(c.body)
+		case styleWith:
+			items, seps := splitNodes(c.body, isComma)
+			for j, it := range items {
+				if j > 0 {
+					p.newline(indent)
+					p.contIndent = indent + 1
+				}
+				p.inline(it)
+				if j < len(seps) {
+					p.node(seps[j])
+				}
+			}
+		case styleJoin:
+			p.joinBody(c.body, indent)
+		default:
+			if p.fits(c.body) || singleItem(c.body, c.style) {
+				// 单项（如 WHERE id IN (子查询)）留在关键字行，由其中的分组自行换行
+				p.inline(c.body)
+				continue
+			}
+			p.brokenBody(c.body, c.style, indent+1)
+		}
+	}
+}
+
+func singleItem(body []*fnode, style int) bool {
+	if style == styleCond {
+		items, _ := splitConditions(body)
+		return len(items) == 1
+	}
+	items, _ := splitNodes(body, isComma)
+	return len(items) == 1
+}
+
+func (p *fprinter) brokenBody(body []*fnode, style, indent int) {
+	if style == styleCond {
+		items, seps := splitConditions(body)
+		for j, it := range items {
+			p.newline(indent)
+			p.contIndent = indent + 1
+			if j > 0 {
+				p.node(seps[j-1])
+			}
+			p.inline(it)
+		}
+		return
+	}
+	items, seps := splitNodes(body, isComma)
+	for j, it := range items {
+		p.newline(indent)
+		p.contIndent = indent + 1
+		p.inline(it)
+		if j < len(seps) {
+			p.node(seps[j])
+		}
+	}
+}
+
+// joinBody：放得下则整行；否则目标留在 JOIN 行，ON 条件另起一行并按 AND / OR 断开
+func (p *fprinter) joinBody(body []*fnode, indent int) {
+	if p.fits(body) {
+		p.inline(body)
+		return
+	}
+	on := -1
+	for i, n := range body {
+		if n.kind == nLeaf && n.tok.up == "ON" {
+			on = i
+			break
+		}
+	}
+	if on < 0 {
+		p.inline(body)
+		return
+	}
+	p.inline(body[:on])
+	p.newline(indent + 1)
+	p.contIndent = indent + 2
+	p.node(body[on])
+	cond := body[on+1:]
+	if p.fits(cond) {
+		p.inline(cond)
+		return
+	}
+	items, seps := splitConditions(cond)
+	for j, it := range items {
+		if j > 0 {
+			p.newline(indent + 1)
+			p.node(seps[j-1])
+		}
+		p.inline(it)
+	}
+}
@@ -0,0 +1,375 @@
+package sqldigest_antlr
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// SQL 注入启发式检查：基于方言 lexer 的可见 token 序列，输入按“单条查询”看待。
+// 规则：
+//   - tautology           OR 1=1 / OR 'a'='a' / OR x=x / 结尾的 OR 1、OR TRUE
+//   - stacked_statement   出现多条语句（SplitStatements > 1）
+//   - comment_truncation  字符串之后以 -- / # 注释截断剩余文本
+//   - union_select        带 WHERE 的简单查询后追加 UNION [ALL] SELECT
+//   - time_probe          SLEEP / BENCHMARK / pg_sleep / WAITFOR DELAY / DBMS_LOCK.SLEEP
+// 只是启发式：合法 SQL 也可能命中（如有意写的多语句脚本），调用方按 Severity 取舍。
+
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// InjectionFinding 一条命中；Start/End 为原文字节区间
+type InjectionFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// itok 可见 token 的精简形式
+type itok struct {
+	text, up   string
+	start, end int // 字节区间
+}
+
+// InspectInjection 对 sql 做注入启发式检查，结果按 Start 排序
+func InspectInjection(sql string, opt Options) ([]InjectionFinding, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	toks, _, err := lexTokens(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	vis := visibleTokens(sql, toks, opt)
+	comments := ExtractComments(sql, toks, opt)
+
+	var out []InjectionFinding
+	out = append(out, injTautologies(vis, opt)...)
+	out = append(out, injStacked(sql, toks, opt)...)
+	tails := injCommentTails(sql, vis, comments)
+	out = append(out, tails...)
+	out = append(out, injUnionSelect(vis, len(tails) > 0)...)
+	out = append(out, injTimeProbes(vis)...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	return out, nil
+}
+
+func visibleTokens(sql string, toks []antlr.Token, opt Options) []itok {
+	var spans []span
+	if opt.Dialect == MySQL {
+		spans = findMySQLCommentSpans(sql)
+	}
+	var out []itok
+	for _, t := range toks {
+		if IsEOFToken(t) || t.GetChannel() != antlr.TokenDefaultChannel || IsWhitespace(t.GetText()) {
+			continue
+		}
+		s, e := RuneIndexToByte(sql, t.GetStart()), RuneIndexToByte(sql, t.GetStop()+1)
+		if len(spans) > 0 && inAnySpan(s, e, spans) {
+			continue
+		}
+		out = append(out, itok{text: t.GetText(), up: strings.ToUpper(t.GetText()), start: s, end: e})
+	}
+	return out
+}
+
+// injTautologies：OR <字面量> <比较> <字面量> 恒真，或 OR x = x；OR 1 / OR TRUE 出现在条件末尾
+func injTautologies(vis []itok, opt Options) []InjectionFinding {
+	var out []InjectionFinding
+	for i := 0; i+1 < len(vis); i++ {
+		if vis[i].up != "OR" && vis[i].text != "||" {
+			continue
+		}
+		if vis[i].text == "||" && opt.Dialect != MySQL {
+			continue // 其它方言里 || 是字符串拼接
+		}
+		a := vis[i+1]
+		if i+3 < len(vis) {
+			op, b := vis[i+2].text, vis[i+3]
+			if alwaysTrue(a, op, b, opt) {
+				out = append(out, InjectionFinding{
+					Rule: "tautology", Severity: SeverityHigh,
+					Message: "always-true condition " + a.text + " " + op + " " + b.text + " after OR",
+					Start:   vis[i].start, End: b.end,
+				})
+				i += 3
+				continue
+			}
+		}
+		// OR 1 / OR TRUE 直接结束条件
+		if (a.up == "TRUE" || (isNumberLiteral(a.text) && strings.Trim(a.text, "0.") != "")) &&
+			(i+2 == len(vis) || vis[i+2].text == ")" || vis[i+2].text == ";") {
+			out = append(out, InjectionFinding{
+				Rule: "tautology", Severity: SeverityHigh,
+				Message: "always-true operand " + a.text + " after OR",
+				Start:   vis[i].start, End: a.end,
+			})
+		}
+	}
+	return out
+}
+
+// alwaysTrue：两侧都是字面量时按值比较；两侧是同一个标识符时 = 视为恒真
+func alwaysTrue(a itok, op string, b itok, opt Options) bool {
+	litKind := func(t itok) string {
+		switch {
+		case isNumberLiteral(t.text):
+			return "Number"
+		case isStringLiteral(t.text):
+			return "String"
+		}
+		return ""
+	}
+	ka, kb := litKind(a), litKind(b)
+	if ka == "" || kb == "" {
+		return op == "=" && ka == "" && kb == "" && looksLikeIdent(a.text) && a.up == b.up &&
+			!isKeywordOperand(a.up)
+	}
+	va, vb := DecodeLiteral(a.text, ka, opt), DecodeLiteral(b.text, kb, opt)
+	fa, ea := strconv.ParseFloat(va, 64)
+	fb, eb := strconv.ParseFloat(vb, 64)
+	if ea == nil && eb == nil {
+		switch op {
+		case "=", "==", "<=>":
+			return fa == fb
+		case "<>", "!=":
+			return fa != fb
+		case "<":
+			return fa < fb
+		case ">":
+			return fa > fb
+		case "<=":
+			return fa <= fb
+		case ">=":
+			return fa >= fb
+		}
+		return false
+	}
+	switch strings.ToUpper(op) {
+	case "=", "==", "<=>", "LIKE":
+		return va == vb
+	case "<>", "!=":
+		return va != vb
+	}
+	return false
+}
+
+func isKeywordOperand(up string) bool {
+	switch up {
+	case "NULL", "TRUE", "FALSE", "SELECT", "NOT", "EXISTS", "CASE":
+		return true
+	}
+	return false
+}
+
+// injStacked：输入应为单条查询，第二条起每条都报
+func injStacked(sql string, toks []antlr.Token, opt Options) []InjectionFinding {
+	stmts := SplitStatements(sql, toks, opt)
+	var out []InjectionFinding
+	for _, st := range stmts[min(1, len(stmts)):] {
+		if strings.Trim(sql[st.StartByte:st.EndByte], " \t\r\n;") == "" {
+			continue
+		}
+		out = append(out, InjectionFinding{
+			Rule: "stacked_statement", Severity: SeverityHigh,
+			Message: "additional statement (" + st.Type + ") after the first one",
+			Start:   st.StartByte, End: st.EndByte,
+		})
+	}
+	return out
+}
+
+// injCommentTails：字符串字面量之后、直到输入末尾的行注释（-- / #）
+func injCommentTails(sql string, vis []itok, comments []Comment) []InjectionFinding {
+	var out []InjectionFinding
+	for _, c := range comments {
+		if c.Kind != "line" {
+			continue
+		}
+		// 注释之后不能再有可见 token，且紧前一个 token 是字符串
+		prev := -1
+		tail := true
+		for k, t := range vis {
+			if t.start < c.Start {
+				prev = k
+			} else {
+				tail = false
+				break
+			}
+		}
+		if !tail || prev < 0 || !isStringLiteral(vis[prev].text) {
+			continue
+		}
+		// 紧贴字符串或注释里带引号（被截掉的是原查询的引号）更可疑；隔了空白的多半是普通行尾注释
+		sev := SeverityLow
+		if vis[prev].end == c.Start || strings.ContainsAny(c.Text[1:], `'"`) {
+			sev = SeverityHigh
+		}
+		out = append(out, InjectionFinding{
+			Rule: "comment_truncation", Severity: sev,
+			Message: "line comment right after a string literal cuts off the rest of the query",
+			Start:   c.Start, End: len(strings.TrimRight(sql, " \t\r\n")),
+		})
+	}
+	return out
+}
+
+// union 注入常见的探测目标
+var unionProbeMarkers = []string{"INFORMATION_SCHEMA", "@@VERSION", "VERSION", "USER", "DATABASE", "CURRENT_USER",
+	"PG_CATALOG", "PG_USER", "PG_SHADOW", "SYS", "SYSOBJECTS", "ALL_TABLES", "ALL_USERS", "USER_TABLES", "MYSQL"}
+
+// injUnionSelect：顶层 WHERE 之后的 UNION [ALL|DISTINCT] SELECT
+func injUnionSelect(vis []itok, commentTail bool) []InjectionFinding {
+	var out []InjectionFinding
+	depth := 0
+	sawWhere, sawUnion := false, false
+	for i := 0; i < len(vis); i++ {
+		switch vis[i].text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		case ";":
+			sawWhere, sawUnion = false, false
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+		switch vis[i].up {
+		case "WHERE":
+			sawWhere = true
+		case "UNION":
+			j := i + 1
+			if j < len(vis) && (vis[j].up == "ALL" || vis[j].up == "DISTINCT") {
+				j++
+			}
+			if j >= len(vis) || vis[j].up != "SELECT" || !sawWhere || sawUnion {
+				sawUnion = true
+				continue
+			}
+			sawUnion = true
+			end := unionSelectEnd(vis, j)
+			sev := SeverityMedium
+			if commentTail || literalOnlySelectList(vis, j+1) || hasProbeMarker(vis[j:end+1]) {
+				sev = SeverityHigh
+			}
+			out = append(out, InjectionFinding{
+				Rule: "union_select", Severity: sev,
+				Message: "UNION SELECT appended after a WHERE clause",
+				Start:   vis[i].start, End: vis[end].end,
+			})
+		}
+	}
+	return out
+}
+
+// unionSelectEnd 追加的 SELECT 的最后一个 token（到顶层 ';' 或末尾）
+func unionSelectEnd(vis []itok, j int) int {
+	depth := 0
+	for k := j; k < len(vis); k++ {
+		switch vis[k].text {
+		case "(":
+			depth++
+		case ")":
+			if depth == 0 {
+				return k - 1
+			}
+			depth--
+		case ";":
+			if depth == 0 {
+				return k - 1
+			}
+		}
+	}
+	return len(vis) - 1
+}
+
+// literalOnlySelectList：SELECT NULL, NULL, 1 —— 典型的列数探测
+func literalOnlySelectList(vis []itok, k int) bool {
+	n := 0
+	for ; k < len(vis); k++ {
+		t := vis[k]
+		if t.up == "FROM" || t.text == ";" || t.text == ")" {
+			break
+		}
+		if t.text == "," {
+			continue
+		}
+		if t.up != "NULL" && !isNumberLiteral(t.text) && !isStringLiteral(t.text) {
+			return false
+		}
+		n++
+	}
+	return n > 0
+}
+
+func hasProbeMarker(vis []itok) bool {
+	for _, t := range vis {
+		for _, m := range unionProbeMarkers {
+			if t.up == m || strings.HasPrefix(t.up, m+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// injTimeProbes：基于时间的盲注探测函数
+func injTimeProbes(vis []itok) []InjectionFinding {
+	var out []InjectionFinding
+	add := func(from, to int, what string) {
+		out = append(out, InjectionFinding{
+			Rule: "time_probe", Severity: SeverityHigh,
+			Message: what + " can be used for time-based probing",
+			Start:   vis[from].start, End: vis[to].end,
+		})
+	}
+	for i := 0; i < len(vis); i++ {
+		switch vis[i].up {
+		case "SLEEP", "BENCHMARK", "PG_SLEEP", "PG_SLEEP_FOR", "PG_SLEEP_UNTIL":
+			if i+1 < len(vis) && vis[i+1].text == "(" {
+				// DBMS_LOCK.SLEEP / DBMS_SESSION.SLEEP：从包名算起
+				from, name := i, vis[i].up
+				if i >= 2 && vis[i-1].text == "." && (vis[i-2].up == "DBMS_LOCK" || vis[i-2].up == "DBMS_SESSION") {
+					from, name = i-2, vis[i-2].up+".SLEEP"
+				}
+				end := closeParenTok(vis, i+1)
+				add(from, end, name)
+				i = end
+			}
+		case "WAITFOR":
+			if i+2 < len(vis) && (vis[i+1].up == "DELAY" || vis[i+1].up == "TIME") {
+				add(i, i+2, "WAITFOR "+vis[i+1].up)
+				i += 2
+			}
+		}
+	}
+	return out
+}
+
+// closeParenTok 与 vis[open] 配对的 ')'；找不到时返回最后一个 token
+func closeParenTok(vis []itok, open int) int {
+	depth := 0
+	for k := open; k < len(vis); k++ {
+		switch vis[k].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return k
+			}
+		}
+	}
+	return len(vis) - 1
+}
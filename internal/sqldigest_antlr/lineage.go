@@ -0,0 +1,1226 @@
+package sqldigest_antlr
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 列级血缘：INSERT ... SELECT、CREATE TABLE / VIEW ... AS SELECT、SELECT ... INTO（SQL Server、PG）、
+// UPDATE ... SET（含 FROM / JOIN）与 MERGE 的目标列 ← 来源 (表, 列)。
+// 只看 token 流：表别名、派生表、CTE 按名字解析；没有 Options.Catalog 时 * 与无法归属的列如实给出，
+// 有目录时基表的 * 展开成列、未限定的列按目录归属、无列清单的 INSERT 按目标表的列定名。
+
+// ColumnRef 表.列；Table 为去引号的限定表名（db.t / emp@remote），保留原文大小写，无法确定来源表时为空
+type ColumnRef struct {
+	Table  string `json:"table,omitempty"`
+	Column string `json:"column"`
+}
+
+// ColumnLineage 一个目标列与它的来源列
+type ColumnLineage struct {
+	Statement int         `json:"statement"`          // 语句序号（1-based）
+	Target    ColumnRef   `json:"target"`             // INSERT 无列清单时按位置写入，Column 为空；表达式没有名字时同样为空
+	Position  int         `json:"position,omitempty"` // 在 SELECT 列表 / VALUES 中的位置（1-based）；SET 赋值为 0
+	Sources   []ColumnRef `json:"sources"`            // 去重排序；常量表达式为空
+}
+
+// Lineage 逐条语句给出目标列 ← 来源列；不写表的语句（及 INSERT ... VALUES）没有结果
+func Lineage(sql string, opt Options) ([]ColumnLineage, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	toks, _, err := lexTokens(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	stmts := SplitStatements(sql, toks, opt)
+	var out []ColumnLineage
+	all := lintTokens(sql, toks, opt)
+	if opt.Dialect == Postgres {
+		all, _ = mergeDollarQuoted(sql, all, nil)
+	}
+	for n, st := range statementTokens(all, stmts) {
+		if len(st) == 0 {
+			continue
+		}
+		lx := newLineageCtx(st, opt)
+		lx.statement()
+		for k, c := range lx.out {
+			c.Statement = n + 1
+			c.Sources = lx.srcs[k].sorted()
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func newLineageCtx(st []LintToken, opt Options) *lineageCtx {
+	return &lineageCtx{toks: st, v: lintItoks(st), dialect: opt.Dialect, cat: opt.Catalog, base: st[0].Depth, index: map[string]int{}}
+}
+
+type colSet map[ColumnRef]bool
+
+func (s colSet) add(o colSet) {
+	for c := range o {
+		s[c] = true
+	}
+}
+
+func (s colSet) sorted() []ColumnRef {
+	out := make([]ColumnRef, 0, len(s))
+	for c := range s {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Table != out[j].Table {
+			return out[i].Table < out[j].Table
+		}
+		return out[i].Column < out[j].Column
+	})
+	return out
+}
+
+// lineageCol 查询的一个输出列
+type lineageCol struct {
+	name string // 别名或列名；表达式无别名为空；基表的 * 为 "*"
+	src  colSet
+}
+
+// relation FROM 里的一项：基表（table 非空）或派生表（子查询 / CTE / 表函数，cols 为输出列；表函数只有一个 * 列）。
+// 目录里有的基表 cols 为目录的列；known 表示 cols 是完整的列清单
+type relation struct {
+	alias, table string
+	qual         string // 限定列时写的前缀原文：别名，或表名最后一段
+	cols         []lineageCol
+	known        bool
+}
+
+type lineageScope struct {
+	rels    []relation
+	parent  *lineageScope   // 关联子查询可引用外层
+	using   map[string]bool // JOIN ... USING 合并的列（大写）
+	natural bool
+	opaque  bool // PIVOT / UNPIVOT / MODEL / MATCH_RECOGNIZE 改变了可见列：未限定的列不归属
+}
+
+type cteCols map[string][]lineageCol // 大写 CTE 名 → 输出列
+
+type lineageCtx struct {
+	toks    []LintToken
+	v       []itok
+	dialect Dialect
+	cat     *Catalog
+	rec     map[int]schemaRef // 非 nil 时记录表 / 列引用（Qualify 与 schema lint 规则用），键为首 token 下标
+	aliases map[string]bool   // 正在看 GROUP BY / HAVING / ORDER BY：SELECT 列表的输出名（大写）
+	base    int               // 语句首 token 的括号深度
+	out     []ColumnLineage
+	srcs    []colSet
+	index   map[string]int // 目标列 → out 下标；同一列多次写入（如 MERGE 多个分支）合并来源
+}
+
+// 不是列引用的词（表达式里出现）
+var lineageExprWords = toSet(`ALL AND ANY ARRAY AS ASC AT BETWEEN BINARY BOTH BY CASE COLLATE CURRENT CURRENT_DATE
+CURRENT_TIME DENSE_RANK CURRENT_TIMESTAMP CURRENT_USER DEFAULT DESC DISTINCT DIV ELSE END ESCAPE EXCLUDE EXISTS FALSE FILTER
+FOLLOWING FOR FROM GROUP GROUPING IGNORE ILIKE IN INTERVAL IS KEEP LEADING LIKE LOCALTIME LOCALTIMESTAMP MOD NOCYCLE NOT
+NULL NULLS OR ORDER OTHERS OVER PARTITION PASSING PRECEDING RANGE REGEXP RESPECT RLIKE ROLLUP ROW ROWS SEPARATOR
+SESSION_USER SIBLINGS SIMILAR SOME THEN TIES TO TRAILING TRUE UNBOUNDED UNKNOWN USING WHEN WHERE WITH WITHIN XOR ZONE`)
+
+// Oracle 伪列
+var oraclePseudoColumns = toSet(`CURRVAL LEVEL NEXTVAL ROWID ROWNUM SYSDATE SYSTIMESTAMP`)
+
+// 第一个参数是时间单位 / 类型名的函数
+var unitArgFuncs = toSet(`CONVERT DATEADD DATEDIFF DATEDIFF_BIG DATENAME DATEPART DATETRUNC EXTRACT TIMESTAMPADD
+TIMESTAMPDIFF TRY_CONVERT`)
+
+var dateUnits = toSet(`MICROSECOND MILLISECOND SECOND MINUTE HOUR DAY WEEK MONTH QUARTER YEAR SECOND_MICROSECOND
+MINUTE_MICROSECOND MINUTE_SECOND HOUR_MICROSECOND HOUR_SECOND HOUR_MINUTE DAY_MICROSECOND DAY_SECOND DAY_MINUTE
+DAY_HOUR YEAR_MONTH`)
+
+// 表名 / 派生表后面不能当别名的词
+var lineageNotAlias = toSet(`FORCE LOG MATCH_RECOGNIZE MODEL OUTPUT PIVOT SAMPLE TABLESAMPLE UNPIVOT USE`)
+
+// 查询里结束 SELECT 列表 / FROM 列表的子句
+var lineageClause = toSet(`CONNECT EXCEPT FETCH FOR FROM GROUP HAVING INTERSECT INTO LIMIT MINUS OFFSET OPTION ORDER
+QUALIFY START UNION WHERE WINDOW`)
+
+func (lx *lineageCtx) up(i int) string {
+	if i >= 0 && i < len(lx.toks) {
+		return lx.toks[i].Upper
+	}
+	return ""
+}
+
+// find 在 [lo,hi) 中找深度为 depth、满足 f 的第一个 token，找不到返回 hi
+func (lx *lineageCtx) find(lo, hi, depth int, f func(string) bool) int {
+	for i := lo; i < hi; i++ {
+		if lx.toks[i].Depth == depth && f(lx.toks[i].Upper) {
+			return i
+		}
+	}
+	return hi
+}
+
+func is(words ...string) func(string) bool {
+	return func(u string) bool {
+		for _, w := range words {
+			if u == w {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// items 按深度 depth 的逗号切分 [lo,hi)
+func (lx *lineageCtx) items(lo, hi, depth int) [][2]int {
+	var out [][2]int
+	start := lo
+	for i := lo; i < hi; i++ {
+		if lx.toks[i].Text == "," && lx.toks[i].Depth == depth {
+			out = append(out, [2]int{start, i})
+			start = i + 1
+		}
+	}
+	if start < hi {
+		out = append(out, [2]int{start, hi})
+	}
+	return out
+}
+
+// nameOf toks[i] 起的限定名：parts 为各段原文，text 为去引号后以 . 连接的表名（带 @dblink）；
+// SQL Server 的 #tmp / ##tmp 也算
+func (lx *lineageCtx) nameOf(i int) (parts []string, text string, end int, ok bool) {
+	if lx.dialect == SQLServer && i < len(lx.toks) && strings.HasPrefix(lx.toks[i].Text, "#") {
+		return []string{lx.toks[i].Text}, lx.toks[i].Text, i, true
+	}
+	parts, link, end, ok := qualifiedName(lx.v, i)
+	if !ok {
+		return nil, "", i, false
+	}
+	text = tableName(parts)
+	if link != "" {
+		text += "@" + tableName(splitQualified(link))
+	}
+	return parts, text, end, true
+}
+
+// tableName ColumnRef.Table 的写法：各段去引号后以 . 连接，"Sales"."Orders" 与 sales.orders 只差大小写
+func tableName(parts []string) string {
+	names := make([]string, len(parts))
+	for k, p := range parts {
+		names[k] = unquoteIdent(p)
+	}
+	return strings.Join(names, ".")
+}
+
+// nameList 读 (a, b, c)，返回各项首个名字（去引号、去限定）
+func (lx *lineageCtx) nameList(open int) ([]string, int) {
+	cl := closeParen(lx.toks, open)
+	var out []string
+	for _, it := range lx.items(open+1, cl, lx.toks[open].Depth+1) {
+		parts, _, _, ok := qualifiedName(lx.v, it[0])
+		if !ok {
+			out = append(out, "")
+			continue
+		}
+		out = append(out, unquoteIdent(parts[len(parts)-1]))
+	}
+	return out, cl
+}
+
+func (lx *lineageCtx) emit(table, column string, pos int, src colSet) {
+	key := strings.ToUpper(table) + "\x00" + strings.ToUpper(column)
+	if column == "" || column == "*" {
+		key += "\x00" + strconv.Itoa(pos)
+	}
+	if k, ok := lx.index[key]; ok {
+		lx.srcs[k].add(src)
+		return
+	}
+	lx.index[key] = len(lx.out)
+	lx.out = append(lx.out, ColumnLineage{Target: ColumnRef{Table: table, Column: column}, Position: pos})
+	s := colSet{}
+	s.add(src)
+	lx.srcs = append(lx.srcs, s)
+}
+
+func (lx *lineageCtx) statement() {
+	n := len(lx.toks)
+	i, cs := 0, cteCols{}
+	if lx.up(0) == "WITH" {
+		i, cs = lx.withClause(0, n, cs, nil)
+	}
+	switch lx.up(i) {
+	case "INSERT", "REPLACE":
+		lx.insert(i, cs)
+	case "CREATE":
+		lx.createAs(i, cs)
+	case "SELECT", "VALUES", "(":
+		if lx.rec != nil {
+			lx.query(i, n, cs, nil)
+		}
+		if lx.up(i) == "SELECT" {
+			lx.selectInto(i, cs)
+		}
+	case "UPDATE":
+		lx.update(i, cs)
+	case "MERGE":
+		lx.merge(i, cs)
+	case "DELETE":
+		if lx.rec != nil {
+			lx.delete(i, cs)
+		}
+	}
+}
+
+// withClause：WITH [RECURSIVE] name [(cols)] AS [[NOT] MATERIALIZED] (query) [, ...]，返回主语句起点
+func (lx *lineageCtx) withClause(lo, hi int, outer cteCols, sc *lineageScope) (int, cteCols) {
+	cs := cteCols{}
+	for k, v := range outer {
+		cs[k] = v
+	}
+	k := lo + 1
+	if lx.up(k) == "RECURSIVE" {
+		k++
+	}
+	for k < hi && looksLikeIdent(lx.toks[k].Text) {
+		name := unquoteIdent(lx.toks[k].Text)
+		k++
+		var names []string
+		if k < hi && lx.toks[k].Text == "(" {
+			names, k = lx.nameList(k)
+			k++
+		}
+		if lx.up(k) != "AS" {
+			break
+		}
+		k++
+		for lx.up(k) == "NOT" || lx.up(k) == "MATERIALIZED" {
+			k++
+		}
+		if k >= hi || lx.toks[k].Text != "(" {
+			break
+		}
+		cl := closeParen(lx.toks, k)
+		key := strings.ToUpper(name)
+		body := renameCols(lx.query(k+1, cl, cs, sc), names)
+		// 递归 CTE：用锚点分支的结果解析自引用，再算一遍
+		for j := k + 1; j < cl; j++ {
+			if strings.EqualFold(unquoteIdent(lx.toks[j].Text), name) {
+				cs[key] = body
+				body = renameCols(lx.query(k+1, cl, cs, sc), names)
+				break
+			}
+		}
+		cs[key] = body
+		k = cl + 1
+		if k >= hi || lx.toks[k].Text != "," {
+			break
+		}
+		k++
+	}
+	return k, cs
+}
+
+func renameCols(cols []lineageCol, names []string) []lineageCol {
+	for i := range cols {
+		if i < len(names) {
+			cols[i].name = names[i]
+		}
+	}
+	return cols
+}
+
+// query：[lo,hi) 是一个查询（可带 WITH、集合运算、外层括号），返回输出列；集合运算各分支按位置合并
+func (lx *lineageCtx) query(lo, hi int, cs cteCols, outer *lineageScope) []lineageCol {
+	if lo >= hi {
+		return nil
+	}
+	if lx.up(lo) == "WITH" {
+		lo, cs = lx.withClause(lo, hi, cs, outer)
+		if lo >= hi {
+			return nil
+		}
+	}
+	depth := lx.toks[lo].Depth
+	switch lx.up(lo) {
+	case "INSERT", "UPDATE", "DELETE":
+		return lx.returning(lo, hi, depth, cs, outer)
+	}
+	var cols []lineageCol
+	branch := func(a, b int) {
+		var out []lineageCol
+		if lx.toks[a].Text == "(" {
+			out = lx.query(a+1, min(closeParen(lx.toks, a), b), cs, outer)
+		} else {
+			out = lx.selectBlock(a, b, cs, outer)
+		}
+		if cols == nil {
+			cols = out
+			return
+		}
+		for k := range cols {
+			if k < len(out) {
+				cols[k].src.add(out[k].src)
+			}
+		}
+	}
+	start := lo
+	for i := lo; i < hi; i++ {
+		if lx.toks[i].Depth != depth {
+			continue
+		}
+		switch lx.toks[i].Upper {
+		case "UNION", "INTERSECT", "EXCEPT", "MINUS":
+			branch(start, i)
+			start = i + 1
+			if u := lx.up(start); u == "ALL" || u == "DISTINCT" {
+				start++
+			}
+		}
+	}
+	if start < hi {
+		branch(start, hi)
+	}
+	return cols
+}
+
+// selectBlock：单个 SELECT（或 VALUES）的输出列
+func (lx *lineageCtx) selectBlock(lo, hi int, cs cteCols, outer *lineageScope) []lineageCol {
+	toks := lx.toks
+	depth := toks[lo].Depth
+	sc := &lineageScope{parent: outer}
+	if lx.aliases != nil {
+		saved := lx.aliases // 外层 ORDER BY 的别名在子查询里不可见
+		lx.aliases = nil
+		defer func() { lx.aliases = saved }()
+	}
+	if toks[lo].Upper == "VALUES" {
+		var cols []lineageCol
+		for i := lo + 1; i < hi; i++ {
+			if toks[i].Text != "(" || toks[i].Depth != depth {
+				continue
+			}
+			cl := closeParen(toks, i)
+			for p, it := range lx.items(i+1, cl, depth+1) {
+				if p == len(cols) {
+					cols = append(cols, lineageCol{src: colSet{}})
+				}
+				cols[p].src.add(lx.exprSources(it[0], it[1], sc, cs))
+			}
+			i = cl
+		}
+		return cols
+	}
+	if toks[lo].Upper != "SELECT" {
+		return nil
+	}
+	i := lo + 1
+mods:
+	for i < hi {
+		switch u := toks[i].Upper; {
+		case u == "DISTINCT" && lx.up(i+1) == "ON" && lx.up(i+2) == "(":
+			i = closeParen(toks, i+2) + 1
+		case u == "TOP":
+			i++
+			if i < hi && toks[i].Text == "(" {
+				i = closeParen(toks, i)
+			}
+			i++
+			for lx.up(i) == "PERCENT" || lx.up(i) == "WITH" || lx.up(i) == "TIES" {
+				i++
+			}
+		case u == "DISTINCT" || u == "ALL" || u == "DISTINCTROW" || u == "UNIQUE" || u == "STRAIGHT_JOIN" ||
+			u == "HIGH_PRIORITY" || strings.HasPrefix(u, "SQL_"):
+			i++
+		default:
+			break mods
+		}
+	}
+	listEnd := lx.find(i, hi, depth, func(u string) bool { return lineageClause[u] })
+	if from := lx.find(listEnd, hi, depth, is("FROM")); from < hi {
+		end := lx.find(from+1, hi, depth, func(u string) bool { return lineageClause[u] && u != "FROM" })
+		lx.fromList(from+1, end, depth, cs, sc)
+	}
+	cols := lx.selectList(i, listEnd, depth, sc, cs)
+	if lx.rec != nil {
+		lx.clauses(listEnd, hi, depth, sc, cs, cols)
+	}
+	return cols
+}
+
+// selectList：SELECT 列表（或 RETURNING 列表）逐项求输出列名与来源
+func (lx *lineageCtx) selectList(lo, hi, depth int, sc *lineageScope, cs cteCols) []lineageCol {
+	toks := lx.toks
+	var cols []lineageCol
+	for _, it := range lx.items(lo, hi, depth) {
+		a, b := it[0], it[1]
+		alias := ""
+		switch {
+		case b-a >= 3 && toks[b-2].Upper == "AS":
+			alias, b = toks[b-1].Text, b-2
+		case b-a >= 2 && lx.isAlias(b-1) && !lx.notColumn(b-1) && !isOperatorTok(toks[b-2].Text) && toks[b-2].Text != "." && toks[b-2].Text != "(":
+			alias, b = toks[b-1].Text, b-1
+		case b-a >= 3 && lx.dialect == SQLServer && toks[a+1].Text == "=" && looksLikeIdent(toks[a].Text):
+			alias, a = toks[a].Text, a+2
+		}
+		// * / t.*：展开派生表与目录里有的基表，其余基表保留 *
+		if toks[b-1].Text == "*" && (b-a == 1 || (b-a >= 3 && toks[b-2].Text == ".")) && alias == "" {
+			qual := ""
+			if b-a >= 3 {
+				qual = unquoteIdent(toks[b-3].Text)
+			}
+			var rels []*relation
+			for k := range sc.rels {
+				r := &sc.rels[k]
+				if qual != "" && !strings.EqualFold(r.alias, qual) {
+					continue
+				}
+				rels = append(rels, r)
+				if r.table != "" && !r.known {
+					cols = append(cols, lineageCol{name: "*", src: colSet{{Table: r.table, Column: "*"}: true}})
+					continue
+				}
+				for _, c := range r.cols {
+					s := colSet{}
+					s.add(c.src)
+					cols = append(cols, lineageCol{name: c.name, src: s})
+				}
+			}
+			if lx.rec != nil {
+				lx.recordStar(a, b-1, sc, rels)
+			}
+			continue
+		}
+		name := unquoteIdent(strings.Trim(alias, "'"))
+		if name == "" {
+			if parts, _, end, ok := qualifiedName(lx.v, a); ok && end == b-1 {
+				name = unquoteIdent(parts[len(parts)-1])
+			}
+		}
+		cols = append(cols, lineageCol{name: name, src: lx.exprSources(a, b, sc, cs)})
+	}
+	return cols
+}
+
+// returning：CTE 里的 INSERT / UPDATE / DELETE ... RETURNING（PG），输出列来自目标表
+func (lx *lineageCtx) returning(lo, hi, depth int, cs cteCols, outer *lineageScope) []lineageCol {
+	ret := lx.find(lo, hi, depth, is("RETURNING"))
+	if ret >= hi {
+		return nil
+	}
+	k := lo + 1
+	switch lx.up(lo) {
+	case "INSERT":
+		k = lx.find(lo, ret, depth, is("INTO")) + 1
+	case "DELETE":
+		k = lx.find(lo, ret, depth, is("FROM")) + 1
+	}
+	if lx.up(k) == "ONLY" {
+		k++
+	}
+	sc := &lineageScope{parent: outer}
+	if k < ret {
+		lx.tableFactor(k, ret, cs, sc)
+	}
+	return lx.selectList(ret+1, hi, depth, sc, cs)
+}
+
+func isOperatorTok(s string) bool {
+	switch s {
+	case "+", "-", "*", "/", "%", "||", "=", "<", ">", "<=", ">=", "<>", "!=", "&", "|", "^", "::", ",":
+		return true
+	}
+	return false
+}
+
+// isAlias：toks[i] 可作别名
+func (lx *lineageCtx) isAlias(i int) bool {
+	if i >= len(lx.toks) {
+		return false
+	}
+	t := lx.toks[i]
+	return looksLikeIdent(t.Text) && (isQuotedIdent(t.Text) || !(formatKeywords[t.Upper] || lineageNotAlias[t.Upper]))
+}
+
+// fromList：[lo,hi) 为 FROM 列表（逗号 / JOIN 连接），把各项加入 sc；ON 条件只在记录引用时解析，
+// USING 的列与 NATURAL 记在 sc 上
+func (lx *lineageCtx) fromList(lo, hi, depth int, cs cteCols, sc *lineageScope) {
+	expect := true
+	var on [][2]int
+	for i := lo; i < hi; {
+		t := lx.toks[i]
+		if t.Depth == depth {
+			switch t.Upper {
+			case ",", "JOIN", "APPLY", "STRAIGHT_JOIN":
+				expect = true
+				i++
+				continue
+			case "ON":
+				expect = false
+				end := i + 1
+				for end < hi && !(lx.toks[end].Depth == depth && (lx.toks[end].Text == "," || lx.toks[end].Upper == "JOIN" ||
+					lx.toks[end].Upper == "APPLY" || (joinModifiers[lx.toks[end].Upper] && lx.up(end+1) != "("))) {
+					end++
+				}
+				on = append(on, [2]int{i + 1, end})
+				i = end
+				continue
+			case "USING":
+				expect = false
+				if lx.up(i+1) == "(" {
+					names, cl := lx.nameList(i + 1)
+					if sc.using == nil {
+						sc.using = map[string]bool{}
+					}
+					for _, n := range names {
+						sc.using[strings.ToUpper(n)] = true
+					}
+					i = cl
+				}
+				i++
+				continue
+			case "NATURAL":
+				sc.natural = true
+			case "PIVOT", "UNPIVOT", "MODEL", "MATCH_RECOGNIZE":
+				sc.opaque, expect = true, false
+				i++
+				continue
+			case "LATERAL", "ONLY":
+				i++
+				continue
+			}
+		}
+		if !expect || t.Depth != depth || joinModifiers[t.Upper] {
+			i++
+			continue
+		}
+		i = lx.tableFactor(i, hi, cs, sc)
+		expect = false
+	}
+	if lx.rec != nil {
+		for _, r := range on {
+			lx.exprSources(r[0], r[1], sc, cs)
+		}
+	}
+}
+
+// tableFactor：表 / (子查询) / 表函数 / (连接) 加上可选别名与列别名，返回其后位置
+func (lx *lineageCtx) tableFactor(i, hi int, cs cteCols, sc *lineageScope) int {
+	toks := lx.toks
+	var rel relation
+	next := i + 1
+	if toks[i].Text == "(" {
+		cl := closeParen(toks, i)
+		switch lx.up(i + 1) {
+		case "SELECT", "WITH", "VALUES", "(":
+			rel.cols = lx.query(i+1, cl, cs, sc)
+			rel.known = completeCols(rel.cols)
+		default:
+			lx.fromList(i+1, cl, toks[i].Depth+1, cs, sc)
+			return cl + 1
+		}
+		next = cl + 1
+	} else {
+		parts, text, end, ok := lx.nameOf(i)
+		if !ok {
+			return i + 1
+		}
+		next = end + 1
+		cols, isCTE := cs[strings.ToUpper(unquoteIdent(parts[0]))]
+		switch {
+		case next < hi && toks[next].Text == "(":
+			// 表函数：输出列都来自参数（JSON_TABLE / XMLTABLE 的 COLUMNS 定义不算）
+			cl := closeParen(toks, next)
+			end := lx.find(next+1, cl, toks[next].Depth+1, is("COLUMNS"))
+			rel.cols = []lineageCol{{name: "*", src: lx.exprSources(next+1, end, sc, cs)}}
+			next = cl + 1
+		case isCTE && len(parts) == 1:
+			rel.cols = cols
+			rel.alias, rel.qual, rel.known = unquoteIdent(parts[0]), parts[0], completeCols(cols)
+			lx.record(schemaRef{start: i, end: end, kind: refTable, status: refResolved})
+		default:
+			rel.table = text
+			rel.alias, rel.qual = unquoteIdent(parts[len(parts)-1]), parts[len(parts)-1]
+			lx.catalogTable(&rel, parts, i, end)
+		}
+	}
+	k := next
+	if lx.up(k) == "AS" {
+		k++
+	}
+	if k < hi && lx.isAlias(k) {
+		rel.alias, rel.qual = unquoteIdent(toks[k].Text), toks[k].Text
+		k++
+		if k < hi && toks[k].Text == "(" && rel.table == "" {
+			var names []string
+			names, k = lx.nameList(k)
+			k++
+			rel.cols = renameCols(copyCols(rel.cols), names)
+			rel.known = rel.known && len(names) == len(rel.cols)
+		}
+	}
+	sc.rels = append(sc.rels, rel)
+	return k
+}
+
+// completeCols：每个输出列都有名字（没有 * 与无名表达式）
+func completeCols(cols []lineageCol) bool {
+	for _, c := range cols {
+		if c.name == "" || c.name == "*" {
+			return false
+		}
+	}
+	return cols != nil
+}
+
+// catalogTable 按目录补全基表的列；toks[i..end] 为表名，记录为表引用
+func (lx *lineageCtx) catalogTable(rel *relation, parts []string, i, end int) {
+	if lx.cat == nil || strings.Contains(rel.table, "@") {
+		return // 无目录 / dblink 不检查
+	}
+	names := make([]string, len(parts))
+	for k, p := range parts {
+		names[k] = unquoteIdent(p)
+	}
+	t := lx.cat.lookup(names)
+	if t == nil {
+		if !(len(parts) == 1 && strings.EqualFold(names[0], "DUAL")) && !strings.HasPrefix(names[0], "#") {
+			lx.record(schemaRef{start: i, end: end, kind: refTable, status: refUnknown})
+		}
+		return
+	}
+	lx.record(schemaRef{start: i, end: end, kind: refTable, status: refResolved})
+	rel.known = true
+	rel.cols = make([]lineageCol, len(t.Columns))
+	for k, c := range t.Columns {
+		rel.cols[k] = lineageCol{name: c.Name, src: colSet{{Table: rel.table, Column: c.Name}: true}}
+	}
+}
+
+func copyCols(cols []lineageCol) []lineageCol {
+	out := make([]lineageCol, len(cols))
+	for i, c := range cols {
+		s := colSet{}
+		s.add(c.src)
+		out[i] = lineageCol{name: c.name, src: s}
+	}
+	return out
+}
+
+// exprSources：[lo,hi) 表达式引用到的来源列（子查询取其全部输出列的来源）
+func (lx *lineageCtx) exprSources(lo, hi int, sc *lineageScope, cs cteCols) colSet {
+	toks := lx.toks
+	src := colSet{}
+	for i := lo; i < hi; i++ {
+		t := toks[i]
+		if t.Text == "(" {
+			if u := lx.up(i + 1); u == "SELECT" || u == "WITH" {
+				cl := closeParen(toks, i)
+				for _, c := range lx.query(i+1, min(cl, hi), cs, sc) {
+					src.add(c.src)
+				}
+				i = cl
+			}
+			continue
+		}
+		if !looksLikeIdent(t.Text) || strings.ContainsRune(t.Text, '\'') || lx.notColumn(i) {
+			continue // E'..' / N'..' / q'..' 等字符串也以字母开头
+		}
+		parts, _, end, ok := qualifiedName(lx.v, i)
+		if !ok {
+			continue
+		}
+		if end+1 < len(toks) && toks[end+1].Text == "(" {
+			i = end // 函数名
+			continue
+		}
+		col := unquoteIdent(parts[len(parts)-1])
+		if lx.dialect == Oracle && oraclePseudoColumns[strings.ToUpper(col)] {
+			i = end
+			continue
+		}
+		if len(parts) == 1 && lx.aliases[strings.ToUpper(col)] {
+			continue // ORDER BY 等引用 SELECT 列表的别名
+		}
+		src.add(lx.ref(i, end, sc, parts, col))
+		i = end
+	}
+	return src
+}
+
+// notColumn：关键字、类型名（CAST ... AS t、::t）、时间单位、带类型的字面量前缀
+func (lx *lineageCtx) notColumn(i int) bool {
+	t := lx.toks[i]
+	switch {
+	case lx.dialect == MySQL && t.Text[0] == '"':
+		return true // MySQL 的 "..." 是字符串
+	case t.Text[0] == '[' && lx.dialect != SQLServer:
+		return true // ARRAY[...] 等的下标括号
+	}
+	if isQuotedIdent(t.Text) {
+		return false
+	}
+	prev := ""
+	if i > 0 {
+		prev = lx.toks[i-1].Text
+	}
+	switch {
+	case lineageExprWords[t.Upper], prev == "::", prev == "." || strings.EqualFold(prev, "AS"):
+		return true
+	case lx.up(i-1) == "COLLATE" || lx.up(i-1) == "OVER" || lx.up(i+1) == "=>":
+		return true // 排序规则名、命名窗口、命名参数
+	case i+1 < len(lx.toks) && isStringLiteral(lx.toks[i+1].Text) && lx.toks[i+1].Start == t.End:
+		return true // 紧贴字符串的前缀：MySQL 字符集 _utf8mb4'...'、Oracle q'[...]'、x'..' 等
+	case lx.dialect == Oracle && (oraclePseudoColumns[t.Upper] || t.Upper == "PRIOR"):
+		return true
+	case (t.Upper == "FIRST" || t.Upper == "LAST") && (lx.up(i-1) == "NULLS" || lx.up(i-1) == "DENSE_RANK"):
+		return true
+	case prev == "(" && unitArgFuncs[lx.up(i-2)]:
+		return true
+	case (t.Upper == "DATE" || t.Upper == "TIME" || t.Upper == "TIMESTAMP") && i+1 < len(lx.toks) &&
+		(isStringLiteral(lx.toks[i+1].Text) || lx.up(i+1) == "ZONE"):
+		return true
+	case dateUnits[t.Upper]:
+		for k := i - 1; k >= 0 && k >= i-3; k-- {
+			if lx.toks[k].Upper == "INTERVAL" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolve 把 qual.col 解析成来源列：限定名按别名 / 表名找；未限定时在最内层能确定的作用域里找。
+// 同时返回归属的关系（无法确定为 nil）与解析状态
+func (lx *lineageCtx) resolve(sc *lineageScope, qual []string, col string) (colSet, *relation, int) {
+	complete, seen := true, 0 // 经过的作用域里每个关系的列都已知；关系个数
+	for s := sc; s != nil; s = s.parent {
+		seen += len(s.rels)
+		if len(qual) > 0 {
+			q := unquoteIdent(qual[len(qual)-1])
+			for k := range s.rels {
+				r := &s.rels[k]
+				if !strings.EqualFold(r.alias, q) && !(r.table != "" && strings.EqualFold(r.table, tableName(qual))) {
+					continue
+				}
+				c, exact := r.lookup(col)
+				switch {
+				case exact:
+					return c, r, refResolved
+				case r.table != "":
+					status := refResolved
+					if r.known {
+						status = refUnknown
+					}
+					return colSet{{Table: r.table, Column: col}: true}, r, status
+				case c != nil:
+					return c, r, refResolved
+				case r.known:
+					return colSet{{Column: col}: true}, r, refUnknown
+				}
+				return colSet{{Column: col}: true}, r, refUnresolved
+			}
+			continue
+		}
+		if s.opaque {
+			return colSet{{Column: col}: true}, nil, refUnresolved
+		}
+		// 派生表（及目录里的表）同名的列优先；否则列未知的基表与只有 * 的派生表都是候选，唯一时才归属
+		var exact, loose []colSet
+		var er, lr []*relation
+		for k := range s.rels {
+			r := &s.rels[k]
+			if c, ok := r.lookup(col); ok {
+				exact, er = append(exact, c), append(er, r)
+				continue
+			}
+			if r.known {
+				continue
+			}
+			complete = false
+			if r.table != "" {
+				loose, lr = append(loose, colSet{{Table: r.table, Column: col}: true}), append(lr, r)
+			} else if c, _ := r.lookup(col); c != nil {
+				loose, lr = append(loose, c), append(lr, r)
+			}
+		}
+		switch {
+		case s.using[strings.ToUpper(col)] || (s.natural && len(exact) > 1):
+			// USING / NATURAL 合并的列：不限定，来源取各表
+			out := colSet{}
+			for _, c := range append(exact, loose...) {
+				out.add(c)
+			}
+			return out, nil, refResolved
+		case len(exact) == 1:
+			return exact[0], er[0], refResolved
+		case len(exact) > 1:
+			return colSet{{Column: col}: true}, nil, refAmbiguous
+		case len(loose) == 1:
+			return loose[0], lr[0], refResolved
+		case len(loose) > 1 || (len(s.rels) > 0 && !complete):
+			return colSet{{Column: col}: true}, nil, refUnresolved // 有歧义
+		}
+	}
+	if len(qual) > 0 {
+		return colSet{{Table: tableName(qual), Column: col}: true}, nil, refUnresolved
+	}
+	if complete && seen > 0 {
+		return colSet{{Column: col}: true}, nil, refUnknown
+	}
+	return colSet{{Column: col}: true}, nil, refUnresolved
+}
+
+// lookup 派生表的输出列（exact）；没有同名列而有 * 时把来源里的 * 换成列名；都没有返回 nil
+func (r relation) lookup(col string) (src colSet, exact bool) {
+	for _, c := range r.cols {
+		if strings.EqualFold(c.name, col) {
+			return c.src, true
+		}
+	}
+	out := colSet{}
+	for _, c := range r.cols {
+		if c.name != "*" {
+			continue
+		}
+		for s := range c.src {
+			if s.Column == "*" {
+				s.Column = col
+			}
+			out[s] = true
+		}
+	}
+	if len(out) == 0 {
+		return nil, false
+	}
+	return out, false
+}
+
+// insert：INSERT / REPLACE [INTO] t [(cols)] [OUTPUT ...] query
+func (lx *lineageCtx) insert(i int, cs cteCols) {
+	n := len(lx.toks)
+	k := i + 1
+	for {
+		switch lx.up(k) {
+		case "IGNORE", "LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY", "INTO", "OVERWRITE", "TABLE":
+			k++
+			continue
+		}
+		break
+	}
+	parts, table, end, ok := lx.nameOf(k)
+	if !ok {
+		return // Oracle INSERT ALL / FIRST 等不处理
+	}
+	target := relation{table: table}
+	lx.catalogTable(&target, parts, k, end)
+	k = end + 1
+	if lx.up(k) == "AS" {
+		k += 2
+	}
+	var names []string
+	if k < n && lx.toks[k].Text == "(" && lx.up(k+1) != "SELECT" && lx.up(k+1) != "WITH" {
+		lx.targetCols(k, &target)
+		names, k = lx.nameList(k)
+		k++
+	} else if target.known {
+		for _, c := range target.cols {
+			names = append(names, c.name) // 无列清单：按目录的列顺序
+		}
+	}
+	if k < n && lx.toks[k].Text != "(" {
+		// 跳过 SQL Server 的 OUTPUT ... [INTO ...]
+		k = lx.find(k, n, lx.base, is("SELECT", "WITH", "VALUES", "DEFAULT", "EXEC", "EXECUTE"))
+	}
+	if u := lx.up(k); !(u == "SELECT" || u == "WITH" || (u == "(" && (lx.up(k+1) == "SELECT" || lx.up(k+1) == "WITH"))) {
+		return // VALUES / DEFAULT VALUES / EXEC 没有来源列
+	}
+	// 截掉 ON CONFLICT / ON DUPLICATE KEY UPDATE / RETURNING
+	hi := k
+	for ; hi < n; hi++ {
+		if t := lx.toks[hi]; t.Depth == lx.base &&
+			(t.Upper == "RETURNING" || (t.Upper == "ON" && (lx.up(hi+1) == "CONFLICT" || lx.up(hi+1) == "DUPLICATE"))) {
+			break
+		}
+	}
+	for p, c := range lx.query(k, hi, cs, nil) {
+		switch {
+		case names == nil && c.name == "*":
+			lx.emit(table, "*", p+1, c.src)
+		case names == nil:
+			lx.emit(table, "", p+1, c.src)
+		case c.name == "*":
+			// 基表的 *：剩下的目标列都来自它
+			for q := p; q < len(names); q++ {
+				lx.emit(table, names[q], q+1, c.src)
+			}
+			return
+		case p < len(names):
+			lx.emit(table, names[p], p+1, c.src)
+		}
+	}
+}
+
+// createAs：CREATE [OR REPLACE] [TEMP ...] TABLE / [MATERIALIZED] VIEW name [(cols)] [options] AS query
+func (lx *lineageCtx) createAs(i int, cs cteCols) {
+	n := len(lx.toks)
+	k := lx.find(i+1, n, lx.base, is("TABLE", "VIEW", "(", "AS", "SELECT"))
+	if u := lx.up(k); u != "TABLE" && u != "VIEW" {
+		return
+	}
+	k++
+	if lx.up(k) == "IF" {
+		k++
+		if lx.up(k) == "NOT" {
+			k++
+		}
+		k++
+	}
+	_, table, end, ok := lx.nameOf(k)
+	if !ok {
+		return
+	}
+	k = end + 1
+	var names []string
+	if k < n && lx.toks[k].Text == "(" && lx.up(k+1) != "SELECT" && lx.up(k+1) != "WITH" {
+		names, k = lx.nameList(k)
+		k++
+	}
+	// 查询起点：AS 之后，或直接跟的 SELECT / (SELECT（MySQL 可省 AS）
+	for ; k < n; k++ {
+		t := lx.toks[k]
+		if t.Depth != lx.base {
+			continue
+		}
+		if t.Upper == "AS" {
+			k++
+			break
+		}
+		if t.Upper == "SELECT" || (t.Upper == "WITH" && lx.toks[min(k+1, n-1)].Text != "(") ||
+			(t.Text == "(" && (lx.up(k+1) == "SELECT" || lx.up(k+1) == "WITH")) {
+			break
+		}
+	}
+	if k >= n {
+		return
+	}
+	// 去掉 WITH [NO] DATA / WITH CHECK OPTION / WITH READ ONLY
+	hi := n
+	for j := k; j+1 < n; j++ {
+		if lx.toks[j].Depth == lx.base && lx.toks[j].Upper == "WITH" {
+			switch lx.up(j + 1) {
+			case "DATA", "NO", "CHECK", "READ", "CASCADED", "LOCAL":
+				hi = j
+			}
+		}
+	}
+	for p, c := range lx.query(k, hi, cs, nil) {
+		name := c.name
+		if p < len(names) {
+			name = names[p]
+		}
+		lx.emit(table, name, p+1, c.src)
+	}
+}
+
+// selectInto：SQL Server / PG 的 SELECT ... INTO new_table FROM ... 建表
+func (lx *lineageCtx) selectInto(i int, cs cteCols) {
+	if lx.dialect != SQLServer && lx.dialect != Postgres {
+		return
+	}
+	n := len(lx.toks)
+	into := lx.find(i, n, lx.base, func(u string) bool { return lineageClause[u] })
+	if lx.up(into) != "INTO" {
+		return
+	}
+	k := into + 1
+	for lx.up(k) == "TEMP" || lx.up(k) == "TEMPORARY" || lx.up(k) == "UNLOGGED" || lx.up(k) == "TABLE" {
+		k++
+	}
+	_, table, _, ok := lx.nameOf(k)
+	if !ok {
+		return
+	}
+	for p, c := range lx.query(i, n, cs, nil) {
+		lx.emit(table, c.name, p+1, c.src)
+	}
+}
+
+// update：UPDATE targets [JOIN ...] SET assignments [FROM ...]
+func (lx *lineageCtx) update(i int, cs cteCols) {
+	n := len(lx.toks)
+	set := lx.find(i+1, n, lx.base, is("SET"))
+	if set >= n {
+		return
+	}
+	k := i + 1
+	for {
+		switch lx.up(k) {
+		case "LOW_PRIORITY", "IGNORE", "ONLY":
+			k++
+			continue
+		case "TOP":
+			k++
+			if lx.up(k) == "(" {
+				k = closeParen(lx.toks, k)
+			}
+			k++
+			continue
+		}
+		break
+	}
+	sc := &lineageScope{}
+	lx.fromList(k, set, lx.base, cs, sc)
+	if len(sc.rels) == 0 {
+		return
+	}
+	target := sc.rels[0]
+	setEnd := lx.find(set+1, n, lx.base, is("FROM", "WHERE", "RETURNING", "OUTPUT", "ORDER", "LIMIT", "OPTION"))
+	if lx.up(setEnd) == "FROM" {
+		own := len(sc.rels)
+		fromEnd := lx.find(setEnd+1, n, lx.base, is("WHERE", "RETURNING", "ORDER", "LIMIT", "OPTION"))
+		lx.fromList(setEnd+1, fromEnd, lx.base, cs, sc)
+		// SQL Server：UPDATE x SET ... FROM t x，目标是 FROM 里别名为 x 的表
+		for _, r := range sc.rels[own:] {
+			if own == 1 && target.table != "" && strings.EqualFold(r.alias, target.table) {
+				target = r
+				sc.rels = sc.rels[1:]
+				break
+			}
+		}
+	}
+	lx.assignments(set+1, setEnd, target, sc, cs)
+	if lx.rec != nil {
+		lx.tail(lx.find(setEnd, n, lx.base, is("WHERE", "RETURNING", "ORDER")), n, sc, cs)
+	}
+}
+
+// assignments：col = expr / t.col = expr / (a, b) = (SELECT ...) 逐项写入
+func (lx *lineageCtx) assignments(lo, hi int, target relation, sc *lineageScope, cs cteCols) {
+	toks := lx.toks
+	depth := lx.base
+	if lo < hi {
+		depth = toks[lo].Depth
+	}
+	relOf := func(qual []string) *relation {
+		if len(qual) > 0 {
+			q := unquoteIdent(qual[len(qual)-1])
+			for k := range sc.rels {
+				if strings.EqualFold(sc.rels[k].alias, q) {
+					return &sc.rels[k]
+				}
+			}
+		}
+		return &target
+	}
+	for _, it := range lx.items(lo, hi, depth) {
+		a, b := it[0], it[1]
+		eq := lx.find(a, b, depth, is("="))
+		if eq >= b {
+			continue
+		}
+		if toks[a].Text == "(" {
+			lx.targetCols(a, &target)
+			names, _ := lx.nameList(a)
+			var outs []colSet
+			if toks[eq+1].Text == "(" && (lx.up(eq+2) == "SELECT" || lx.up(eq+2) == "WITH") {
+				for _, c := range lx.query(eq+2, closeParen(toks, eq+1), cs, sc) {
+					outs = append(outs, c.src)
+				}
+			} else if toks[eq+1].Text == "(" {
+				cl := closeParen(toks, eq+1)
+				for _, e := range lx.items(eq+2, cl, depth+1) {
+					outs = append(outs, lx.exprSources(e[0], e[1], sc, cs))
+				}
+			}
+			for p, name := range names {
+				if p < len(outs) && target.table != "" {
+					lx.emit(target.table, name, 0, outs[p])
+				}
+			}
+			continue
+		}
+		parts, _, end, ok := qualifiedName(lx.v, a)
+		if !ok {
+			continue
+		}
+		r := relOf(parts[:len(parts)-1])
+		lx.targetCol(a, end, r)
+		if r.table != "" {
+			lx.emit(r.table, unquoteIdent(parts[len(parts)-1]), 0, lx.exprSources(eq+1, b, sc, cs))
+		}
+	}
+}
+
+// merge：MERGE [INTO] target USING source ON ... WHEN ... THEN UPDATE SET ... | INSERT [(cols)] VALUES (...)
+func (lx *lineageCtx) merge(i int, cs cteCols) {
+	n := len(lx.toks)
+	k := i + 1
+	if lx.up(k) == "INTO" {
+		k++
+	}
+	using := lx.find(k, n, lx.base, is("USING"))
+	on := lx.find(using, n, lx.base, is("ON"))
+	sc := &lineageScope{}
+	lx.fromList(k, using, lx.base, cs, sc)
+	if len(sc.rels) == 0 || on >= n {
+		return
+	}
+	target := sc.rels[0]
+	lx.fromList(using+1, on, lx.base, cs, sc)
+	if target.table == "" {
+		return
+	}
+	// 不匹配的分支里只能引用来源
+	source := &lineageScope{rels: sc.rels[1:]}
+	first := lx.find(on, n, lx.base, is("WHEN"))
+	if lx.rec != nil {
+		lx.exprSources(on+1, first, sc, cs)
+	}
+	for w := first; w < n; {
+		next := lx.find(w+1, n, lx.base, is("WHEN"))
+		then := lx.find(w, next, lx.base, is("THEN"))
+		if and := lx.find(w, then, lx.base, is("AND")); lx.rec != nil && and < then {
+			lx.exprSources(and+1, then, sc, cs)
+		}
+		switch lx.up(then + 1) {
+		case "UPDATE":
+			if lx.up(then+2) == "SET" {
+				end := lx.find(then+3, next, lx.base, is("WHERE", "DELETE", "OUTPUT", "OPTION"))
+				lx.assignments(then+3, end, target, sc, cs)
+				// Oracle：UPDATE SET ... WHERE ... DELETE WHERE ...
+				for k := end; lx.rec != nil && k < next; k = lx.find(k+1, next, lx.base, is("WHERE")) {
+					if lx.up(k) == "WHERE" {
+						lx.exprSources(k+1, lx.find(k+1, next, lx.base, is("DELETE", "OUTPUT", "OPTION")), sc, cs)
+					}
+				}
+			}
+		case "INSERT":
+			k := then + 2
+			var names []string
+			if k < next && lx.toks[k].Text == "(" {
+				lx.targetCols(k, &target)
+				names, k = lx.nameList(k)
+				k++
+			} else if target.known {
+				for _, c := range target.cols {
+					names = append(names, c.name)
+				}
+			}
+			k = lx.find(k, next, lx.base, is("VALUES"))
+			if k+1 < next && lx.toks[k+1].Text == "(" {
+				cl := closeParen(lx.toks, k+1)
+				for p, e := range lx.items(k+2, cl, lx.base+1) {
+					name := ""
+					if p < len(names) {
+						name = names[p]
+					}
+					lx.emit(target.table, name, p+1, lx.exprSources(e[0], e[1], source, cs))
+				}
+			}
+		}
+		w = next
+	}
+}
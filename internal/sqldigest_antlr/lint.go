@@ -0,0 +1,251 @@
+package sqldigest_antlr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// SQL lint 框架：规则实现 LintRule，对 SplitStatements 切出的每条语句求值。
+// 抑制注释（-- / # / /* */ 均可）：
+//   - sqlglot:ignore [rule,...]       只作用于注释所属的语句（语句内、紧跟其后同一行、或紧挨在它前面的注释）
+//   - sqlglot:ignore-file [rule,...]  作用于整段输入
+// 不写规则名表示全部规则；指令与规则名之间须有空白，不在本次规则集中的名字报 LintIgnoreDirective 结果。
+
+// LintToken 规则看到的可见 token；被 lexer 拆开的 :name / @p1 合并为一个
+type LintToken struct {
+	Text  string `json:"text"`
+	Upper string `json:"upper"`
+	Start int    `json:"start"` // 字节区间
+	End   int    `json:"end"`
+	Depth int    `json:"depth"` // 括号深度；括号本身取外层深度
+}
+
+// LintStatement 交给规则的一条语句
+type LintStatement struct {
+	Index    int // 从 1 起
+	Type     string
+	Dialect  Dialect
+	SQL      string // 整段输入；Start/End 与 token 区间都相对它
+	Start    int
+	End      int
+	Tokens   []LintToken // 不含结尾的 ;
+	Comments []Comment   // 归属本语句的注释
+	Catalog  *Catalog    // Options.Catalog；为 nil 时依赖 schema 的规则不报
+}
+
+// LintFinding 一条 lint 结果；Rule / Statement 由框架填写，Severity 为空按 medium
+type LintFinding struct {
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	Statement int    `json:"statement"`
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+}
+
+// LintRule 规则接口；Name 用于输出与抑制注释
+type LintRule interface {
+	Name() string
+	Check(st *LintStatement) []LintFinding
+}
+
+// FindingAt 以 Tokens[i..j] 为区间构造一条结果
+func (st *LintStatement) FindingAt(i, j int, severity, msg string) LintFinding {
+	return LintFinding{Severity: severity, Message: msg, Start: st.Tokens[i].Start, End: st.Tokens[j].End}
+}
+
+type funcRule struct {
+	name string
+	fn   func(*LintStatement) []LintFinding
+}
+
+func (r funcRule) Name() string                          { return r.name }
+func (r funcRule) Check(st *LintStatement) []LintFinding { return r.fn(st) }
+
+// NewLintRule 用函数构造规则
+func NewLintRule(name string, fn func(*LintStatement) []LintFinding) LintRule {
+	return funcRule{name: name, fn: fn}
+}
+
+// Lint 对 sql 逐条语句运行 rules（nil 时用 DefaultLintRules），结果按语句、位置排序
+func Lint(sql string, rules []LintRule, opt Options) ([]LintFinding, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	if rules == nil {
+		rules = DefaultLintRules()
+	}
+	toks, _, err := lexTokens(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	all := lintTokens(sql, toks, opt)
+	stmts := SplitStatements(sql, toks, opt)
+	comments := ExtractComments(sql, toks, opt)
+	owner := commentOwners(sql, stmts, comments)
+
+	known := map[string]bool{}
+	for _, r := range rules {
+		known[r.Name()] = true
+	}
+	fileIgnore := map[string]bool{}
+	stmtIgnore := make([]map[string]bool, len(stmts))
+	byStmt := make([][]Comment, len(stmts))
+	var out []LintFinding
+	directive := make([][]LintFinding, len(stmts))
+	for i, c := range comments {
+		if owner[i] >= 0 {
+			byStmt[owner[i]] = append(byStmt[owner[i]], c)
+		}
+		file, names, ok := parseLintIgnore(c.Text)
+		for _, n := range names {
+			if known[n] {
+				continue
+			}
+			f := LintFinding{Rule: LintIgnoreDirective, Severity: SeverityLow, Start: c.Start, End: c.End,
+				Message: fmt.Sprintf("sqlglot:ignore names unknown rule %q; nothing is suppressed for it", n)}
+			if file || owner[i] < 0 {
+				out = append(out, f)
+			} else {
+				f.Statement = owner[i] + 1
+				directive[owner[i]] = append(directive[owner[i]], f)
+			}
+		}
+		switch {
+		case !ok:
+		case file:
+			addIgnores(fileIgnore, names)
+		case owner[i] >= 0:
+			if stmtIgnore[owner[i]] == nil {
+				stmtIgnore[owner[i]] = map[string]bool{}
+			}
+			addIgnores(stmtIgnore[owner[i]], names)
+		}
+	}
+	ignored := func(set map[string]bool, rule string) bool { return set["*"] || set[rule] }
+
+	for n, si := range stmts {
+		st := &LintStatement{
+			Index: n + 1, Type: si.Type, Dialect: opt.Dialect, SQL: sql,
+			Start: si.StartByte, End: si.EndByte, Comments: byStmt[n], Catalog: opt.Catalog,
+		}
+		for _, t := range all {
+			if t.Start >= si.StartByte && t.End <= si.EndByte {
+				st.Tokens = append(st.Tokens, t)
+			}
+		}
+		if k := len(st.Tokens); k > 0 && st.Tokens[k-1].Text == ";" {
+			st.Tokens = st.Tokens[:k-1]
+		}
+		if len(st.Tokens) == 0 {
+			continue
+		}
+		found := directive[n]
+		for _, r := range rules {
+			name := r.Name()
+			if ignored(fileIgnore, name) || ignored(stmtIgnore[n], name) {
+				continue
+			}
+			for _, f := range r.Check(st) {
+				f.Rule, f.Statement = name, n+1
+				if f.Severity == "" {
+					f.Severity = SeverityMedium
+				}
+				found = append(found, f)
+			}
+		}
+		sort.SliceStable(found, func(i, j int) bool { return found[i].Start < found[j].Start })
+		out = append(out, found...)
+	}
+	return out, nil
+}
+
+// lintTokens：可见 token + 括号深度；NormalizeBinds 同款的拆分绑定在这里总是合并
+func lintTokens(sql string, toks []antlr.Token, opt Options) []LintToken {
+	var spans []span
+	if opt.Dialect == MySQL {
+		spans = findMySQLCommentSpans(sql)
+	}
+	var out []LintToken
+	depth := 0
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		if IsEOFToken(t) || t.GetChannel() != antlr.TokenDefaultChannel || IsWhitespace(t.GetText()) {
+			continue
+		}
+		s, e := RuneIndexToByte(sql, t.GetStart()), RuneIndexToByte(sql, t.GetStop()+1)
+		if len(spans) > 0 && inAnySpan(s, e, spans) {
+			continue
+		}
+		text := t.GetText()
+		// PG 以外的 lexer 可能把 :: 拆成两个 :，第二个 : 不能当绑定前缀
+		if j, ok := splitBindEnd(toks, i); ok && !(len(out) > 0 && out[len(out)-1].Text == ":" && out[len(out)-1].End == s) {
+			e = RuneIndexToByte(sql, toks[j].GetStop()+1)
+			text = sql[s:e]
+			i = j
+		}
+		lt := LintToken{Text: text, Upper: strings.ToUpper(text), Start: s, End: e}
+		switch text {
+		case "(":
+			lt.Depth = depth
+			depth++
+		case ")":
+			if depth > 0 {
+				depth--
+			}
+			lt.Depth = depth
+		default:
+			lt.Depth = depth
+		}
+		out = append(out, lt)
+	}
+	return out
+}
+
+// commentOwners 给每条注释找所属语句下标（-1 表示无）：
+// 同一行紧跟在语句之后的归该语句，其余归第一条结束位置不早于注释的语句
+func commentOwners(sql string, stmts []StmtInfo, comments []Comment) []int {
+	out := make([]int, len(comments))
+	for ci, c := range comments {
+		out[ci] = -1
+		for i, st := range stmts {
+			if st.EndByte <= c.Start && !strings.ContainsAny(sql[st.EndByte:c.Start], "\r\n") &&
+				(i+1 == len(stmts) || c.End <= stmts[i+1].StartByte) {
+				out[ci] = i
+				break
+			}
+			if st.EndByte >= c.End {
+				out[ci] = i
+				break
+			}
+		}
+	}
+	return out
+}
+
+// 指令后只能是行尾、注释结尾或空白加规则列表；sqlglot:ignore-foo / sqlglot:ignore: 不是指令
+var reLintIgnore = regexp.MustCompile(`sqlglot:ignore(-file)?(?:[ \t]+([^\r\n]*?))?[ \t]*(?:\*/|[\r\n]|$)`)
+
+// parseLintIgnore 解析注释中的抑制指令；names 为空表示全部规则。
+// 规则名之外的文字（如 "because legacy"）也按名字返回，由调用方报告为未知规则
+func parseLintIgnore(text string) (file bool, names []string, ok bool) {
+	m := reLintIgnore.FindStringSubmatch(text)
+	if m == nil {
+		return false, nil, false
+	}
+	names = strings.FieldsFunc(m[2], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	return m[1] != "", names, true
+}
+
+func addIgnores(set map[string]bool, names []string) {
+	if len(names) == 0 {
+		set["*"] = true
+	}
+	for _, n := range names {
+		set[n] = true
+	}
+}
@@ -0,0 +1,356 @@
+package sqldigest_antlr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 内置 lint 规则名
+const (
+	LintCommaJoin        = "comma_join"
+	LintNotInSubquery    = "not_in_subquery"
+	LintEqualsNull       = "equals_null"
+	LintFunctionOnColumn = "function_on_column"
+	LintMixedBindStyles  = "mixed_bind_styles"
+	LintOracleOuterJoin  = "oracle_outer_join"
+	LintOrderByOrdinal   = "order_by_ordinal"
+	// 以下需要 Options.Catalog
+	LintUnknownTable    = "unknown_table"
+	LintUnknownColumn   = "unknown_column"
+	LintAmbiguousColumn = "ambiguous_column"
+	// 框架自身的结果：sqlglot:ignore 里写了本次规则集中没有的名字
+	LintIgnoreDirective = "ignore_directive"
+)
+
+// DefaultLintRules 全部内置规则
+func DefaultLintRules() []LintRule {
+	return []LintRule{
+		NewLintRule(LintCommaJoin, lintCommaJoin),
+		NewLintRule(LintNotInSubquery, lintNotInSubquery),
+		NewLintRule(LintEqualsNull, lintEqualsNull),
+		NewLintRule(LintFunctionOnColumn, lintFunctionOnColumn),
+		NewLintRule(LintMixedBindStyles, lintMixedBindStyles),
+		NewLintRule(LintOracleOuterJoin, lintOracleOuterJoin),
+		NewLintRule(LintOrderByOrdinal, lintOrderByOrdinal),
+		NewLintRule(LintUnknownTable, lintSchema(refTable, refUnknown, SeverityHigh, "table %s is not in the catalog")),
+		NewLintRule(LintUnknownColumn, lintSchema(refColumn, refUnknown, SeverityHigh, "column %s does not exist in the referenced tables")),
+		NewLintRule(LintAmbiguousColumn, lintSchema(refColumn, refAmbiguous, SeverityMedium, "column %s exists in more than one table; qualify it")),
+	}
+}
+
+// 子句结束关键字（与起始关键字同一深度时生效）
+var lintClauseEnd = map[string]bool{
+	"WHERE": true, "GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true, "OFFSET": true, "FETCH": true,
+	"UNION": true, "INTERSECT": true, "EXCEPT": true, "MINUS": true, "WINDOW": true, "QUALIFY": true,
+	"FOR": true, "CONNECT": true, "START": true, "RETURNING": true, "OPTION": true,
+}
+
+// selectScopes：每个 token 所在括号层里，此前是否出现过 SELECT（区分 SELECT 的 FROM / ORDER BY 与
+// EXTRACT(x FROM y)、DELETE FROM、OVER (ORDER BY ...) 等）
+func selectScopes(toks []LintToken) []bool {
+	out := make([]bool, len(toks))
+	stack := []bool{false}
+	for i, t := range toks {
+		switch {
+		case t.Text == "(":
+			out[i] = stack[len(stack)-1]
+			stack = append(stack, false)
+			continue
+		case t.Text == ")":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case t.Upper == "SELECT":
+			stack[len(stack)-1] = true
+		}
+		out[i] = stack[len(stack)-1]
+	}
+	return out
+}
+
+// clauseEnd：从 i 开始、深度为 depth 的子句结束位置（不含）
+func clauseEnd(toks []LintToken, i, depth int) int {
+	for ; i < len(toks); i++ {
+		t := toks[i]
+		if t.Depth < depth || (t.Depth == depth && (lintClauseEnd[t.Upper] || t.Text == ";")) {
+			return i
+		}
+	}
+	return len(toks)
+}
+
+// closeParen：toks[open] 为 ( 时返回配对 ) 的位置
+func closeParen(toks []LintToken, open int) int {
+	for k := open + 1; k < len(toks); k++ {
+		if toks[k].Text == ")" && toks[k].Depth == toks[open].Depth {
+			return k
+		}
+	}
+	return len(toks) - 1
+}
+
+// lintCommaJoin：SELECT 的 FROM 列表里逗号分隔的表；逗号后是 LATERAL / UNNEST / 表函数时不算
+func lintCommaJoin(st *LintStatement) []LintFinding {
+	toks := st.Tokens
+	scope := selectScopes(toks)
+	var out []LintFinding
+	for i, t := range toks {
+		if t.Upper != "FROM" || !scope[i] {
+			continue
+		}
+		end := clauseEnd(toks, i+1, t.Depth)
+		for j := i + 1; j+1 < end; j++ {
+			if toks[j].Text != "," || toks[j].Depth != t.Depth {
+				continue
+			}
+			next := toks[j+1]
+			if next.Upper == "LATERAL" || next.Upper == "UNNEST" || next.Upper == "TABLE" ||
+				(j+2 < end && toks[j+2].Text == "(" && looksLikeIdent(next.Text)) {
+				continue
+			}
+			out = append(out, st.FindingAt(j, j+1, SeverityMedium, "implicit cross join: comma-separated tables in FROM; use JOIN ... ON"))
+		}
+	}
+	return out
+}
+
+// lintNotInSubquery：NOT IN (SELECT ...)；子查询里写了 IS NOT NULL 的视为已处理
+func lintNotInSubquery(st *LintStatement) []LintFinding {
+	toks := st.Tokens
+	var out []LintFinding
+	for i := 0; i+3 < len(toks); i++ {
+		if toks[i].Upper != "NOT" || toks[i+1].Upper != "IN" || toks[i+2].Text != "(" || toks[i+3].Upper != "SELECT" {
+			continue
+		}
+		cl := closeParen(toks, i+2)
+		guarded := false
+		for k := i + 3; k+2 < cl; k++ {
+			if toks[k].Upper == "IS" && toks[k+1].Upper == "NOT" && toks[k+2].Upper == "NULL" {
+				guarded = true
+				break
+			}
+		}
+		if !guarded {
+			out = append(out, st.FindingAt(i, cl, SeverityMedium, "NOT IN (subquery) matches nothing if the subquery returns a NULL; use NOT EXISTS"))
+		}
+	}
+	return out
+}
+
+// lintEqualsNull：= NULL / <> NULL / != NULL；赋值（SET a = NULL、ON DUPLICATE KEY UPDATE）与
+// 非 DML 语句里的 =（参数默认值、EXEC 具名参数）不算
+func lintEqualsNull(st *LintStatement) []LintFinding {
+	toks := st.Tokens
+	dml := false
+	switch st.Type {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE", "UPSERT":
+		dml = true
+	}
+	var out []LintFinding
+	assignDepth := -1
+	for i, t := range toks {
+		switch {
+		case t.Upper == "SET" || (t.Upper == "UPDATE" && i > 0 && toks[i-1].Upper == "KEY"):
+			assignDepth = t.Depth
+			continue
+		case t.Depth == assignDepth && (t.Upper == "WHERE" || t.Upper == "FROM" || t.Upper == "WHEN" ||
+			t.Upper == "RETURNING" || t.Upper == "OUTPUT"):
+			assignDepth = -1
+		case t.Depth < assignDepth:
+			assignDepth = -1
+		}
+		if t.Text != "=" && t.Text != "<>" && t.Text != "!=" {
+			continue
+		}
+		if t.Text == "=" && (!dml || t.Depth == assignDepth) {
+			continue
+		}
+		lo, hi := i, i
+		switch {
+		case i+1 < len(toks) && toks[i+1].Upper == "NULL":
+			hi = i + 1
+		case i > 0 && toks[i-1].Upper == "NULL":
+			lo = i - 1
+		default:
+			continue
+		}
+		fix := "IS NULL"
+		if t.Text != "=" {
+			fix = "IS NOT NULL"
+		}
+		out = append(out, st.FindingAt(lo, hi, SeverityHigh, "comparison with NULL is never true; use "+fix))
+	}
+	return out
+}
+
+// 不当作函数名的关键字（其后的括号不是函数调用）
+var lintNotFunc = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IN": true, "EXISTS": true, "ANY": true, "ALL": true, "SOME": true,
+	"VALUES": true, "SELECT": true, "WHERE": true, "ON": true, "USING": true, "AS": true, "OVER": true, "FILTER": true,
+	"BETWEEN": true, "LIKE": true, "IS": true, "WHEN": true, "THEN": true, "ELSE": true, "CASE": true,
+}
+
+// 比较运算（函数调用是其操作数时才报）
+var lintCompare = map[string]bool{
+	"=": true, "<>": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true,
+	"LIKE": true, "ILIKE": true, "BETWEEN": true, "IN": true, "NOT": true, "IS": true,
+}
+
+// 参数里不算列名的关键字
+var lintNotColumn = map[string]bool{
+	"NULL": true, "TRUE": true, "FALSE": true, "AND": true, "OR": true, "NOT": true, "AS": true,
+	"CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true, "INTERVAL": true,
+	"FROM": true, "FOR": true, "BOTH": true, "LEADING": true, "TRAILING": true, "DISTINCT": true,
+}
+
+// indexedLooking：像是带索引的列名（id、*_id、userId、*_at、*_date、email ...）
+func indexedLooking(name string) bool {
+	name = strings.Trim(name, "\"`[]")
+	if strings.HasSuffix(name, "Id") || strings.HasSuffix(name, "ID") {
+		return true
+	}
+	n := strings.ToLower(name)
+	switch n {
+	case "id", "uuid", "email", "created", "updated", "date", "code":
+		return true
+	}
+	for _, suf := range []string{"_id", "_key", "_code", "_no", "_uuid", "_email", "_at", "_on", "_date", "_time", "_ts"} {
+		if strings.HasSuffix(n, suf) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintFunctionOnColumn：WHERE 里作为比较操作数的函数调用包住了像是有索引的列，如 DATE(created_at) = ?
+func lintFunctionOnColumn(st *LintStatement) []LintFinding {
+	toks := st.Tokens
+	var out []LintFinding
+	seen := map[int]bool{} // 外层 WHERE 的范围包含子查询的 WHERE
+	for w, wt := range toks {
+		if wt.Upper != "WHERE" {
+			continue
+		}
+		end := clauseEnd(toks, w+1, wt.Depth)
+		for i := w + 1; i+1 < end; i++ {
+			f := toks[i]
+			if seen[i] || toks[i+1].Text != "(" || !looksLikeIdent(f.Text) || isQuotedIdent(f.Text) || lintNotFunc[f.Upper] {
+				continue
+			}
+			cl := closeParen(toks, i+1)
+			before := i > 0 && lintCompare[toks[i-1].Upper] && toks[i-1].Upper != "IN" && toks[i-1].Upper != "NOT"
+			after := cl+1 < len(toks) && lintCompare[toks[cl+1].Upper]
+			if !before && !after {
+				continue
+			}
+			for k := i + 2; k < cl; k++ {
+				c := toks[k]
+				if !looksLikeIdent(c.Text) || lintNotColumn[c.Upper] || toks[k-1].Upper == "AS" ||
+					(k+1 < len(toks) && (toks[k+1].Text == "(" || toks[k+1].Text == ".")) {
+					continue
+				}
+				if indexedLooking(c.Text) {
+					seen[i] = true
+					out = append(out, st.FindingAt(i, cl, SeverityLow,
+						f.Upper+"(...) on column "+strings.Trim(c.Text, "\"`[]")+" in WHERE prevents index use"))
+					i = cl
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// bindStyle 绑定占位符的写法；MySQL 的 @x 是用户变量，不算
+func bindStyle(text string, d Dialect) string {
+	switch {
+	case text == "?":
+		return "?"
+	case reDollarN.MatchString(text):
+		return "$n"
+	case reColon.MatchString(text):
+		return ":name"
+	case reAtNamed.MatchString(text) && d != MySQL:
+		return "@name"
+	}
+	return ""
+}
+
+// lintMixedBindStyles：同一语句混用 ? / $n / :name / @name
+func lintMixedBindStyles(st *LintStatement) []LintFinding {
+	first := ""
+	for i, t := range st.Tokens {
+		s := bindStyle(t.Text, st.Dialect)
+		switch {
+		case s == "":
+		case first == "":
+			first = s
+		case s != first:
+			return []LintFinding{st.FindingAt(i, i, SeverityMedium, "statement mixes bind styles "+first+" and "+s)}
+		}
+	}
+	return nil
+}
+
+// lintOracleOuterJoin：Oracle 旧式外连接 (+)
+func lintOracleOuterJoin(st *LintStatement) []LintFinding {
+	if st.Dialect != Oracle {
+		return nil
+	}
+	toks := st.Tokens
+	var out []LintFinding
+	for i := 0; i+2 < len(toks); i++ {
+		if toks[i].Text == "(" && toks[i+1].Text == "+" && toks[i+2].Text == ")" {
+			out = append(out, st.FindingAt(i, i+2, SeverityLow, "legacy (+) outer join; use LEFT/RIGHT JOIN ... ON"))
+		}
+	}
+	return out
+}
+
+// lintOrderByOrdinal：SELECT 的 ORDER BY 里只写列序号的项
+func lintOrderByOrdinal(st *LintStatement) []LintFinding {
+	toks := st.Tokens
+	scope := selectScopes(toks)
+	var out []LintFinding
+	for i := 0; i+1 < len(toks); i++ {
+		if toks[i].Upper != "ORDER" || toks[i+1].Upper != "BY" || !scope[i] {
+			continue
+		}
+		d := toks[i].Depth
+		end := clauseEnd(toks, i+2, d)
+		for k := i + 2; k < end; k++ {
+			if k > i+2 && (toks[k-1].Text != "," || toks[k-1].Depth != d) {
+				continue
+			}
+			if !isAllDigits(toks[k].Text) {
+				continue
+			}
+			if n := k + 1; n == end || toks[n].Text == "," || toks[n].Upper == "ASC" || toks[n].Upper == "DESC" || toks[n].Upper == "NULLS" {
+				out = append(out, st.FindingAt(k, k, SeverityLow, "ORDER BY "+toks[k].Text+" refers to a select-list position; name the column"))
+			}
+		}
+	}
+	return out
+}
+
+// lintSchema：按 st.Catalog 解析表 / 列引用，报告种类与状态相符的；没有目录时不报
+func lintSchema(kind, status int, severity, format string) func(*LintStatement) []LintFinding {
+	return func(st *LintStatement) []LintFinding {
+		if st.Catalog == nil {
+			return nil
+		}
+		toks := st.Tokens
+		if st.Dialect == Postgres {
+			toks, _ = mergeDollarQuoted(st.SQL, toks, nil)
+		}
+		var out []LintFinding
+		for _, r := range schemaRefs(toks, Options{Dialect: st.Dialect, Catalog: st.Catalog}) {
+			if r.kind == kind && r.status == status {
+				s, e := toks[r.start].Start, toks[r.end].End
+				out = append(out, LintFinding{Severity: severity, Message: fmt.Sprintf(format, st.SQL[s:e]), Start: s, End: e})
+			}
+		}
+		return out
+	}
+}
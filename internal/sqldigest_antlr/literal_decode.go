@@ -0,0 +1,416 @@
+package sqldigest_antlr
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNotLiteral 参数不是可解码的字面量（绑定占位符、函数等）
+var ErrNotLiteral = errors.New("param is not a literal")
+
+// DecodeLiteral 按方言转义规则把字面量原文解码为值：
+//   - '..' / ".."：双写引号；MySQL（未开启 NoBackslashEscapes）下识别反斜杠转义；
+//     MySQL 之外 ".." 是引号标识符，返回空串
+//   - N'..'：去掉国别字符前缀后同上
+//   - E'..'：PG 扩展字符串（\n、\xHH、\uXXXX 等）
+//   - $tag$..$tag$：PG dollar-quoted，原样取内部
+//   - q'[..]'：Oracle 替代引号，原样取内部
+//   - x'..' / 0x..：十六进制 → 原始字节；b'..'：位串 → 原始字节
+//   - DATE/TIME/TIMESTAMP/INTERVAL '..'：取内部字符串
+//
+// 绑定占位符与函数返回空串。
+func DecodeLiteral(raw, typ string, opt Options) string {
+	switch typ {
+	case "Bind", "NamedBind", "Func":
+		return ""
+	case "Date", "Time", "Timestamp", "Interval":
+		// 形如 DATE '2020-01-01'：跳过关键字，解码后面的字符串
+		if i := strings.IndexAny(raw, "'\"$"); i > 0 {
+			return decodeStringLiteral(strings.TrimSpace(raw[i:]), opt)
+		}
+		// NOW()/SYSDATE 等时间函数不是字面量
+		return ""
+	case "Number":
+		return decodeNumberLiteral(raw, opt)
+	}
+	if quotedIdent(raw, opt.Dialect) {
+		return ""
+	}
+	return decodeStringLiteral(raw, opt)
+}
+
+// quotedIdent PG/Oracle/SQL Server 的 ".." 是引号标识符，词法上却会被当作字符串参数
+func quotedIdent(raw string, d Dialect) bool {
+	return d != "" && d != MySQL && strings.HasPrefix(raw, `"`)
+}
+
+func decodeNumberLiteral(raw string, opt Options) string {
+	if len(raw) > 2 && (raw[:2] == "0x" || raw[:2] == "0X") {
+		if b, ok := decodeHexDigits(raw[2:]); ok {
+			return string(b)
+		}
+		return raw
+	}
+	// Oracle BINARY_FLOAT / BINARY_DOUBLE 后缀：3f / 4d
+	if opt.Dialect == Oracle && len(raw) > 1 {
+		switch raw[len(raw)-1] {
+		case 'f', 'F', 'd', 'D':
+			return raw[:len(raw)-1]
+		}
+	}
+	return raw
+}
+
+func decodeStringLiteral(raw string, opt Options) string {
+	if raw == "" {
+		return ""
+	}
+	// PG dollar-quoted：$tag$...$tag$
+	if raw[0] == '$' {
+		if j := strings.IndexByte(raw[1:], '$'); j >= 0 {
+			tag := raw[:j+2]
+			if len(raw) >= 2*len(tag) && strings.HasSuffix(raw, tag) {
+				return raw[len(tag) : len(raw)-len(tag)]
+			}
+		}
+		return raw
+	}
+	if len(raw) >= 2 && raw[1] == '\'' {
+		switch raw[0] {
+		case 'N', 'n':
+			return decodeStringLiteral(raw[1:], opt)
+		case 'E', 'e':
+			return unquotePGEscape(raw[1:])
+		case 'X', 'x':
+			if b, ok := decodeHexDigits(trimQuotes(raw[1:], '\'')); ok {
+				return string(b)
+			}
+			return raw
+		case 'B', 'b':
+			if b, ok := decodeBitDigits(trimQuotes(raw[1:], '\'')); ok {
+				return string(b)
+			}
+			return raw
+		case 'Q', 'q':
+			return unquoteOracleQ(raw)
+		}
+	}
+	switch raw[0] {
+	case '\'', '"':
+		return unquoteStd(raw, raw[0], opt.Dialect == MySQL && !opt.NoBackslashEscapes)
+	}
+	return raw
+}
+
+func trimQuotes(s string, q byte) string {
+	if len(s) >= 2 && s[0] == q && s[len(s)-1] == q {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// 标准引号：双写引号表示一个引号；backslash=true 时按 MySQL 规则处理 \ 转义
+func unquoteStd(raw string, q byte, backslash bool) string {
+	body := trimQuotes(raw, q)
+	if strings.IndexByte(body, q) < 0 && (!backslash || strings.IndexByte(body, '\\') < 0) {
+		return body
+	}
+	var sb strings.Builder
+	sb.Grow(len(body))
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c == q && i+1 < len(body) && body[i+1] == q {
+			sb.WriteByte(q)
+			i++
+			continue
+		}
+		if backslash && c == '\\' && i+1 < len(body) {
+			i++
+			switch e := body[i]; e {
+			case '0':
+				sb.WriteByte(0)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'Z':
+				sb.WriteByte(0x1a)
+			case '%', '_':
+				// MySQL 保留 \% 与 \_（LIKE 模式用）
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			default:
+				sb.WriteByte(e)
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// PG E'...'：C 风格反斜杠转义 + 双写单引号
+func unquotePGEscape(raw string) string {
+	body := trimQuotes(raw, '\'')
+	var sb strings.Builder
+	sb.Grow(len(body))
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c == '\'' && i+1 < len(body) && body[i+1] == '\'' {
+			sb.WriteByte('\'')
+			i++
+			continue
+		}
+		if c != '\\' || i+1 >= len(body) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch e := body[i]; e {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'x':
+			// \xh / \xhh
+			j := i + 1
+			for j < len(body) && j < i+3 && isHexDigit(body[j]) {
+				j++
+			}
+			if j == i+1 {
+				sb.WriteByte('x')
+				continue
+			}
+			v, _ := strconv.ParseUint(body[i+1:j], 16, 8)
+			sb.WriteByte(byte(v))
+			i = j - 1
+		case 'u', 'U':
+			// \uXXXX / \UXXXXXXXX
+			n := 4
+			if e == 'U' {
+				n = 8
+			}
+			// 长度不足或非法码点时按普通字符处理
+			if i+1+n <= len(body) {
+				if v, err := strconv.ParseUint(body[i+1:i+1+n], 16, 32); err == nil && utf8.ValidRune(rune(v)) {
+					sb.WriteRune(rune(v))
+					i += n
+					continue
+				}
+			}
+			sb.WriteByte(e)
+		default:
+			if e >= '0' && e <= '7' {
+				// \o / \oo / \ooo
+				j := i
+				for j < len(body) && j < i+3 && body[j] >= '0' && body[j] <= '7' {
+					j++
+				}
+				v, _ := strconv.ParseUint(body[i:j], 8, 16)
+				sb.WriteByte(byte(v))
+				i = j - 1
+				continue
+			}
+			sb.WriteByte(e)
+		}
+	}
+	return sb.String()
+}
+
+// Oracle q'<d>...<d>'：括号类定界符成对匹配，其余字符首尾相同
+func unquoteOracleQ(raw string) string {
+	if len(raw) < 5 || raw[len(raw)-1] != '\'' {
+		return raw
+	}
+	open := raw[2]
+	closeCh := open
+	switch open {
+	case '[':
+		closeCh = ']'
+	case '{':
+		closeCh = '}'
+	case '(':
+		closeCh = ')'
+	case '<':
+		closeCh = '>'
+	}
+	if raw[len(raw)-2] != closeCh {
+		return raw
+	}
+	return raw[3 : len(raw)-2]
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// 十六进制数字串 → 字节；奇数位左补 0（与 MySQL 一致）
+func decodeHexDigits(s string) ([]byte, bool) {
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+// 位串 → 字节；不足 8 位时左补 0
+func decodeBitDigits(s string) ([]byte, bool) {
+	if s == "" {
+		return []byte{}, true
+	}
+	if pad := len(s) % 8; pad != 0 {
+		s = strings.Repeat("0", 8-pad) + s
+	}
+	out := make([]byte, 0, len(s)/8)
+	for i := 0; i < len(s); i += 8 {
+		v, err := strconv.ParseUint(s[i:i+8], 2, 8)
+		if err != nil {
+			return nil, false
+		}
+		out = append(out, byte(v))
+	}
+	return out, true
+}
+
+// isBinaryLiteral 原文是否是十六进制/位串字面量（Decoded 保存的是原始字节）
+func isBinaryLiteral(raw string) bool {
+	if len(raw) > 2 && (raw[:2] == "0x" || raw[:2] == "0X") {
+		return true
+	}
+	if len(raw) > 2 && raw[1] == '\'' {
+		switch raw[0] {
+		case 'X', 'x', 'B', 'b':
+			return true
+		}
+	}
+	return false
+}
+
+func (p ExParam) isLiteral() bool {
+	switch p.Type {
+	case "Bind", "NamedBind", "Func":
+		return false
+	case "Date", "Time", "Timestamp", "Interval":
+		// NOW()/SYSDATE 这类参数化的时间函数不含引号
+		return strings.ContainsAny(p.Value, "'\"$")
+	}
+	return true
+}
+
+// Int64 把参数解码为整数；十六进制/位串按大端字节折叠（最多 8 字节）
+func (p ExParam) Int64() (int64, error) {
+	if !p.isLiteral() {
+		return 0, ErrNotLiteral
+	}
+	if isBinaryLiteral(p.Value) {
+		if len(p.Decoded) > 8 {
+			return 0, fmt.Errorf("binary literal %q overflows int64", p.Value)
+		}
+		var u uint64
+		for i := 0; i < len(p.Decoded); i++ {
+			u = u<<8 | uint64(p.Decoded[i])
+		}
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf("binary literal %q overflows int64", p.Value)
+		}
+		return int64(u), nil
+	}
+	return strconv.ParseInt(strings.TrimSpace(p.Decoded), 10, 64)
+}
+
+// Float64 把参数解码为浮点数
+func (p ExParam) Float64() (float64, error) {
+	if !p.isLiteral() {
+		return 0, ErrNotLiteral
+	}
+	if isBinaryLiteral(p.Value) {
+		v, err := p.Int64()
+		return float64(v), err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(p.Decoded), 64)
+}
+
+// Bytes 返回解码后的原始字节（十六进制/位串为二进制内容，字符串为其 UTF-8 字节）
+func (p ExParam) Bytes() ([]byte, error) {
+	if !p.isLiteral() {
+		return nil, ErrNotLiteral
+	}
+	return []byte(p.Decoded), nil
+}
+
+// Text 返回解码后的字符串值
+func (p ExParam) Text() (string, error) {
+	if !p.isLiteral() {
+		return "", ErrNotLiteral
+	}
+	return p.Decoded, nil
+}
+
+// 日期/时间字面量常见格式（解析时秒后的小数部分可省略）
+var timeLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05 Z07:00",
+	"2006-01-02 15:04:05-07",
+	"2006-01-02 15:04:05 -07",
+	"2006-01-02 15:04",
+	"15:04:05",
+	"15:04:05Z07:00",
+	"15:04:05-07",
+	"15:04",
+}
+
+// Time 把 DATE/TIME/TIMESTAMP 字面量（或形如日期的字符串）解析为 time.Time（无时区时为 UTC）
+func (p ExParam) Time() (time.Time, error) {
+	if !p.isLiteral() || p.Type == "Interval" {
+		return time.Time{}, ErrNotLiteral
+	}
+	s := strings.TrimSpace(p.Decoded)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as time", p.Decoded)
+}
+
+// Typed 按参数类型返回最自然的 Go 值：
+// Number → int64（整数）/ float64 / []byte（十六进制）；String → string（十六进制/位串为 []byte）；
+// Date/Time/Timestamp → time.Time；Interval → string。
+func (p ExParam) Typed() (any, error) {
+	if !p.isLiteral() {
+		return nil, ErrNotLiteral
+	}
+	if isBinaryLiteral(p.Value) {
+		return p.Bytes()
+	}
+	switch p.Type {
+	case "Number":
+		if v, err := p.Int64(); err == nil {
+			return v, nil
+		}
+		return p.Float64()
+	case "Date", "Time", "Timestamp":
+		return p.Time()
+	}
+	return p.Text()
+}
@@ -0,0 +1,699 @@
+package sqldigest_antlr
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 在线迁移安全分析：按方言与版本估计每条 DDL 的锁级别与是否重写整表，并给出更安全的写法。
+// 只看语句本身与已知的表结构（MigrationOptions.Schema，以及脚本里前面的 DDL），不连数据库；
+// 脚本里新建的表视为空表，对它的操作不报风险。规则取各数据库文档里的默认行为，结论是估计。
+
+// MigrationOptions 分析选项
+type MigrationOptions struct {
+	Version string  `json:"version,omitempty"` // 服务器版本：MySQL 8.0.29、PG 11、SQL Server 2019、Oracle 19c；空为当前版本
+	Schema  *Schema `json:"-"`                 // 迁移前的表结构；改列类型时据此判断是否只是放宽
+}
+
+// MigrationCheck 一条 DDL 的结论
+type MigrationCheck struct {
+	Statement int             `json:"statement"` // 与 ParseDDL 的序号一致
+	Kind      string          `json:"kind"`
+	Start     int             `json:"start"`
+	End       int             `json:"end"`
+	Name      string          `json:"name"`
+	Lock      string          `json:"lock"`                // 各动作里最重的锁：none / metadata / write / exclusive
+	Rewrite   bool            `json:"rewrite,omitempty"`   // 重建或复制整表（含索引）
+	Algorithm string          `json:"algorithm,omitempty"` // MySQL：INSTANT / INPLACE / COPY
+	Fails     bool            `json:"fails,omitempty"`     // 指定的 ALGORITHM / LOCK 做不到，服务器会拒绝执行
+	Risk      string          `json:"risk"`                // low / medium / high
+	Notes     []MigrationNote `json:"notes,omitempty"`
+}
+
+// MigrationNote 一个有风险的动作
+type MigrationNote struct {
+	Action     string `json:"action,omitempty"` // 动作原文；整条语句的提示为空
+	Lock       string `json:"lock"`
+	Rewrite    bool   `json:"rewrite,omitempty"`
+	Reason     string `json:"reason"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// 锁级别，由轻到重
+const (
+	LockNone      = "none"      // 读写照常
+	LockMetadata  = "metadata"  // 短暂的排它元数据锁；前面有长事务时会排队并堵住后来的读写
+	LockWrite     = "write"     // 执行期间阻塞写，读照常
+	LockExclusive = "exclusive" // 执行期间阻塞读写
+)
+
+// 风险等级
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+var lockOrder = map[string]int{LockNone: 0, LockMetadata: 1, LockWrite: 2, LockExclusive: 3}
+
+type migration struct {
+	d      Dialect
+	ver    []int
+	schema *Schema
+}
+
+// AnalyzeMigration 解析 sql 里的 DDL，逐条给出锁级别、是否重写整表与更安全的写法
+func AnalyzeMigration(sql string, opt Options, mo MigrationOptions) ([]MigrationCheck, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	stmts, err := ParseDDL(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	m := &migration{d: opt.Dialect, ver: parseVersion(mo.Version, opt.Dialect), schema: &Schema{Dialect: opt.Dialect}}
+	if mo.Schema != nil {
+		for _, t := range mo.Schema.Tables {
+			m.schema.Tables = append(m.schema.Tables, cloneTable(t))
+		}
+	}
+	created := map[string]bool{} // 脚本里新建的表：没有数据，也没有别的会话在用
+	var out []MigrationCheck
+	for _, st := range stmts {
+		c := MigrationCheck{Statement: st.Statement, Kind: st.Kind, Start: st.Start, End: st.End, Name: st.Name, Lock: LockNone}
+		var t *TableDef
+		switch st.Kind {
+		case "ALTER TABLE":
+			t = m.schema.Table(st.Name)
+		case "CREATE INDEX", "DROP INDEX":
+			if st.Index != nil && st.Index.Table != "" {
+				t = m.schema.Table(st.Index.Table)
+			}
+		}
+		isNew := t != nil && created[strings.ToLower(t.FullName())]
+		if !st.Unsupported && !isNew && st.Kind != "CREATE TABLE" {
+			m.check(&c, st, t, sql[st.Start:st.End])
+		}
+		c.Risk = riskOf(c)
+		out = append(out, c)
+
+		if !c.Fails {
+			m.schema.Apply(st) // ALTER TABLE 不增删表，t 仍有效
+		}
+		switch {
+		case st.Kind == "CREATE TABLE" && !st.Unsupported:
+			created[strings.ToLower(st.Table.FullName())] = true
+		case isNew && st.Kind == "ALTER TABLE":
+			created[strings.ToLower(t.FullName())] = true // 可能改了名
+		}
+	}
+	return out, nil
+}
+
+func riskOf(c MigrationCheck) string {
+	switch {
+	case c.Fails, c.Lock == LockExclusive, c.Rewrite && c.Lock == LockWrite:
+		return RiskHigh
+	case c.Rewrite, c.Lock == LockWrite:
+		return RiskMedium
+	}
+	return RiskLow
+}
+
+var reDigits = regexp.MustCompile(`\d+`)
+
+// parseVersion 取版本里的数字段；SQL Server 的 11 / 12 / 13 ... 换成发行年份
+func parseVersion(v string, d Dialect) []int {
+	var out []int
+	for _, f := range reDigits.FindAllString(v, 3) {
+		n, _ := strconv.Atoi(f)
+		out = append(out, n)
+	}
+	if d == SQLServer && len(out) > 0 && out[0] < 100 {
+		years := map[int]int{9: 2005, 10: 2008, 11: 2012, 12: 2014, 13: 2016, 14: 2017, 15: 2019, 16: 2022}
+		if y, ok := years[out[0]]; ok {
+			out = []int{y}
+		}
+	}
+	return out
+}
+
+// atLeast 版本不低于 want；没给版本按当前版本
+func (m *migration) atLeast(want ...int) bool { return versionAtLeast(m.ver, want...) }
+
+func versionAtLeast(ver []int, want ...int) bool {
+	if len(ver) == 0 {
+		return true
+	}
+	for i, w := range want {
+		v := 0
+		if i < len(ver) {
+			v = ver[i]
+		}
+		if v != w {
+			return v > w
+		}
+	}
+	return true
+}
+
+// note 记一个动作的结论；reason 为空只合并锁级别
+func (c *MigrationCheck) note(action, lock string, rewrite bool, reason, suggestion string) {
+	if lockOrder[lock] > lockOrder[c.Lock] {
+		c.Lock = lock
+	}
+	c.Rewrite = c.Rewrite || rewrite
+	if reason != "" {
+		c.Notes = append(c.Notes, MigrationNote{Action: action, Lock: lock, Rewrite: rewrite, Reason: reason, Suggestion: suggestion})
+	}
+}
+
+func (m *migration) check(c *MigrationCheck, st DDLStatement, t *TableDef, text string) {
+	switch m.d {
+	case MySQL:
+		m.mysql(c, st, t, text)
+	case Postgres:
+		m.postgres(c, st, t)
+	case SQLServer:
+		m.sqlserver(c, st, t, text)
+	case Oracle:
+		m.oracle(c, st, t)
+	}
+}
+
+func hasWords(text string, words ...string) bool {
+	f := strings.Fields(strings.ToUpper(text))
+	for i := 0; i+len(words) <= len(f); i++ {
+		ok := true
+		for j, w := range words {
+			if strings.Trim(f[i+j], "(),;") != w {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// widensInPlace 只放宽变长字符串长度或定点数精度：多数数据库只改元数据
+func widensInPlace(from, to string, d Dialect) bool {
+	if typeKey(from) == typeKey(to) {
+		return true
+	}
+	a, b := parseType(from), parseType(to)
+	text := func(s sqlType) bool {
+		switch s.base {
+		case "varchar", "nvarchar", "varchar2", "nvarchar2":
+			return true
+		case "text":
+			return d == Postgres
+		}
+		return false
+	}
+	if text(a) && text(b) && (a.base == b.base || d == Postgres) {
+		return narrowing(from, to, d) == ""
+	}
+	if (a.base == "numeric" || a.base == "number") && a.base == b.base {
+		_, sa := numericSize(a)
+		_, sb := numericSize(b)
+		return sa == sb && narrowing(from, to, d) == ""
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------
+// PostgreSQL
+
+// PG 里每次调用都会变的函数：带这样默认值的新列要逐行计算，重写整表
+var rePGVolatile = regexp.MustCompile(`(?i)\b(random|gen_random_uuid|uuid_generate_v1|uuid_generate_v1mc|uuid_generate_v4|clock_timestamp|timeofday|nextval|txid_current)\s*\(`)
+
+func (m *migration) postgres(c *MigrationCheck, st DDLStatement, t *TableDef) {
+	switch st.Kind {
+	case "CREATE INDEX":
+		if _, ok := st.Options["CONCURRENTLY"]; !ok {
+			c.note("", LockWrite, false, "CREATE INDEX blocks writes to "+st.Index.Table+" until the index is built",
+				"CREATE INDEX CONCURRENTLY (outside a transaction block)")
+		}
+		return
+	case "DROP INDEX":
+		if _, ok := st.Options["CONCURRENTLY"]; !ok {
+			c.note("", LockExclusive, false, "DROP INDEX takes an ACCESS EXCLUSIVE lock on the table",
+				"DROP INDEX CONCURRENTLY (outside a transaction block)")
+		}
+		return
+	case "ALTER TABLE":
+	default:
+		c.note("", LockMetadata, false, "", "")
+		return
+	}
+	for _, a := range st.Actions {
+		var old *ColumnDef
+		if t != nil {
+			old = t.Column(a.Name)
+		}
+		switch a.Kind {
+		case AlterAddColumn:
+			col := a.Column
+			switch {
+			case col.Generated != "" || col.AutoIncrement || rePGVolatile.MatchString(col.Default):
+				c.note(a.Text, LockExclusive, true, "adding "+col.Name+" with a volatile default, identity or generated value rewrites the table",
+					"add the column without a default, then SET DEFAULT and backfill existing rows in batches")
+			case col.Default != "" && !m.atLeast(11):
+				c.note(a.Text, LockExclusive, true, "before PostgreSQL 11 adding a column with a default rewrites the table",
+					"add the column without a default, then SET DEFAULT and backfill existing rows in batches")
+			case !col.Nullable && col.Default == "":
+				c.note(a.Text, LockMetadata, false, "adding NOT NULL column "+col.Name+" without a default fails if the table has rows",
+					"add it nullable, backfill, then SET NOT NULL")
+			default:
+				c.note(a.Text, LockMetadata, false, "", "")
+			}
+		case AlterSetType:
+			if old != nil && widensInPlace(old.Type, a.Column.Type, m.d) {
+				c.note(a.Text, LockMetadata, false, "", "")
+				break
+			}
+			c.note(a.Text, LockExclusive, true, "changing the type of "+a.Name+" rewrites the table and its indexes under an ACCESS EXCLUSIVE lock",
+				"add a new column, backfill it in batches and switch over")
+		case AlterSetNotNull:
+			s := "keep a CHECK (" + a.Name + " IS NOT NULL) NOT VALID constraint and VALIDATE it instead"
+			if m.atLeast(12) {
+				s = "ADD CONSTRAINT ... CHECK (" + a.Name + " IS NOT NULL) NOT VALID, VALIDATE CONSTRAINT, then SET NOT NULL (no scan) and drop the check"
+			}
+			c.note(a.Text, LockExclusive, false, "SET NOT NULL scans the whole table under an ACCESS EXCLUSIVE lock", s)
+		case AlterAddConstraint:
+			switch kind := a.Constraint.Kind; {
+			case hasWords(a.Text, "NOT", "VALID"), hasWords(a.Text, "USING", "INDEX"):
+				c.note(a.Text, LockMetadata, false, "", "")
+			case kind == "FOREIGN KEY":
+				c.note(a.Text, LockWrite, false, "ADD FOREIGN KEY checks every row while blocking writes to both tables",
+					"ADD CONSTRAINT ... NOT VALID, then VALIDATE CONSTRAINT in a separate transaction")
+			case kind == "CHECK":
+				c.note(a.Text, LockExclusive, false, "ADD CHECK scans the whole table under an ACCESS EXCLUSIVE lock",
+					"ADD CONSTRAINT ... NOT VALID, then VALIDATE CONSTRAINT in a separate transaction")
+			default:
+				c.note(a.Text, LockExclusive, false, "ADD "+kind+" builds its index under an ACCESS EXCLUSIVE lock",
+					"CREATE UNIQUE INDEX CONCURRENTLY, then ADD CONSTRAINT ... "+kind+" USING INDEX")
+			}
+		case AlterOther:
+			switch {
+			case hasWords(a.Text, "VALIDATE", "CONSTRAINT"):
+				// SHARE UPDATE EXCLUSIVE：读写照常
+			case hasWords(a.Text, "SET", "LOGGED"), hasWords(a.Text, "SET", "UNLOGGED"), hasWords(a.Text, "SET", "TABLESPACE"),
+				hasWords(a.Text, "SET", "ACCESS", "METHOD"):
+				c.note(a.Text, LockExclusive, true, "this rewrites the table under an ACCESS EXCLUSIVE lock", "")
+			default:
+				c.note(a.Text, LockMetadata, false, "", "")
+			}
+		default:
+			c.note(a.Text, LockMetadata, false, "", "")
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// MySQL（InnoDB 在线 DDL）
+
+// InnoDB 的执行方式，由轻到重
+const (
+	algInstant = iota + 1
+	algInplace // 不重建表
+	algRebuild // INPLACE 但重建表
+	algCopy
+)
+
+var algName = map[int]string{algInstant: "INSTANT", algInplace: "INPLACE", algRebuild: "INPLACE", algCopy: "COPY"}
+
+const ghostHint = "for large tables use gh-ost or pt-online-schema-change"
+
+func (m *migration) mysql(c *MigrationCheck, st DDLStatement, t *TableDef, text string) {
+	alg, blocks := 0, false
+	add := func(action string, a int, block bool, reason, suggestion string) {
+		if !m.atLeast(8, 0, 12) && a == algInstant {
+			a = algInplace // 8.0.12 之前没有 INSTANT
+		}
+		alg, blocks = max(alg, a), blocks || block
+		if reason != "" {
+			c.Notes = append(c.Notes, MigrationNote{Action: action, Lock: mysqlLock(a, block), Rewrite: a >= algRebuild, Reason: reason, Suggestion: suggestion})
+		}
+	}
+	switch st.Kind {
+	case "CREATE INDEX":
+		m.mysqlIndex(add, "", *st.Index)
+	case "ALTER TABLE":
+		addsPK := false
+		for _, a := range st.Actions {
+			addsPK = addsPK || a.Kind == AlterAddConstraint && a.Constraint.Kind == "PRIMARY KEY"
+		}
+		for _, a := range st.Actions {
+			var old *ColumnDef
+			if t != nil {
+				old = t.Column(a.Name)
+			}
+			m.mysqlAction(add, a, old, addsPK)
+		}
+	case "DROP INDEX":
+		add("", algInplace, false, "", "")
+	default:
+		c.note("", LockMetadata, false, "", "")
+		return
+	}
+
+	// 显式的 ALGORITHM / LOCK
+	want, lock := st.Options["ALGORITHM"], st.Options["LOCK"]
+	switch {
+	case want == "INSTANT" && alg > algInstant, want == "INPLACE" && alg == algCopy:
+		c.Fails = true
+		c.Notes = append(c.Notes, MigrationNote{Lock: LockNone, Reason: "ALGORITHM=" + want + " is not supported for this change; the server rejects it",
+			Suggestion: "split the statement, or " + ghostHint})
+	case lock == "NONE" && (blocks || alg == algCopy):
+		c.Fails = true
+		c.Notes = append(c.Notes, MigrationNote{Lock: LockNone, Reason: "LOCK=NONE is not supported for this change; the server rejects it",
+			Suggestion: ghostHint})
+	case want == "COPY":
+		alg = algCopy
+	}
+	if c.Fails {
+		c.Algorithm = want
+		return
+	}
+	switch lock {
+	case "SHARED":
+		blocks = true
+	case "EXCLUSIVE":
+		c.note("", LockExclusive, false, "", "")
+	}
+	c.Algorithm = algName[alg]
+	c.note("", mysqlLock(alg, blocks), alg >= algRebuild, "", "")
+}
+
+func mysqlLock(alg int, blocks bool) string {
+	switch {
+	case alg == algCopy || blocks:
+		return LockWrite
+	case alg == algInstant:
+		return LockMetadata
+	}
+	return LockNone
+}
+
+type mysqlAdd func(action string, alg int, blocks bool, reason, suggestion string)
+
+func (m *migration) mysqlIndex(add mysqlAdd, action string, ix IndexDef) {
+	switch strings.ToUpper(ix.Kind) {
+	case "FULLTEXT", "SPATIAL":
+		add(action, algInplace, true, "adding a "+ix.Kind+" index blocks writes (the first FULLTEXT index also rebuilds the table)", ghostHint)
+	default:
+		add(action, algInplace, false, "", "")
+	}
+}
+
+func (m *migration) mysqlAction(add mysqlAdd, a AlterAction, old *ColumnDef, addsPK bool) {
+	switch a.Kind {
+	case AlterAddColumn:
+		col := a.Column
+		positioned := hasWords(a.Text, "AFTER") || hasWords(a.Text, "FIRST")
+		switch {
+		case col.Generated != "" && hasWords(a.Text, "STORED"):
+			add(a.Text, algCopy, true, "adding a STORED generated column copies the table", "use a VIRTUAL generated column")
+		case col.Generated != "":
+			add(a.Text, algInstant, false, "", "")
+		case col.AutoIncrement:
+			add(a.Text, algRebuild, true, "adding an AUTO_INCREMENT column rebuilds the table and blocks writes", ghostHint)
+		case m.atLeast(8, 0, 29), m.atLeast(8, 0, 12) && !positioned:
+			add(a.Text, algInstant, false, "", "")
+		case positioned && m.atLeast(8, 0, 12):
+			add(a.Text, algRebuild, false, "ADD COLUMN with AFTER / FIRST rebuilds the table before MySQL 8.0.29",
+				"add the column last to keep ALGORITHM=INSTANT")
+		default:
+			add(a.Text, algRebuild, false, "ADD COLUMN rebuilds the table before MySQL 8.0.12", "")
+		}
+	case AlterDropColumn:
+		if m.atLeast(8, 0, 29) {
+			add(a.Text, algInstant, false, "", "")
+		} else {
+			add(a.Text, algRebuild, false, "DROP COLUMN rebuilds the table before MySQL 8.0.29", "")
+		}
+	case AlterRenameColumn:
+		if m.atLeast(8, 0, 28) {
+			add(a.Text, algInstant, false, "", "")
+		} else {
+			add(a.Text, algInplace, false, "", "")
+		}
+	case AlterModifyColumn:
+		m.mysqlModify(add, a, old)
+	case AlterSetDefault, AlterDropDefault, AlterRenameTable:
+		add(a.Text, algInstant, false, "", "")
+	case AlterAddIndex:
+		m.mysqlIndex(add, a.Text, *a.Index)
+	case AlterDropIndex:
+		add(a.Text, algInplace, false, "", "")
+	case AlterAddConstraint:
+		switch a.Constraint.Kind {
+		case "PRIMARY KEY":
+			add(a.Text, algRebuild, false, "ADD PRIMARY KEY rebuilds the table", "")
+		case "UNIQUE":
+			add(a.Text, algInplace, false, "", "")
+		case "FOREIGN KEY":
+			add(a.Text, algCopy, true, "with foreign_key_checks=1 ADD FOREIGN KEY copies the table",
+				"SET foreign_key_checks = 0 for this statement lets it run INPLACE (existing rows are not checked)")
+		default:
+			add(a.Text, algCopy, true, "ADD CHECK copies the table to check existing rows", ghostHint)
+		}
+	case AlterDropConstraint:
+		switch {
+		case a.Constraint != nil && a.Constraint.Kind == "PRIMARY KEY" && !addsPK:
+			add(a.Text, algCopy, true, "DROP PRIMARY KEY without adding a new one copies the table", "drop and add the primary key in one ALTER TABLE")
+		case a.Constraint != nil && a.Constraint.Kind == "PRIMARY KEY":
+			add(a.Text, algRebuild, false, "", "")
+		default:
+			add(a.Text, algInplace, false, "", "")
+		}
+	case AlterOther:
+		switch {
+		case hasWords(a.Text, "CONVERT", "TO"):
+			add(a.Text, algCopy, true, "CONVERT TO CHARACTER SET copies the table", ghostHint)
+		case hasWords(a.Text, "FORCE"), strings.HasPrefix(strings.ToUpper(a.Text), "ENGINE"), strings.HasPrefix(strings.ToUpper(a.Text), "ROW_FORMAT"),
+			strings.HasPrefix(strings.ToUpper(a.Text), "KEY_BLOCK_SIZE"):
+			add(a.Text, algRebuild, false, "this rebuilds the table", "")
+		default:
+			add(a.Text, algInplace, false, "", "")
+		}
+	default:
+		add(a.Text, algInplace, false, "", "")
+	}
+}
+
+// mysqlModify MODIFY / CHANGE：只改默认值、注释与名字是 INSTANT；改可空重建表；放宽 VARCHAR 视长度前缀而定；其它换类型复制表
+func (m *migration) mysqlModify(add mysqlAdd, a AlterAction, old *ColumnDef) {
+	col := a.Column
+	if old == nil {
+		add(a.Text, algCopy, true, "changing the definition of "+a.Name+" copies the table unless only the default, comment or VARCHAR length changes (the old definition is unknown)", ghostHint)
+		return
+	}
+	if typeKey(old.Type) == typeKey(col.Type) {
+		switch {
+		case old.Nullable != col.Nullable:
+			add(a.Text, algRebuild, false, "changing NULL / NOT NULL of "+a.Name+" rebuilds the table", "")
+		case old.AutoIncrement != col.AutoIncrement || !strings.EqualFold(old.Charset, col.Charset) || !strings.EqualFold(old.Collation, col.Collation):
+			add(a.Text, algCopy, true, "changing AUTO_INCREMENT, the character set or the collation of "+a.Name+" copies the table", ghostHint)
+		default:
+			add(a.Text, algInstant, false, "", "")
+		}
+		return
+	}
+	from, to := parseType(old.Type), parseType(col.Type)
+	if (from.base == "enum" || from.base == "set") && from.base == to.base && len(to.args) >= len(from.args) &&
+		strings.Join(to.args[:len(from.args)], ",") == strings.Join(from.args, ",") {
+		add(a.Text, algInstant, false, "", "") // 在末尾追加成员
+		return
+	}
+	if from.base == "varchar" && to.base == "varchar" && narrowing(old.Type, col.Type, MySQL) == "" {
+		// utf8mb4 每字符最多 4 字节：长度超过 255 字节要换成 2 字节的长度前缀
+		if (textSize(from, MySQL)*4 < 256) == (textSize(to, MySQL)*4 < 256) {
+			add(a.Text, algInplace, false, "", "")
+			return
+		}
+		add(a.Text, algCopy, true, "widening "+a.Name+" across the 255-byte length boundary copies the table", ghostHint)
+		return
+	}
+	add(a.Text, algCopy, true, "changing the type of "+a.Name+" from "+old.Type+" to "+col.Type+" copies the table and blocks writes", ghostHint)
+}
+
+// ---------------------------------------------------------------------------
+// SQL Server
+
+// 每行不同的默认值：加 NOT NULL 列时要逐行写入
+var reMSNonConstant = regexp.MustCompile(`(?i)\b(newid|newsequentialid|rand|crypt_gen_random)\s*\(`)
+
+var reOnlineOn = regexp.MustCompile(`(?i)\bONLINE\s*=\s*ON\b`)
+
+func (m *migration) sqlserver(c *MigrationCheck, st DDLStatement, t *TableDef, text string) {
+	switch st.Kind {
+	case "CREATE INDEX":
+		if st.Options["ONLINE"] == "ON" {
+			return
+		}
+		if strings.ToUpper(st.Index.Kind) == "CLUSTERED" {
+			c.note("", LockExclusive, true, "building a clustered index rewrites the table and blocks reads and writes",
+				"WITH (ONLINE = ON) (Enterprise edition)")
+		} else {
+			c.note("", LockWrite, false, "CREATE INDEX blocks writes to "+st.Index.Table+" until the index is built",
+				"WITH (ONLINE = ON) (Enterprise edition)")
+		}
+		return
+	case "ALTER TABLE":
+	default:
+		c.note("", LockMetadata, false, "", "")
+		return
+	}
+	nocheck := hasWords(text, "WITH", "NOCHECK")
+	for _, a := range st.Actions {
+		var old *ColumnDef
+		if t != nil {
+			old = t.Column(a.Name)
+		}
+		switch a.Kind {
+		case AlterAddColumn:
+			col := a.Column
+			switch {
+			case col.Generated != "" && hasWords(a.Text, "PERSISTED"), col.AutoIncrement:
+				c.note(a.Text, LockExclusive, true, "adding a persisted computed or IDENTITY column writes every row under a schema modification lock",
+					"add a nullable column and backfill it in batches")
+			case !col.Nullable && col.Default != "" && (reMSNonConstant.MatchString(col.Default) || !m.atLeast(2012)):
+				c.note(a.Text, LockExclusive, true, "adding NOT NULL column "+col.Name+" with this default writes every row under a schema modification lock",
+					"add it nullable, backfill in batches, then ALTER COLUMN ... NOT NULL")
+			case !col.Nullable && col.Default == "":
+				c.note(a.Text, LockMetadata, false, "adding NOT NULL column "+col.Name+" without a default fails if the table has rows",
+					"add it with a default, or nullable and backfill")
+			case !col.Nullable:
+				// 常量默认值只在 Enterprise/Developer 版是元数据操作，其它版本仍逐行写入
+				c.note(a.Text, LockMetadata, false, "adding NOT NULL column "+col.Name+" with a constant default is metadata only on Enterprise and Developer editions; other editions write every row",
+					"on other editions add it nullable, backfill in batches, then ALTER COLUMN ... NOT NULL")
+			default:
+				c.note(a.Text, LockMetadata, false, "", "")
+			}
+		case AlterModifyColumn:
+			col := a.Column
+			online := reOnlineOn.MatchString(a.Text)
+			lock, s := LockExclusive, "add a new column, backfill it in batches and switch over"
+			if m.atLeast(2016) {
+				s = "ALTER COLUMN ... WITH (ONLINE = ON) (Enterprise edition)"
+			}
+			if online {
+				lock, s = LockNone, ""
+			}
+			switch {
+			case old != nil && widensInPlace(old.Type, col.Type, m.d) && (col.Nullable || !old.Nullable):
+				c.note(a.Text, LockMetadata, false, "", "")
+			case old != nil && widensInPlace(old.Type, col.Type, m.d):
+				c.note(a.Text, lock, false, "ALTER COLUMN ... NOT NULL checks every row under a schema modification lock", s)
+			default:
+				c.note(a.Text, lock, true, "ALTER COLUMN on "+a.Name+" rewrites every row under a schema modification lock", s)
+			}
+		case AlterAddConstraint:
+			switch kind := a.Constraint.Kind; {
+			case kind == "FOREIGN KEY" || kind == "CHECK":
+				if nocheck {
+					c.note(a.Text, LockMetadata, false, "", "")
+					break
+				}
+				c.note(a.Text, LockExclusive, false, "ADD "+kind+" checks every row under a schema modification lock",
+					"WITH NOCHECK ADD CONSTRAINT ..., then validate off-peak (the constraint stays untrusted until WITH CHECK CHECK CONSTRAINT)")
+			case reOnlineOn.MatchString(a.Text):
+			default:
+				c.note(a.Text, LockExclusive, hasWords(a.Text, "CLUSTERED") || kind == "PRIMARY KEY" && !hasWords(a.Text, "NONCLUSTERED"),
+					"ADD "+kind+" builds its index while blocking reads and writes", "WITH (ONLINE = ON) (Enterprise edition)")
+			}
+		default:
+			c.note(a.Text, LockMetadata, false, "", "")
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Oracle
+
+func (m *migration) oracle(c *MigrationCheck, st DDLStatement, t *TableDef) {
+	switch st.Kind {
+	case "CREATE INDEX":
+		if _, ok := st.Options["ONLINE"]; !ok {
+			c.note("", LockWrite, false, "CREATE INDEX blocks DML on "+st.Index.Table+" until the index is built", "CREATE INDEX ... ONLINE")
+		}
+		return
+	case "ALTER TABLE":
+	default:
+		c.note("", LockMetadata, false, "", "")
+		return
+	}
+	for _, a := range st.Actions {
+		var old *ColumnDef
+		if t != nil {
+			old = t.Column(a.Name)
+		}
+		novalidate := hasWords(a.Text, "NOVALIDATE")
+		switch a.Kind {
+		case AlterAddColumn:
+			col := a.Column
+			switch {
+			case col.AutoIncrement:
+				c.note(a.Text, LockExclusive, true, "adding an identity column writes every row", "add a nullable column and backfill it in batches")
+			case col.Default != "" && !col.Nullable && !m.atLeast(11):
+				c.note(a.Text, LockExclusive, true, "before Oracle 11g adding a column with a default writes every row",
+					"add the column without a default and backfill it in batches")
+			case col.Default != "" && col.Nullable && !m.atLeast(12):
+				c.note(a.Text, LockExclusive, true, "before Oracle 12c adding a nullable column with a default writes every row",
+					"add it NOT NULL with the default (metadata only), or without a default and backfill")
+			case !col.Nullable && col.Default == "":
+				c.note(a.Text, LockMetadata, false, "adding NOT NULL column "+col.Name+" without a default fails if the table has rows",
+					"add it with a default, or nullable and backfill")
+			default:
+				c.note(a.Text, LockMetadata, false, "", "")
+			}
+		case AlterSetType:
+			if old != nil && widensInPlace(old.Type, a.Column.Type, m.d) {
+				c.note(a.Text, LockMetadata, false, "", "")
+				break
+			}
+			c.note(a.Text, LockExclusive, true, "changing the type of "+a.Name+" checks or converts every row while DML is blocked",
+				"DBMS_REDEFINITION, or a new column backfilled in batches")
+		case AlterSetNotNull:
+			if novalidate {
+				c.note(a.Text, LockMetadata, false, "", "")
+				break
+			}
+			c.note(a.Text, LockWrite, false, "MODIFY ... NOT NULL checks every row while DML is blocked",
+				"MODIFY "+a.Name+" NOT NULL ENABLE NOVALIDATE, then validate the constraint separately")
+		case AlterDropColumn:
+			c.note(a.Text, LockExclusive, true, "DROP COLUMN rewrites every block of the table",
+				"SET UNUSED ("+a.Name+") now, DROP UNUSED COLUMNS CHECKPOINT off-peak")
+		case AlterAddConstraint:
+			switch kind := a.Constraint.Kind; {
+			case novalidate:
+				c.note(a.Text, LockMetadata, false, "", "")
+			case kind == "FOREIGN KEY" || kind == "CHECK":
+				c.note(a.Text, LockWrite, false, "ADD "+kind+" checks every row while DML is blocked",
+					"ADD CONSTRAINT ... ENABLE NOVALIDATE, then ENABLE VALIDATE CONSTRAINT")
+			case hasWords(a.Text, "USING", "INDEX") && !hasWords(a.Text, "USING", "INDEX", "TABLESPACE"):
+				c.note(a.Text, LockMetadata, false, "", "")
+			default:
+				c.note(a.Text, LockWrite, false, "ADD "+kind+" builds its index while DML is blocked",
+					"CREATE UNIQUE INDEX ... ONLINE, then ADD CONSTRAINT ... USING INDEX")
+			}
+		case AlterOther:
+			switch {
+			case hasWords(a.Text, "MOVE") && hasWords(a.Text, "ONLINE"):
+				c.note(a.Text, LockNone, true, "", "")
+			case hasWords(a.Text, "MOVE"):
+				c.note(a.Text, LockExclusive, true, "MOVE rewrites the table and blocks DML; its indexes become unusable", "ALTER TABLE ... MOVE ONLINE (12.2+)")
+			default:
+				c.note(a.Text, LockMetadata, false, "", "")
+			}
+		default:
+			c.note(a.Text, LockMetadata, false, "", "")
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package sqldigest_antlr
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	mylex "github.com/tensafe/sqlglot-go/internal/parsers/mysql"
+)
+
+// MySQL performance_schema DIGEST_TEXT 兼容渲染（对应 sql_digest.cc 的归约规则）：
+//   - 标识符一律加反引号：`t`、`db` . `t`；关键字按词表大写；token 之间单个空格；
+//     （关键字有意用大写而不是小写：8.0 的 DIGEST_TEXT 实际就是大写）
+//     非保留关键字在标识符位置上（status、name、date 作列名）按标识符输出：`status`；
+//   - 字面量（数字/字符串/十六进制/TRUE/FALSE/非 IS 之后的 NULL）与 ? 统一为 ?，一元 +/- 并入；
+//   - ?, ?, ... → "?, ..."；(?) 与 (?, ...) → "(?)" / "(...)"；
+//     连续的行 (…),(…) → "(?) /* , ... */" / "(...) /* , ... */"；
+//   - 注释去掉，末尾分号去掉。
+// 服务端的 DIGEST 列是对内部 token 序列（token id 随版本变化）做的 SHA-256，
+// 这里无法复现，因此不提供；需要时用服务端的 STATEMENT_DIGEST()。
+
+const (
+	mdValue          = "?"
+	mdValueList      = "?, ..."
+	mdRowSingle      = "(?)"
+	mdRowSingleList  = "(?) /* , ... */"
+	mdRowMultiple    = "(...)"
+	mdRowMultipleLst = "(...) /* , ... */"
+)
+
+// mysqlDigestText 生成与 events_statements_summary_by_digest.DIGEST_TEXT 对齐的文本
+func mysqlDigestText(original string, toks []antlr.Token) string {
+	spans := findMySQLCommentSpans(original)
+	var out []string
+	last := func(k int) string {
+		if len(out) < k {
+			return ""
+		}
+		return out[len(out)-k]
+	}
+	isValueItem := func(s string) bool {
+		return s == mdValue || s == mdValueList
+	}
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		if IsEOFToken(t) || t.GetChannel() != antlr.TokenDefaultChannel {
+			continue
+		}
+		if inAnySpan(RuneIndexToByte(original, t.GetStart()), RuneIndexToByte(original, t.GetStop()+1), spans) {
+			continue
+		}
+		txt := t.GetText()
+		switch tt := t.GetTokenType(); {
+		case tt == mylex.MySQLLexerUNDERSCORE_CHARSET || mysqlCharsetIntroducer(toks, i):
+			continue // _utf8mb4'x'：字符集引导词随字面量一起归约
+		case mysqlDigestValue(tt, txt, last(1), last(2)):
+			// 一元 +/- 并入字面量
+			if s := last(1); (s == "-" || s == "+") && !mysqlDigestOperandEnd(last(2)) {
+				out = out[:len(out)-1]
+			}
+			if last(1) == "," && isValueItem(last(2)) {
+				out = append(out[:len(out)-2], mdValueList)
+			} else {
+				out = append(out, mdValue)
+			}
+		case txt == ")":
+			switch {
+			case last(2) == "(" && last(1) == mdValue:
+				out = append(out[:len(out)-2], mdRowSingle)
+			case last(2) == "(" && last(1) == mdValueList:
+				out = append(out[:len(out)-2], mdRowMultiple)
+			default:
+				out = append(out, ")")
+				continue
+			}
+			// 连续的行：(?) , (?) → (?) /* , ... */
+			if last(2) == "," {
+				cur, prev := last(1), last(3)
+				switch {
+				case cur == mdRowSingle && (prev == mdRowSingle || prev == mdRowSingleList):
+					out = append(out[:len(out)-3], mdRowSingleList)
+				case cur == mdRowMultiple && (prev == mdRowMultiple || prev == mdRowMultipleLst):
+					out = append(out[:len(out)-3], mdRowMultipleLst)
+				}
+			}
+		case tt == mylex.MySQLLexerIDENTIFIER || tt == mylex.MySQLLexerBACK_TICK_QUOTED_ID:
+			out = append(out, "`"+strings.Trim(txt, "`")+"`")
+		case tt == mylex.MySQLLexerAT_TEXT_SUFFIX:
+			out = append(out, "@", "`"+strings.Trim(strings.TrimPrefix(txt, "@"), "`'\"")+"`")
+		case looksLikeIdent(txt):
+			if !mysqlReserved[strings.ToUpper(txt)] && mysqlIdentPosition(last(1), toks, i) {
+				out = append(out, "`"+txt+"`")
+			} else {
+				out = append(out, strings.ToUpper(txt))
+			}
+		default:
+			out = append(out, txt)
+		}
+	}
+	for len(out) > 0 && out[len(out)-1] == ";" {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, " ")
+}
+
+// mysqlReserved MySQL 8.0 的保留字：它们不能不加引号当标识符用，始终按关键字输出
+var mysqlReserved = toSet(`ACCESSIBLE ADD ALL ALTER ANALYZE AND AS ASC ASENSITIVE BEFORE BETWEEN BIGINT BINARY BLOB BOTH BY
+CALL CASCADE CASE CHANGE CHAR CHARACTER CHECK COLLATE COLUMN CONDITION CONSTRAINT CONTINUE CONVERT CREATE CROSS
+CUBE CUME_DIST CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER CURSOR DATABASE DATABASES DAY_HOUR
+DAY_MICROSECOND DAY_MINUTE DAY_SECOND DEC DECIMAL DECLARE DEFAULT DELAYED DELETE DENSE_RANK DESC DESCRIBE
+DETERMINISTIC DISTINCT DISTINCTROW DIV DOUBLE DROP DUAL EACH ELSE ELSEIF EMPTY ENCLOSED ESCAPED EXCEPT EXISTS EXIT
+EXPLAIN FALSE FETCH FIRST_VALUE FLOAT FLOAT4 FLOAT8 FOR FORCE FOREIGN FROM FULLTEXT FUNCTION GENERATED GET GRANT
+GROUP GROUPING GROUPS HAVING HIGH_PRIORITY HOUR_MICROSECOND HOUR_MINUTE HOUR_SECOND IF IGNORE IN INDEX INFILE
+INNER INOUT INSENSITIVE INSERT INT INT1 INT2 INT3 INT4 INT8 INTEGER INTERSECT INTERVAL INTO IO_AFTER_GTIDS
+IO_BEFORE_GTIDS IS ITERATE JOIN JSON_TABLE KEY KEYS KILL LAG LAST_VALUE LATERAL LEAD LEADING LEAVE LEFT LIKE LIMIT
+LINEAR LINES LOAD LOCALTIME LOCALTIMESTAMP LOCK LONG LONGBLOB LONGTEXT LOOP LOW_PRIORITY MASTER_BIND
+MASTER_SSL_VERIFY_SERVER_CERT MATCH MAXVALUE MEDIUMBLOB MEDIUMINT MEDIUMTEXT MIDDLEINT MINUTE_MICROSECOND
+MINUTE_SECOND MOD MODIFIES NATURAL NOT NO_WRITE_TO_BINLOG NTH_VALUE NTILE NULL NUMERIC OF ON OPTIMIZE
+OPTIMIZER_COSTS OPTION OPTIONALLY OR ORDER OUT OUTER OUTFILE OVER PARTITION PERCENT_RANK PRECISION PRIMARY
+PROCEDURE PURGE RANGE RANK READ READS READ_WRITE REAL RECURSIVE REFERENCES REGEXP RELEASE RENAME REPEAT REPLACE
+REQUIRE RESIGNAL RESTRICT RETURN REVOKE RIGHT RLIKE ROW ROWS ROW_NUMBER SCHEMA SCHEMAS SECOND_MICROSECOND SELECT
+SENSITIVE SEPARATOR SET SHOW SIGNAL SMALLINT SPATIAL SPECIFIC SQL SQLEXCEPTION SQLSTATE SQLWARNING SQL_BIG_RESULT
+SQL_CALC_FOUND_ROWS SQL_SMALL_RESULT SSL STARTING STORED STRAIGHT_JOIN SYSTEM TABLE TERMINATED THEN TINYBLOB
+TINYINT TINYTEXT TO TRAILING TRIGGER TRUE UNDO UNION UNIQUE UNLOCK UNSIGNED UPDATE USAGE USE USING UTC_DATE
+UTC_TIME UTC_TIMESTAMP VALUES VARBINARY VARCHAR VARCHARACTER VARYING VIRTUAL WHEN WHERE WHILE WINDOW WITH WRITE
+XOR YEAR_MONTH ZEROFILL`)
+
+// 非保留关键字前后为这些输出项时处于标识符位置（列名、表名、别名）
+var (
+	mdIdentBefore = toSet(`, ( SELECT DISTINCT WHERE AND OR NOT XOR ON BY SET FROM JOIN INTO UPDATE TABLE AS
+= < > <= >= <> != <=> + - * / % WHEN THEN ELSE HAVING`)
+	mdIdentAfter = toSet(`, ) ; = < > <= >= <> != <=> + - * / % FROM WHERE AS AND OR XOR IS IN NOT LIKE BETWEEN
+ORDER GROUP HAVING LIMIT ASC DESC SET VALUES ON JOIN INNER LEFT RIGHT CROSS STRAIGHT_JOIN UNION USING WHEN THEN
+ELSE END`)
+)
+
+// mysqlIdentPosition：toks[i]（关键字 token）是否处于标识符位置；后面紧跟 "(" 的是函数或关键字
+func mysqlIdentPosition(prev string, toks []antlr.Token, i int) bool {
+	next, nextType := "", antlr.TokenEOF
+	for j := i + 1; j < len(toks); j++ {
+		if t := toks[j]; t.GetChannel() == antlr.TokenDefaultChannel {
+			next, nextType = strings.ToUpper(t.GetText()), t.GetTokenType()
+			break
+		}
+	}
+	switch {
+	case next == "(":
+		return false
+	case prev == "." || next == ".":
+		return true
+	case prev == "AS" && next == ")":
+		return false // CAST(x AS DATE)
+	}
+	return mdIdentBefore[prev] && (next == "" || mdIdentAfter[next] ||
+		nextType == mylex.MySQLLexerIDENTIFIER || nextType == mylex.MySQLLexerBACK_TICK_QUOTED_ID)
+}
+
+// mysqlDigestValue：会被归约为 ? 的 token
+func mysqlDigestValue(tt int, txt, prev, prev2 string) bool {
+	switch tt {
+	case mylex.MySQLLexerINT_NUMBER, mylex.MySQLLexerLONG_NUMBER, mylex.MySQLLexerULONGLONG_NUMBER,
+		mylex.MySQLLexerDECIMAL_NUMBER, mylex.MySQLLexerFLOAT_NUMBER,
+		mylex.MySQLLexerHEX_NUMBER, mylex.MySQLLexerBIN_NUMBER,
+		mylex.MySQLLexerSINGLE_QUOTED_TEXT, mylex.MySQLLexerDOUBLE_QUOTED_TEXT, mylex.MySQLLexerNCHAR_TEXT,
+		mylex.MySQLLexerDOLLAR_QUOTED_STRING_TEXT,
+		mylex.MySQLLexerPARAM_MARKER, mylex.MySQLLexerTRUE_SYMBOL, mylex.MySQLLexerFALSE_SYMBOL:
+		return true
+	case mylex.MySQLLexerNULL_SYMBOL, mylex.MySQLLexerNULL2_SYMBOL:
+		// IS NULL / IS NOT NULL 保留
+		return !(prev == "IS" || (prev == "NOT" && prev2 == "IS"))
+	}
+	return isBind(txt)
+}
+
+// mysqlCharsetIntroducer：lexer 未识别字符集时 _utf8mb4 会成为普通标识符，后面紧贴字符串字面量
+func mysqlCharsetIntroducer(toks []antlr.Token, i int) bool {
+	if !strings.HasPrefix(toks[i].GetText(), "_") || i+1 >= len(toks) {
+		return false
+	}
+	switch toks[i+1].GetTokenType() {
+	case mylex.MySQLLexerSINGLE_QUOTED_TEXT, mylex.MySQLLexerDOUBLE_QUOTED_TEXT, mylex.MySQLLexerHEX_NUMBER, mylex.MySQLLexerBIN_NUMBER:
+		return true
+	}
+	return false
+}
+
+// mysqlDigestOperandEnd：输出项是否可作为二元运算的左操作数
+func mysqlDigestOperandEnd(s string) bool {
+	switch s {
+	case "", "(", ",", "=", "<", ">", "<=", ">=", "<>", "!=", "<=>", "+", "-", "*", "/", "%",
+		"SELECT", "WHERE", "AND", "OR", "NOT", "WHEN", "THEN", "ELSE", "CASE", "LIMIT", "OFFSET",
+		"VALUES", "IN", "BETWEEN", "SET", "IS", "LIKE", "ON", "HAVING", "BY", "DIV", "MOD", "INTERVAL", "RETURN":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,225 @@
+package sqldigest_antlr
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	reSlowUserHost = regexp.MustCompile(`^# User@Host: ([^\[]*)\[([^\]]*)\] @ (\S*) ?\[([^\]]*)\](?:\s+Id:\s+(\d+))?`)
+	reSlowKV       = regexp.MustCompile(`(\w+):\s+(\S+)`)
+	reSlowSetTS    = regexp.MustCompile(`(?i)^SET timestamp=(\d+)(?:\.\d+)?;$`)
+	reSlowUse      = regexp.MustCompile("(?i)^use `?([^`;]+)`?;$")
+	// general log：时间（8.0 ISO 或 5.x yymmdd h:mm:ss，可省略）、线程 ID、命令、参数
+	reGeneralLine = regexp.MustCompile(`^(?:(\d{4}-\d{2}-\d{2}T\S+|\d{6}\s+\d{1,2}:\d{2}:\d{2})\s+|\t\t)\s*(\d+) ([A-Za-z ]+?)\t(.*)$`)
+	reGeneralConn = regexp.MustCompile(`^(\S+)@(\S+) on (\S*)`)
+)
+
+// isMySQLLogBanner 服务启动时写入日志头的三行
+func isMySQLLogBanner(line string) bool {
+	return strings.Contains(line, ", Version: ") && strings.HasPrefix(line, "/") ||
+		strings.HasPrefix(line, "Tcp port: ") ||
+		strings.HasPrefix(line, "Time") && strings.Contains(line, "Id Command") && strings.HasSuffix(strings.TrimSpace(line), "Argument")
+}
+
+// parseMySQLLogTime 支持 8.0 的 ISO 8601（含/不含时区）与 5.x 的 "yymmdd h:mm:ss"
+func parseMySQLLogTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, l := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999", "2006-01-02 15:04:05.999999"} {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, true
+		}
+	}
+	if f := strings.Fields(s); len(f) == 2 {
+		if t, err := time.Parse("060102 15:04:05", f[0]+" "+f[1]); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseSeconds(s string) time.Duration {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(f * float64(time.Second))
+}
+
+// ParseMySQLSlowLog 流式解析 MySQL / Percona / MariaDB 慢日志，每条语句回调一次。
+// 语句的时间优先取 "SET timestamp="（语句开始时间），否则沿用最近的 "# Time:"；
+// "use db;" 会延续到后续条目（慢日志只在库切换时写 use）。
+func ParseMySQLSlowLog(r io.Reader, fn func(LogEntry) error) error {
+	sc := newLogScanner(r)
+	var (
+		cur      LogEntry
+		body     []string
+		lastTime time.Time
+		db       string
+	)
+	flush := func() error {
+		sql := strings.TrimSpace(strings.Join(body, "\n"))
+		sql = strings.TrimSpace(strings.TrimSuffix(sql, ";"))
+		e := cur
+		cur, body = LogEntry{}, nil
+		if sql == "" {
+			return nil
+		}
+		e.SQL = sql
+		if e.DB == "" {
+			e.DB = db
+		}
+		if e.Time.IsZero() {
+			e.Time = lastTime
+		}
+		return fn(e)
+	}
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case isMySQLLogBanner(line):
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		case strings.HasPrefix(line, "# Time:"):
+			if err := flush(); err != nil {
+				return err
+			}
+			if t, ok := parseMySQLLogTime(strings.TrimPrefix(line, "# Time:")); ok {
+				lastTime = t
+			}
+			continue
+		case strings.HasPrefix(line, "# User@Host:"):
+			if len(body) > 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if m := reSlowUserHost.FindStringSubmatch(line); m != nil {
+				cur.User = strings.TrimSpace(m[1])
+				if cur.User == "" {
+					cur.User = m[2]
+				}
+				cur.Host = m[3]
+				if cur.Host == "" {
+					cur.Host = m[4]
+				}
+				cur.ThreadID, _ = strconv.ParseInt(m[5], 10, 64)
+			}
+			continue
+		case strings.HasPrefix(line, "#") && len(body) == 0:
+			// 头部的其它 "# k: v" 行（Query_time、Percona 的 Schema/Thread_id 等）
+			for _, m := range reSlowKV.FindAllStringSubmatch(line, -1) {
+				switch m[1] {
+				case "Query_time":
+					cur.QueryTime = parseSeconds(m[2])
+				case "Lock_time":
+					cur.LockTime = parseSeconds(m[2])
+				case "Rows_sent":
+					cur.RowsSent, _ = strconv.ParseInt(m[2], 10, 64)
+				case "Rows_examined":
+					cur.RowsExamined, _ = strconv.ParseInt(m[2], 10, 64)
+				case "Schema":
+					cur.DB, db = m[2], m[2]
+				case "Thread_id":
+					cur.ThreadID, _ = strconv.ParseInt(m[2], 10, 64)
+				}
+			}
+			continue
+		}
+		if len(body) == 0 {
+			if m := reSlowSetTS.FindStringSubmatch(line); m != nil {
+				sec, _ := strconv.ParseInt(m[1], 10, 64)
+				cur.Time = time.Unix(sec, 0).UTC()
+				continue
+			}
+			if m := reSlowUse.FindStringSubmatch(line); m != nil {
+				cur.DB, db = m[1], m[1]
+				continue
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+		}
+		body = append(body, line)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// ParseMySQLGeneralLog 流式解析 MySQL general log；只回调 Query / Execute 命令。
+// 用户、主机与当前库按线程从 Connect / Init DB 记录中跟踪；general log 没有耗时信息。
+func ParseMySQLGeneralLog(r io.Reader, fn func(LogEntry) error) error {
+	type thread struct{ user, host, db string }
+	threads := map[int64]*thread{}
+	sc := newLogScanner(r)
+	var (
+		cur      *LogEntry
+		lastTime time.Time
+	)
+	flush := func() error {
+		if cur == nil {
+			return nil
+		}
+		e := *cur
+		cur = nil
+		e.SQL = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(e.SQL), ";"))
+		if e.SQL == "" {
+			return nil
+		}
+		return fn(e)
+	}
+	for sc.Scan() {
+		line := sc.Text()
+		if isMySQLLogBanner(line) {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		m := reGeneralLine.FindStringSubmatch(line)
+		if m == nil {
+			// 多行语句的后续行
+			if cur != nil {
+				cur.SQL += "\n" + line
+			}
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if m[1] != "" {
+			if t, ok := parseMySQLLogTime(m[1]); ok {
+				lastTime = t
+			}
+		}
+		id, _ := strconv.ParseInt(m[2], 10, 64)
+		th := threads[id]
+		if th == nil {
+			th = &thread{}
+			threads[id] = th
+		}
+		switch cmd, arg := m[3], m[4]; cmd {
+		case "Connect":
+			if c := reGeneralConn.FindStringSubmatch(arg); c != nil {
+				th.user, th.host, th.db = c[1], c[2], c[3]
+			}
+		case "Init DB":
+			th.db = strings.TrimSpace(arg)
+		case "Quit":
+			delete(threads, id)
+		case "Query", "Execute":
+			cur = &LogEntry{Time: lastTime, User: th.user, Host: th.host, DB: th.db, ThreadID: id, SQL: arg}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,431 @@
+package sqldigest_antlr
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PostgresLogOptions 描述 PG 服务端日志的格式
+type PostgresLogOptions struct {
+	Format     string // stderr（默认）/ csvlog / jsonlog
+	LinePrefix string // stderr 的 log_line_prefix；空则为 PG 默认的 "%m [%p] "
+}
+
+// pgLogRecord 三种格式统一后的一条日志记录
+type pgLogRecord struct {
+	Time     time.Time
+	User     string
+	DB       string
+	Host     string
+	PID      int64
+	Severity string
+	Message  string
+	Detail   string // csvlog / jsonlog 中 DETAIL 与消息同一记录；stderr 中为单独一行
+}
+
+var (
+	rePGDuration = regexp.MustCompile(`(?s)^duration: ([0-9.]+) ms(?:\s+(.*))?$`)
+	rePGStmt     = regexp.MustCompile(`(?s)^(statement|execute [^:]*|parse [^:]*|bind [^:]*):\s?(.*)$`)
+)
+
+// ParsePostgresLog 流式解析 PG 服务端日志，回调 "statement:" 与 "execute <name>:" 语句；
+// 其后的 "DETAIL: parameters: $1 = '...'" 记入 LogEntry.BindValues，
+// 单独的 "duration: ... ms" 行（log_duration）归到同一进程（PID）的上一条语句。parse/bind 阶段忽略，避免重复计数。
+func ParsePostgresLog(r io.Reader, po PostgresLogOptions, fn func(LogEntry) error) error {
+	st := &pgLogState{fn: fn}
+	var err error
+	switch po.Format {
+	case "", "stderr":
+		err = readPGStderr(r, po.LinePrefix, st.record)
+	case "csvlog", "csv":
+		err = readPGCSV(r, st.record)
+	case "jsonlog", "json":
+		err = readPGJSON(r, st.record)
+	default:
+		return fmt.Errorf("unknown postgres log format: %s", po.Format)
+	}
+	if err != nil {
+		return err
+	}
+	return st.flush()
+}
+
+// pgLogState 每个后端进程（PID）各自暂存最近一条语句，等它的 duration / DETAIL 行；
+// 不同 PID 的日志行交错时互不影响。该 PID 的下一条语句、断开连接或 EOF 时输出。
+type pgLogState struct {
+	fn      func(LogEntry) error
+	pending map[int64]*pgPending
+	seq     int
+}
+
+type pgPending struct {
+	LogEntry
+	seq int // 语句出现的顺序：EOF 时按它输出
+}
+
+func (s *pgLogState) flushPID(pid int64) error {
+	p := s.pending[pid]
+	if p == nil {
+		return nil
+	}
+	delete(s.pending, pid)
+	return s.fn(p.LogEntry)
+}
+
+func (s *pgLogState) flush() error {
+	rest := make([]*pgPending, 0, len(s.pending))
+	for _, p := range s.pending {
+		rest = append(rest, p)
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i].seq < rest[j].seq })
+	s.pending = nil
+	for _, p := range rest {
+		if err := s.fn(p.LogEntry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *pgLogState) record(r pgLogRecord) error {
+	p := s.pending[r.PID]
+	if r.Severity == "DETAIL" {
+		if p != nil {
+			attachPGDetail(&p.LogEntry, r.Message)
+		}
+		return nil
+	}
+	if r.Severity != "LOG" {
+		return nil
+	}
+	var dur time.Duration
+	msg := strings.TrimSpace(r.Message)
+	if strings.HasPrefix(msg, "disconnection: ") {
+		return s.flushPID(r.PID)
+	}
+	if m := rePGDuration.FindStringSubmatch(msg); m != nil {
+		dur = parseMillis(m[1])
+		if m[2] == "" {
+			// log_duration 单独输出的耗时
+			if p != nil && p.QueryTime == 0 {
+				p.QueryTime = dur
+			}
+			return nil
+		}
+		msg = m[2]
+	}
+	m := rePGStmt.FindStringSubmatch(msg)
+	if m == nil || strings.HasPrefix(m[1], "parse ") || strings.HasPrefix(m[1], "bind ") {
+		return nil
+	}
+	if err := s.flushPID(r.PID); err != nil {
+		return err
+	}
+	if s.pending == nil {
+		s.pending = map[int64]*pgPending{}
+	}
+	s.seq++
+	p = &pgPending{LogEntry: LogEntry{Time: r.Time, User: r.User, DB: r.DB, Host: r.Host, ThreadID: r.PID, QueryTime: dur, SQL: strings.TrimSpace(m[2])}, seq: s.seq}
+	s.pending[r.PID] = p
+	if r.Detail != "" {
+		attachPGDetail(&p.LogEntry, r.Detail)
+	}
+	return nil
+}
+
+func attachPGDetail(e *LogEntry, detail string) {
+	detail = strings.TrimSpace(detail)
+	if !strings.HasPrefix(detail, "parameters: ") {
+		return
+	}
+	if vals := parsePGParameters(strings.TrimPrefix(detail, "parameters: ")); len(vals) > 0 {
+		e.BindValues = vals
+	}
+}
+
+// parsePGParameters 解析 DETAIL 中的参数列表：$1 = '42', $2 = NULL（字符串内单引号双写转义）
+func parsePGParameters(s string) map[int]string {
+	out := map[int]string{}
+	i := 0
+	for i < len(s) {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) || s[i] != '$' {
+			break
+		}
+		j := i + 1
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		n, err := strconv.Atoi(s[i+1 : j])
+		if err != nil || !strings.HasPrefix(s[j:], " = ") {
+			break
+		}
+		j += 3
+		k := j
+		if k < len(s) && s[k] == '\'' {
+			for k++; k < len(s); k++ {
+				if s[k] == '\'' {
+					if k+1 < len(s) && s[k+1] == '\'' {
+						k++
+						continue
+					}
+					k++
+					break
+				}
+			}
+		} else {
+			for k < len(s) && s[k] != ',' {
+				k++
+			}
+		}
+		out[n] = s[j:k]
+		i = k
+	}
+	return out
+}
+
+func parseMillis(s string) time.Duration {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(f * float64(time.Millisecond))
+}
+
+// parsePGLogTime 解析 %m / %t（带时区缩写或数字时区）与 %n（Unix 秒）
+func parsePGLogTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, l := range []string{
+		"2006-01-02 15:04:05.999999 MST", "2006-01-02 15:04:05.999999 -07", "2006-01-02 15:04:05.999999 -0700",
+		"2006-01-02 15:04:05.999999",
+	} {
+		if t, err := time.Parse(l, s); err == nil {
+			return t
+		}
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC()
+	}
+	return time.Time{}
+}
+
+// compilePGLinePrefix 把 log_line_prefix 转成正则，带命名分组 time/pid/user/db/host
+func compilePGLinePrefix(prefix string) (*regexp.Regexp, error) {
+	if prefix == "" {
+		prefix = "%m [%p] "
+	}
+	const ts = `\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}`
+	used := map[string]bool{}
+	group := func(name, pat string) string {
+		if used[name] {
+			return "(?:" + pat + ")"
+		}
+		used[name] = true
+		return "(?P<" + name + ">" + pat + ")"
+	}
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	optional := 0
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		if c != '%' || i+1 >= len(prefix) {
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			continue
+		}
+		i++
+		// %-10u 之类的填充宽度
+		pad := false
+		for i < len(prefix) && (prefix[i] == '-' || (prefix[i] >= '0' && prefix[i] <= '9')) {
+			pad = true
+			i++
+		}
+		if i >= len(prefix) {
+			break
+		}
+		if pad {
+			b.WriteString(` *`)
+		}
+		switch prefix[i] {
+		case 'm':
+			b.WriteString(group("time", ts+`\.\d+(?: \S+)?`))
+		case 't':
+			b.WriteString(group("time", ts+`(?: \S+)?`))
+		case 'n':
+			b.WriteString(group("time", `\d+(?:\.\d+)?`))
+		case 's':
+			b.WriteString(ts + `(?: \S+)?`)
+		case 'p':
+			b.WriteString(group("pid", `\d+`))
+		case 'P', 'l', 'x':
+			b.WriteString(`\d*`)
+		case 'Q':
+			b.WriteString(`-?\d*`)
+		case 'c':
+			b.WriteString(`[0-9a-f]+\.[0-9a-f]+`)
+		case 'e':
+			b.WriteString(`[0-9A-Z]{5}`)
+		case 'v':
+			b.WriteString(`\S*`)
+		case 'u':
+			b.WriteString(group("user", `.*?`))
+		case 'd':
+			b.WriteString(group("db", `.*?`))
+		case 'h', 'r':
+			b.WriteString(group("host", `.*?`))
+		case 'a', 'i', 'b':
+			b.WriteString(`.*?`)
+		case 'q':
+			// 非会话进程在 %q 处截断：其后部分整体可选
+			b.WriteString(`(?:`)
+			optional++
+		case '%':
+			b.WriteString(`%`)
+		default:
+			b.WriteString(regexp.QuoteMeta("%" + string(prefix[i])))
+		}
+	}
+	for ; optional > 0; optional-- {
+		b.WriteString(`)?`)
+	}
+	b.WriteString(`(?P<sev>[A-Z]+[0-9]?):  (?P<msg>.*)$`)
+	return regexp.Compile(b.String())
+}
+
+func readPGStderr(r io.Reader, prefix string, fn func(pgLogRecord) error) error {
+	re, err := compilePGLinePrefix(prefix)
+	if err != nil {
+		return err
+	}
+	idx := func(name string) int { return re.SubexpIndex(name) }
+	iTime, iPID, iUser, iDB, iHost, iSev, iMsg := idx("time"), idx("pid"), idx("user"), idx("db"), idx("host"), idx("sev"), idx("msg")
+	get := func(m []string, i int) string {
+		if i < 0 {
+			return ""
+		}
+		return m[i]
+	}
+	var cur *pgLogRecord
+	emit := func() error {
+		if cur == nil {
+			return nil
+		}
+		rec := *cur
+		cur = nil
+		return fn(rec)
+	}
+	sc := newLogScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			// 多行语句的续行（PG 以制表符开头）
+			if cur != nil {
+				cur.Message += "\n" + strings.TrimPrefix(line, "\t")
+			}
+			continue
+		}
+		if err := emit(); err != nil {
+			return err
+		}
+		rec := pgLogRecord{
+			User:     get(m, iUser),
+			DB:       get(m, iDB),
+			Host:     get(m, iHost),
+			Severity: m[iSev],
+			Message:  m[iMsg],
+		}
+		if t := get(m, iTime); t != "" {
+			rec.Time = parsePGLogTime(t)
+		}
+		rec.PID, _ = strconv.ParseInt(get(m, iPID), 10, 64)
+		cur = &rec
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return emit()
+}
+
+// readPGCSV csvlog 列：log_time,user_name,database_name,process_id,connection_from,session_id,
+// session_line_num,command_tag,session_start_time,virtual_transaction_id,transaction_id,
+// error_severity,sql_state_code,message,detail,...
+func readPGCSV(r io.Reader, fn func(pgLogRecord) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	for {
+		f, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(f) < 14 {
+			continue
+		}
+		rec := pgLogRecord{
+			Time:     parsePGLogTime(f[0]),
+			User:     f[1],
+			DB:       f[2],
+			Host:     f[4],
+			Severity: f[11],
+			Message:  f[13],
+		}
+		rec.PID, _ = strconv.ParseInt(f[3], 10, 64)
+		if len(f) > 14 {
+			rec.Detail = f[14]
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// readPGJSON jsonlog（PG 15+）每行一个对象
+func readPGJSON(r io.Reader, fn func(pgLogRecord) error) error {
+	sc := newLogScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var j struct {
+			Timestamp  string `json:"timestamp"`
+			User       string `json:"user"`
+			DBName     string `json:"dbname"`
+			PID        int64  `json:"pid"`
+			RemoteHost string `json:"remote_host"`
+			Severity   string `json:"error_severity"`
+			Message    string `json:"message"`
+			Detail     string `json:"detail"`
+		}
+		if err := json.Unmarshal([]byte(line), &j); err != nil {
+			return err
+		}
+		rec := pgLogRecord{
+			Time:     parsePGLogTime(j.Timestamp),
+			User:     j.User,
+			DB:       j.DBName,
+			Host:     j.RemoteHost,
+			PID:      j.PID,
+			Severity: j.Severity,
+			Message:  j.Message,
+			Detail:   j.Detail,
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
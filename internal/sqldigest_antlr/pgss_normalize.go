@@ -0,0 +1,261 @@
+package sqldigest_antlr
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// pg_stat_statements 兼容的规范化（PG 13–18 行为，Options.PGVersion 选择版本，0 按 18）：
+//   - 原文逐字保留（大小写、空白、注释），只把常量替换为 $n；
+//   - 每条语句各自编号：从该语句里已有的最大 $n 之后开始；
+//   - IN 列表里每个常量各自编号（PG 18 的列表折叠不在此复现，PG 18 也按此处理）；
+//   - 一元负号并入数字常量（-1 → $1）；TRUE/FALSE/NULL 是常量，IS [NOT] NULL/TRUE 不是；
+//   - 类型修饰（::numeric(10,2)、列定义的 varchar(10)）、ORDER BY / GROUP BY 的列序号不是常量；
+//   - 非 DML 语句（DDL 等）：PG 16 起其中的表达式常量（DEFAULT、CHECK……）同样替换，
+//     选项列表 WITH (...)、序列参数、COMMENT / NOTIFY 的文本不是常量；PG 15 及以前原样保留；
+//     SET 的值到 PG 18 才替换（utility.out：16、17 中 SET work_mem = '64MB' 原样保留）；
+//   - 去掉首尾空白与末尾分号。
+
+// pgssStatementText 生成与 pg_stat_statements.query 一致的文本
+func pgssStatementText(original string, toks []antlr.Token, opt Options) string {
+	type rng struct{ s, e, n int }
+	var consts []rng
+	utility := opt.PGVersion == 0 || opt.PGVersion >= 16
+	for _, st := range SplitStatements(original, toks, opt) {
+		var v []antlr.Token
+		maxParam := 0
+		for i := st.StartTok; i <= st.EndTok && i < len(toks); i++ {
+			t := toks[i]
+			if IsEOFToken(t) || t.GetChannel() != antlr.TokenDefaultChannel {
+				continue
+			}
+			v = append(v, t)
+			if txt := t.GetText(); len(txt) > 1 && txt[0] == '$' && isAllDigits(txt[1:]) {
+				if n, err := strconv.Atoi(txt[1:]); err == nil && n > maxParam {
+					maxParam = n
+				}
+			}
+		}
+		var rs [][2]int
+		switch {
+		case pgssJumbled(st.Type, v):
+			rs = pgssConstants(v, false)
+		case utility && pgssUtilityConsts(v, opt.PGVersion):
+			rs = pgssConstants(v, true)
+		}
+		for k, r := range rs {
+			consts = append(consts, rng{RuneIndexToByte(original, r[0]), RuneIndexToByte(original, r[1]+1), maxParam + k + 1})
+		}
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, c := range consts {
+		out.WriteString(original[last:c.s])
+		out.WriteString("$" + strconv.Itoa(c.n))
+		last = c.e
+	}
+	out.WriteString(original[last:])
+	s := strings.TrimSpace(out.String())
+	return strings.TrimSpace(strings.TrimSuffix(s, ";"))
+}
+
+// pgssJumbled：pg_stat_statements 只对可规划语句做常量规范化
+func pgssJumbled(typ string, v []antlr.Token) bool {
+	switch typ {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE":
+		return true
+	}
+	if len(v) == 0 {
+		return false
+	}
+	switch strings.ToUpper(v[0].GetText()) {
+	case "VALUES", "TABLE", "(":
+		return true
+	}
+	return false
+}
+
+// pgssUtilityConsts：非 DML 语句里是否有会被规范化的常量（参数是文本或数字节点而非表达式的语句没有）
+func pgssUtilityConsts(v []antlr.Token, version int) bool {
+	if len(v) == 0 {
+		return false
+	}
+	switch strings.ToUpper(v[0].GetText()) {
+	case "COMMENT", "NOTIFY", "LISTEN", "UNLISTEN", "SECURITY":
+		return false
+	case "SET":
+		return version == 0 || version >= 18
+	case "CREATE", "ALTER":
+		for _, t := range v[1:min(len(v), 4)] {
+			if strings.EqualFold(t.GetText(), "SEQUENCE") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pgssConstants 返回可见 token 序列中常量的 rune 区间 [start, stop]；
+// utility 为非 DML 语句：列定义里的类型修饰、WITH (...) 选项与 SET STATISTICS n 不算常量
+func pgssConstants(v []antlr.Token, utility bool) [][2]int {
+	var out [][2]int
+	up := func(i int) string {
+		if i < 0 || i >= len(v) {
+			return ""
+		}
+		return strings.ToUpper(v[i].GetText())
+	}
+	depth := 0
+	posDepth := -1 // ORDER BY / GROUP BY 列表所在的括号深度
+	for i := 0; i < len(v); i++ {
+		txt := v[i].GetText()
+		u := up(i)
+		switch {
+		case txt == "(":
+			if pgssTypmodParen(v, i, utility) || (utility && up(i-1) == "WITH") {
+				i = pgssSkipParen(v, i)
+				continue
+			}
+			depth++
+			continue
+		case txt == ")":
+			depth--
+			if depth < posDepth {
+				posDepth = -1
+			}
+			continue
+		case u == "BY" && (up(i-1) == "ORDER" || up(i-1) == "GROUP"):
+			posDepth = depth
+			continue
+		case depth == posDepth && pgssClauseEnd(u):
+			posDepth = -1
+		}
+
+		switch {
+		case isNumberLiteral(txt):
+			if utility && up(i-1) == "STATISTICS" {
+				continue
+			}
+			if depth == posDepth && (up(i-1) == "BY" || up(i-1) == ",") && pgssSortItemEnd(up(i+1)) {
+				continue // 列序号
+			}
+			start := v[i].GetStart()
+			if i > 0 && v[i-1].GetText() == "-" && pgssUnaryContext(v, i-2) {
+				start = v[i-1].GetStart()
+			}
+			out = append(out, [2]int{start, v[i].GetStop()})
+		case pgssStringConst(txt):
+			out = append(out, [2]int{v[i].GetStart(), v[i].GetStop()})
+		case len(txt) >= 2 && txt[0] == '$' && txt[len(txt)-1] == '$':
+			// $tag$ ... $tag$：lexer 拆成 开始标记 / 正文 / 结束标记
+			j := i + 1
+			for j < len(v) && v[j].GetText() != txt {
+				j++
+			}
+			if j >= len(v) {
+				j = len(v) - 1
+			}
+			out = append(out, [2]int{v[i].GetStart(), v[j].GetStop()})
+			i = j
+		case u == "TRUE" || u == "FALSE" || u == "NULL":
+			if up(i-1) == "IS" || (up(i-1) == "NOT" && up(i-2) == "IS") {
+				continue
+			}
+			// 列约束 [NOT] NULL 不是常量；DEFAULT NULL 与表达式里的 NULL 是
+			if utility && u == "NULL" && up(i-1) != "DEFAULT" && (up(i-1) == "NOT" || !pgssUnaryContext(v, i-1)) {
+				continue
+			}
+			out = append(out, [2]int{v[i].GetStart(), v[i].GetStop()})
+		}
+	}
+	return out
+}
+
+// pgssStringConst：'..' / E'..' / B'..' / X'..' / N'..' / U&'..'（"..." 在 PG 里是标识符）
+func pgssStringConst(txt string) bool {
+	if strings.HasPrefix(txt, "'") {
+		return true
+	}
+	if len(txt) > 2 && txt[1] == '\'' {
+		switch txt[0] {
+		case 'E', 'e', 'B', 'b', 'X', 'x', 'N', 'n':
+			return true
+		}
+	}
+	return strings.HasPrefix(txt, "U&'") || strings.HasPrefix(txt, "u&'")
+}
+
+// pgssUnaryContext：v[i] 之后的 '-' 是否为一元负号（前面不是操作数）
+func pgssUnaryContext(v []antlr.Token, i int) bool {
+	if i < 0 {
+		return true
+	}
+	txt := v[i].GetText()
+	if txt == ")" || txt == "]" || isNumberLiteral(txt) || pgssStringConst(txt) || isQuotedIdent(txt) {
+		return false
+	}
+	if !looksLikeIdent(txt) {
+		return true // 运算符 / 标点
+	}
+	switch strings.ToUpper(txt) {
+	case "SELECT", "WHERE", "AND", "OR", "NOT", "WHEN", "THEN", "ELSE", "CASE", "RETURNING",
+		"LIMIT", "OFFSET", "VALUES", "IN", "BETWEEN", "SET", "IS", "LIKE", "ILIKE", "ON",
+		"HAVING", "BY", "DISTINCT", "ALL", "ANY", "SOME", "ARRAY", "FETCH", "FIRST", "NEXT":
+		return true
+	}
+	return false
+}
+
+// pgssTypmodParen："::type(" 或 "CAST(x AS type(" 中的类型修饰括号；DDL 里类型名后的括号都是
+func pgssTypmodParen(v []antlr.Token, i int, ddl bool) bool {
+	j := i - 1
+	for j >= 0 && pgssTypeWord(strings.ToUpper(v[j].GetText())) {
+		j--
+	}
+	if j == i-1 || j < 0 {
+		return false
+	}
+	prev := strings.ToUpper(v[j].GetText())
+	return ddl || prev == "::" || prev == "AS"
+}
+
+func pgssTypeWord(u string) bool {
+	switch u {
+	case "VARCHAR", "CHAR", "CHARACTER", "VARYING", "NCHAR", "BPCHAR",
+		"NUMERIC", "DECIMAL", "DEC", "FLOAT", "DOUBLE", "PRECISION",
+		"BIT", "VARBIT", "TIME", "TIMESTAMP", "TIMESTAMPTZ", "TIMETZ", "INTERVAL":
+		return true
+	}
+	return false
+}
+
+func pgssSkipParen(v []antlr.Token, i int) int {
+	d := 0
+	for ; i < len(v); i++ {
+		switch v[i].GetText() {
+		case "(":
+			d++
+		case ")":
+			d--
+			if d == 0 {
+				return i
+			}
+		}
+	}
+	return len(v) - 1
+}
+
+func pgssClauseEnd(u string) bool {
+	switch u {
+	case "LIMIT", "OFFSET", "HAVING", "WINDOW", "FETCH", "FOR", "UNION", "EXCEPT", "INTERSECT", "RETURNING", ";":
+		return true
+	}
+	return false
+}
+
+func pgssSortItemEnd(u string) bool {
+	return u == "" || u == "," || u == ")" || u == "ASC" || u == "DESC" || u == "NULLS" || u == "ORDER" || pgssClauseEnd(u)
+}
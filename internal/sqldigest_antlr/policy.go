@@ -0,0 +1,459 @@
+package sqldigest_antlr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// 危险语句策略：声明式规则集，对 SplitStatements 切出的每条语句求值。
+// 内置检查（PolicyRule.Check）：
+//   - missing_where         UPDATE / DELETE 顶层没有 WHERE（Types 可改适用类型）
+//   - statement_type        语句类型属于 Types（如 TRUNCATE、DROP）
+//   - select_star           SELECT * / t.* 直接读具名表（Tables 非空时只看这些表；EXISTS 子查询除外）
+//   - unbounded_select      顶层 SELECT 读表但没有 LIMIT / TOP / FETCH / ROWNUM（纯聚合除外）
+//   - grant_all             GRANT ALL [PRIVILEGES]
+//   - cross_database_write  写入目标带库名限定且不在 Databases 中；Oracle 看 @dblink
+// 规则 JSON 形如 {"rules":[{"id":"no-truncate","check":"statement_type","types":["TRUNCATE"]}]}
+
+const (
+	CheckMissingWhere       = "missing_where"
+	CheckStatementType      = "statement_type"
+	CheckSelectStar         = "select_star"
+	CheckUnboundedSelect    = "unbounded_select"
+	CheckGrantAll           = "grant_all"
+	CheckCrossDatabaseWrite = "cross_database_write"
+)
+
+// 各检查的默认级别
+var policyChecks = map[string]string{
+	CheckMissingWhere:       SeverityHigh,
+	CheckStatementType:      SeverityHigh,
+	CheckSelectStar:         SeverityLow,
+	CheckUnboundedSelect:    SeverityMedium,
+	CheckGrantAll:           SeverityHigh,
+	CheckCrossDatabaseWrite: SeverityMedium,
+}
+
+// PolicyRule 一条规则：ID 由调用方命名，Check 选内置检查，其余字段是检查参数
+type PolicyRule struct {
+	ID        string   `json:"id"`
+	Check     string   `json:"check"`
+	Severity  string   `json:"severity,omitempty"`  // 为空取检查默认级别
+	Message   string   `json:"message,omitempty"`   // 为空用检查生成的说明
+	Types     []string `json:"types,omitempty"`     // statement_type / missing_where 的语句类型
+	Tables    []string `json:"tables,omitempty"`    // select_star 只看这些表
+	Databases []string `json:"databases,omitempty"` // cross_database_write 允许写的库
+}
+
+// Policy 规则集
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyViolation 一条违规；Statement 从 1 起，Start/End 为原文字节区间
+type PolicyViolation struct {
+	Rule      string `json:"rule"`
+	Check     string `json:"check"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	Statement int    `json:"statement"`
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+}
+
+// DefaultPolicy 每个内置检查一条规则
+func DefaultPolicy() Policy {
+	return Policy{Rules: []PolicyRule{
+		{ID: "write-without-where", Check: CheckMissingWhere},
+		{ID: "truncate-or-drop", Check: CheckStatementType, Types: []string{"TRUNCATE", "DROP"}},
+		{ID: "select-star", Check: CheckSelectStar},
+		{ID: "unbounded-select", Check: CheckUnboundedSelect},
+		{ID: "grant-all", Check: CheckGrantAll},
+		{ID: "cross-database-write", Check: CheckCrossDatabaseWrite},
+	}}
+}
+
+// ParsePolicy 解析 JSON 规则集（未知字段报错）并校验
+func ParsePolicy(data []byte) (Policy, error) {
+	var p Policy
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return Policy{}, fmt.Errorf("policy: %w", err)
+	}
+	return p, p.Validate()
+}
+
+// Validate 检查 ID 唯一、检查名与级别合法、statement_type 带 Types
+func (p Policy) Validate() error {
+	seen := map[string]bool{}
+	for i, r := range p.Rules {
+		if r.ID == "" {
+			return fmt.Errorf("policy: rule #%d has no id", i+1)
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("policy: duplicate rule id %q", r.ID)
+		}
+		seen[r.ID] = true
+		if _, ok := policyChecks[r.Check]; !ok {
+			return fmt.Errorf("policy: rule %q: unknown check %q", r.ID, r.Check)
+		}
+		switch r.Severity {
+		case "", SeverityHigh, SeverityMedium, SeverityLow:
+		default:
+			return fmt.Errorf("policy: rule %q: unknown severity %q", r.ID, r.Severity)
+		}
+		if r.Check == CheckStatementType && len(r.Types) == 0 {
+			return fmt.Errorf("policy: rule %q: statement_type needs types", r.ID)
+		}
+	}
+	return nil
+}
+
+// policyHit 检查命中的区间与说明
+type policyHit struct {
+	start, end int
+	msg        string
+}
+
+// EvaluatePolicy 对 sql 的每条语句依次应用 p 的规则，结果按语句、规则顺序排列
+func EvaluatePolicy(sql string, p Policy, opt Options) ([]PolicyViolation, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	toks, _, err := lexTokens(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	vis := visibleTokens(sql, toks, opt)
+	var out []PolicyViolation
+	for n, st := range SplitStatements(sql, toks, opt) {
+		var v []itok
+		for _, t := range vis {
+			if t.start >= st.StartByte && t.end <= st.EndByte {
+				v = append(v, t)
+			}
+		}
+		if len(v) > 0 && v[len(v)-1].text == ";" {
+			v = v[:len(v)-1]
+		}
+		if len(v) == 0 {
+			continue
+		}
+		for _, r := range p.Rules {
+			for _, h := range runPolicyCheck(r, st, v, opt) {
+				pv := PolicyViolation{
+					Rule: r.ID, Check: r.Check, Severity: r.Severity, Message: r.Message,
+					Statement: n + 1, Start: h.start, End: h.end,
+				}
+				if pv.Severity == "" {
+					pv.Severity = policyChecks[r.Check]
+				}
+				if pv.Message == "" {
+					pv.Message = h.msg
+				}
+				out = append(out, pv)
+			}
+		}
+	}
+	return out, nil
+}
+
+func runPolicyCheck(r PolicyRule, st StmtInfo, v []itok, opt Options) []policyHit {
+	whole := []policyHit{{start: v[0].start, end: v[len(v)-1].end}}
+	switch r.Check {
+	case CheckMissingWhere:
+		types := r.Types
+		if len(types) == 0 {
+			types = []string{"UPDATE", "DELETE"}
+		}
+		if !containsFold(types, st.Type) || topLevelIndex(v, "WHERE") >= 0 {
+			return nil
+		}
+		whole[0].msg = st.Type + " without WHERE affects every row"
+		return whole
+	case CheckStatementType:
+		if !containsFold(r.Types, st.Type) {
+			return nil
+		}
+		whole[0].msg = st.Type + " statement is not allowed"
+		return whole
+	case CheckSelectStar:
+		return selectStarHits(v, r.Tables)
+	case CheckUnboundedSelect:
+		if st.Type != "SELECT" || !unboundedSelect(v) {
+			return nil
+		}
+		whole[0].msg = "SELECT without LIMIT/TOP/FETCH reads an unbounded number of rows"
+		return whole
+	case CheckGrantAll:
+		if len(v) < 2 || v[0].up != "GRANT" || v[1].up != "ALL" {
+			return nil
+		}
+		end := v[1].end
+		if len(v) > 2 && v[2].up == "PRIVILEGES" {
+			end = v[2].end
+		}
+		return []policyHit{{start: v[0].start, end: end, msg: "GRANT ALL gives every privilege"}}
+	case CheckCrossDatabaseWrite:
+		return crossDatabaseWrite(st, v, r.Databases, opt)
+	}
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(x string) bool { return strings.EqualFold(x, s) })
+}
+
+// topLevelIndex 括号外第一个关键字 up 的位置，没有返回 -1
+func topLevelIndex(v []itok, up string) int {
+	depth := 0
+	for i, t := range v {
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			if depth > 0 {
+				depth--
+			}
+		}
+		if depth == 0 && t.up == up {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectStarHits：每个 SELECT 的选择列表里的 * / t.*，其 FROM 后紧跟具名表时命中
+func selectStarHits(v []itok, tables []string) []policyHit {
+	ctes := cteNames(v)
+	var out []policyHit
+	for i, t := range v {
+		if t.up != "SELECT" || (i >= 2 && v[i-1].text == "(" && v[i-2].up == "EXISTS") {
+			continue
+		}
+		k := selectListStart(v, i)
+		var stars []int
+		depth := 0
+		from := -1
+		for j := k; j < len(v) && from < 0; j++ {
+			switch v[j].text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			case "*":
+				if depth == 0 && (j == k || v[j-1].text == "," || v[j-1].text == ".") {
+					stars = append(stars, j)
+				}
+			}
+			if depth < 0 || (depth == 0 && (v[j].up == "UNION" || v[j].up == "SELECT")) {
+				break
+			}
+			if depth == 0 && v[j].up == "FROM" {
+				from = j
+			}
+		}
+		if len(stars) == 0 || from < 0 || from+1 >= len(v) {
+			continue
+		}
+		name, _, _, ok := qualifiedName(v, from+1)
+		if !ok {
+			continue
+		}
+		table := unquoteIdent(name[len(name)-1])
+		if strings.EqualFold(table, "DUAL") || containsFold(ctes, table) || (len(tables) > 0 && !containsFold(tables, table)) {
+			continue
+		}
+		for _, s := range stars {
+			start := v[s].start
+			if v[s-1].text == "." && s >= 2 {
+				start = v[s-2].start
+			}
+			out = append(out, policyHit{start: start, end: v[s].end, msg: "SELECT * on table " + table})
+		}
+	}
+	return out
+}
+
+// selectListStart 跳过 SELECT 后的 DISTINCT / ALL / TOP n [PERCENT] [WITH TIES]
+func selectListStart(v []itok, sel int) int {
+	k := sel + 1
+	for k < len(v) && (v[k].up == "DISTINCT" || v[k].up == "ALL" || v[k].up == "DISTINCTROW") {
+		k++
+	}
+	if k < len(v) && v[k].up == "TOP" {
+		k++
+		if k < len(v) && v[k].text == "(" {
+			k = closeParenTok(v, k)
+		}
+		k++
+		if k < len(v) && v[k].up == "PERCENT" {
+			k++
+		}
+		if k+1 < len(v) && v[k].up == "WITH" && v[k+1].up == "TIES" {
+			k += 2
+		}
+	}
+	return k
+}
+
+// cteNames：WITH 语句里 name [ (cols) ] AS ( 形式定义的 CTE 名
+func cteNames(v []itok) []string {
+	if v[0].up != "WITH" {
+		return nil
+	}
+	var out []string
+	for i := 1; i+2 < len(v); i++ {
+		j := i + 1
+		if v[j].text == "(" {
+			j = closeParenTok(v, j) + 1
+		}
+		if j+1 < len(v) && v[j].up == "AS" && v[j+1].text == "(" && looksLikeIdent(unquoteIdent(v[i].text)) {
+			out = append(out, unquoteIdent(v[i].text))
+		}
+	}
+	return out
+}
+
+// unboundedSelect：顶层 FROM 读了表，却没有 LIMIT / TOP / FETCH / ROWNUM，且不是无 GROUP BY 的纯聚合
+func unboundedSelect(v []itok) bool {
+	from := topLevelIndex(v, "FROM")
+	if from < 0 || from+1 >= len(v) || strings.EqualFold(v[from+1].text, "DUAL") {
+		return false
+	}
+	for _, kw := range []string{"LIMIT", "TOP", "FETCH", "ROWNUM"} {
+		if topLevelIndex(v, kw) >= 0 {
+			return false
+		}
+	}
+	sel := topLevelIndex(v, "SELECT")
+	if sel < 0 || topLevelIndex(v, "GROUP") >= 0 {
+		return true
+	}
+	// 选择列表逐项看是否都是聚合函数
+	k := selectListStart(v, sel)
+	for k < from {
+		if k+1 >= len(v) || v[k+1].text != "(" {
+			return true
+		}
+		switch v[k].up {
+		case "COUNT", "SUM", "MIN", "MAX", "AVG", "COUNT_BIG":
+		default:
+			return true
+		}
+		k = closeParenTok(v, k+1) + 1
+		// 可选别名
+		for k < from && v[k].text != "," {
+			k++
+		}
+		k++
+	}
+	return false
+}
+
+// qualifiedName 读 a.b.c / a..c / t@link 形式的名字，返回各段、dblink、最后一个 token 的位置
+func qualifiedName(v []itok, i int) (parts []string, link string, end int, ok bool) {
+	if i >= len(v) || !(looksLikeIdent(v[i].text) || isQuotedIdent(v[i].text)) {
+		return nil, "", i, false
+	}
+	parts = []string{v[i].text}
+	end = i
+	for end+2 < len(v) {
+		switch sep := v[end+1].text; {
+		case sep == "@":
+			link, end = v[end+2].text, end+2
+			for end+2 < len(v) && v[end+1].text == "." {
+				link += "." + v[end+2].text
+				end += 2
+			}
+			return parts, link, end, true
+		case sep == "..":
+			parts = append(parts, "", v[end+2].text)
+			end += 2
+		case sep == "." && v[end+2].text == "." && end+3 < len(v): // 有的 lexer 把 db..t 拆成两个点
+			parts = append(parts, "", v[end+3].text)
+			end += 3
+		case sep == ".":
+			parts = append(parts, v[end+2].text)
+			end += 2
+		default:
+			return parts, link, end, true
+		}
+	}
+	return parts, link, end, true
+}
+
+// unquoteIdent 去掉 "x" / `x` / [x] 的引号
+func unquoteIdent(s string) string {
+	if len(s) >= 2 {
+		switch {
+		case s[0] == '"' && s[len(s)-1] == '"', s[0] == '`' && s[len(s)-1] == '`', s[0] == '[' && s[len(s)-1] == ']':
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+// crossDatabaseWrite：定位写语句的目标表，按方言取出库名（MySQL 两段名的首段；
+// PG / SQL Server 三段及以上的库名段；Oracle 的 @dblink），不在 allowed 中即命中
+func crossDatabaseWrite(st StmtInfo, v []itok, allowed []string, opt Options) []policyHit {
+	switch st.Type {
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE", "UPSERT":
+	default:
+		return nil
+	}
+	i := topLevelIndex(v, st.Type)
+	if i < 0 {
+		return nil
+	}
+skip:
+	for i++; i < len(v); i++ {
+		switch v[i].up {
+		case "INTO", "FROM", "IGNORE", "LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY", "QUICK", "ONLY":
+		case "TOP":
+			if i+1 < len(v) && v[i+1].text == "(" {
+				i = closeParenTok(v, i+1)
+			}
+			if i+1 < len(v) && v[i+1].up == "PERCENT" {
+				i++
+			}
+		default:
+			break skip
+		}
+	}
+	parts, link, end, ok := qualifiedName(v, i)
+	if !ok {
+		return nil
+	}
+	db := ""
+	switch {
+	case link != "":
+		db = link
+	case opt.Dialect == MySQL && len(parts) >= 2:
+		db = parts[0]
+	case opt.Dialect != MySQL && opt.Dialect != Oracle && len(parts) >= 3:
+		db = parts[len(parts)-3]
+		if len(parts) >= 4 {
+			db = parts[0] + "." + db // 链接服务器
+		}
+	}
+	db = unquoteIdent(db)
+	if db == "" || containsFold(allowed, db) {
+		return nil
+	}
+	where := "in database " + db
+	if link != "" {
+		where = "over database link " + db
+	}
+	return []policyHit{{
+		start: v[i].start, end: v[end].end,
+		msg: fmt.Sprintf("%s writes to %s %s", st.Type, unquoteIdent(parts[len(parts)-1]), where),
+	}}
+}
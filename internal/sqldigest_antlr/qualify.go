@@ -0,0 +1,238 @@
+package sqldigest_antlr
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 列限定：未限定的列引用补上所属关系的别名（没有别名时为表名最后一段），SELECT * / t.* 在各关系的列
+// 都已知时展开成列清单。解析沿用血缘的作用域（别名、派生表、CTE、关联子查询）；有 Options.Catalog 时
+// 基表的列来自目录，同一遍解析也给出 schema lint 规则要的未知表、未知列与歧义列。
+
+// 引用种类
+const (
+	refTable = iota
+	refColumn
+	refStar
+)
+
+// 解析状态
+const (
+	refUnresolved = iota // 限定名不在作用域里，或候选关系的列未知
+	refResolved
+	refUnknown   // 目录里没有的表；列都已知的关系里都没有的列
+	refAmbiguous // 多个关系都有这一列
+)
+
+// schemaRef 一条语句里的一处表 / 列 / * 引用
+type schemaRef struct {
+	start, end int // token 下标（含）
+	kind       int
+	status     int
+	qual       string // 列所属关系的限定前缀原文
+	qualified  bool   // 原文已带限定
+	noQualify  bool   // 写入的目标列（SET a = / INSERT (a)），不补限定
+	expand     string // * 的展开结果；不能展开为空
+}
+
+func (lx *lineageCtx) record(r schemaRef) {
+	if lx.rec != nil {
+		lx.rec[r.start] = r // 递归 CTE 会解析两遍，以后一遍为准
+	}
+}
+
+// ref 解析 toks[i..end] 处的列引用并记录
+func (lx *lineageCtx) ref(i, end int, sc *lineageScope, parts []string, col string) colSet {
+	src, r, status := lx.resolve(sc, parts[:len(parts)-1], col)
+	if lx.rec != nil {
+		ref := schemaRef{start: i, end: end, kind: refColumn, status: status, qualified: len(parts) > 1}
+		if r != nil {
+			ref.qual = r.qual
+		}
+		lx.record(ref)
+	}
+	return src
+}
+
+// recordStar 记录 toks[i..end] 处的 * / t.*；rels 的列都已知、都有限定前缀且没有 USING / NATURAL 合并时才展开
+func (lx *lineageCtx) recordStar(i, end int, sc *lineageScope, rels []*relation) {
+	ref := schemaRef{start: i, end: end, kind: refStar, status: refResolved}
+	var items []string
+	ok := len(rels) > 0 && sc.using == nil && !sc.natural && !sc.opaque
+	for _, r := range rels {
+		if !r.known || r.qual == "" {
+			ok = false
+			break
+		}
+		for _, c := range r.cols {
+			items = append(items, r.qual+"."+quoteIdent(c.name, lx.dialect))
+		}
+	}
+	if ok {
+		ref.expand = strings.Join(items, ", ")
+	}
+	lx.record(ref)
+}
+
+// targetCol 记录写入的目标列 toks[i..end]（只检查是否存在，不补限定）
+func (lx *lineageCtx) targetCol(i, end int, r *relation) {
+	if lx.rec == nil || r == nil || !r.known {
+		return
+	}
+	status := refResolved
+	if _, ok := r.lookup(unquoteIdent(lx.toks[end].Text)); !ok {
+		status = refUnknown
+	}
+	lx.record(schemaRef{start: i, end: end, kind: refColumn, status: status, noQualify: true})
+}
+
+// targetCols 记录 (a, b, ...) 里的目标列
+func (lx *lineageCtx) targetCols(open int, r *relation) {
+	if lx.rec == nil {
+		return
+	}
+	cl := closeParen(lx.toks, open)
+	for _, it := range lx.items(open+1, cl, lx.toks[open].Depth+1) {
+		if _, _, end, ok := qualifiedName(lx.v, it[0]); ok {
+			lx.targetCol(it[0], end, r)
+		}
+	}
+}
+
+// clauses：SELECT 列表之后的 WHERE / GROUP BY / HAVING / ORDER BY 等（只在记录引用时）；
+// GROUP BY / HAVING / ORDER BY 里未限定的名字可以是输出列的别名
+func (lx *lineageCtx) clauses(lo, hi, depth int, sc *lineageScope, cs cteCols, cols []lineageCol) {
+	saved := lx.aliases
+	defer func() { lx.aliases = saved }()
+	for k := lo; k < hi; {
+		next := lx.find(k+1, hi, depth, func(u string) bool { return lineageClause[u] })
+		lx.aliases = nil
+		switch lx.up(k) {
+		case "WHERE", "CONNECT", "START", "QUALIFY":
+			lx.exprSources(k+1, next, sc, cs)
+		case "GROUP", "HAVING", "ORDER":
+			lx.aliases = map[string]bool{}
+			for _, c := range cols {
+				lx.aliases[strings.ToUpper(c.name)] = true
+			}
+			lx.exprSources(k+1, next, sc, cs)
+		}
+		k = next
+	}
+}
+
+// tail：UPDATE / DELETE 的 WHERE、ORDER BY 与 RETURNING（只在记录引用时）
+func (lx *lineageCtx) tail(lo, hi int, sc *lineageScope, cs cteCols) {
+	for k := lo; k < hi; {
+		next := lx.find(k+1, hi, lx.base, is("WHERE", "RETURNING", "OUTPUT", "ORDER", "LIMIT", "OPTION"))
+		switch lx.up(k) {
+		case "WHERE", "ORDER":
+			lx.exprSources(k+1, next, sc, cs)
+		case "RETURNING":
+			lx.selectList(k+1, lx.find(k+1, next, lx.base, is("INTO")), lx.base, sc, cs) // Oracle RETURNING ... INTO :v
+		}
+		k = next
+	}
+}
+
+// delete：DELETE [FROM] t [alias] [USING ... | FROM ...] [WHERE ...] [RETURNING ...]（只在记录引用时）
+func (lx *lineageCtx) delete(i int, cs cteCols) {
+	n := len(lx.toks)
+	k := i + 1
+	for {
+		switch lx.up(k) {
+		case "LOW_PRIORITY", "QUICK", "IGNORE", "FROM", "ONLY":
+			k++
+			continue
+		case "TOP":
+			k++
+			if lx.up(k) == "(" {
+				k = closeParen(lx.toks, k)
+			}
+			k++
+			continue
+		}
+		break
+	}
+	end := lx.find(k, n, lx.base, is("WHERE", "RETURNING", "OUTPUT", "ORDER", "LIMIT", "OPTION"))
+	sc := &lineageScope{}
+	switch f := lx.find(k, end, lx.base, is("USING", "FROM")); {
+	case f < end && lx.up(f) == "USING" && lx.dialect == Postgres:
+		lx.fromList(k, f, lx.base, cs, sc)
+		lx.fromList(f+1, end, lx.base, cs, sc)
+	case f < end:
+		// MySQL 多表删除、SQL Server 的 DELETE x FROM t x：前面是别名，表在后面
+		lx.fromList(f+1, end, lx.base, cs, sc)
+	default:
+		lx.fromList(k, end, lx.base, cs, sc)
+	}
+	lx.tail(end, n, sc, cs)
+}
+
+// schemaRefs 解析一条语句（不含结尾 ;）的引用，按位置排序
+func schemaRefs(st []LintToken, opt Options) []schemaRef {
+	if len(st) == 0 {
+		return nil
+	}
+	lx := newLineageCtx(st, opt)
+	lx.rec = map[int]schemaRef{}
+	lx.statement()
+	out := make([]schemaRef, 0, len(lx.rec))
+	for _, r := range lx.rec {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].start < out[j].start })
+	return out
+}
+
+// Qualify 返回改写后的 sql：未限定的列补上表别名 / 表名，* 与 t.* 展开成列；其余文本（含注释、空白）不变。
+// 无法确定归属的列（有歧义、或候选表的列未知）与不能展开的 * 保持原样
+func Qualify(sql string, opt Options) (string, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	toks, _, err := lexTokens(sql, opt)
+	if err != nil {
+		return "", err
+	}
+	stmts := SplitStatements(sql, toks, opt)
+	all := lintTokens(sql, toks, opt)
+	if opt.Dialect == Postgres {
+		all, _ = mergeDollarQuoted(sql, all, nil)
+	}
+	var b strings.Builder
+	last := 0
+	for _, st := range statementTokens(all, stmts) {
+		for _, r := range schemaRefs(st, opt) {
+			switch {
+			case r.kind == refColumn && r.status == refResolved && !r.qualified && !r.noQualify && r.qual != "":
+				b.WriteString(sql[last:st[r.start].Start])
+				b.WriteString(r.qual + ".")
+				last = st[r.start].Start
+			case r.kind == refStar && r.expand != "":
+				b.WriteString(sql[last:st[r.start].Start])
+				b.WriteString(r.expand)
+				last = st[r.end].End
+			}
+		}
+	}
+	b.WriteString(sql[last:])
+	return b.String(), nil
+}
+
+var rePlainIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// quoteIdent 需要时按方言给标识符加引号（非常规字符或关键字）
+func quoteIdent(name string, d Dialect) string {
+	if rePlainIdent.MatchString(name) && !formatKeywords[strings.ToUpper(name)] {
+		return name
+	}
+	switch d {
+	case MySQL:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case SQLServer:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
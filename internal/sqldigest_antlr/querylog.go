@@ -0,0 +1,199 @@
+package sqldigest_antlr
+
+import (
+	"bufio"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogEntry 从数据库查询日志中解析出的一条语句
+type LogEntry struct {
+	Time         time.Time     `json:"time"`
+	User         string        `json:"user,omitempty"`
+	Host         string        `json:"host,omitempty"`
+	DB           string        `json:"db,omitempty"`
+	ThreadID     int64         `json:"thread_id,omitempty"`
+	QueryTime    time.Duration `json:"query_time"`
+	LockTime     time.Duration `json:"lock_time,omitempty"`
+	RowsSent     int64         `json:"rows_sent"`
+	RowsExamined int64         `json:"rows_examined"`
+	SQL          string        `json:"sql"`
+	// 绑定参数值（序号 → SQL 字面量原文，如 "'42'"、"NULL"），来自 PG 的 DETAIL: parameters
+	BindValues map[int]string `json:"bind_values,omitempty"`
+	// 命名绑定的值（小写参数名，不含 @/: → 字面量原文），来自 sp_executesql 的外层实参
+	NamedBindValues map[string]string `json:"named_bind_values,omitempty"`
+}
+
+// Result 计算条目的 digest，并把 BindValues / NamedBindValues 挂到对应的绑定 ExParam 上
+func (e LogEntry) Result(opt Options) (Result, error) {
+	res, err := BuildDigestANTLR(e.SQL, opt)
+	if err != nil {
+		return res, err
+	}
+	AttachBindValues(res.Params, e.BindValues)
+	AttachNamedBindValues(res.Params, e.NamedBindValues)
+	return res, nil
+}
+
+// AttachBindValues 按 BindOrdinal 填充绑定占位符的 BoundValue
+func AttachBindValues(params []ExParam, values map[int]string) {
+	if len(values) == 0 {
+		return
+	}
+	for i := range params {
+		if params[i].BindOrdinal == 0 {
+			continue
+		}
+		if v, ok := values[params[i].BindOrdinal]; ok {
+			params[i].BoundValue = v
+		}
+	}
+}
+
+// AttachNamedBindValues 按 BindName（不区分大小写）填充命名绑定的 BoundValue
+func AttachNamedBindValues(params []ExParam, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	for i := range params {
+		if params[i].BindName == "" {
+			continue
+		}
+		if v, ok := values[strings.ToLower(params[i].BindName)]; ok {
+			params[i].BoundValue = v
+		}
+	}
+}
+
+// DigestStats 按 digest 聚合的统计
+type DigestStats struct {
+	Digest       string        `json:"digest"`
+	SQLType      []string      `json:"sql_type,omitempty"`
+	Count        int64         `json:"count"`
+	TotalTime    time.Duration `json:"total_time"`
+	AvgTime      time.Duration `json:"avg_time"`
+	P95Time      time.Duration `json:"p95_time"`
+	MaxTime      time.Duration `json:"max_time"`
+	LockTime     time.Duration `json:"lock_time"`
+	RowsSent     int64         `json:"rows_sent"`
+	RowsExamined int64         `json:"rows_examined"`
+	FirstSeen    time.Time     `json:"first_seen"`
+	LastSeen     time.Time     `json:"last_seen"`
+	Sample       string        `json:"sample"` // 最慢一次的原文，字面量已脱敏
+}
+
+// LogAggregator 把日志条目按 digest 归并（类似 pt-query-digest）。
+// 不是并发安全的：多个 goroutine 同时 Add / Results 需要调用方加锁。
+type LogAggregator struct {
+	opt Options
+	m   map[string]*digestAcc
+	rnd *rand.Rand
+}
+
+// p95Reservoir 每个 digest 最多保留的耗时样本数；次数不超过它时 P95 是精确值，之后为均匀抽样的估计
+const p95Reservoir = 1024
+
+type digestAcc struct {
+	DigestStats
+	times      []time.Duration // 蓄水池抽样（Algorithm R）
+	sample     string
+	sampleTime time.Duration
+}
+
+func NewLogAggregator(opt Options) *LogAggregator {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	// 固定种子：同一份日志得到同样的结果
+	return &LogAggregator{opt: opt, m: map[string]*digestAcc{}, rnd: rand.New(rand.NewPCG(1, 2))}
+}
+
+// Add 计算条目的 digest 并累加
+func (a *LogAggregator) Add(e LogEntry) error {
+	res, err := BuildDigestANTLR(e.SQL, a.opt)
+	if err != nil {
+		return err
+	}
+	acc := a.m[res.Digest]
+	if acc == nil {
+		acc = &digestAcc{DigestStats: DigestStats{Digest: res.Digest, SQLType: res.SQLType}, sampleTime: -1}
+		a.m[res.Digest] = acc
+	}
+	acc.Count++
+	acc.TotalTime += e.QueryTime
+	acc.LockTime += e.LockTime
+	acc.RowsSent += e.RowsSent
+	acc.RowsExamined += e.RowsExamined
+	if len(acc.times) < p95Reservoir {
+		acc.times = append(acc.times, e.QueryTime)
+	} else if k := a.rnd.Int64N(acc.Count); k < p95Reservoir {
+		acc.times[k] = e.QueryTime
+	}
+	if e.QueryTime > acc.MaxTime {
+		acc.MaxTime = e.QueryTime
+	}
+	if !e.Time.IsZero() {
+		if acc.FirstSeen.IsZero() || e.Time.Before(acc.FirstSeen) {
+			acc.FirstSeen = e.Time
+		}
+		if e.Time.After(acc.LastSeen) {
+			acc.LastSeen = e.Time
+		}
+	}
+	if e.QueryTime > acc.sampleTime {
+		acc.sample, acc.sampleTime = e.SQL, e.QueryTime
+	}
+	return nil
+}
+
+// Results 返回聚合结果，按总耗时、次数降序
+func (a *LogAggregator) Results() []DigestStats {
+	out := make([]DigestStats, 0, len(a.m))
+	for _, acc := range a.m {
+		st := acc.DigestStats
+		st.AvgTime = st.TotalTime / time.Duration(st.Count)
+		st.P95Time = percentile(acc.times, 0.95)
+		st.Sample = st.Digest
+		if s, _, err := Redact(acc.sample, RedactOptions{Options: a.opt}); err == nil {
+			st.Sample = s
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TotalTime != out[j].TotalTime {
+			return out[i].TotalTime > out[j].TotalTime
+		}
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Digest < out[j].Digest
+	})
+	return out
+}
+
+// percentile 最近秩法
+func percentile(ds []time.Duration, p float64) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	s := append([]time.Duration(nil), ds...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	k := int(p*float64(len(s))+0.999999) - 1
+	if k < 0 {
+		k = 0
+	}
+	if k >= len(s) {
+		k = len(s) - 1
+	}
+	return s[k]
+}
+
+// newLogScanner 按行读取日志，允许超长的单行语句
+func newLogScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	return sc
+}
@@ -0,0 +1,79 @@
+package sqldigest_antlr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// RedactMode 字面量替换方式
+type RedactMode int
+
+const (
+	RedactMask    RedactMode = iota // 固定掩码（默认 "?"）
+	RedactTypeTag                   // 类型标签：<String> / <Number> / <Date> ...
+	RedactHMAC                      // 带密钥的 HMAC-SHA256（hmac:前 16 位十六进制），同值同码，便于关联
+)
+
+// RedactOptions 控制 Redact 行为
+type RedactOptions struct {
+	Options
+	Mode    RedactMode
+	Mask    string               // RedactMask 使用的掩码；空则为 "?"
+	HMACKey []byte               // RedactHMAC 必填
+	Replace func(ExParam) string // 自定义替换；非 nil 时优先于 Mode
+}
+
+// ErrHMACKeyRequired RedactHMAC 未提供密钥
+var ErrHMACKeyRequired = errors.New("redact: HMAC mode requires HMACKey")
+
+// Redact 只把字面量（字符串/数字/日期等）按 ExParam 的字节区间原位替换，
+// 其余内容（大小写、注释、空白、绑定占位符，以及 MySQL 之外的双引号标识符）逐字节保留。
+func Redact(sql string, ro RedactOptions) (string, []ExParam, error) {
+	if ro.Mode == RedactHMAC && ro.Replace == nil && len(ro.HMACKey) == 0 {
+		return "", nil, ErrHMACKeyRequired
+	}
+	opt := ro.Options
+	// 函数整体参数化会把内部字面量吞进 Func 参数，脱敏时必须逐个字面量抽取
+	opt.ParamizeTimeFuncs = false
+	res, err := BuildDigestANTLR(sql, opt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(sql))
+	last := 0
+	var redacted []ExParam
+	for _, p := range res.Params {
+		if !p.isLiteral() || quotedIdent(p.Value, opt.Dialect) || p.Start < last {
+			continue
+		}
+		sb.WriteString(sql[last:p.Start])
+		sb.WriteString(redactOne(p, ro))
+		last = p.End
+		redacted = append(redacted, p)
+	}
+	sb.WriteString(sql[last:])
+	return sb.String(), redacted, nil
+}
+
+func redactOne(p ExParam, ro RedactOptions) string {
+	if ro.Replace != nil {
+		return ro.Replace(p)
+	}
+	switch ro.Mode {
+	case RedactTypeTag:
+		return "<" + p.Type + ">"
+	case RedactHMAC:
+		m := hmac.New(sha256.New, ro.HMACKey)
+		m.Write([]byte(p.Decoded))
+		return "hmac:" + hex.EncodeToString(m.Sum(nil))[:16]
+	}
+	if ro.Mask != "" {
+		return ro.Mask
+	}
+	return "?"
+}
@@ -0,0 +1,308 @@
+package sqldigest_antlr
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RenderDigest 把抽出的参数按顺序填回 digest 的 "?" 占位（逆操作）。
+// 参数使用原文 Value（已经是方言正确的字面量写法）；绑定参数填回原占位符。
+// digest 里的词已统一大写、原始大小写已丢失：关键字保持大写，其余未加引号的词
+// 按方言折叠（Oracle 大写，其它小写），与服务端对未加引号标识符的处理一致。
+// 注意：CollapseValuesInDigest 折叠后的 digest 占位数少于参数数，无法还原。
+func RenderDigest(digest string, params []ExParam, dialect Dialect) (string, error) {
+	holes := digestPlaceholders(digest, dialect)
+	if len(holes) != len(params) {
+		return "", fmt.Errorf("digest has %d placeholders but %d params (collapsed VALUES cannot be re-inflated)", len(holes), len(params))
+	}
+	if dialect != Oracle {
+		digest = foldDigestWords(digest, dialect)
+	}
+	ordered := make([]ExParam, len(params))
+	copy(ordered, params)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Index < ordered[j].Index })
+
+	var sb strings.Builder
+	sb.Grow(len(digest) + len(params)*8)
+	last := 0
+	for k, pos := range holes {
+		sb.WriteString(digest[last:pos])
+		sb.WriteString(ordered[k].Value)
+		last = pos + 1
+	}
+	sb.WriteString(digest[last:])
+	return sb.String(), nil
+}
+
+// foldDigestWords 把引号外、非关键字的词转成小写（只改 ASCII 字母，字节位置不变）
+func foldDigestWords(digest string, dialect Dialect) string {
+	b := []byte(digest)
+	var quote byte
+	for i := 0; i < len(b); {
+		c := b[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			i++
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[' && dialect == SQLServer:
+			quote = ']'
+		case isWordByte(c) && (i == 0 || !isWordByte(b[i-1])):
+			j := i
+			for j < len(b) && isWordByte(b[j]) {
+				j++
+			}
+			// 限定名里的部分（a.SELECT）一定是标识符
+			qualified := (i > 0 && b[i-1] == '.') || (j < len(b) && b[j] == '.')
+			if qualified || !formatKeywords[string(b[i:j])] {
+				for k := i; k < j; k++ {
+					if b[k] >= 'A' && b[k] <= 'Z' {
+						b[k] += 'a' - 'A'
+					}
+				}
+			}
+			i = j
+			continue
+		}
+		i++
+	}
+	return string(b)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c == '#' || c == '@' || c >= 0x80 ||
+		(c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
+
+// digestPlaceholders 返回 digest 中 "?" 占位的字节位置（跳过引号标识符里的 ?；[..] 仅 SQL Server 视为标识符）
+func digestPlaceholders(digest string, dialect Dialect) []int {
+	var out []int
+	var quote byte
+	for i := 0; i < len(digest); i++ {
+		c := digest[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '[':
+			if dialect == SQLServer {
+				quote = ']'
+			}
+		case '?':
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// BindValues 把 Go 值代入 SQL 中的绑定占位符（?、$n、:name、:1、@name），按方言转义输出可执行 SQL。
+// 值按 BindOrdinal 对应 values[ord-1]；values 中的 sql.NamedArg 按名字（大小写不敏感）匹配命名绑定。
+func BindValues(sqlText string, values []any, opt Options) (string, error) {
+	opt.NormalizeBinds = true
+	res, err := BuildDigestANTLR(sqlText, opt)
+	if err != nil {
+		return "", err
+	}
+	named := map[string]any{}
+	var positional []any
+	for _, v := range values {
+		if na, ok := v.(sql.NamedArg); ok {
+			named[strings.ToLower(na.Name)] = na.Value
+			continue
+		}
+		positional = append(positional, v)
+	}
+
+	type repl struct {
+		s, e int
+		text string
+	}
+	var reps []repl
+	usedNamed := map[string]bool{}
+	maxOrdinal := 0
+	for _, p := range res.Params {
+		if p.Type != "Bind" && p.Type != "NamedBind" {
+			continue
+		}
+		// MySQL 的 @name 是用户变量，不是绑定
+		if opt.Dialect == MySQL && strings.HasPrefix(p.Value, "@") {
+			continue
+		}
+		var v any
+		if nv, ok := named[strings.ToLower(p.BindName)]; ok && p.BindName != "" {
+			v = nv
+			usedNamed[strings.ToLower(p.BindName)] = true
+		} else if p.BindOrdinal >= 1 && p.BindOrdinal <= len(positional) {
+			v = positional[p.BindOrdinal-1]
+			maxOrdinal = max(maxOrdinal, p.BindOrdinal)
+		} else {
+			return "", fmt.Errorf("no value for bind %s (ordinal %d, %d values)", p.Value, p.BindOrdinal, len(positional))
+		}
+		lit, err := FormatValue(v, opt)
+		if err != nil {
+			return "", fmt.Errorf("bind %s: %w", p.Value, err)
+		}
+		reps = append(reps, repl{p.Start, p.End, lit})
+	}
+	// 多余的值多半是参数错位，不静默丢弃
+	if len(positional) > maxOrdinal {
+		return "", fmt.Errorf("%d values but only %d bind placeholders use them", len(positional), maxOrdinal)
+	}
+	for name := range named {
+		if !usedNamed[name] {
+			return "", fmt.Errorf("no bind placeholder named %q", name)
+		}
+	}
+	sort.Slice(reps, func(i, j int) bool { return reps[i].s < reps[j].s })
+
+	var sb strings.Builder
+	sb.Grow(len(sqlText))
+	last := 0
+	for _, r := range reps {
+		sb.WriteString(sqlText[last:r.s])
+		sb.WriteString(r.text)
+		last = r.e
+	}
+	sb.WriteString(sqlText[last:])
+	return sb.String(), nil
+}
+
+// FormatValue 把一个 Go 值写成方言正确的 SQL 字面量
+func FormatValue(v any, opt Options) (string, error) {
+	if vr, ok := v.(driver.Valuer); ok {
+		dv, err := vr.Value()
+		if err != nil {
+			return "", err
+		}
+		v = dv
+	}
+	switch x := v.(type) {
+	case nil:
+		return "NULL", nil
+	case ExParam:
+		return x.Value, nil
+	case bool:
+		switch opt.Dialect {
+		case SQLServer, Oracle:
+			if x {
+				return "1", nil
+			}
+			return "0", nil
+		}
+		if x {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int:
+		return strconv.FormatInt(int64(x), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(x), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(x), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(x), 10), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(x), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(x), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(x), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(x), 10), nil
+	case uint64:
+		return strconv.FormatUint(x, 10), nil
+	case float32:
+		return formatFloat(float64(x), 32, opt)
+	case float64:
+		return formatFloat(x, 64, opt)
+	case string:
+		return QuoteString(x, opt)
+	case []byte:
+		return formatBytes(x, opt), nil
+	case time.Time:
+		return formatTime(x, opt), nil
+	case fmt.Stringer:
+		return QuoteString(x.String(), opt)
+	}
+	return "", fmt.Errorf("unsupported bind value type %T", v)
+}
+
+func formatFloat(f float64, bits int, opt Options) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		if opt.Dialect != Postgres {
+			return "", fmt.Errorf("non-finite float %v has no %s literal", f, opt.Dialect)
+		}
+		s := "NaN"
+		if math.IsInf(f, 1) {
+			s = "Infinity"
+		} else if math.IsInf(f, -1) {
+			s = "-Infinity"
+		}
+		return "'" + s + "'::float8", nil
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits), nil
+}
+
+// QuoteString 按方言把字符串写成字面量：
+// MySQL 双写单引号并转义反斜杠（NoBackslashEscapes 时不转义）；PG/Oracle 双写单引号；SQL Server 用 N'...'。
+func QuoteString(s string, opt Options) (string, error) {
+	if strings.IndexByte(s, 0) >= 0 && opt.Dialect == Postgres {
+		return "", fmt.Errorf("postgres text cannot contain NUL bytes")
+	}
+	body := strings.ReplaceAll(s, "'", "''")
+	switch opt.Dialect {
+	case MySQL:
+		if !opt.NoBackslashEscapes {
+			body = strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", "''")
+		}
+		return "'" + body + "'", nil
+	case SQLServer:
+		return "N'" + body + "'", nil
+	}
+	return "'" + body + "'", nil
+}
+
+func formatBytes(b []byte, opt Options) string {
+	h := hex.EncodeToString(b)
+	switch opt.Dialect {
+	case Postgres:
+		return `'\x` + h + `'::bytea`
+	case SQLServer:
+		return "0x" + strings.ToUpper(h)
+	case Oracle:
+		return "HEXTORAW('" + strings.ToUpper(h) + "')"
+	}
+	return "X'" + strings.ToUpper(h) + "'"
+}
+
+func formatTime(t time.Time, opt Options) string {
+	switch opt.Dialect {
+	case Postgres:
+		return "TIMESTAMPTZ '" + t.Format("2006-01-02 15:04:05.999999Z07:00") + "'"
+	case SQLServer:
+		return "'" + t.Format("2006-01-02T15:04:05.9999999Z07:00") + "'"
+	case Oracle:
+		return "TIMESTAMP '" + t.Format("2006-01-02 15:04:05.999999999 -07:00") + "'"
+	}
+	// MySQL DATETIME 不带时区：统一换算成 UTC 墙上时间（与 go-sql-driver/mysql 默认 loc=UTC 一致）
+	return "'" + t.UTC().Format("2006-01-02 15:04:05.999999") + "'"
+}
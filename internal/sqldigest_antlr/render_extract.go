@@ -279,7 +279,8 @@ func isTableColumnListContext(original string, toks []antlr.Token, idx int, comm
 }
 
 // 扫描任意 VALUES 段，若元组里出现绑定占位符（?/$n/:name/@p1）则返回 true
-func valuesSectionHasBind(toks []antlr.Token) bool {
+// splitBinds=true 时同时识别被拆开的 ":" + name 形式（见 splitBindEnd）
+func valuesSectionHasBind(toks []antlr.Token, splitBinds bool) bool {
 	inValues := false
 	depth := 0
 	for i := 0; i < len(toks); i++ {
@@ -315,6 +316,11 @@ func valuesSectionHasBind(toks []antlr.Token) bool {
 		if depth > 0 && isBind(txt) {
 			return true
 		}
+		if depth > 0 && splitBinds {
+			if _, ok := splitBindEnd(toks, i); ok {
+				return true
+			}
+		}
 	}
 	return false
 }
@@ -568,6 +574,18 @@ func classifyValueHead(original string, toks []antlr.Token, idx int, commentSpan
 
 // 主流程：把 token 流规范化渲染为 digest，并抽取参数
 func RenderAndExtract(original string, toks []antlr.Token, opt Options) (string, []ExParam) {
+	// pg_stat_statements 兼容：参数照常抽取，digest 换成扩展的规范化文本
+	if opt.PGStatStatements && opt.Dialect == Postgres {
+		opt.PGStatStatements = false
+		_, params := RenderAndExtract(original, toks, opt)
+		return pgssStatementText(original, toks, opt), params
+	}
+	// MySQL DIGEST_TEXT 兼容：同上
+	if opt.MySQLDigestText && opt.Dialect == MySQL {
+		opt.MySQLDigestText = false
+		_, params := RenderAndExtract(original, toks, opt)
+		return mysqlDigestText(original, toks), params
+	}
 	var out strings.Builder
 	var params []ExParam
 	iParam := 1
@@ -584,7 +602,7 @@ func RenderAndExtract(original string, toks []antlr.Token, opt Options) (string,
 	// INSERT…VALUES 折叠控制（硬门禁 + 形状一致性）
 	allowCollapseValues := false
 	if opt.CollapseValuesInDigest {
-		allowCollapseValues = !valuesSectionHasBind(toks) &&
+		allowCollapseValues = !valuesSectionHasBind(toks, opt.NormalizeBinds) &&
 			valuesFunctionsConsistent(original, toks, commentSpans, opt.ParamizeTimeFuncs)
 	}
 
@@ -885,6 +903,27 @@ func RenderAndExtract(original string, toks []antlr.Token, opt Options) (string,
 			}
 		}
 
+		// —— 被拆开的绑定占位符（:name / :1 / @p1）合并为一个参数 ——
+		if opt.NormalizeBinds {
+			if j, ok := splitBindEnd(toks, i); ok {
+				needSpaceBeforeWord()
+				startByte := RuneIndexToByte(original, t.GetStart())
+				endByte := RuneIndexToByte(original, toks[j].GetStop()+1)
+				params = append(params, ExParam{
+					Index: iParam, Type: classifyBind(original[startByte:endByte]),
+					Value: original[startByte:endByte],
+					Start: startByte, End: endByte,
+				})
+				iParam++
+				if !suppressOut {
+					out.WriteString("?")
+				}
+				i = j
+				prevWord = ""
+				continue
+			}
+		}
+
 		// 参数/字面量
 		switch {
 		case isBind(text):
@@ -1125,6 +1164,10 @@ func isStringLiteral(text string) bool {
 	if strings.HasPrefix(text, "$$") || strings.HasPrefix(text, "E'") || strings.HasPrefix(text, "e'") {
 		return true
 	}
+	// SQL Server / MySQL / Oracle: N'...'
+	if len(text) > 2 && (text[0] == 'N' || text[0] == 'n') && text[1] == '\'' {
+		return true
+	}
 	// Oracle: q'[...]'
 	if len(text) > 2 && (text[0] == 'q' || text[0] == 'Q') && text[1] == '\'' {
 		return true
@@ -0,0 +1,354 @@
+package sqldigest_antlr
+
+import (
+	"strings"
+)
+
+// Schema：按顺序应用 DDL 之后的表结构。表名查找与 Catalog 相同：不区分大小写，写出的限定段才比较。
+
+// Schema 一组表
+type Schema struct {
+	Dialect Dialect    `json:"dialect,omitempty"`
+	Version string     `json:"version,omitempty"` // 服务器版本（写法同 MigrationOptions.Version），DiffSchemas 据此选择语句写法；空为当前版本
+	Tables  []TableDef `json:"tables"`
+}
+
+// SchemaFromDDL 解析 sql 的 DDL 并依次应用到空 Schema 上
+func SchemaFromDDL(sql string, opt Options) (*Schema, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	stmts, err := ParseDDL(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{Dialect: opt.Dialect}
+	for _, st := range stmts {
+		s.Apply(st)
+	}
+	return s, nil
+}
+
+// FullName 限定名各段以 . 连接
+func (t *TableDef) FullName() string {
+	var parts []string
+	for _, p := range []string{t.Database, t.Schema, t.Name} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// Column 按名字查列，找不到返回 nil
+func (t *TableDef) Column(name string) *ColumnDef {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Index 按名字查索引，找不到返回 nil
+func (t *TableDef) Index(name string) *IndexDef {
+	for i := range t.Indexes {
+		if strings.EqualFold(t.Indexes[i].Name, name) {
+			return &t.Indexes[i]
+		}
+	}
+	return nil
+}
+
+// Table 按 "db.schema.t" / "schema.t" / "t" 查表（各段可带引号），找不到返回 nil
+func (s *Schema) Table(name string) *TableDef {
+	if i := s.tableIndex(splitQualified(name)); i >= 0 {
+		return &s.Tables[i]
+	}
+	return nil
+}
+
+// tableIndex 两边都写出的限定段才比较
+func (s *Schema) tableIndex(parts []string) int {
+	n := len(parts)
+	if n == 0 || n > 3 {
+		return -1
+	}
+	match := func(a, b string) bool { return a == "" || b == "" || strings.EqualFold(a, b) }
+	for i, t := range s.Tables {
+		if !strings.EqualFold(t.Name, parts[n-1]) {
+			continue
+		}
+		if n >= 2 && !match(t.Schema, parts[n-2]) || n == 3 && !match(t.Database, parts[0]) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// Apply 应用一条 DDL；引用的表不存在时忽略
+func (s *Schema) Apply(st DDLStatement) {
+	if st.Unsupported {
+		return
+	}
+	switch st.Kind {
+	case "CREATE TABLE":
+		t := cloneTable(*st.Table)
+		if i := s.tableIndex(splitQualified(st.Name)); i >= 0 {
+			if !st.IfNotExists {
+				s.Tables[i] = t
+			}
+			return
+		}
+		s.Tables = append(s.Tables, t)
+	case "ALTER TABLE":
+		if t := s.Table(st.Name); t != nil {
+			for _, a := range st.Actions {
+				s.alter(t, a)
+			}
+		}
+	case "CREATE INDEX":
+		if t := s.Table(st.Index.Table); t != nil {
+			t.addIndex(*st.Index)
+		}
+	case "DROP TABLE":
+		for _, n := range st.Names {
+			if i := s.tableIndex(splitQualified(n)); i >= 0 {
+				s.Tables = append(s.Tables[:i], s.Tables[i+1:]...)
+			}
+		}
+	case "DROP INDEX":
+		for _, n := range st.Names {
+			parts := splitQualified(n)
+			name := parts[len(parts)-1]
+			if st.Index != nil && st.Index.Table != "" {
+				if t := s.Table(st.Index.Table); t != nil {
+					t.dropIndex(name)
+				}
+				continue
+			}
+			for i := range s.Tables {
+				if s.Tables[i].dropIndex(name) {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (s *Schema) alter(t *TableDef, a AlterAction) {
+	col := t.Column(a.Name)
+	switch a.Kind {
+	case AlterAddColumn:
+		if col != nil {
+			*col = *a.Column
+		} else {
+			t.Columns = append(t.Columns, *a.Column)
+		}
+	case AlterDropColumn:
+		t.dropColumn(a.Name, s.Dialect)
+	case AlterModifyColumn:
+		if col == nil {
+			return
+		}
+		c := *a.Column
+		if s.Dialect == SQLServer {
+			// ALTER COLUMN 不改默认值约束与 IDENTITY
+			c.Default, c.DefaultName, c.AutoIncrement = col.Default, col.DefaultName, col.AutoIncrement
+		}
+		if !strings.EqualFold(c.Name, col.Name) {
+			t.renameColumn(col.Name, c.Name)
+		}
+		*t.Column(c.Name) = c
+	case AlterRenameColumn:
+		t.renameColumn(a.Name, a.NewName)
+	case AlterSetType:
+		if col != nil {
+			col.Type = a.Column.Type
+		}
+	case AlterSetDefault:
+		if col != nil {
+			col.Default, col.DefaultName = a.Column.Default, a.Column.DefaultName
+		}
+	case AlterDropDefault:
+		if col != nil {
+			col.Default, col.DefaultName = "", ""
+		}
+	case AlterSetNotNull, AlterDropNotNull:
+		if col != nil {
+			col.Nullable = a.Kind == AlterDropNotNull
+		}
+	case AlterAddConstraint:
+		c := *a.Constraint
+		c.Columns = append([]string(nil), c.Columns...)
+		if c.Kind == "PRIMARY KEY" {
+			t.PrimaryKey = append([]string(nil), c.Columns...)
+			for _, n := range c.Columns {
+				if col := t.Column(n); col != nil {
+					col.Nullable = false
+				}
+			}
+		}
+		t.Constraints = append(t.Constraints, c)
+	case AlterDropConstraint:
+		pk := a.Constraint != nil && a.Constraint.Kind == "PRIMARY KEY"
+		for i := 0; i < len(t.Constraints); i++ {
+			c := t.Constraints[i]
+			if pk && c.Kind == "PRIMARY KEY" || a.Name != "" && strings.EqualFold(c.Name, a.Name) {
+				if c.Kind == "PRIMARY KEY" {
+					t.PrimaryKey = nil
+				}
+				t.Constraints = append(t.Constraints[:i], t.Constraints[i+1:]...)
+				i--
+			}
+		}
+		if pk {
+			t.PrimaryKey = nil
+		}
+		for i := range t.Columns {
+			if c := &t.Columns[i]; a.Name != "" && strings.EqualFold(c.DefaultName, a.Name) {
+				c.Default, c.DefaultName = "", "" // SQL Server 默认值约束
+			}
+		}
+	case AlterAddIndex:
+		t.addIndex(*a.Index)
+	case AlterDropIndex:
+		t.dropIndex(a.Name)
+	case AlterRenameTable:
+		parts := splitQualified(a.NewName)
+		if len(parts) == 1 {
+			t.Name = parts[0]
+		} else {
+			t.Database, t.Schema = "", ""
+			setTableName(t, parts)
+		}
+	}
+}
+
+func (t *TableDef) addIndex(ix IndexDef) {
+	ix.Table = ""
+	ix.Columns = append([]string(nil), ix.Columns...)
+	if old := t.Index(ix.Name); old != nil && ix.Name != "" {
+		*old = ix
+		return
+	}
+	t.Indexes = append(t.Indexes, ix)
+}
+
+// dropIndex 删除索引；MySQL 的 UNIQUE 键也按索引名删除
+func (t *TableDef) dropIndex(name string) bool {
+	found := false
+	for i := 0; i < len(t.Indexes); i++ {
+		if strings.EqualFold(t.Indexes[i].Name, name) {
+			t.Indexes = append(t.Indexes[:i], t.Indexes[i+1:]...)
+			i--
+			found = true
+		}
+	}
+	for i := 0; i < len(t.Constraints); i++ {
+		if c := t.Constraints[i]; c.Kind == "UNIQUE" && strings.EqualFold(c.Name, name) {
+			t.Constraints = append(t.Constraints[:i], t.Constraints[i+1:]...)
+			i--
+			found = true
+		}
+	}
+	return found
+}
+
+// dropColumn 删除列与引用它的约束。MySQL 的索引只去掉这一列，没有列了才删除；
+// PG / Oracle 删除含该列的整个索引，SQL Server 拒绝执行（须先删索引），模型里同样整个删除
+func (t *TableDef) dropColumn(name string, d Dialect) {
+	has := func(cols []string) bool {
+		for _, c := range cols {
+			if strings.EqualFold(c, name) {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < len(t.Columns); i++ {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			t.Columns = append(t.Columns[:i], t.Columns[i+1:]...)
+			i--
+		}
+	}
+	if has(t.PrimaryKey) {
+		t.PrimaryKey = nil
+	}
+	for i := 0; i < len(t.Constraints); i++ {
+		if has(t.Constraints[i].Columns) {
+			t.Constraints = append(t.Constraints[:i], t.Constraints[i+1:]...)
+			i--
+		}
+	}
+	for i := 0; i < len(t.Indexes); i++ {
+		ix := &t.Indexes[i]
+		var cols []string
+		for _, c := range ix.Columns {
+			if !strings.EqualFold(c, name) {
+				cols = append(cols, c)
+			}
+		}
+		if len(cols) == len(ix.Columns) {
+			continue
+		}
+		ix.Columns = cols
+		if len(cols) == 0 || d != MySQL {
+			t.Indexes = append(t.Indexes[:i], t.Indexes[i+1:]...)
+			i--
+		}
+	}
+}
+
+// renameColumn 改列名，主键、约束与索引里的列名一起改
+func (t *TableDef) renameColumn(from, to string) {
+	rename := func(cols []string) {
+		for i, c := range cols {
+			if strings.EqualFold(c, from) {
+				cols[i] = to
+			}
+		}
+	}
+	if col := t.Column(from); col != nil {
+		col.Name = to
+	}
+	rename(t.PrimaryKey)
+	for i := range t.Constraints {
+		rename(t.Constraints[i].Columns)
+	}
+	for i := range t.Indexes {
+		rename(t.Indexes[i].Columns)
+	}
+}
+
+// cloneTable 复制切片，Apply 不改动传入的 DDLStatement
+func cloneTable(t TableDef) TableDef {
+	t.Columns = append([]ColumnDef(nil), t.Columns...)
+	t.PrimaryKey = append([]string(nil), t.PrimaryKey...)
+	t.Constraints = append([]ConstraintDef(nil), t.Constraints...)
+	for i := range t.Constraints {
+		t.Constraints[i].Columns = append([]string(nil), t.Constraints[i].Columns...)
+	}
+	t.Indexes = append([]IndexDef(nil), t.Indexes...)
+	for i := range t.Indexes {
+		t.Indexes[i].Columns = append([]string(nil), t.Indexes[i].Columns...)
+	}
+	return t
+}
+
+// Catalog 转成目录（列名与类型）；CREATE TABLE ... AS 的表列未知，不收
+func (s *Schema) Catalog() *Catalog {
+	c := &Catalog{}
+	for _, t := range s.Tables {
+		if t.AsQuery {
+			continue
+		}
+		ct := CatalogTable{Name: t.Name}
+		for _, col := range t.Columns {
+			ct.Columns = append(ct.Columns, CatalogColumn{Name: col.Name, Type: col.Type})
+		}
+		c.AddTable(t.Database, t.Schema, ct)
+	}
+	return c
+}
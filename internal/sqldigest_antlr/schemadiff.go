@@ -0,0 +1,607 @@
+package sqldigest_antlr
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Schema 差异：比较两个 Schema（如两个版本的 DDL），得到有序的变更列表，并按方言渲染成 ALTER 语句。
+// 变更顺序按能直接执行排：先删外键与其它约束 / 索引，再建表、加列、改列、删列，然后加约束 / 索引、加外键，最后删表
+// （被引用的表排在引用它的表之后）。
+// 列改名认不出来，按删列 + 加列处理。删表、删列、收窄类型（长度 / 精度变小、整数或浮点变窄、ENUM / SET 删改取值、换类型族）标 Destructive。
+
+// SchemaChange 一处差异
+type SchemaChange struct {
+	Kind        string         `json:"kind"` // CREATE TABLE / DROP TABLE / ADD COLUMN / DROP COLUMN / ALTER COLUMN / ADD CONSTRAINT / DROP CONSTRAINT / ADD INDEX / DROP INDEX
+	Table       string         `json:"table"`
+	Name        string         `json:"name,omitempty"`    // 列、约束或索引名
+	Changed     []string       `json:"changed,omitempty"` // ALTER COLUMN 变了的属性：type / nullable / default / auto_increment / generated / on_update / charset / collation / comment
+	From        *ColumnDef     `json:"from,omitempty"`    // ALTER COLUMN / DROP COLUMN 的旧定义
+	To          *ColumnDef     `json:"to,omitempty"`      // ALTER COLUMN / ADD COLUMN 的新定义
+	Def         *TableDef      `json:"def,omitempty"`     // CREATE TABLE
+	Constraint  *ConstraintDef `json:"constraint,omitempty"`
+	Index       *IndexDef      `json:"index,omitempty"`
+	Destructive bool           `json:"destructive,omitempty"`
+	Reason      string         `json:"reason,omitempty"` // 为什么会丢数据
+	SQL         []string       `json:"sql"`              // 按 DiffSchemas 选定的方言渲染；无法原地完成的给出 -- 注释
+}
+
+// SchemaChange.Kind
+const (
+	ChangeCreateTable    = "CREATE TABLE"
+	ChangeDropTable      = "DROP TABLE"
+	ChangeAddColumn      = "ADD COLUMN"
+	ChangeDropColumn     = "DROP COLUMN"
+	ChangeAlterColumn    = "ALTER COLUMN"
+	ChangeAddConstraint  = "ADD CONSTRAINT"
+	ChangeDropConstraint = "DROP CONSTRAINT"
+	ChangeAddIndex       = "ADD INDEX"
+	ChangeDropIndex      = "DROP INDEX"
+)
+
+// DiffSchemas 从 a 变到 b 所需的变更；SQL 按 b 的方言与版本渲染（b 没有用 a 的，方言都没有用 MySQL）。nil 当空 Schema
+func DiffSchemas(a, b *Schema) []SchemaChange {
+	if a == nil {
+		a = &Schema{}
+	}
+	if b == nil {
+		b = &Schema{}
+	}
+	d := b.Dialect
+	if d == "" {
+		d = a.Dialect
+	}
+	if d == "" {
+		d = MySQL
+	}
+	ver := b.Version
+	if ver == "" {
+		ver = a.Version
+	}
+
+	// 执行顺序的各段
+	var dropFK, dropOther, create, addCol, alterCol, dropCol, addOther, addFK, dropTable []SchemaChange
+	matched := make([]bool, len(a.Tables))
+	for i := range b.Tables {
+		tb := &b.Tables[i]
+		if tb.AsQuery {
+			continue
+		}
+		name := tb.FullName()
+		j := a.tableIndex(tableParts(tb))
+		if j < 0 || matched[j] {
+			def := cloneTable(*tb)
+			create = append(create, SchemaChange{Kind: ChangeCreateTable, Table: name, Def: &def})
+			for k := range tb.Constraints {
+				if c := tb.Constraints[k]; c.Kind == "FOREIGN KEY" {
+					addFK = append(addFK, SchemaChange{Kind: ChangeAddConstraint, Table: name, Name: c.Name, Constraint: &c})
+				}
+			}
+			for k := range tb.Indexes {
+				ix := tb.Indexes[k]
+				addOther = append(addOther, SchemaChange{Kind: ChangeAddIndex, Table: name, Name: ix.Name, Index: &ix})
+			}
+			continue
+		}
+		matched[j] = true
+		ta := &a.Tables[j]
+
+		for k := range tb.Columns {
+			cb := tb.Columns[k]
+			ca := ta.Column(cb.Name)
+			if ca == nil {
+				addCol = append(addCol, SchemaChange{Kind: ChangeAddColumn, Table: name, Name: cb.Name, To: &cb})
+				continue
+			}
+			if changed := columnChanges(*ca, cb); len(changed) > 0 {
+				from := *ca
+				c := SchemaChange{Kind: ChangeAlterColumn, Table: name, Name: cb.Name, Changed: changed, From: &from, To: &cb}
+				if from.Type != "" && cb.Type != "" {
+					c.Reason = narrowing(from.Type, cb.Type, d)
+					c.Destructive = c.Reason != ""
+				}
+				alterCol = append(alterCol, c)
+			}
+		}
+		for k := range ta.Columns {
+			if ca := ta.Columns[k]; tb.Column(ca.Name) == nil {
+				dropCol = append(dropCol, SchemaChange{Kind: ChangeDropColumn, Table: name, Name: ca.Name, From: &ca,
+					Destructive: true, Reason: "drops column " + ca.Name})
+			}
+		}
+
+		drops, adds := diffConstraints(ta.Constraints, tb.Constraints)
+		for k := range drops {
+			c := SchemaChange{Kind: ChangeDropConstraint, Table: name, Name: drops[k].Name, Constraint: &drops[k]}
+			if c.Constraint.Kind == "FOREIGN KEY" {
+				dropFK = append(dropFK, c)
+			} else {
+				dropOther = append(dropOther, c)
+			}
+		}
+		for k := range adds {
+			c := SchemaChange{Kind: ChangeAddConstraint, Table: name, Name: adds[k].Name, Constraint: &adds[k]}
+			if c.Constraint.Kind == "FOREIGN KEY" {
+				addFK = append(addFK, c)
+			} else {
+				addOther = append(addOther, c)
+			}
+		}
+		dropIx, addIx := diffIndexes(ta.Indexes, tb.Indexes)
+		for k := range dropIx {
+			dropOther = append(dropOther, SchemaChange{Kind: ChangeDropIndex, Table: name, Name: dropIx[k].Name, Index: &dropIx[k]})
+		}
+		for k := range addIx {
+			addOther = append(addOther, SchemaChange{Kind: ChangeAddIndex, Table: name, Name: addIx[k].Name, Index: &addIx[k]})
+		}
+	}
+	fks, order := dropOrder(a, matched)
+	dropFK = append(dropFK, fks...)
+	for _, j := range order {
+		name := a.Tables[j].FullName()
+		dropTable = append(dropTable, SchemaChange{Kind: ChangeDropTable, Table: name, Destructive: true, Reason: "drops table " + name})
+	}
+
+	var out []SchemaChange
+	for _, part := range [][]SchemaChange{dropFK, dropOther, create, addCol, alterCol, dropCol, addOther, addFK, dropTable} {
+		out = append(out, part...)
+	}
+	for i := range out {
+		out[i].SQL = out[i].RenderVersion(d, ver)
+	}
+	return out
+}
+
+// dropOrder 要删的表（a 中未匹配的）的删除顺序：引用别人的表先删，被引用的后删；
+// 互相引用成环时，先删环上指向下一张表的外键（作为 DROP CONSTRAINT 排进最前面）
+func dropOrder(a *Schema, matched []bool) (fks []SchemaChange, order []int) {
+	type ref struct {
+		from, to int
+		c        ConstraintDef
+	}
+	var refs []ref
+	left := map[int]bool{}
+	for j := range a.Tables {
+		if !matched[j] {
+			left[j] = true
+		}
+	}
+	for j := range left {
+		for _, c := range a.Tables[j].Constraints {
+			if c.Kind != "FOREIGN KEY" {
+				continue
+			}
+			if k := a.tableIndex(splitQualified(c.RefTable)); k >= 0 && k != j && left[k] {
+				refs = append(refs, ref{j, k, c})
+			}
+		}
+	}
+	slices.SortStableFunc(refs, func(x, y ref) int { return x.from - y.from })
+	referenced := func(k int) bool {
+		for _, r := range refs {
+			if r.to == k && left[r.from] {
+				return true
+			}
+		}
+		return false
+	}
+	for len(left) > 0 {
+		next := -1
+		for j := range a.Tables {
+			if left[j] && !referenced(j) {
+				next = j
+				break
+			}
+		}
+		if next < 0 {
+			// 成环：取第一张剩下的表，先删指向它的外键
+			for j := range a.Tables {
+				if left[j] {
+					next = j
+					break
+				}
+			}
+			for _, r := range refs {
+				if r.to == next && left[r.from] {
+					c := r.c
+					fks = append(fks, SchemaChange{Kind: ChangeDropConstraint, Table: a.Tables[r.from].FullName(), Name: c.Name, Constraint: &c})
+				}
+			}
+		}
+		order = append(order, next)
+		delete(left, next)
+	}
+	return fks, order
+}
+
+func tableParts(t *TableDef) []string {
+	var parts []string
+	for _, p := range []string{t.Database, t.Schema} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return append(parts, t.Name)
+}
+
+// columnChanges 列的哪些属性变了
+func columnChanges(a, b ColumnDef) []string {
+	var out []string
+	add := func(changed bool, what string) {
+		if changed {
+			out = append(out, what)
+		}
+	}
+	add(typeKey(a.Type) != typeKey(b.Type), "type")
+	add(a.Nullable != b.Nullable, "nullable")
+	add(defaultKey(a.Default) != defaultKey(b.Default), "default")
+	add(a.AutoIncrement != b.AutoIncrement, "auto_increment")
+	add(normSQL(a.Generated) != normSQL(b.Generated), "generated")
+	add(normSQL(a.OnUpdate) != normSQL(b.OnUpdate), "on_update")
+	add(!strings.EqualFold(a.Charset, b.Charset), "charset")
+	add(!strings.EqualFold(a.Collation, b.Collation), "collation")
+	add(a.Comment != b.Comment, "comment")
+	return out
+}
+
+func changed(c SchemaChange, what string) bool {
+	for _, w := range c.Changed {
+		if w == what {
+			return true
+		}
+	}
+	return false
+}
+
+var reSpaceAroundPunct = regexp.MustCompile(`\s*([(),.\[\]])\s*`)
+
+// normSQL 比较用：小写、合并空白、去掉括号逗号两侧的空白；带引号的原文只合并空白
+func normSQL(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = reSpaceAroundPunct.ReplaceAllString(s, "$1")
+	if strings.ContainsAny(s, `'"`) {
+		return s
+	}
+	return strings.ToLower(s)
+}
+
+// defaultKey 去掉 SQL Server 风格的外层括号 ((0))
+func defaultKey(s string) string {
+	s = strings.TrimSpace(s)
+	for len(s) >= 2 && s[0] == '(' && s[len(s)-1] == ')' && closeParenText(s) == len(s)-1 {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return normSQL(s)
+}
+
+// closeParenText s[0] 处左括号对应的右括号位置
+func closeParenText(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// 类型同义词，比较前统一
+var typeSynonyms = map[string]string{
+	"integer": "int", "int4": "int", "int2": "smallint", "int8": "bigint", "bool": "boolean",
+	"character varying": "varchar", "character": "char", "bpchar": "char", "decimal": "numeric", "dec": "numeric",
+	"float8": "double precision", "double": "double precision", "float4": "real",
+	"timestamptz": "timestamp with time zone", "timetz": "time with time zone",
+	"serial": "int", "serial4": "int", "bigserial": "bigint", "serial8": "bigint", "smallserial": "smallint", "serial2": "smallint",
+}
+
+type sqlType struct {
+	base     string   // 去掉参数的类型名，如 varchar、timestamp with time zone
+	args     []string // 括号里的参数
+	unsigned bool
+}
+
+func parseType(t string) sqlType {
+	t = normSQL(t)
+	var st sqlType
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		if j := strings.LastIndexByte(t, ')'); j > i {
+			for _, a := range strings.Split(t[i+1:j], ",") {
+				st.args = append(st.args, strings.TrimSpace(a))
+			}
+			t = strings.TrimSpace(t[:i] + " " + t[j+1:])
+		}
+	}
+	var words []string
+	// 带引号的类型（ENUM('a') / SET('a')）normSQL 整体不转小写，类型名与修饰词在这里统一小写；参数保留原文
+	for _, w := range strings.Fields(strings.ToLower(t)) {
+		switch w {
+		case "unsigned":
+			st.unsigned = true
+		case "signed", "zerofill":
+		default:
+			words = append(words, w)
+		}
+	}
+	st.base = strings.Join(words, " ")
+	if s, ok := typeSynonyms[st.base]; ok {
+		st.base = s
+	}
+	return st
+}
+
+func typeKey(t string) string {
+	st := parseType(t)
+	k := st.base
+	if len(st.args) > 0 {
+		k += "(" + strings.Join(st.args, ",") + ")"
+	}
+	if st.unsigned {
+		k += " unsigned"
+	}
+	return k
+}
+
+// 整数类型的宽度等级与十进制位数
+var intRank = map[string]int{"tinyint": 1, "smallint": 2, "mediumint": 3, "int": 4, "bigint": 5}
+var intDigits = []int{0, 3, 5, 8, 10, 19}
+
+// 定长 / 变长字符与二进制类型；大对象类型的容量
+var sizedText = toSet(`char varchar nchar nvarchar varchar2 nvarchar2 binary varbinary raw`)
+var lobSize = map[string]int{
+	"tinytext": 255, "tinyblob": 255, "blob": 65535, "mediumtext": 1 << 24, "mediumblob": 1 << 24,
+	"longtext": unbounded, "longblob": unbounded, "clob": unbounded, "nclob": unbounded, "ntext": unbounded,
+	"image": unbounded, "bytea": unbounded, "long": unbounded, "citext": unbounded,
+}
+
+const unbounded = 1 << 62
+
+// textSize 字符 / 二进制类型能放多长，不是这类类型返回 0
+func textSize(st sqlType, d Dialect) int {
+	if sizedText[st.base] {
+		if len(st.args) == 0 {
+			if st.base == "varchar" && d == Postgres {
+				return unbounded
+			}
+			return 1
+		}
+		return argInt(st.args[0])
+	}
+	if st.base == "text" {
+		if d == MySQL {
+			return 65535
+		}
+		return unbounded
+	}
+	return lobSize[st.base]
+}
+
+// argInt 参数的数值；MAX 当无限
+func argInt(a string) int {
+	f := strings.Fields(a)
+	if len(f) == 0 {
+		return 0
+	}
+	if f[0] == "max" {
+		return unbounded
+	}
+	n, _ := strconv.Atoi(f[0])
+	return n
+}
+
+// numericSize 定点数的精度与小数位；不写精度当无限
+func numericSize(st sqlType) (p, s int) {
+	if len(st.args) == 0 {
+		return unbounded, unbounded / 2
+	}
+	p = argInt(st.args[0])
+	if len(st.args) > 1 {
+		s = argInt(st.args[1])
+	}
+	return p, s
+}
+
+// narrowing 从 from 改成 to 会不会截断或丢数据，会则返回原因
+func narrowing(from, to string, d Dialect) string {
+	a, b := parseType(from), parseType(to)
+	if typeKey(from) == typeKey(to) {
+		return ""
+	}
+	narrows := "narrows " + from + " to " + to
+	converts := "converts " + from + " to " + to
+	ra, rb := intRank[a.base], intRank[b.base]
+	fa, fb := floatRank(a, d), floatRank(b, d)
+	isNum := func(st sqlType) bool { return st.base == "numeric" || st.base == "number" }
+	switch {
+	case ra > 0 && rb > 0:
+		if rb < ra || a.unsigned != b.unsigned {
+			return narrows
+		}
+		return ""
+	case fa > 0 && fb > 0:
+		if fb < fa {
+			return narrows
+		}
+		return ""
+	case ra > 0 && fb > 0:
+		return "" // 整数转浮点不会失败
+	case (a.base == "enum" || a.base == "set") && a.base == b.base:
+		// 只在末尾追加取值不改变已有值；删除、改名或调整顺序会让已有数据对不上
+		if len(b.args) >= len(a.args) && slices.Equal(a.args, b.args[:len(a.args)]) {
+			return ""
+		}
+		return narrows
+	case ra > 0 && isNum(b):
+		p, s := numericSize(b)
+		digits := intDigits[ra]
+		if a.unsigned && ra == 5 {
+			digits = 20
+		}
+		if p-s < digits {
+			return narrows
+		}
+		return ""
+	case isNum(a) && isNum(b):
+		pa, sa := numericSize(a)
+		pb, sb := numericSize(b)
+		if pb-sb < pa-sa || sb < sa {
+			return narrows
+		}
+		return ""
+	case textSize(a, d) > 0 && textSize(b, d) > 0:
+		if textSize(b, d) < textSize(a, d) {
+			return narrows
+		}
+		return ""
+	case textSize(b, d) >= 64:
+		return "" // 数字、日期等转成足够长的字符串
+	}
+	ta, tb := timeRank(a.base), timeRank(b.base)
+	if ta > 0 && tb > 0 {
+		if tb < ta || tb == ta && len(a.args) > 0 && len(b.args) > 0 && argInt(b.args[0]) < argInt(a.args[0]) {
+			return narrows
+		}
+		return ""
+	}
+	if a.base == b.base && len(b.args) == 0 {
+		return "" // 去掉参数一般是放宽，如 float(24) -> float
+	}
+	return converts
+}
+
+// floatRank 单精度 1、双精度 2，不是浮点类型为 0。
+// MySQL 的 FLOAT 是单精度、REAL 是双精度；PG / SQL Server 不带参数的 FLOAT 是双精度；FLOAT(n) 按 n ≤ 24 分
+func floatRank(st sqlType, d Dialect) int {
+	switch st.base {
+	case "real":
+		if d == MySQL {
+			return 2
+		}
+		return 1
+	case "binary_float":
+		return 1
+	case "double precision", "binary_double":
+		return 2
+	case "float":
+		if len(st.args) == 1 {
+			if argInt(st.args[0]) <= 24 {
+				return 1
+			}
+			return 2
+		}
+		if d == MySQL {
+			return 1
+		}
+		return 2
+	}
+	return 0
+}
+
+// timeRank date < 带时间的类型；带时区的最宽
+func timeRank(base string) int {
+	switch {
+	case base == "date":
+		return 1
+	case strings.HasPrefix(base, "datetime") || base == "smalldatetime" ||
+		base == "timestamp" || base == "timestamp without time zone":
+		return 2
+	case base == "datetimeoffset" || base == "timestamp with time zone" || base == "timestamp with local time zone":
+		return 3
+	}
+	return 0
+}
+
+// constraintKey 约束比较用的签名（不含名字）
+func constraintKey(c ConstraintDef) string {
+	return strings.Join([]string{c.Kind, normList(c.Columns), strings.ToLower(c.RefTable), normList(c.RefColumns),
+		strings.ToUpper(c.OnDelete), strings.ToUpper(c.OnUpdate), normSQL(c.Check)}, "|")
+}
+
+func indexKey(ix IndexDef) string {
+	return strings.Join([]string{strconv.FormatBool(ix.Unique), strings.ToUpper(ix.Kind), strings.ToLower(ix.Method),
+		normList(ix.Columns), normList(ix.Include), normSQL(ix.Where)}, "|")
+}
+
+func normList(list []string) string {
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = normSQL(s)
+	}
+	return strings.Join(out, ",")
+}
+
+// diffConstraints 同名的按签名比较，变了就先删后加；没名字的按签名配对
+func diffConstraints(a, b []ConstraintDef) (drops, adds []ConstraintDef) {
+	da, db := diffByKey(len(a), len(b),
+		func(i, j int) bool { return a[i].Name != "" && strings.EqualFold(a[i].Name, b[j].Name) },
+		func(i, j int) bool { return constraintKey(a[i]) == constraintKey(b[j]) })
+	for _, i := range da {
+		drops = append(drops, a[i])
+	}
+	for _, j := range db {
+		adds = append(adds, b[j])
+	}
+	return drops, adds
+}
+
+func diffIndexes(a, b []IndexDef) (drops, adds []IndexDef) {
+	da, db := diffByKey(len(a), len(b),
+		func(i, j int) bool { return a[i].Name != "" && strings.EqualFold(a[i].Name, b[j].Name) },
+		func(i, j int) bool { return indexKey(a[i]) == indexKey(b[j]) })
+	for _, i := range da {
+		drops = append(drops, a[i])
+	}
+	for _, j := range db {
+		adds = append(adds, b[j])
+	}
+	return drops, adds
+}
+
+// diffByKey 先按名字配对，再把剩下的按签名配对；返回 a 里要删的与 b 里要加的下标
+func diffByKey(na, nb int, sameName, sameKey func(i, j int) bool) (drops, adds []int) {
+	pair := make([]int, nb)
+	used := make([]bool, na)
+	for j := range pair {
+		pair[j] = -1
+		for i := 0; i < na; i++ {
+			if !used[i] && sameName(i, j) {
+				pair[j], used[i] = i, true
+				break
+			}
+		}
+	}
+	for j := range pair {
+		if pair[j] >= 0 {
+			continue
+		}
+		for i := 0; i < na; i++ {
+			if !used[i] && sameKey(i, j) {
+				pair[j], used[i] = i, true
+				break
+			}
+		}
+	}
+	for j, i := range pair {
+		if i >= 0 && !sameKey(i, j) {
+			drops, adds = append(drops, i), append(adds, j)
+		}
+	}
+	for i := 0; i < na; i++ {
+		if !used[i] {
+			drops = append(drops, i)
+		}
+	}
+	for j, i := range pair {
+		if i < 0 {
+			adds = append(adds, j)
+		}
+	}
+	return drops, adds
+}
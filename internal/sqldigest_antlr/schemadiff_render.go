@@ -0,0 +1,466 @@
+package sqldigest_antlr
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SchemaChange 按方言渲染：MySQL 改列用 MODIFY COLUMN 整列重写；PG 拆成 ALTER COLUMN TYPE / SET DEFAULT / SET NOT NULL；
+// SQL Server 用 ALTER COLUMN，默认值走默认值约束；Oracle 用 MODIFY (...)，只写变了的部分。
+// 不能原地完成的（如 SQL Server 改 IDENTITY）输出一行 -- 注释说明。
+
+// Render 把一处变更渲染成 d 方言当前版本的语句
+func (c SchemaChange) Render(d Dialect) []string { return c.RenderVersion(d, "") }
+
+// RenderVersion 按 d 方言的 version 版本渲染（写法同 MigrationOptions.Version）；空为当前版本。
+// 目前只影响 PG 17 之前不能用的 ALTER COLUMN ... SET EXPRESSION
+func (c SchemaChange) RenderVersion(d Dialect, version string) []string {
+	t := qname(c.Table, d)
+	switch c.Kind {
+	case ChangeCreateTable:
+		return createTableSQL(c.Def, d)
+	case ChangeDropTable:
+		return []string{"DROP TABLE " + t}
+	case ChangeAddColumn:
+		switch d {
+		case SQLServer:
+			return []string{"ALTER TABLE " + t + " ADD " + columnSQL(*c.To, d)}
+		case Oracle:
+			return []string{"ALTER TABLE " + t + " ADD (" + columnSQL(*c.To, d) + ")"}
+		}
+		return []string{"ALTER TABLE " + t + " ADD COLUMN " + columnSQL(*c.To, d)}
+	case ChangeDropColumn:
+		drop := "ALTER TABLE " + t + " DROP COLUMN " + quoteIdent(c.Name, d)
+		if d == SQLServer && c.From != nil && c.From.DefaultName != "" {
+			return []string{"ALTER TABLE " + t + " DROP CONSTRAINT " + quoteIdent(c.From.DefaultName, d), drop} // 先删默认值约束
+		}
+		return []string{drop}
+	case ChangeAlterColumn:
+		return alterColumnSQL(c, t, d, parseVersion(version, d))
+	case ChangeAddConstraint:
+		return []string{"ALTER TABLE " + t + " ADD " + constraintSQL(*c.Constraint, d)}
+	case ChangeDropConstraint:
+		return []string{dropConstraintSQL(c.Table, *c.Constraint, d)}
+	case ChangeAddIndex:
+		return []string{createIndexSQL(c.Table, *c.Index, d)}
+	case ChangeDropIndex:
+		ix := quoteIdent(c.Index.Name, d)
+		switch d {
+		case MySQL, SQLServer:
+			return []string{"DROP INDEX " + ix + " ON " + t}
+		}
+		if parts := splitQualified(c.Table); len(parts) > 1 {
+			ix = qname(strings.Join(parts[:len(parts)-1], "."), d) + "." + ix // PG / Oracle 的索引跟表在同一 schema
+		}
+		return []string{"DROP INDEX " + ix}
+	}
+	return nil
+}
+
+// qname 限定名各段按需加引号
+func qname(name string, d Dialect) string {
+	parts := splitQualified(name)
+	for i, p := range parts {
+		parts[i] = quoteIdent(p, d)
+	}
+	return strings.Join(parts, ".")
+}
+
+func qnames(names []string, d Dialect) string {
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = quoteIdent(n, d)
+	}
+	return strings.Join(out, ", ")
+}
+
+// columnSQL 列定义
+func columnSQL(c ColumnDef, d Dialect) string {
+	b := []string{quoteIdent(c.Name, d)}
+	add := func(s ...string) { b = append(b, s...) }
+	if c.Type != "" && !(d == SQLServer && c.Generated != "") {
+		add(c.Type)
+	}
+	switch d {
+	case MySQL:
+		if c.Charset != "" {
+			add("CHARACTER SET", c.Charset)
+		}
+		if c.Collation != "" {
+			add("COLLATE", c.Collation)
+		}
+		if c.Generated != "" {
+			add("AS (" + c.Generated + ")")
+		}
+		if !c.Nullable {
+			add("NOT NULL")
+		}
+		if c.Default != "" {
+			add("DEFAULT", c.Default)
+		}
+		if c.OnUpdate != "" {
+			add("ON UPDATE", c.OnUpdate)
+		}
+		if c.AutoIncrement {
+			add("AUTO_INCREMENT")
+		}
+		if c.Comment != "" {
+			add("COMMENT", c.Comment)
+		}
+	case Postgres:
+		if c.Collation != "" {
+			add(`COLLATE "` + c.Collation + `"`)
+		}
+		if c.Generated != "" {
+			add("GENERATED ALWAYS AS (" + c.Generated + ") STORED")
+		} else if c.AutoIncrement && !serialTypes[strings.ToUpper(c.Type)] {
+			add("GENERATED BY DEFAULT AS IDENTITY")
+		}
+		if c.Default != "" {
+			add("DEFAULT", c.Default)
+		}
+		if !c.Nullable {
+			add("NOT NULL")
+		}
+	case SQLServer:
+		if c.Generated != "" {
+			return strings.Join(append(b, "AS ("+c.Generated+")"), " ")
+		}
+		if c.AutoIncrement {
+			add("IDENTITY(1,1)")
+		}
+		if c.Collation != "" {
+			add("COLLATE", c.Collation)
+		}
+		if c.Default != "" {
+			if c.DefaultName != "" {
+				add("CONSTRAINT", quoteIdent(c.DefaultName, d))
+			}
+			add("DEFAULT", c.Default)
+		}
+		if c.Nullable {
+			add("NULL")
+		} else {
+			add("NOT NULL")
+		}
+	case Oracle:
+		if c.Generated != "" {
+			return strings.Join(append(b, "AS ("+c.Generated+")"), " ")
+		}
+		if c.AutoIncrement {
+			add("GENERATED BY DEFAULT AS IDENTITY")
+		}
+		if c.Default != "" {
+			add("DEFAULT", c.Default)
+		}
+		if !c.Nullable {
+			add("NOT NULL")
+		}
+	}
+	return strings.Join(b, " ")
+}
+
+// constraintSQL ADD 之后 / CREATE TABLE 里的约束
+func constraintSQL(c ConstraintDef, d Dialect) string {
+	var s string
+	if c.Name != "" {
+		s = "CONSTRAINT " + quoteIdent(c.Name, d) + " "
+	}
+	switch c.Kind {
+	case "CHECK":
+		return s + "CHECK (" + c.Check + ")"
+	case "FOREIGN KEY":
+		s += "FOREIGN KEY (" + qnames(c.Columns, d) + ") REFERENCES " + qname(c.RefTable, d)
+		if len(c.RefColumns) > 0 {
+			s += " (" + qnames(c.RefColumns, d) + ")"
+		}
+		if c.OnDelete != "" {
+			s += " ON DELETE " + c.OnDelete
+		}
+		if c.OnUpdate != "" && d != Oracle {
+			s += " ON UPDATE " + c.OnUpdate
+		}
+		return s
+	}
+	return s + c.Kind + " (" + qnames(c.Columns, d) + ")"
+}
+
+// dropConstraintSQL 没名字的约束：MySQL / Oracle 有专门写法，PG 用默认命名规则，SQL Server 的系统名只能提示
+func dropConstraintSQL(table string, c ConstraintDef, d Dialect) string {
+	t := "ALTER TABLE " + qname(table, d) + " DROP "
+	switch {
+	case d == MySQL:
+		switch c.Kind {
+		case "PRIMARY KEY":
+			return t + "PRIMARY KEY"
+		case "FOREIGN KEY":
+			return t + "FOREIGN KEY " + quoteIdent(c.Name, d)
+		case "UNIQUE":
+			name := c.Name
+			if name == "" && len(c.Columns) > 0 {
+				name = c.Columns[0] // MySQL 给唯一键的默认名
+			}
+			return t + "INDEX " + quoteIdent(name, d)
+		}
+		return t + "CHECK " + quoteIdent(c.Name, d)
+	case c.Name != "":
+		return t + "CONSTRAINT " + quoteIdent(c.Name, d)
+	case d == Oracle && c.Kind == "PRIMARY KEY":
+		return t + "PRIMARY KEY"
+	case d == Oracle && c.Kind == "UNIQUE":
+		return t + "UNIQUE (" + qnames(c.Columns, d) + ")"
+	case d == Postgres:
+		return t + "CONSTRAINT " + quoteIdent(pgConstraintName(table, c), d)
+	}
+	return "-- drop the unnamed " + c.Kind + " constraint of " + table + " by its system-generated name"
+}
+
+// pgConstraintName PG 给没名字的约束起的名字：t_pkey / t_a_b_key / t_a_fkey / t_a_check
+func pgConstraintName(table string, c ConstraintDef) string {
+	parts := splitQualified(table)
+	name := parts[len(parts)-1]
+	switch c.Kind {
+	case "PRIMARY KEY":
+		return name + "_pkey"
+	case "UNIQUE":
+		return name + "_" + strings.Join(c.Columns, "_") + "_key"
+	case "FOREIGN KEY":
+		return name + "_" + strings.Join(c.Columns, "_") + "_fkey"
+	}
+	if len(c.Columns) > 0 {
+		return name + "_" + strings.Join(c.Columns, "_") + "_check"
+	}
+	return name + "_check"
+}
+
+var reNonIdent = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// createIndexSQL 没名字的索引按 t_a_b_idx 起名；索引种类只在对应方言里写出
+func createIndexSQL(table string, ix IndexDef, d Dialect) string {
+	name := ix.Name
+	if name == "" {
+		parts := splitQualified(table)
+		name = parts[len(parts)-1]
+		for _, c := range ix.Columns {
+			if s := strings.Trim(reNonIdent.ReplaceAllString(c, "_"), "_"); s != "" {
+				name += "_" + s
+			}
+		}
+		name += "_idx"
+	}
+	s := "CREATE "
+	if ix.Unique {
+		s += "UNIQUE "
+	}
+	kind := strings.ToUpper(ix.Kind)
+	switch {
+	case d == MySQL && (kind == "FULLTEXT" || kind == "SPATIAL"),
+		d == SQLServer && (kind == "CLUSTERED" || kind == "NONCLUSTERED"),
+		d == Oracle && kind == "BITMAP":
+		s += kind + " "
+	}
+	s += "INDEX " + quoteIdent(name, d) + " ON " + qname(table, d)
+	if d == Postgres && ix.Method != "" {
+		s += " USING " + ix.Method
+	}
+	cols := make([]string, len(ix.Columns))
+	for i, c := range ix.Columns {
+		cols[i] = c
+		if !strings.ContainsAny(c, " ()") {
+			cols[i] = quoteIdent(c, d) // 表达式、前缀长度与 ASC / DESC 保留原文
+		}
+	}
+	s += " (" + strings.Join(cols, ", ") + ")"
+	if len(ix.Include) > 0 && (d == Postgres || d == SQLServer) {
+		s += " INCLUDE (" + qnames(ix.Include, d) + ")"
+	}
+	if ix.Where != "" && (d == Postgres || d == SQLServer) {
+		s += " WHERE " + ix.Where
+	}
+	return s
+}
+
+// createTableSQL 外键与索引不在这里，作为单独的变更排在后面。
+// 临时表：MySQL 用 TEMPORARY；PG 用 TEMPORARY 且不能带 schema；SQL Server 没有关键字，表名加 # 前缀（不带 schema）；
+// Oracle 用 GLOBAL TEMPORARY，并写 ON COMMIT PRESERVE ROWS 与其它库的会话级语义一致
+func createTableSQL(t *TableDef, d Dialect) []string {
+	var items []string
+	for _, c := range t.Columns {
+		items = append(items, columnSQL(c, d))
+	}
+	for _, c := range t.Constraints {
+		if c.Kind != "FOREIGN KEY" {
+			items = append(items, constraintSQL(c, d))
+		}
+	}
+	s, name, tail := "CREATE TABLE ", qname(t.FullName(), d), ""
+	if t.Temporary {
+		tt := *t
+		tt.Name = strings.TrimLeft(t.Name, "#")
+		switch d {
+		case SQLServer:
+			prefix := t.Name[:len(t.Name)-len(tt.Name)]
+			if prefix == "" {
+				prefix = "#"
+			}
+			if name = prefix + tt.Name; !rePlainIdent.MatchString(tt.Name) {
+				name = quoteIdent(name, d)
+			}
+		case Postgres:
+			s, name = "CREATE TEMPORARY TABLE ", quoteIdent(tt.Name, d)
+		case Oracle:
+			s, name, tail = "CREATE GLOBAL TEMPORARY TABLE ", qname(tt.FullName(), d), " ON COMMIT PRESERVE ROWS"
+		default:
+			s, name = "CREATE TEMPORARY TABLE ", qname(tt.FullName(), d)
+		}
+	}
+	return []string{s + name + " (\n  " + strings.Join(items, ",\n  ") + "\n)" + tail}
+}
+
+// alterColumnSQL 改列
+func alterColumnSQL(c SchemaChange, t string, d Dialect, ver []int) []string {
+	col := quoteIdent(c.Name, d)
+	from, to := c.From, c.To
+	typeChanged := changed(c, "type") || changed(c, "collation")
+	switch d {
+	case MySQL:
+		return []string{"ALTER TABLE " + t + " MODIFY COLUMN " + columnSQL(*to, d)}
+
+	case Postgres:
+		var acts, out []string
+		if typeChanged {
+			s := "ALTER COLUMN " + col + " TYPE " + to.Type
+			if to.Collation != "" {
+				s += ` COLLATE "` + to.Collation + `"`
+			}
+			if strings.HasPrefix(c.Reason, "converts") {
+				s += " USING " + col + "::" + to.Type
+			}
+			acts = append(acts, s)
+		}
+		if changed(c, "default") {
+			if to.Default != "" {
+				acts = append(acts, "ALTER COLUMN "+col+" SET DEFAULT "+to.Default)
+			} else {
+				acts = append(acts, "ALTER COLUMN "+col+" DROP DEFAULT")
+			}
+		}
+		if changed(c, "nullable") {
+			if to.Nullable {
+				acts = append(acts, "ALTER COLUMN "+col+" DROP NOT NULL")
+			} else {
+				acts = append(acts, "ALTER COLUMN "+col+" SET NOT NULL")
+			}
+		}
+		if changed(c, "auto_increment") {
+			if to.AutoIncrement {
+				acts = append(acts, "ALTER COLUMN "+col+" ADD GENERATED BY DEFAULT AS IDENTITY")
+			} else {
+				acts = append(acts, "ALTER COLUMN "+col+" DROP IDENTITY IF EXISTS")
+			}
+		}
+		if changed(c, "generated") {
+			// SET EXPRESSION 要 PG 17，DROP EXPRESSION 要 PG 13
+			switch {
+			case from.Generated != "" && to.Generated != "" && versionAtLeast(ver, 17):
+				acts = append(acts, "ALTER COLUMN "+col+" SET EXPRESSION AS ("+to.Generated+")")
+			case from.Generated != "" && to.Generated != "":
+				out = append(out, "-- PostgreSQL before 17 cannot change the expression of "+c.Table+"."+c.Name+" in place; drop and re-add it")
+			case to.Generated == "" && versionAtLeast(ver, 13):
+				acts = append(acts, "ALTER COLUMN "+col+" DROP EXPRESSION")
+			case to.Generated == "":
+				out = append(out, "-- PostgreSQL before 13 cannot turn "+c.Table+"."+c.Name+" into a plain column in place; drop and re-add it")
+			default:
+				out = append(out, "-- "+c.Table+"."+c.Name+" cannot become a generated column in place; drop and re-add it")
+			}
+		}
+		if len(acts) > 0 {
+			out = append([]string{"ALTER TABLE " + t + " " + strings.Join(acts, ", ")}, out...)
+		}
+		if changed(c, "comment") {
+			out = append(out, commentSQL(t, col, to.Comment))
+		}
+		return out
+
+	case SQLServer:
+		var out []string
+		if changed(c, "auto_increment") || changed(c, "generated") {
+			out = append(out, "-- SQL Server cannot change IDENTITY or computed column "+c.Table+"."+c.Name+" in place; rebuild the column")
+		}
+		if changed(c, "default") && from.Default != "" {
+			if from.DefaultName != "" {
+				out = append(out, "ALTER TABLE "+t+" DROP CONSTRAINT "+quoteIdent(from.DefaultName, d))
+			} else {
+				out = append(out, "-- drop the unnamed default constraint of "+c.Table+"."+c.Name+" by its system-generated name")
+			}
+		}
+		if (typeChanged || changed(c, "nullable")) && to.Generated == "" {
+			s := "ALTER TABLE " + t + " ALTER COLUMN " + col + " " + to.Type
+			if to.Collation != "" {
+				s += " COLLATE " + to.Collation
+			}
+			if to.Nullable {
+				s += " NULL"
+			} else {
+				s += " NOT NULL"
+			}
+			out = append(out, s)
+		}
+		if changed(c, "default") && to.Default != "" {
+			s := "ALTER TABLE " + t + " ADD "
+			if to.DefaultName != "" {
+				s += "CONSTRAINT " + quoteIdent(to.DefaultName, d) + " "
+			}
+			out = append(out, s+"DEFAULT "+to.Default+" FOR "+col)
+		}
+		return out
+
+	case Oracle:
+		var parts, out []string
+		if changed(c, "type") {
+			parts = append(parts, to.Type)
+		}
+		if changed(c, "collation") && to.Collation != "" {
+			parts = append(parts, "COLLATE "+to.Collation)
+		}
+		if changed(c, "default") {
+			if to.Default != "" {
+				parts = append(parts, "DEFAULT "+to.Default)
+			} else {
+				parts = append(parts, "DEFAULT NULL")
+			}
+		}
+		if changed(c, "nullable") {
+			if to.Nullable {
+				parts = append(parts, "NULL")
+			} else {
+				parts = append(parts, "NOT NULL")
+			}
+		}
+		if len(parts) > 0 {
+			out = append(out, "ALTER TABLE "+t+" MODIFY ("+col+" "+strings.Join(parts, " ")+")")
+		}
+		if changed(c, "auto_increment") {
+			if to.AutoIncrement {
+				out = append(out, "ALTER TABLE "+t+" MODIFY ("+col+" GENERATED BY DEFAULT AS IDENTITY)")
+			} else {
+				out = append(out, "ALTER TABLE "+t+" MODIFY "+col+" DROP IDENTITY")
+			}
+		}
+		if changed(c, "generated") {
+			out = append(out, "-- "+c.Table+"."+c.Name+" cannot change its virtual column expression in place; drop and re-add it")
+		}
+		if changed(c, "comment") {
+			out = append(out, commentSQL(t, col, to.Comment))
+		}
+		return out
+	}
+	return nil
+}
+
+func commentSQL(t, col, comment string) string {
+	if comment == "" {
+		comment = "NULL"
+	}
+	return "COMMENT ON COLUMN " + t + "." + col + " IS " + comment
+}
@@ -0,0 +1,173 @@
+package sqldigest_antlr
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Extended Events 导出的 XML：<event name="..." timestamp="..."><data name="..."><value>..</value></data><action .../></event>
+type xeEvent struct {
+	Name      string    `xml:"name,attr"`
+	Timestamp string    `xml:"timestamp,attr"`
+	Data      []xeField `xml:"data"`
+	Actions   []xeField `xml:"action"`
+}
+
+type xeField struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+func (e *xeEvent) field(names ...string) string {
+	for _, n := range names {
+		for _, f := range e.Data {
+			if f.Name == n {
+				return f.Value
+			}
+		}
+		for _, f := range e.Actions {
+			if f.Name == n {
+				return f.Value
+			}
+		}
+	}
+	return ""
+}
+
+// ParseSQLServerXEL 流式读取 Extended Events 的 XML 导出（<events> 包裹或多个 <event> 直接拼接均可），
+// 回调 sql_batch_completed / rpc_completed 事件。duration 单位为微秒；
+// sp_executesql 调用会被展开：SQL 为内层语句，外层实参记入 NamedBindValues。
+func ParseSQLServerXEL(r io.Reader, fn func(LogEntry) error) error {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "event" {
+			continue
+		}
+		var ev xeEvent
+		if err := dec.DecodeElement(&ev, &se); err != nil {
+			return err
+		}
+		var sql string
+		switch ev.Name {
+		case "sql_batch_completed":
+			sql = ev.field("batch_text")
+		case "rpc_completed":
+			sql = ev.field("statement")
+		default:
+			continue
+		}
+		if strings.TrimSpace(sql) == "" {
+			continue
+		}
+		e := LogEntry{
+			User: ev.field("username", "server_principal_name", "nt_username"),
+			Host: ev.field("client_hostname"),
+			DB:   ev.field("database_name"),
+			SQL:  strings.TrimSpace(sql),
+		}
+		if t, err := time.Parse(time.RFC3339Nano, ev.Timestamp); err == nil {
+			e.Time = t
+		}
+		if us, err := strconv.ParseInt(ev.field("duration"), 10, 64); err == nil {
+			e.QueryTime = time.Duration(us) * time.Microsecond
+		}
+		e.RowsSent, _ = strconv.ParseInt(ev.field("row_count"), 10, 64)
+		e.ThreadID, _ = strconv.ParseInt(ev.field("session_id"), 10, 64)
+		if stmt, vals, ok := UnwrapSPExecuteSQL(e.SQL); ok {
+			e.SQL, e.NamedBindValues = stmt, vals
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+var reSPExecuteSQL = regexp.MustCompile(`(?i)^\s*(?:exec(?:ute)?\s+)?(?:\[?(?:sys|master)\]?\s*\.\s*(?:\[?dbo\]?\s*\.\s*)?)?\[?sp_executesql\]?\s+`)
+
+// UnwrapSPExecuteSQL 展开 sp_executesql N'stmt', N'@p0 int, @p1 nvarchar(10)', @p0=5, @p1=N'x'；
+// 实参可按名字或按声明顺序给出。返回内层语句与 参数名（小写、不含 @）→ 实参字面量原文。
+func UnwrapSPExecuteSQL(sql string) (string, map[string]string, bool) {
+	m := reSPExecuteSQL.FindStringIndex(sql)
+	if m == nil {
+		return "", nil, false
+	}
+	rest := strings.TrimRight(strings.TrimSpace(sql[m[1]:]), ";")
+	var args []string
+	for _, p := range splitTopLevel(rest, 0, len(rest), ',') {
+		args = append(args, strings.TrimSpace(rest[p[0]:p[1]]))
+	}
+	stmt, ok := tsqlStringValue(args[0])
+	if !ok {
+		return "", nil, false
+	}
+	var decl []string
+	if len(args) > 1 {
+		if d, ok := tsqlStringValue(args[1]); ok {
+			for _, p := range splitTopLevel(d, 0, len(d), ',') {
+				if f := strings.Fields(d[p[0]:p[1]]); len(f) > 0 {
+					decl = append(decl, strings.ToLower(strings.TrimPrefix(f[0], "@")))
+				}
+			}
+		}
+	}
+	vals := map[string]string{}
+	for i, a := range args[min(2, len(args)):] {
+		name := ""
+		if strings.HasPrefix(a, "@") {
+			if eq := strings.IndexByte(a, '='); eq > 0 {
+				name = strings.ToLower(strings.TrimSpace(a[1:eq]))
+				a = strings.TrimSpace(a[eq+1:])
+			}
+		}
+		if name == "" && i < len(decl) {
+			name = decl[i]
+		}
+		if name == "" {
+			continue
+		}
+		if f := strings.Fields(a); len(f) > 1 {
+			if last := strings.ToUpper(f[len(f)-1]); last == "OUTPUT" || last == "OUT" {
+				a = strings.TrimSpace(a[:strings.LastIndex(a, f[len(f)-1])])
+			}
+		}
+		vals[name] = a
+	}
+	return stmt, vals, true
+}
+
+// tsqlStringValue 解出 N'..' / '..' 字面量的内容（双写的单引号还原）
+func tsqlStringValue(s string) (string, bool) {
+	if len(s) > 0 && (s[0] == 'N' || s[0] == 'n') {
+		s = s[1:]
+	}
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return "", false
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), true
+}
+
+// SplitQueryStoreText 拆开 Query Store query_sql_text 的参数声明前缀：
+// "(@p0 int,@p1 nvarchar(4000))SELECT ..." → ("SELECT ...", "@p0 int,@p1 nvarchar(4000)")
+func SplitQueryStoreText(text string) (stmt, params string) {
+	s := strings.TrimSpace(text)
+	if !strings.HasPrefix(s, "(@") {
+		return s, ""
+	}
+	end := matchParen(s, 0, len(s))
+	if end < 0 {
+		return s, ""
+	}
+	return strings.TrimSpace(s[end+1:]), s[1:end]
+}
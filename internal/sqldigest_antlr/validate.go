@@ -0,0 +1,167 @@
+package sqldigest_antlr
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	mylex "github.com/tensafe/sqlglot-go/internal/parsers/mysql"
+	ollex "github.com/tensafe/sqlglot-go/internal/parsers/plsql"
+	pglex "github.com/tensafe/sqlglot-go/internal/parsers/postgresql"
+	tsllex "github.com/tensafe/sqlglot-go/internal/parsers/tsql"
+)
+
+// Issue 校验发现的问题（Offset 为字节偏移，Line/Column 为 1-based / 0-based，与 ANTLR 一致）
+type Issue struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Offset  int    `json:"offset"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d:%d %s", i.Line, i.Column, i.Message)
+}
+
+// newDialectLexer 按方言构造 lexer
+func newDialectLexer(d Dialect, is antlr.CharStream) (antlr.Lexer, error) {
+	switch d {
+	case Postgres:
+		return pglex.NewPostgreSQLLexer(is), nil
+	case MySQL:
+		return mylex.NewMySQLLexer(is), nil
+	case SQLServer:
+		return tsllex.NewTSqlLexer(is), nil
+	case Oracle:
+		return ollex.NewPlSqlLexer(is), nil
+	}
+	return nil, fmt.Errorf("unsupported dialect: %s", d)
+}
+
+// lexTokens 词法分析并返回全部 token（含隐藏通道）；lexer 错误收集到 issues，不再打印到 stderr
+func lexTokens(sql string, opt Options) ([]antlr.Token, []Issue, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	lexer, err := newDialectLexer(opt.Dialect, antlr.NewInputStream(sql))
+	if err != nil {
+		return nil, nil, err
+	}
+	el := &issueListener{sql: sql}
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(el)
+	ts := antlr.NewCommonTokenStream(lexer, 0)
+	ts.Fill()
+	return ts.GetAllTokens(), el.issues, nil
+}
+
+type issueListener struct {
+	*antlr.DefaultErrorListener
+	sql    string
+	issues []Issue
+}
+
+func (l *issueListener) SyntaxError(_ antlr.Recognizer, _ interface{}, line, column int, msg string, _ antlr.RecognitionException) {
+	l.issues = append(l.issues, Issue{Line: line, Column: column, Offset: lineColToByte(l.sql, line, column), Message: msg})
+}
+
+// lineColToByte：ANTLR 的 (line, 以 rune 计的 column) → 字节偏移
+func lineColToByte(s string, line, column int) int {
+	off := 0
+	for l := 1; l < line && off < len(s); off++ {
+		if s[off] == '\n' {
+			l++
+		}
+	}
+	rs := 0
+	for i := range s[off:] {
+		if rs == column {
+			return off + i
+		}
+		rs++
+	}
+	return len(s)
+}
+
+// SplitSQL 词法分析后按顶层 ';' 切分语句
+func SplitSQL(sql string, opt Options) ([]StmtInfo, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	toks, _, err := lexTokens(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	return SplitStatements(sql, toks, opt), nil
+}
+
+// Validate 基于方言 lexer 做轻量校验：非法字符/未闭合的字符串、未闭合的块注释、每条语句内括号不配对。
+// 不做语法树级别的校验。
+func Validate(sql string, opt Options) ([]Issue, error) {
+	if opt.Dialect == "" {
+		opt.Dialect = MySQL
+	}
+	toks, issues, err := lexTokens(sql, opt)
+	if err != nil {
+		return nil, err
+	}
+	issueAt := func(t antlr.Token, msg string) Issue {
+		return Issue{Line: t.GetLine(), Column: t.GetColumn(), Offset: RuneIndexToByte(sql, t.GetStart()), Message: msg}
+	}
+	var spans []span
+	if opt.Dialect == MySQL {
+		spans = findMySQLCommentSpans(sql)
+	}
+	// 括号按 ';' 分段检查：SplitStatements 在括号内不切分，未闭合的 '(' 会吞掉后续语句
+	var open []antlr.Token
+	closeStmt := func() {
+		for _, t := range open {
+			issues = append(issues, issueAt(t, "unclosed '('"))
+		}
+		open = open[:0]
+	}
+	for _, t := range toks {
+		if IsEOFToken(t) || t.GetChannel() != antlr.TokenDefaultChannel {
+			continue
+		}
+		if len(spans) > 0 && inAnySpan(RuneIndexToByte(sql, t.GetStart()), RuneIndexToByte(sql, t.GetStop()+1), spans) {
+			continue
+		}
+		txt := t.GetText()
+		if unterminatedQuote(txt) {
+			issues = append(issues, issueAt(t, "unterminated quoted literal"))
+			continue
+		}
+		switch txt {
+		case "(":
+			open = append(open, t)
+		case ")":
+			if len(open) == 0 {
+				issues = append(issues, issueAt(t, "unmatched ')'"))
+				continue
+			}
+			open = open[:len(open)-1]
+		case ";":
+			closeStmt()
+		case "/*":
+			issues = append(issues, issueAt(t, "unterminated block comment"))
+		}
+	}
+	closeStmt()
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Offset < issues[j].Offset })
+	return issues, nil
+}
+
+// unterminatedQuote：部分 lexer（如 PG）把未闭合的字符串产出为普通 token 而非报错
+func unterminatedQuote(txt string) bool {
+	i := 0
+	for i < len(txt) && i < 2 && (txt[i] == 'N' || txt[i] == 'n' || txt[i] == 'E' || txt[i] == 'e') {
+		i++
+	}
+	if i >= len(txt) || (txt[i] != '\'' && txt[i] != '"') {
+		return false
+	}
+	q := txt[i]
+	return len(txt) == i+1 || txt[len(txt)-1] != q
+}
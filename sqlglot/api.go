@@ -2,6 +2,8 @@ package sqlglot
 
 import (
 	"errors"
+	"io"
+
 	core "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
 )
 
@@ -31,6 +33,267 @@ func ResultFor(sql string, opt Options) (Result, error) {
 	return core.BuildDigestANTLR(sql, opt)
 }
 
+// Render re-inflates a digest: each `?` is replaced, in order, by the raw source
+// text of the matching param (bind params put their original placeholder back).
+// The digest does not keep the identifier case of the source: keywords stay
+// upper case and other unquoted words are folded the way the server folds
+// unquoted identifiers (upper case for Oracle, lower case otherwise).
+// Digests produced with CollapseValuesInDigest cannot be re-inflated when rows
+// were collapsed, because the placeholder count no longer matches the params.
+func Render(digest string, params []ExParam, dialect Dialect) (string, error) {
+	return core.RenderDigest(digest, params, dialect)
+}
+
+// Bind substitutes values into the bind placeholders (?, $n, :name, :1, @name)
+// of sql, quoting and escaping each value for the dialect. values[i] feeds the
+// bind whose ExParam.BindOrdinal is i+1; sql.NamedArg values match named binds.
+// A value that no placeholder uses is an error. MySQL time.Time values are
+// written as UTC wall-clock time.
+func Bind(sql string, values []any, dialect Dialect) (string, error) {
+	return core.BindValues(sql, values, Options{Dialect: dialect})
+}
+
+// BindWithOptions is Bind honoring Options such as NoBackslashEscapes.
+func BindWithOptions(sql string, values []any, opt Options) (string, error) {
+	return core.BindValues(sql, values, opt)
+}
+
+// Redact replaces only the literals of sql (strings, numbers, DATE '...', ...)
+// in place, using the ExParam byte ranges; keyword case, comments, whitespace,
+// bind placeholders and (outside MySQL) double-quoted identifiers are preserved
+// byte-for-byte.
+func Redact(sql string, opt RedactOptions) (string, error) {
+	out, _, err := core.Redact(sql, opt)
+	return out, err
+}
+
+// Split cuts sql into statements at top-level ';' using the dialect lexer.
+// Each Statement carries its type and byte range in sql.
+func Split(sql string, opt Options) ([]Statement, error) {
+	return core.SplitSQL(sql, opt)
+}
+
+// Validate runs lexer-level checks (illegal characters, unterminated strings
+// and comments, unbalanced parentheses per statement). An empty result means
+// no problem was found; it is not a full grammar check.
+func Validate(sql string, opt Options) ([]Issue, error) {
+	return core.Validate(sql, opt)
+}
+
+// ParseMySQLSlowLog streams a MySQL slow query log (also Percona / MariaDB
+// variants), calling fn once per logged statement.
+func ParseMySQLSlowLog(r io.Reader, fn func(LogEntry) error) error {
+	return core.ParseMySQLSlowLog(r, fn)
+}
+
+// ParseMySQLGeneralLog streams a MySQL general query log, calling fn for each
+// Query / Execute command. General logs carry no timing, so QueryTime is zero.
+func ParseMySQLGeneralLog(r io.Reader, fn func(LogEntry) error) error {
+	return core.ParseMySQLGeneralLog(r, fn)
+}
+
+// ParsePostgresLog streams a PostgreSQL server log, calling fn for every
+// "statement:" / "execute <name>:" entry. Values from a following
+// "DETAIL: parameters: $1 = ..." line are kept in LogEntry.BindValues;
+// LogEntry.Result attaches them to the matching $n ExParam as BoundValue.
+func ParsePostgresLog(r io.Reader, po PostgresLogOptions, fn func(LogEntry) error) error {
+	return core.ParsePostgresLog(r, po, fn)
+}
+
+// ParseSQLServerXEL streams an Extended Events XML export and calls fn for
+// each sql_batch_completed / rpc_completed event. sp_executesql calls are
+// unwrapped: LogEntry.SQL is the inner statement and the outer argument
+// values are kept in LogEntry.NamedBindValues (see LogEntry.Result).
+func ParseSQLServerXEL(r io.Reader, fn func(LogEntry) error) error {
+	return core.ParseSQLServerXEL(r, fn)
+}
+
+// UnwrapSPExecuteSQL splits "EXEC sp_executesql N'stmt', N'@p0 int', @p0=5"
+// into the inner statement and a map of lower-cased parameter name → literal.
+func UnwrapSPExecuteSQL(sql string) (stmt string, values map[string]string, ok bool) {
+	return core.UnwrapSPExecuteSQL(sql)
+}
+
+// SplitQueryStoreText separates the "(@p0 int,...)" declaration prefix that
+// Query Store keeps in query_sql_text from the statement itself.
+func SplitQueryStoreText(text string) (stmt, params string) {
+	return core.SplitQueryStoreText(text)
+}
+
+// NewLogAggregator groups LogEntry values by digest (computed with opt) and
+// reports count, total/avg/p95/max time, rows, first/last seen and a redacted
+// sample per digest:
+//
+//	agg := sqlglot.NewLogAggregator(sqlglot.Options{Dialect: sqlglot.MySQL})
+//	err := sqlglot.ParseMySQLSlowLog(f, agg.Add)
+//	for _, st := range agg.Results() { ... }
+//
+// Memory per digest is bounded: p95 is exact up to 1024 entries of a digest
+// and estimated from a uniform sample of 1024 durations beyond that. A
+// LogAggregator is not safe for concurrent use; guard Add and Results with a
+// mutex when several goroutines feed it.
+func NewLogAggregator(opt Options) *LogAggregator {
+	return core.NewLogAggregator(opt)
+}
+
+// InspectInjection runs token-level SQL injection heuristics on sql, which is
+// treated as a single query: always-true OR conditions, stacked statements,
+// line comments cutting a query off after a string, UNION SELECT appended to
+// a filtered query, and time-based probes (SLEEP, pg_sleep, WAITFOR DELAY,
+// DBMS_LOCK.SLEEP). Findings carry a severity and a byte span in sql and are
+// sorted by position; legitimate SQL can match, so treat them as signals.
+func InspectInjection(sql string, opt Options) ([]InjectionFinding, error) {
+	return core.InspectInjection(sql, opt)
+}
+
+// DefaultPolicy returns a rule set with one rule per built-in check:
+// UPDATE/DELETE without WHERE, TRUNCATE and DROP, SELECT * on a table,
+// SELECT without LIMIT/TOP/FETCH, GRANT ALL and writes qualified with a
+// database name.
+func DefaultPolicy() Policy {
+	return core.DefaultPolicy()
+}
+
+// ParsePolicy decodes and validates a JSON rule set such as
+//
+//	{"rules": [
+//	  {"id": "no-truncate", "check": "statement_type", "types": ["TRUNCATE"]},
+//	  {"id": "orders-only", "check": "cross_database_write", "databases": ["orders"]}
+//	]}
+func ParsePolicy(data []byte) (Policy, error) {
+	return core.ParsePolicy(data)
+}
+
+// CheckPolicy evaluates every rule of p against each statement of sql (as
+// split by Split). Violations are ordered by statement, then by rule, and
+// carry the rule id, a severity and a byte span in sql.
+func CheckPolicy(sql string, p Policy, opt Options) ([]PolicyViolation, error) {
+	return core.EvaluatePolicy(sql, p, opt)
+}
+
+// Lint runs rules against each statement of sql (as split by Split); nil
+// rules means DefaultLintRules. Findings are ordered by statement, then by
+// position. Comments containing "sqlglot:ignore [rule,...]" silence rules
+// for the statement they belong to (inside it, trailing it on the same line,
+// or directly above it); "sqlglot:ignore-file [rule,...]" silences them for
+// the whole input. Without rule names every rule is silenced.
+func Lint(sql string, rules []LintRule, opt Options) ([]LintFinding, error) {
+	return core.Lint(sql, rules, opt)
+}
+
+// DefaultLintRules returns every built-in rule (see the Lint* name constants).
+func DefaultLintRules() []LintRule {
+	return core.DefaultLintRules()
+}
+
+// NewLintRule builds a LintRule from a function. The function returns
+// findings with Start/End/Message (and optionally Severity) set; Lint fills in
+// the rule name and statement number.
+//
+//	noSleep := sqlglot.NewLintRule("no_sleep", func(st *sqlglot.LintStatement) []sqlglot.LintFinding {
+//		var out []sqlglot.LintFinding
+//		for i, t := range st.Tokens {
+//			if t.Upper == "SLEEP" {
+//				out = append(out, st.FindingAt(i, i, sqlglot.SeverityHigh, "SLEEP in application SQL"))
+//			}
+//		}
+//		return out
+//	})
+//	findings, err := sqlglot.Lint(sql, append(sqlglot.DefaultLintRules(), noSleep), opt)
+func NewLintRule(name string, fn func(st *LintStatement) []LintFinding) LintRule {
+	return core.NewLintRule(name, fn)
+}
+
+// Format pretty-prints sql: clauses start on their own line, subqueries and
+// CTE bodies are indented, and comma lists or AND/OR chains that do not fit
+// fo.LineWidth are broken one item per line. Literals, identifiers and
+// comments are kept as written; only whitespace and the case of keywords
+// change, so the digest of the output equals the digest of the input, and
+// formatting the output again returns it unchanged.
+func Format(sql string, opt Options, fo FormatOptions) (string, error) {
+	return core.Format(sql, opt, fo)
+}
+
+// Lineage reports, for each statement that writes a table from a query
+// (INSERT ... SELECT, CREATE TABLE/VIEW ... AS SELECT, SQL Server and Postgres
+// SELECT ... INTO, UPDATE ... SET with FROM or JOIN, MERGE), which source
+// columns feed each target column. Table aliases, derived tables and CTEs are
+// resolved to base tables. Without a schema, a column that cannot be tied to
+// one table has an empty Table, and SELECT * from a base table is reported as
+// column "*". An INSERT without a column list writes by position, so its
+// targets carry Position and an empty Column.
+func Lineage(sql string, opt Options) ([]ColumnLineage, error) {
+	return core.Lineage(sql, opt)
+}
+
+// ParseCatalog decodes a JSON catalog such as
+//
+//	{"default_schema": "public", "databases": [{"schemas": [{"name": "public",
+//	  "tables": [{"name": "users", "columns": [{"name": "id", "type": "bigint"}]}]}]}]}
+//
+// Unknown fields are rejected. Names are matched case-insensitively.
+func ParseCatalog(data []byte) (*Catalog, error) {
+	return core.ParseCatalog(data)
+}
+
+// CatalogFromDDL builds a catalog from the tables SchemaFromDDL finds in sql.
+// Column types are kept as written.
+func CatalogFromDDL(sql string, opt Options) (*Catalog, error) {
+	return core.CatalogFromDDL(sql, opt)
+}
+
+// Qualify rewrites sql so that every column reference it can resolve is
+// qualified with its table alias (or table name), and SELECT * / t.* lists
+// the columns when all of them are known: from opt.Catalog for base tables,
+// from the select list for derived tables and CTEs. Ambiguous references and
+// columns of tables missing from the catalog are left alone. Everything else,
+// comments and whitespace included, is kept as written.
+func Qualify(sql string, opt Options) (string, error) {
+	return core.Qualify(sql, opt)
+}
+
+// ParseDDL extracts CREATE TABLE, ALTER TABLE, CREATE INDEX and DROP
+// statements from sql as structured objects: columns with type, nullability,
+// default and identity, primary/unique/foreign keys, checks and indexes, and
+// the individual ALTER TABLE actions. Other statements are skipped. Names are
+// unquoted; types, defaults and expressions are kept as written.
+func ParseDDL(sql string, opt Options) ([]DDLStatement, error) {
+	return core.ParseDDL(sql, opt)
+}
+
+// SchemaFromDDL applies the DDL in sql, in order, to an empty Schema and
+// returns the resulting tables. Statements on tables it has not seen are
+// ignored.
+func SchemaFromDDL(sql string, opt Options) (*Schema, error) {
+	return core.SchemaFromDDL(sql, opt)
+}
+
+// DiffSchemas lists the changes that turn schema a into schema b, in an order
+// that can be applied as is: constraint and index drops first, then new
+// tables, added, altered and dropped columns, new constraints and indexes,
+// foreign keys, and table drops last. Each change carries its SQL in b's
+// dialect and for b's Version, the latest when empty (use SchemaChange.Render
+// or RenderVersion for another one). Column drops, table
+// drops and narrowing type changes are marked Destructive with a Reason.
+// Renamed columns show up as a drop plus an add.
+func DiffSchemas(a, b *Schema) []SchemaChange {
+	return core.DiffSchemas(a, b)
+}
+
+// AnalyzeMigration estimates, for each DDL statement in sql, the lock it takes
+// (LockNone, LockMetadata, LockWrite or LockExclusive), whether it rewrites
+// the table, and its risk, with dialect- and version-specific rules: volatile
+// defaults and type changes in PostgreSQL, CREATE INDEX without CONCURRENTLY
+// or ONLINE, InnoDB INSTANT / INPLACE / COPY eligibility and explicit
+// ALGORITHM / LOCK clauses the server would reject, SQL Server and Oracle
+// online options. Notes name the risky actions and a safer alternative.
+// mo.Schema describes the existing tables; tables created earlier in the
+// same script are treated as empty. The result is an estimate from the
+// statements alone, not from the server.
+func AnalyzeMigration(sql string, opt Options, mo MigrationOptions) ([]MigrationCheck, error) {
+	return core.AnalyzeMigration(sql, opt, mo)
+}
+
 // -----------------------------------------------------------------------------
 // Placeholders (align naming with python sqlglot; implement later when AST ready)
 // -----------------------------------------------------------------------------
@@ -1,6 +1,11 @@
 package sqlglot
 
-import core "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+import (
+	"fmt"
+	"strings"
+
+	core "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
 
 // Re-export core enums so users don't import internal.
 type Dialect = core.Dialect
@@ -12,9 +17,225 @@ const (
 	Oracle    = core.Oracle
 )
 
+// ParseDialect maps a dialect name, case-insensitively, to a Dialect. Common
+// aliases are accepted: mariadb, postgresql / pg, mssql / tsql, plsql.
+func ParseDialect(s string) (Dialect, error) {
+	switch strings.ToLower(s) {
+	case "mysql", "mariadb":
+		return MySQL, nil
+	case "postgres", "postgresql", "pg":
+		return Postgres, nil
+	case "sqlserver", "mssql", "tsql":
+		return SQLServer, nil
+	case "oracle", "plsql":
+		return Oracle, nil
+	}
+	return "", fmt.Errorf("unknown dialect %q", s)
+}
+
 // Also surface core Options/Result/ExParam for convenience.
 type (
 	Options = core.Options
 	Result  = core.Result
 	ExParam = core.ExParam
+	Comment = core.Comment
+	Hint    = core.Hint
+	// NestedSQL is dynamic SQL found inside a string parameter (Options.UnwrapDynamicSQL).
+	NestedSQL = core.NestedSQL
+	// Complexity holds the per-statement metrics reported in Result.Complexity
+	// when Options.Complexity is set.
+	Complexity = core.Complexity
+	// ComplexityLimits are the thresholds for Complexity.Exceeds; zero fields
+	// are not checked.
+	ComplexityLimits = core.ComplexityLimits
+
+	// Statement is one statement returned by Split.
+	Statement = core.StmtInfo
+	// Issue is one problem reported by Validate.
+	Issue = core.Issue
+	// InjectionFinding is one match reported by InspectInjection.
+	InjectionFinding = core.InjectionFinding
+	// Policy is a declarative rule set evaluated by CheckPolicy.
+	Policy = core.Policy
+	// PolicyRule selects a built-in check (one of the Check constants) and its parameters.
+	PolicyRule = core.PolicyRule
+	// PolicyViolation is one rule match reported by CheckPolicy.
+	PolicyViolation = core.PolicyViolation
+	// LintRule is implemented by lint rules: Name identifies the rule in
+	// findings and suppression comments, Check inspects one statement.
+	LintRule = core.LintRule
+	// LintStatement is the view of one statement handed to LintRule.Check.
+	LintStatement = core.LintStatement
+	// LintToken is a visible token of a LintStatement with its byte span and parenthesis depth.
+	LintToken = core.LintToken
+	// LintFinding is one problem reported by Lint.
+	LintFinding = core.LintFinding
+	// FormatOptions controls Format; the zero value means upper-case
+	// keywords, two-space indentation and an 80-column line width.
+	FormatOptions = core.FormatOptions
+	// ColumnRef names a column of a table. Table is the qualified name with
+	// quotes removed (db.t, emp@remote); it is empty when the column could not
+	// be tied to one table.
+	ColumnRef = core.ColumnRef
+	// ColumnLineage is one target column and the source columns feeding it,
+	// as reported by Lineage.
+	ColumnLineage = core.ColumnLineage
+	// Catalog describes databases, schemas, tables and typed columns; set
+	// Options.Catalog to let Lineage, Qualify and the schema lint rules
+	// resolve columns against it.
+	Catalog = core.Catalog
+	// CatalogDatabase is one database of a Catalog.
+	CatalogDatabase = core.CatalogDatabase
+	// CatalogSchema is one schema of a CatalogDatabase.
+	CatalogSchema = core.CatalogSchema
+	// CatalogTable is a table and its columns in declaration order.
+	CatalogTable = core.CatalogTable
+	// CatalogColumn is a column name and its type as written in the DDL.
+	CatalogColumn = core.CatalogColumn
+	// DDLStatement is one CREATE TABLE / ALTER TABLE / CREATE INDEX / DROP
+	// statement as parsed by ParseDDL.
+	DDLStatement = core.DDLStatement
+	// TableDef is a table's columns, keys, constraints and indexes.
+	TableDef = core.TableDef
+	// ColumnDef is a column definition; Type and Default are kept as written.
+	ColumnDef = core.ColumnDef
+	// ConstraintDef is a PRIMARY KEY, UNIQUE, FOREIGN KEY or CHECK constraint.
+	ConstraintDef = core.ConstraintDef
+	// IndexDef is an index from CREATE INDEX or an inline KEY / INDEX.
+	IndexDef = core.IndexDef
+	// AlterAction is one action of an ALTER TABLE statement; Kind is one of
+	// the Alter* constants.
+	AlterAction = core.AlterAction
+	// Schema is the set of tables left after applying DDL in order.
+	Schema = core.Schema
+	// SchemaChange is one difference found by DiffSchemas; Kind is one of
+	// the Change* constants.
+	SchemaChange = core.SchemaChange
+	// MigrationOptions configures AnalyzeMigration: the server version and
+	// the existing schema.
+	MigrationOptions = core.MigrationOptions
+	// MigrationCheck is the lock level, rewrite risk and notes of one DDL
+	// statement.
+	MigrationCheck = core.MigrationCheck
+	// MigrationNote is one risky action of a MigrationCheck with a safer
+	// alternative.
+	MigrationNote = core.MigrationNote
+)
+
+// Severities of an InjectionFinding, PolicyViolation or LintFinding.
+const (
+	SeverityHigh   = core.SeverityHigh
+	SeverityMedium = core.SeverityMedium
+	SeverityLow    = core.SeverityLow
+)
+
+// Built-in policy checks for PolicyRule.Check.
+const (
+	CheckMissingWhere       = core.CheckMissingWhere       // UPDATE/DELETE (or PolicyRule.Types) without a top-level WHERE
+	CheckStatementType      = core.CheckStatementType      // statement type listed in PolicyRule.Types
+	CheckSelectStar         = core.CheckSelectStar         // SELECT * / t.* reading a named table (PolicyRule.Tables narrows it)
+	CheckUnboundedSelect    = core.CheckUnboundedSelect    // SELECT from a table without LIMIT/TOP/FETCH/ROWNUM
+	CheckGrantAll           = core.CheckGrantAll           // GRANT ALL [PRIVILEGES]
+	CheckCrossDatabaseWrite = core.CheckCrossDatabaseWrite // write target in a database outside PolicyRule.Databases
+)
+
+// Names of the built-in lint rules.
+const (
+	LintCommaJoin        = core.LintCommaJoin        // FROM a, b instead of an explicit JOIN
+	LintNotInSubquery    = core.LintNotInSubquery    // NOT IN (SELECT ...) without an IS NOT NULL guard
+	LintEqualsNull       = core.LintEqualsNull       // = NULL / <> NULL comparisons
+	LintFunctionOnColumn = core.LintFunctionOnColumn // function around an id/date-like column compared in WHERE
+	LintMixedBindStyles  = core.LintMixedBindStyles  // ? mixed with $n / :name / @name in one statement
+	LintOracleOuterJoin  = core.LintOracleOuterJoin  // Oracle (+) outer joins
+	LintOrderByOrdinal   = core.LintOrderByOrdinal   // ORDER BY 1
+	// The following report nothing unless Options.Catalog is set.
+	LintUnknownTable    = core.LintUnknownTable    // table not in the catalog
+	LintUnknownColumn   = core.LintUnknownColumn   // column missing from every table it could belong to
+	LintAmbiguousColumn = core.LintAmbiguousColumn // unqualified column present in more than one joined table
+	// Reported by Lint itself for a sqlglot:ignore comment naming a rule that is not being run.
+	LintIgnoreDirective = core.LintIgnoreDirective
+)
+
+// Kinds of AlterAction.
+const (
+	AlterAddColumn      = core.AlterAddColumn
+	AlterDropColumn     = core.AlterDropColumn
+	AlterModifyColumn   = core.AlterModifyColumn // full redefinition: MySQL MODIFY / CHANGE, SQL Server ALTER COLUMN
+	AlterRenameColumn   = core.AlterRenameColumn
+	AlterSetType        = core.AlterSetType // PostgreSQL ALTER COLUMN TYPE, Oracle MODIFY with a type
+	AlterSetDefault     = core.AlterSetDefault
+	AlterDropDefault    = core.AlterDropDefault
+	AlterSetNotNull     = core.AlterSetNotNull
+	AlterDropNotNull    = core.AlterDropNotNull
+	AlterAddConstraint  = core.AlterAddConstraint
+	AlterDropConstraint = core.AlterDropConstraint
+	AlterAddIndex       = core.AlterAddIndex
+	AlterDropIndex      = core.AlterDropIndex
+	AlterRenameTable    = core.AlterRenameTable
+	AlterOther          = core.AlterOther // anything else; only Text is set
+)
+
+// Kinds of SchemaChange.
+const (
+	ChangeCreateTable    = core.ChangeCreateTable
+	ChangeDropTable      = core.ChangeDropTable
+	ChangeAddColumn      = core.ChangeAddColumn
+	ChangeDropColumn     = core.ChangeDropColumn
+	ChangeAlterColumn    = core.ChangeAlterColumn
+	ChangeAddConstraint  = core.ChangeAddConstraint
+	ChangeDropConstraint = core.ChangeDropConstraint
+	ChangeAddIndex       = core.ChangeAddIndex
+	ChangeDropIndex      = core.ChangeDropIndex
+)
+
+// Lock levels of a MigrationCheck, from lightest to heaviest.
+const (
+	LockNone      = core.LockNone      // reads and writes continue
+	LockMetadata  = core.LockMetadata  // brief exclusive lock; queues behind long transactions
+	LockWrite     = core.LockWrite     // writes blocked while it runs
+	LockExclusive = core.LockExclusive // reads and writes blocked while it runs
+)
+
+// Risk levels of a MigrationCheck.
+const (
+	RiskLow    = core.RiskLow
+	RiskMedium = core.RiskMedium
+	RiskHigh   = core.RiskHigh
+)
+
+// Keyword casing for FormatOptions.KeywordCase.
+const (
+	KeywordUpper    = core.KeywordUpper
+	KeywordLower    = core.KeywordLower
+	KeywordPreserve = core.KeywordPreserve // keep keywords as written
+)
+
+// Query-log ingestion and per-digest aggregation.
+type (
+	LogEntry      = core.LogEntry
+	DigestStats   = core.DigestStats
+	LogAggregator = core.LogAggregator
+
+	// PostgresLogOptions selects the server log format (stderr/csvlog/jsonlog)
+	// and, for stderr, the log_line_prefix in use.
+	PostgresLogOptions = core.PostgresLogOptions
+)
+
+// Redaction settings (see Redact).
+type (
+	RedactOptions = core.RedactOptions
+	RedactMode    = core.RedactMode
 )
+
+const (
+	RedactMask    = core.RedactMask
+	RedactTypeTag = core.RedactTypeTag
+	RedactHMAC    = core.RedactHMAC
+)
+
+// ErrHMACKeyRequired is returned by Redact in RedactHMAC mode without a key.
+var ErrHMACKeyRequired = core.ErrHMACKeyRequired
+
+// ErrNotLiteral is returned by the ExParam typed accessors (Int64, Float64,
+// Bytes, Text, Time, Typed) when the param is a bind placeholder or a function.
+var ErrNotLiteral = core.ErrNotLiteral
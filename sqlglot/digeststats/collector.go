@@ -0,0 +1,256 @@
+// Package digeststats keeps per-digest statement statistics in process and
+// serves them as Prometheus text or as a JSON top-N list, without a metrics
+// client library.
+//
+// Digests are keyed by a short fingerprint so that metric labels stay small;
+// only the first MaxDigests fingerprints are tracked individually and later
+// ones are folded into a single "other" series, which bounds cardinality.
+//
+//	stats := digeststats.New(digeststats.Config{})
+//	db := sql.OpenDB(sqldriver.WrapConnector(connector, opt, stats.Hook()))
+//	http.Handle("/metrics/sql", stats) // ?format=json&top=20&sort=total for the JSON view
+package digeststats
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tensafe/sqlglot-go/sqlglot/sqldriver"
+)
+
+// OtherFingerprint labels the series that collects digests beyond MaxDigests
+// (and statements that could not be digested).
+const OtherFingerprint = "other"
+
+// DefaultBuckets are the latency histogram upper bounds in seconds.
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Config tunes a Collector. The zero value is usable.
+type Config struct {
+	MaxDigests int       // individually tracked fingerprints; default 500
+	Buckets    []float64 // histogram upper bounds in seconds, ascending; default DefaultBuckets
+	Namespace  string    // metric name prefix; default "sqlglot"
+}
+
+// Collector aggregates observations per digest. It is safe for concurrent use.
+type Collector struct {
+	cfg     Config
+	mu      sync.Mutex
+	entries map[string]*entry
+	other   *entry
+}
+
+type entry struct {
+	fingerprint string
+	digest      string
+	count       uint64
+	errors      uint64
+	sum         float64 // seconds
+	max         float64
+	buckets     []uint64 // per bucket, not cumulative; last slot is +Inf
+}
+
+// Stat is a snapshot of one tracked digest.
+type Stat struct {
+	Fingerprint string  `json:"fingerprint"`
+	Digest      string  `json:"digest,omitempty"`
+	Count       uint64  `json:"count"`
+	Errors      uint64  `json:"errors"`
+	TotalTime   float64 `json:"total_seconds"`
+	MeanTime    float64 `json:"mean_seconds"`
+	MaxTime     float64 `json:"max_seconds"`
+	P95Time     float64 `json:"p95_seconds"` // estimated from the histogram
+	// Buckets[i] counts observations <= Config.Buckets[i] (cumulative);
+	// the last element is the +Inf bucket and equals Count.
+	Buckets []uint64 `json:"buckets"`
+}
+
+// New returns an empty Collector.
+func New(cfg Config) *Collector {
+	if cfg.MaxDigests <= 0 {
+		cfg.MaxDigests = 500
+	}
+	if len(cfg.Buckets) == 0 {
+		cfg.Buckets = DefaultBuckets
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = "sqlglot"
+	}
+	return &Collector{
+		cfg:     cfg,
+		entries: map[string]*entry{},
+		other:   newEntry(OtherFingerprint, "", len(cfg.Buckets)),
+	}
+}
+
+func newEntry(fp, digest string, n int) *entry {
+	return &entry{fingerprint: fp, digest: digest, buckets: make([]uint64, n+1)}
+}
+
+// Fingerprint is the label used for digest: the first 16 hex digits of its SHA-256.
+func Fingerprint(digest string) string {
+	sum := sha256.Sum256([]byte(digest))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Observe records one execution of digest. An empty digest counts towards "other".
+func (c *Collector) Observe(digest string, d time.Duration, err error) {
+	sec := d.Seconds()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.other
+	if digest != "" {
+		fp := Fingerprint(digest)
+		if got, ok := c.entries[fp]; ok {
+			e = got
+		} else if len(c.entries) < c.cfg.MaxDigests {
+			e = newEntry(fp, digest, len(c.cfg.Buckets))
+			c.entries[fp] = e
+		}
+	}
+	e.count++
+	if err != nil {
+		e.errors++
+	}
+	e.sum += sec
+	e.max = math.Max(e.max, sec)
+	e.buckets[sort.SearchFloat64s(c.cfg.Buckets, sec)]++
+}
+
+// Hook adapts the Collector to sqldriver: executions (not Prepare calls) are observed.
+func (c *Collector) Hook() sqldriver.Hook {
+	return func(_ context.Context, ev sqldriver.Event) {
+		if ev.Op == sqldriver.OpPrepare {
+			return
+		}
+		c.Observe(ev.Digest, ev.Duration, ev.Err)
+	}
+}
+
+// Reset drops all statistics.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*entry{}
+	c.other = newEntry(OtherFingerprint, "", len(c.cfg.Buckets))
+}
+
+// Snapshot returns every tracked digest, sorted by total time (then count)
+// descending. The "other" series is last and only present once it was used.
+func (c *Collector) Snapshot() []Stat {
+	c.mu.Lock()
+	out := make([]Stat, 0, len(c.entries)+1)
+	for _, e := range c.entries {
+		out = append(out, c.stat(e))
+	}
+	other := c.stat(c.other)
+	c.mu.Unlock()
+
+	sortStats(out, SortTotal)
+	if other.Count > 0 {
+		out = append(out, other)
+	}
+	return out
+}
+
+// stat converts e; the caller holds c.mu.
+func (c *Collector) stat(e *entry) Stat {
+	s := Stat{
+		Fingerprint: e.fingerprint,
+		Digest:      e.digest,
+		Count:       e.count,
+		Errors:      e.errors,
+		TotalTime:   e.sum,
+		MaxTime:     e.max,
+		Buckets:     make([]uint64, len(e.buckets)),
+	}
+	var cum uint64
+	for i, n := range e.buckets {
+		cum += n
+		s.Buckets[i] = cum
+	}
+	if e.count > 0 {
+		s.MeanTime = e.sum / float64(e.count)
+		s.P95Time = c.quantile(0.95, s.Buckets, e.max)
+	}
+	return s
+}
+
+// quantile interpolates linearly inside the bucket holding rank q, as
+// Prometheus' histogram_quantile does; the +Inf bucket is capped at max.
+func (c *Collector) quantile(q float64, cum []uint64, max float64) float64 {
+	total := cum[len(cum)-1]
+	rank := q * float64(total)
+	for i, n := range cum {
+		if float64(n) < rank {
+			continue
+		}
+		lo, hi := 0.0, max
+		if i > 0 {
+			lo = c.cfg.Buckets[i-1]
+		}
+		if i < len(c.cfg.Buckets) {
+			hi = math.Min(c.cfg.Buckets[i], max)
+		}
+		var prev uint64
+		if i > 0 {
+			prev = cum[i-1]
+		}
+		if n == prev || hi <= lo {
+			return hi
+		}
+		return lo + (hi-lo)*(rank-float64(prev))/float64(n-prev)
+	}
+	return max
+}
+
+// Sort orders for TopN.
+const (
+	SortTotal  = "total"
+	SortCount  = "count"
+	SortErrors = "errors"
+	SortMean   = "mean"
+	SortMax    = "max"
+)
+
+// TopN returns the n tracked digests with the highest value of by (one of
+// the Sort constants; unknown values sort by total time). The "other"
+// series is ranked like any digest.
+func (c *Collector) TopN(n int, by string) []Stat {
+	all := c.Snapshot()
+	sortStats(all, by)
+	if n > 0 && len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
+
+func sortStats(s []Stat, by string) {
+	key := func(st Stat) float64 {
+		switch by {
+		case SortCount:
+			return float64(st.Count)
+		case SortErrors:
+			return float64(st.Errors)
+		case SortMean:
+			return st.MeanTime
+		case SortMax:
+			return st.MaxTime
+		}
+		return st.TotalTime
+	}
+	sort.SliceStable(s, func(i, j int) bool {
+		if a, b := key(s[i]), key(s[j]); a != b {
+			return a > b
+		}
+		if s[i].Count != s[j].Count {
+			return s[i].Count > s[j].Count
+		}
+		return s[i].Fingerprint < s[j].Fingerprint
+	})
+}
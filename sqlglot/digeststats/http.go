@@ -0,0 +1,99 @@
+package digeststats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxDigestLabel bounds the digest text exported in the info metric.
+const maxDigestLabel = 256
+
+// ServeHTTP writes the Prometheus text exposition format. With ?format=json
+// (or an Accept header preferring application/json) it writes the top-N view
+// instead; ?top=N (default 20, 0 for all) and ?sort=total|count|errors|mean|max
+// select the rows.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("format") == "json" || (q.Get("format") == "" && strings.HasPrefix(r.Header.Get("Accept"), "application/json")) {
+		top := 20
+		if v := q.Get("top"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "bad top: "+v, http.StatusBadRequest)
+				return
+			}
+			top = n
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(c.TopN(top, q.Get("sort")))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = c.WritePrometheus(w)
+}
+
+// WritePrometheus writes all series in the Prometheus text format:
+//
+//	<ns>_query_duration_seconds histogram by fingerprint
+//	<ns>_query_errors_total     counter by fingerprint
+//	<ns>_query_digest_info      gauge (always 1) mapping fingerprint → digest text
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	stats := c.Snapshot()
+	ns := c.cfg.Namespace
+	bw := bufio.NewWriter(w)
+
+	name := ns + "_query_duration_seconds"
+	fmt.Fprintf(bw, "# HELP %s Statement latency by digest fingerprint.\n# TYPE %s histogram\n", name, name)
+	for _, s := range stats {
+		fp := labelValue(s.Fingerprint)
+		for i, n := range s.Buckets {
+			le := "+Inf"
+			if i < len(c.cfg.Buckets) {
+				le = strconv.FormatFloat(c.cfg.Buckets[i], 'g', -1, 64)
+			}
+			fmt.Fprintf(bw, "%s_bucket{fingerprint=\"%s\",le=\"%s\"} %d\n", name, fp, le, n)
+		}
+		fmt.Fprintf(bw, "%s_sum{fingerprint=\"%s\"} %s\n", name, fp, strconv.FormatFloat(s.TotalTime, 'g', -1, 64))
+		fmt.Fprintf(bw, "%s_count{fingerprint=\"%s\"} %d\n", name, fp, s.Count)
+	}
+
+	name = ns + "_query_errors_total"
+	fmt.Fprintf(bw, "# HELP %s Statements that returned an error, by digest fingerprint.\n# TYPE %s counter\n", name, name)
+	for _, s := range stats {
+		fmt.Fprintf(bw, "%s{fingerprint=\"%s\"} %d\n", name, labelValue(s.Fingerprint), s.Errors)
+	}
+
+	name = ns + "_query_digest_info"
+	fmt.Fprintf(bw, "# HELP %s Digest text of each fingerprint.\n# TYPE %s gauge\n", name, name)
+	for _, s := range stats {
+		if s.Digest == "" {
+			continue
+		}
+		fmt.Fprintf(bw, "%s{fingerprint=\"%s\",digest=\"%s\"} 1\n", name, labelValue(s.Fingerprint), labelValue(truncate(s.Digest, maxDigestLabel)))
+	}
+	return bw.Flush()
+}
+
+// labelValue escapes backslash, double quote and newline as the text format requires.
+func labelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// truncate cuts s to at most n bytes on a rune boundary, marking the cut with "...".
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	i := n - 3
+	for i > 0 && s[i]&0xC0 == 0x80 {
+		i--
+	}
+	return s[:i] + "..."
+}
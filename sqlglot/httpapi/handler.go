@@ -0,0 +1,280 @@
+// Package httpapi serves the sqlglot digest functions over HTTP with JSON
+// bodies, so that services in other languages share one implementation.
+//
+//	POST /v1/digest    {"sql": "...", "dialect": "postgres"}          → {"digest", "sql_type"}
+//	POST /v1/params    same body                                       → sqlglot.Result (digest, params, comments, ...)
+//	POST /v1/split     same body                                       → {"statements": [{index, type, start, end, sql}]}
+//	POST /v1/validate  same body                                       → {"valid", "issues"}
+//	GET  /healthz                                                      → {"status": "ok"}
+//
+// A body with "items": [{"id", "sql", "dialect"}, ...] instead of "sql" is a
+// batch; the response is {"results": [...]} in item order, each result
+// carrying its id and, on failure, an "error" field. Request-level "dialect"
+// and "options" apply to every item; an item may override the dialect.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+)
+
+// Config tunes the handler. The zero value is usable.
+type Config struct {
+	Defaults     sqlglot.Options // used when a request omits dialect or options
+	MaxBodyBytes int64           // request body limit; default 1 MiB
+	MaxBatch     int             // items per batch; default 1000
+}
+
+// Request is the body accepted by every /v1 endpoint.
+type Request struct {
+	SQL     string  `json:"sql,omitempty"`
+	Items   []Item  `json:"items,omitempty"`
+	Dialect string  `json:"dialect,omitempty"`
+	Options Options `json:"options"`
+}
+
+// Item is one statement (or script) of a batch request.
+type Item struct {
+	ID      string `json:"id,omitempty"`
+	SQL     string `json:"sql"`
+	Dialect string `json:"dialect,omitempty"`
+}
+
+// Options mirrors sqlglot.Options; a nil field keeps the server default.
+type Options struct {
+	CollapseValues     *bool `json:"collapse_values,omitempty"`
+	ParamizeTime       *bool `json:"paramize_time,omitempty"`
+	NormalizeBinds     *bool `json:"normalize_binds,omitempty"`
+	NoBackslashEscapes *bool `json:"no_backslash_escapes,omitempty"`
+	PGStatStatements   *bool `json:"pg_stat_statements,omitempty"`
+	PGVersion          *int  `json:"pg_version,omitempty"`
+	MySQLDigestText    *bool `json:"mysql_digest_text,omitempty"`
+	UnwrapDynamic      *bool `json:"unwrap_dynamic,omitempty"`
+	Complexity         *bool `json:"complexity,omitempty"`
+}
+
+// DigestResult is the /v1/digest response for one item.
+type DigestResult struct {
+	ID         string               `json:"id,omitempty"`
+	Digest     string               `json:"digest,omitempty"`
+	SQLType    []string             `json:"sql_type,omitempty"`
+	Complexity []sqlglot.Complexity `json:"complexity,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// ParamsResult is the /v1/params response for one item.
+type ParamsResult struct {
+	ID string `json:"id,omitempty"`
+	sqlglot.Result
+	Error string `json:"error,omitempty"`
+}
+
+// Statement is one entry of a /v1/split response.
+type Statement struct {
+	Index int    `json:"index"`
+	Type  string `json:"type"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	SQL   string `json:"sql"`
+}
+
+// SplitResult is the /v1/split response for one item.
+type SplitResult struct {
+	ID         string      `json:"id,omitempty"`
+	Statements []Statement `json:"statements"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// ValidateResult is the /v1/validate response for one item.
+type ValidateResult struct {
+	ID     string          `json:"id,omitempty"`
+	Valid  bool            `json:"valid"`
+	Issues []sqlglot.Issue `json:"issues,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// BatchResponse wraps the per-item results of a batch request.
+type BatchResponse struct {
+	Results []any `json:"results"`
+}
+
+// ErrorResponse is returned for requests that could not be processed at all,
+// and as the result of a batch item whose dialect is unknown.
+type ErrorResponse struct {
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// processor computes one item; the returned error is also recorded in the result.
+type processor func(id, sql string, opt sqlglot.Options) (any, error)
+
+// NewHandler returns the HTTP handler for the endpoints listed in the package doc.
+func NewHandler(cfg Config) http.Handler {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 1 << 20
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 1000
+	}
+	if cfg.Defaults.Dialect == "" {
+		cfg.Defaults.Dialect = sqlglot.MySQL
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("POST /v1/digest", endpoint(cfg, digestItem))
+	mux.Handle("POST /v1/params", endpoint(cfg, paramsItem))
+	mux.Handle("POST /v1/split", endpoint(cfg, splitItem))
+	mux.Handle("POST /v1/validate", endpoint(cfg, validateItem))
+	return mux
+}
+
+func endpoint(cfg Config, proc processor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, cfg.MaxBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", cfg.MaxBodyBytes))
+				return
+			}
+			writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+			return
+		}
+		opt, err := req.options(cfg.Defaults)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Items == nil {
+			if strings.TrimSpace(req.SQL) == "" {
+				writeError(w, http.StatusBadRequest, `"sql" or "items" is required`)
+				return
+			}
+			res, err := proc("", req.SQL, opt)
+			status := http.StatusOK
+			if err != nil {
+				status = http.StatusUnprocessableEntity
+			}
+			writeJSON(w, status, res)
+			return
+		}
+		if req.SQL != "" {
+			writeError(w, http.StatusBadRequest, `"sql" and "items" are mutually exclusive`)
+			return
+		}
+		if len(req.Items) > cfg.MaxBatch {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("batch has %d items, limit is %d", len(req.Items), cfg.MaxBatch))
+			return
+		}
+		out := BatchResponse{Results: make([]any, len(req.Items))}
+		for i, it := range req.Items {
+			itemOpt := opt
+			if it.Dialect != "" {
+				if itemOpt.Dialect, err = sqlglot.ParseDialect(it.Dialect); err != nil {
+					out.Results[i] = ErrorResponse{ID: it.ID, Error: err.Error()}
+					continue
+				}
+			}
+			out.Results[i], _ = proc(it.ID, it.SQL, itemOpt)
+		}
+		writeJSON(w, http.StatusOK, out)
+	})
+}
+
+// options resolves the request dialect and option overrides against def.
+func (req *Request) options(def sqlglot.Options) (sqlglot.Options, error) {
+	opt := def
+	if req.Dialect != "" {
+		d, err := sqlglot.ParseDialect(req.Dialect)
+		if err != nil {
+			return opt, err
+		}
+		opt.Dialect = d
+	}
+	o := req.Options
+	for _, f := range []struct {
+		v   *bool
+		dst *bool
+	}{
+		{o.CollapseValues, &opt.CollapseValuesInDigest},
+		{o.ParamizeTime, &opt.ParamizeTimeFuncs},
+		{o.NormalizeBinds, &opt.NormalizeBinds},
+		{o.NoBackslashEscapes, &opt.NoBackslashEscapes},
+		{o.PGStatStatements, &opt.PGStatStatements},
+		{o.MySQLDigestText, &opt.MySQLDigestText},
+		{o.UnwrapDynamic, &opt.UnwrapDynamicSQL},
+		{o.Complexity, &opt.Complexity},
+	} {
+		if f.v != nil {
+			*f.dst = *f.v
+		}
+	}
+	if o.PGVersion != nil {
+		opt.PGVersion = *o.PGVersion
+	}
+	return opt, nil
+}
+
+func digestItem(id, sql string, opt sqlglot.Options) (any, error) {
+	res, err := sqlglot.ResultFor(sql, opt)
+	if err != nil {
+		return DigestResult{ID: id, Error: err.Error()}, err
+	}
+	return DigestResult{ID: id, Digest: res.Digest, SQLType: res.SQLType, Complexity: res.Complexity}, nil
+}
+
+func paramsItem(id, sql string, opt sqlglot.Options) (any, error) {
+	res, err := sqlglot.ResultFor(sql, opt)
+	if err != nil {
+		return ParamsResult{ID: id, Error: err.Error()}, err
+	}
+	if res.Params == nil {
+		res.Params = []sqlglot.ExParam{}
+	}
+	return ParamsResult{ID: id, Result: res}, nil
+}
+
+func splitItem(id, sql string, opt sqlglot.Options) (any, error) {
+	stmts, err := sqlglot.Split(sql, opt)
+	if err != nil {
+		return SplitResult{ID: id, Statements: []Statement{}, Error: err.Error()}, err
+	}
+	out := SplitResult{ID: id, Statements: make([]Statement, len(stmts))}
+	for i, st := range stmts {
+		out.Statements[i] = Statement{
+			Index: i + 1,
+			Type:  st.Type,
+			Start: st.StartByte,
+			End:   st.EndByte,
+			SQL:   strings.TrimSpace(sql[st.StartByte:st.EndByte]),
+		}
+	}
+	return out, nil
+}
+
+func validateItem(id, sql string, opt sqlglot.Options) (any, error) {
+	issues, err := sqlglot.Validate(sql, opt)
+	if err != nil {
+		return ValidateResult{ID: id, Error: err.Error()}, err
+	}
+	return ValidateResult{ID: id, Valid: len(issues) == 0, Issues: issues}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, ErrorResponse{Error: msg})
+}
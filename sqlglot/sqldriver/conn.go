@@ -0,0 +1,223 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// wrappedConn implements every optional connection interface and falls back
+// to driver.ErrSkip (or the database/sql default) when the parent does not,
+// so database/sql behaves as it would with the bare driver.
+type wrappedConn struct {
+	parent driver.Conn
+	t      *tracer
+	// skipped is the Exec/Query call last answered with driver.ErrSkip;
+	// database/sql retries it as Prepare plus a statement call, which is
+	// reported as that one call. A Conn is used by one goroutine at a time.
+	skipped *skippedCall
+}
+
+type skippedCall struct {
+	op    Op
+	query string
+	args  []driver.NamedValue
+}
+
+var (
+	_ driver.Conn               = (*wrappedConn)(nil)
+	_ driver.ConnPrepareContext = (*wrappedConn)(nil)
+	_ driver.ConnBeginTx        = (*wrappedConn)(nil)
+	_ driver.ExecerContext      = (*wrappedConn)(nil)
+	_ driver.QueryerContext     = (*wrappedConn)(nil)
+	_ driver.Pinger             = (*wrappedConn)(nil)
+	_ driver.SessionResetter    = (*wrappedConn)(nil)
+	_ driver.Validator          = (*wrappedConn)(nil)
+	_ driver.NamedValueChecker  = (*wrappedConn)(nil)
+)
+
+func (c *wrappedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	skipped := c.skipped
+	c.skipped = nil
+	sig := c.t.sign(query)
+	start := time.Now()
+	if skipped == nil || skipped.query != query {
+		// the implicit prepare of a skipped call was checked by that call
+		if err := c.t.enforce(query); err != nil {
+			c.t.report(ctx, OpPrepare, sig, nil, start, nil, err)
+			return nil, err
+		}
+	}
+	var st driver.Stmt
+	var err error
+	if pc, ok := c.parent.(driver.ConnPrepareContext); ok {
+		st, err = pc.PrepareContext(ctx, query)
+	} else {
+		if err = ctx.Err(); err == nil {
+			st, err = c.parent.Prepare(query)
+		}
+	}
+	if skipped != nil && skipped.query == query {
+		// The implicit prepare of a skipped call: report nothing unless it
+		// fails, and let the statement report the call with its own Op.
+		if err != nil {
+			c.t.report(ctx, skipped.op, sig, skipped.args, start, nil, err)
+			return nil, err
+		}
+		return &wrappedStmt{parent: st, conn: c.parent, sig: sig, t: c.t,
+			execOp: OpExec, queryOp: OpQuery, start: start}, nil
+	}
+	c.t.report(ctx, OpPrepare, sig, nil, start, nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedStmt{parent: st, conn: c.parent, sig: sig, t: c.t,
+		execOp: OpStmtExec, queryOp: OpStmtQuery}, nil
+}
+
+func (c *wrappedConn) Close() error { return c.parent.Close() }
+
+func (c *wrappedConn) Begin() (driver.Tx, error) { return c.parent.Begin() }
+
+func (c *wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bt, ok := c.parent.(driver.ConnBeginTx); ok {
+		return bt.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errors.New("sqldriver: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sqldriver: driver does not support read-only transactions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.parent.Begin()
+}
+
+func (c *wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.t.enforce(query); err != nil {
+		c.t.report(ctx, OpExec, c.t.sign(query), args, time.Now(), nil, err)
+		return nil, err
+	}
+	var run func() (driver.Result, error)
+	switch p := c.parent.(type) {
+	case driver.ExecerContext:
+		run = func() (driver.Result, error) { return p.ExecContext(ctx, query, args) }
+	case driver.Execer:
+		run = func() (driver.Result, error) {
+			vs, err := namedValues(args)
+			if err != nil {
+				return nil, err
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return p.Exec(query, vs)
+		}
+	default:
+		// database/sql prepares the statement instead; the Stmt reports it.
+		return nil, c.skip(OpExec, query, args)
+	}
+	sig := c.t.sign(query)
+	start := time.Now()
+	res, err := run()
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, c.skip(OpExec, query, args)
+	}
+	c.t.report(ctx, OpExec, sig, args, start, res, err)
+	return res, err
+}
+
+func (c *wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.t.enforce(query); err != nil {
+		c.t.report(ctx, OpQuery, c.t.sign(query), args, time.Now(), nil, err)
+		return nil, err
+	}
+	var run func() (driver.Rows, error)
+	switch p := c.parent.(type) {
+	case driver.QueryerContext:
+		run = func() (driver.Rows, error) { return p.QueryContext(ctx, query, args) }
+	case driver.Queryer:
+		run = func() (driver.Rows, error) {
+			vs, err := namedValues(args)
+			if err != nil {
+				return nil, err
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return p.Query(query, vs)
+		}
+	default:
+		return nil, c.skip(OpQuery, query, args)
+	}
+	sig := c.t.sign(query)
+	start := time.Now()
+	rows, err := run()
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, c.skip(OpQuery, query, args)
+	}
+	c.t.report(ctx, OpQuery, sig, args, start, nil, err)
+	return rows, err
+}
+
+// skip remembers a call database/sql is about to retry through Prepare.
+func (c *wrappedConn) skip(op Op, query string, args []driver.NamedValue) error {
+	c.skipped = &skippedCall{op: op, query: query, args: args}
+	return driver.ErrSkip
+}
+
+func (c *wrappedConn) Ping(ctx context.Context) error {
+	if p, ok := c.parent.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *wrappedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.parent.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *wrappedConn) IsValid() bool {
+	if v, ok := c.parent.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *wrappedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if ch, ok := c.parent.(driver.NamedValueChecker); ok {
+		return ch.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// namedValues converts arguments for the pre-context driver interfaces.
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	vs := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("sqldriver: driver does not support the use of Named Parameters")
+		}
+		vs[i] = a.Value
+	}
+	return vs, nil
+}
+
+// ordinalValues is the inverse of namedValues, for Events from pre-context calls.
+func ordinalValues(vs []driver.Value) []driver.NamedValue {
+	args := make([]driver.NamedValue, len(vs))
+	for i, v := range vs {
+		args[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return args
+}
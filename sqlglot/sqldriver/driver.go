@@ -0,0 +1,198 @@
+// Package sqldriver wraps a database/sql driver so that every statement sent
+// through it is digested with sqlglot.Signature and reported to a hook,
+// together with its duration, rows affected and error.
+//
+//	db := sql.OpenDB(sqldriver.WrapConnector(connector, sqlglot.Options{Dialect: sqlglot.Postgres},
+//		func(ctx context.Context, ev sqldriver.Event) {
+//			latency.WithLabelValues(ev.Digest).Observe(ev.Duration.Seconds())
+//		}))
+//
+// or, for drivers registered by name:
+//
+//	sql.Register("postgres+digest", sqldriver.Wrap(&pq.Driver{}, opt, hook))
+//	db, err := sql.Open("postgres+digest", dsn)
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"time"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+)
+
+// Op names the driver call an Event was produced by.
+type Op string
+
+const (
+	OpExec      Op = "exec"       // Conn.Exec / ExecContext
+	OpQuery     Op = "query"      // Conn.Query / QueryContext
+	OpPrepare   Op = "prepare"    // Conn.Prepare / PrepareContext
+	OpStmtExec  Op = "stmt_exec"  // Exec on a prepared statement
+	OpStmtQuery Op = "stmt_query" // Query on a prepared statement
+)
+
+// When the underlying connection cannot execute directly, database/sql
+// prepares the statement, runs it and closes it; that sequence is reported
+// as a single OpExec/OpQuery event, not as OpPrepare plus OpStmtExec.
+
+// Event describes one call that carried SQL text.
+type Event struct {
+	Op      Op
+	SQL     string
+	Digest  string // empty when the SQL could not be digested (see DigestErr)
+	Params  []sqlglot.ExParam
+	SQLType []string
+	// Args are the bind values passed by the caller; they are not redacted.
+	Args []driver.NamedValue
+	// Duration is measured around the driver call. For queries it ends when
+	// the driver returns its Rows, before any row is read.
+	Duration time.Duration
+	// RowsAffected is filled for exec calls that succeeded and -1 otherwise.
+	RowsAffected int64
+	Err          error // error returned by the driver
+	DigestErr    error // error returned by sqlglot.Signature
+}
+
+// Hook receives one Event per Exec/Query/Prepare call. It runs synchronously
+// on the calling goroutine and must be safe for concurrent use.
+type Hook func(ctx context.Context, ev Event)
+
+// Option configures Wrap and WrapConnector.
+type Option func(*tracer)
+
+// WithPolicy makes wrapped connections refuse statements that violate p: a
+// Prepare, Exec or Query whose SQL has violations returns a *PolicyError
+// without calling the underlying driver, and its Event carries that error.
+// Executions of a prepared statement are not checked again. SQL that cannot
+// be lexed is passed through.
+func WithPolicy(p sqlglot.Policy) Option {
+	return func(t *tracer) { t.policy = &p }
+}
+
+// Wrap returns a driver whose connections report every statement to hook.
+// Digests are computed with opt.
+func Wrap(d driver.Driver, opt sqlglot.Options, hook Hook, opts ...Option) driver.Driver {
+	return &wrappedDriver{parent: d, t: newTracer(opt, hook, opts)}
+}
+
+// WrapConnector is Wrap for a driver.Connector, for use with sql.OpenDB.
+func WrapConnector(c driver.Connector, opt sqlglot.Options, hook Hook, opts ...Option) driver.Connector {
+	t := newTracer(opt, hook, opts)
+	return &wrappedConnector{parent: c, driver: &wrappedDriver{parent: c.Driver(), t: t}, t: t}
+}
+
+// tracer holds the digest options, the hook and the policy shared by all
+// wrapped objects.
+type tracer struct {
+	opt    sqlglot.Options
+	hook   Hook
+	policy *sqlglot.Policy
+}
+
+func newTracer(opt sqlglot.Options, hook Hook, opts []Option) *tracer {
+	t := &tracer{opt: opt, hook: hook}
+	for _, o := range opts {
+		o(t)
+	}
+	return t
+}
+
+// signature is the digest of one SQL text, computed once per Prepare.
+type signature struct {
+	sql     string
+	digest  string
+	params  []sqlglot.ExParam
+	sqlType []string
+	err     error
+}
+
+func (t *tracer) sign(query string) signature {
+	s := signature{sql: query}
+	s.digest, s.params, s.sqlType, s.err = sqlglot.Signature(query, t.opt)
+	return s
+}
+
+func (t *tracer) report(ctx context.Context, op Op, sig signature, args []driver.NamedValue, start time.Time, res driver.Result, err error) {
+	if t.hook == nil {
+		return
+	}
+	ev := Event{
+		Op:           op,
+		SQL:          sig.sql,
+		Digest:       sig.digest,
+		Params:       sig.params,
+		SQLType:      sig.sqlType,
+		Args:         args,
+		Duration:     time.Since(start),
+		RowsAffected: -1,
+		Err:          err,
+		DigestErr:    sig.err,
+	}
+	if err == nil && res != nil {
+		if n, rerr := res.RowsAffected(); rerr == nil {
+			ev.RowsAffected = n
+		}
+	}
+	t.hook(ctx, ev)
+}
+
+type wrappedDriver struct {
+	parent driver.Driver
+	t      *tracer
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.parent.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedConn{parent: c, t: d.t}, nil
+}
+
+func (d *wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.parent.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &wrappedConnector{parent: c, driver: d, t: d.t}, nil
+	}
+	return &dsnConnector{name: name, driver: d}, nil
+}
+
+type wrappedConnector struct {
+	parent driver.Connector
+	driver *wrappedDriver
+	t      *tracer
+}
+
+func (c *wrappedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.parent.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedConn{parent: conn, t: c.t}, nil
+}
+
+func (c *wrappedConnector) Driver() driver.Driver { return c.driver }
+
+// Close is called by sql.DB.Close; it closes the parent connector when it
+// holds resources of its own.
+func (c *wrappedConnector) Close() error {
+	if cl, ok := c.parent.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+// dsnConnector serves drivers that do not implement driver.DriverContext.
+type dsnConnector struct {
+	name   string
+	driver *wrappedDriver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.name) }
+
+func (c *dsnConnector) Driver() driver.Driver { return c.driver }
@@ -0,0 +1,69 @@
+package sqldriver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+)
+
+// PolicyHook returns a Hook that checks the SQL of every Prepare and direct
+// Exec/Query call against p and calls fn when it has violations. Executions
+// of a prepared statement are not checked again. SQL that cannot be lexed is
+// skipped.
+//
+// PolicyHook is for auditing only: a Hook runs after the driver call and
+// cannot fail it, so a violating statement has already been executed when fn
+// sees it. To refuse statements, wrap the driver with WithPolicy:
+//
+//	db := sql.OpenDB(sqldriver.WrapConnector(connector, opt, hook,
+//		sqldriver.WithPolicy(sqlglot.DefaultPolicy())))
+//
+// Auditing from the driver:
+//
+//	hook := sqldriver.PolicyHook(sqlglot.DefaultPolicy(), opt,
+//		func(ctx context.Context, ev sqldriver.Event, vs []sqlglot.PolicyViolation) {
+//			for _, v := range vs {
+//				log.Printf("policy %s: %s: %s", v.Rule, v.Message, ev.SQL)
+//			}
+//		})
+func PolicyHook(p sqlglot.Policy, opt sqlglot.Options, fn func(ctx context.Context, ev Event, violations []sqlglot.PolicyViolation)) Hook {
+	return func(ctx context.Context, ev Event) {
+		if ev.Op == OpStmtExec || ev.Op == OpStmtQuery {
+			return
+		}
+		vs, err := sqlglot.CheckPolicy(ev.SQL, p, opt)
+		if err != nil || len(vs) == 0 {
+			return
+		}
+		fn(ctx, ev, vs)
+	}
+}
+
+// PolicyError is returned by connections wrapped with WithPolicy for a
+// statement that violates the policy; the statement was not sent to the
+// driver.
+type PolicyError struct {
+	SQL        string
+	Violations []sqlglot.PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Rule + ": " + v.Message
+	}
+	return "sqldriver: policy violation: " + strings.Join(msgs, "; ")
+}
+
+// enforce returns a *PolicyError when query violates the WithPolicy policy.
+func (t *tracer) enforce(query string) error {
+	if t.policy == nil {
+		return nil
+	}
+	vs, err := sqlglot.CheckPolicy(query, *t.policy, t.opt)
+	if err != nil || len(vs) == 0 {
+		return nil
+	}
+	return &PolicyError{SQL: query, Violations: vs}
+}
@@ -0,0 +1,107 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// wrappedStmt reuses the signature computed at Prepare for every execution.
+type wrappedStmt struct {
+	parent driver.Stmt
+	conn   driver.Conn
+	sig    signature
+	t      *tracer
+	// execOp/queryOp are OpStmtExec/OpStmtQuery for statements the caller
+	// prepared and OpExec/OpQuery for the implicit prepare of a skipped
+	// call, whose duration then starts at the prepare (start).
+	execOp, queryOp Op
+	start           time.Time
+}
+
+var (
+	_ driver.Stmt              = (*wrappedStmt)(nil)
+	_ driver.StmtExecContext   = (*wrappedStmt)(nil)
+	_ driver.StmtQueryContext  = (*wrappedStmt)(nil)
+	_ driver.NamedValueChecker = (*wrappedStmt)(nil)
+	_ driver.ColumnConverter   = (*wrappedStmt)(nil)
+)
+
+func (s *wrappedStmt) begin() time.Time {
+	if !s.start.IsZero() {
+		return s.start
+	}
+	return time.Now()
+}
+
+func (s *wrappedStmt) Close() error  { return s.parent.Close() }
+func (s *wrappedStmt) NumInput() int { return s.parent.NumInput() }
+
+func (s *wrappedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := s.begin()
+	res, err := s.parent.Exec(args)
+	s.t.report(context.Background(), s.execOp, s.sig, ordinalValues(args), start, res, err)
+	return res, err
+}
+
+func (s *wrappedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := s.begin()
+	rows, err := s.parent.Query(args)
+	s.t.report(context.Background(), s.queryOp, s.sig, ordinalValues(args), start, nil, err)
+	return rows, err
+}
+
+func (s *wrappedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := s.begin()
+	var res driver.Result
+	var err error
+	if ec, ok := s.parent.(driver.StmtExecContext); ok {
+		res, err = ec.ExecContext(ctx, args)
+	} else {
+		var vs []driver.Value
+		if vs, err = namedValues(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				res, err = s.parent.Exec(vs)
+			}
+		}
+	}
+	s.t.report(ctx, s.execOp, s.sig, args, start, res, err)
+	return res, err
+}
+
+func (s *wrappedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := s.begin()
+	var rows driver.Rows
+	var err error
+	if qc, ok := s.parent.(driver.StmtQueryContext); ok {
+		rows, err = qc.QueryContext(ctx, args)
+	} else {
+		var vs []driver.Value
+		if vs, err = namedValues(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				rows, err = s.parent.Query(vs)
+			}
+		}
+	}
+	s.t.report(ctx, s.queryOp, s.sig, args, start, nil, err)
+	return rows, err
+}
+
+// CheckNamedValue follows database/sql's lookup order: the statement's
+// checker first, then the connection's, then the default conversion.
+func (s *wrappedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if ch, ok := s.parent.(driver.NamedValueChecker); ok {
+		return ch.CheckNamedValue(nv)
+	}
+	if ch, ok := s.conn.(driver.NamedValueChecker); ok {
+		return ch.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (s *wrappedStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if cc, ok := s.parent.(driver.ColumnConverter); ok {
+		return cc.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
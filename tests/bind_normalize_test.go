@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run Bind_Normalize
+
+func Test_Bind_Normalize_SameDigestAcrossStyles(t *testing.T) {
+	cases := []struct {
+		dialect d.Dialect
+		a, b    string
+	}{
+		{d.MySQL, `SELECT * FROM t WHERE id = ? AND name = ?`, `SELECT * FROM t WHERE id = :id AND name = :name`},
+		{d.Postgres, `SELECT * FROM t WHERE id = $1 AND name = $2`, `SELECT * FROM t WHERE id = ? AND name = @name`},
+		{d.SQLServer, `SELECT * FROM t WHERE id = @p1 AND name = @p2`, `SELECT * FROM t WHERE id = :1 AND name = :2`},
+		{d.Oracle, `SELECT * FROM t WHERE id = :1 AND name = :name`, `SELECT * FROM t WHERE id = ? AND name = @name`},
+	}
+	for i, c := range cases {
+		opt := d.Options{Dialect: c.dialect, NormalizeBinds: true}
+		ra, err := d.BuildDigestANTLR(c.a, opt)
+		if err != nil {
+			t.Fatalf("[#%d] build a: %v", i, err)
+		}
+		rb, err := d.BuildDigestANTLR(c.b, opt)
+		if err != nil {
+			t.Fatalf("[#%d] build b: %v", i, err)
+		}
+		if ra.Digest != rb.Digest {
+			t.Fatalf("[#%d %s] digests differ:\n a=%q\n b=%q", i, c.dialect, ra.Digest, rb.Digest)
+		}
+		if len(rb.Params) != 2 {
+			t.Fatalf("[#%d %s] param count: %+v", i, c.dialect, rb.Params)
+		}
+		for _, p := range rb.Params {
+			if c.b[p.Start:p.End] != p.Value {
+				t.Fatalf("[#%d %s] param range mismatch: %+v", i, c.dialect, p)
+			}
+		}
+	}
+}
+
+func Test_Bind_Normalize_OffKeepsDigest(t *testing.T) {
+	sql := `SELECT * FROM t WHERE id = :id`
+	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if res.Digest != "SELECT * FROM T WHERE ID = : ID" {
+		t.Fatalf("digest without NormalizeBinds changed: %q", res.Digest)
+	}
+}
+
+func Test_Bind_Normalize_SliceNotBind(t *testing.T) {
+	sql := `SELECT arr[1:n] FROM t`
+	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.MySQL, NormalizeBinds: true})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	for _, p := range res.Params {
+		if p.Type == "NamedBind" {
+			t.Fatalf("array slice should not become a bind: %+v", res.Params)
+		}
+	}
+}
+
+func Test_Bind_Ordinals_RepeatedNamed(t *testing.T) {
+	sql := `SELECT * FROM t WHERE a = :id OR b = :name OR c = :ID`
+	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.Oracle})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	want := []struct {
+		name string
+		ord  int
+	}{{"id", 1}, {"name", 2}, {"ID", 1}}
+	if len(res.Params) != len(want) {
+		t.Fatalf("param count: %+v", res.Params)
+	}
+	for i, w := range want {
+		if p := res.Params[i]; p.BindName != w.name || p.BindOrdinal != w.ord {
+			t.Fatalf("param #%d: name=%q ord=%d want %q/%d", i+1, p.BindName, p.BindOrdinal, w.name, w.ord)
+		}
+	}
+
+	res, err = d.BuildDigestANTLR(`SELECT $2, $1, $2, 'x'`, d.Options{Dialect: d.Postgres})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	ords := []int{2, 1, 2, 0}
+	for i, p := range res.Params {
+		if p.BindOrdinal != ords[i] {
+			t.Fatalf("pg param #%d ordinal=%d want %d", i+1, p.BindOrdinal, ords[i])
+		}
+	}
+}
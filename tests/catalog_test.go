@@ -0,0 +1,243 @@
+package tests
+
+// go test -v -count=1 . -run Catalog_
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+const catalogMyDDL = `
+CREATE TABLE IF NOT EXISTS shop.users (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  name VARCHAR(64) CHARACTER SET utf8mb4 NOT NULL,
+  ` + "`select`" + ` TINYINT(1) DEFAULT 0,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_name (name)
+) ENGINE=InnoDB;
+CREATE TABLE shop.orders (id BIGINT PRIMARY KEY, user_id BIGINT, amount DECIMAL(10, 2), created DATETIME,
+  CONSTRAINT fk_u FOREIGN KEY (user_id) REFERENCES users (id));
+CREATE TABLE shop.archive (id BIGINT, uid BIGINT, total DECIMAL(10,2));
+CREATE INDEX ix ON shop.orders (created);
+`
+
+func catalogOf(t *testing.T, dialect d.Dialect, ddl string) *d.Catalog {
+	t.Helper()
+	c, err := d.CatalogFromDDL(ddl, d.Options{Dialect: dialect})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func checkQualify(t *testing.T, opt d.Options, sql, want string) {
+	t.Helper()
+	got, err := d.Qualify(sql, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("%s\n--- got\n%s\n--- want\n%s", sql, got, want)
+	}
+}
+
+func Test_Catalog_FromDDL(t *testing.T) {
+	c := catalogOf(t, d.MySQL, catalogMyDDL)
+	u := c.Table("shop.users")
+	if u == nil {
+		t.Fatal("shop.users missing")
+	}
+	var cols []string
+	for _, col := range u.Columns {
+		cols = append(cols, col.Name+" "+col.Type)
+	}
+	if got := strings.Join(cols, "; "); got != "id BIGINT UNSIGNED; name VARCHAR(64); select TINYINT(1)" {
+		t.Fatalf("users: %s", got)
+	}
+	if col := c.Table("`shop`.`ORDERS`").Column("AMOUNT"); col == nil || col.Type != "DECIMAL(10,2)" {
+		t.Fatalf("orders.amount: %+v", col)
+	}
+	if c.Table("orders") == nil || c.Table("nope") != nil || c.Table("other.orders") != nil {
+		t.Fatal("lookup")
+	}
+
+	// PG：带引号的名字、多词类型、数组；CREATE TABLE AS 不算
+	pg := catalogOf(t, d.Postgres, `create table "Sales"."Item" ("Id" serial primary key, price double precision not null,
+		tags text[], at timestamp(3) with time zone default now(), check (price > 0));
+		create table x as select 1 as a;`)
+	it := pg.Table(`"Sales"."Item"`)
+	if it == nil || len(it.Columns) != 4 || it.Columns[0].Name != "Id" || it.Columns[1].Type != "double precision" ||
+		it.Columns[2].Type != "text[]" || it.Columns[3].Type != "timestamp(3) with time zone" {
+		t.Fatalf("pg: %+v", it)
+	}
+	if pg.Table("x") != nil {
+		t.Fatal("CTAS should not be cataloged")
+	}
+
+	// SQL Server 三段名、IDENTITY；Oracle 临时表
+	ms := catalogOf(t, d.SQLServer, "create table [db].[dbo].[t] ([id] int identity(1,1) not null, v nvarchar(max) null)")
+	if tb := ms.Table("db.dbo.t"); tb == nil || len(tb.Columns) != 2 || tb.Columns[1].Type != "nvarchar(max)" {
+		t.Fatalf("sqlserver: %+v", tb)
+	}
+	or := catalogOf(t, d.Oracle, "create global temporary table tmp_ids (id number(10)) on commit delete rows")
+	if tb := or.Table("TMP_IDS"); tb == nil || tb.Columns[0].Type != "number(10)" {
+		t.Fatalf("oracle: %+v", tb)
+	}
+}
+
+func Test_Catalog_ParseJSON(t *testing.T) {
+	c, err := d.ParseCatalog([]byte(`{"default_schema": "public", "databases": [{"schemas": [{"name": "public",
+		"tables": [{"name": "t", "columns": [{"name": "a", "type": "int"}, {"name": "b"}]}]}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Table("t") == nil || c.Table("public.t").Column("b") == nil || c.Table("other.t") != nil {
+		t.Fatalf("%+v", c)
+	}
+	for _, bad := range []string{
+		`{"databases": [], "extra": 1}`,
+		`{"databases": [{"schemas": [{"tables": [{"columns": []}]}]}]}`,
+		`{"databases": `,
+	} {
+		if _, err := d.ParseCatalog([]byte(bad)); err == nil {
+			t.Fatalf("want error for %s", bad)
+		}
+	}
+	c.AddTable("", "public", d.CatalogTable{Name: "T", Columns: []d.CatalogColumn{{Name: "z"}}})
+	if tb := c.Table("t"); tb == nil || len(tb.Columns) != 1 || tb.Columns[0].Name != "z" {
+		t.Fatalf("AddTable should replace: %+v", tb)
+	}
+}
+
+func Test_Catalog_Qualify(t *testing.T) {
+	opt := d.Options{Dialect: d.MySQL, Catalog: catalogOf(t, d.MySQL, catalogMyDDL)}
+	checkQualify(t, opt, "select * from shop.users", "select users.id, users.name, users.`select` from shop.users")
+	checkQualify(t, opt, "select u.*, amount from shop.users u join shop.orders o on o.user_id = u.id where created > now() /* keep */",
+		"select u.id, u.name, u.`select`, o.amount from shop.users u join shop.orders o on o.user_id = u.id where o.created > now() /* keep */")
+	// GROUP BY / HAVING / ORDER BY 里与输出列同名的名字、歧义列、USING 合并列都不动
+	checkQualify(t, opt, "select user_id, sum(amount) total from shop.orders group by user_id having total > 1 order by total",
+		"select orders.user_id, sum(orders.amount) total from shop.orders group by user_id having total > 1 order by total")
+	checkQualify(t, opt, "select id, name from shop.users u, shop.orders o", "select id, u.name from shop.users u, shop.orders o")
+	checkQualify(t, opt, "select id, amount from shop.orders join shop.archive using (id)",
+		"select id, orders.amount from shop.orders join shop.archive using (id)")
+	// 关联子查询、派生表
+	checkQualify(t, opt, "select name from shop.users u where exists (select 1 from shop.orders where user_id = u.id)",
+		"select u.name from shop.users u where exists (select 1 from shop.orders where orders.user_id = u.id)")
+	checkQualify(t, opt, "select s.* from (select user_id, count(*) n from shop.orders) s",
+		"select s.user_id, s.n from (select orders.user_id, count(*) n from shop.orders) s")
+	// 写入语句：目标列不加限定
+	checkQualify(t, opt, "update shop.orders set amount = amount * 2 where created < now(); delete from shop.users where name = 'x'",
+		"update shop.orders set amount = orders.amount * 2 where orders.created < now(); delete from shop.users where users.name = 'x'")
+	// 目录里没有的表：已知表里没有的列只能属于它
+	checkQualify(t, opt, "select id, x from shop.users join other o on o.uid = id",
+		"select users.id, o.x from shop.users join other o on o.uid = users.id")
+	checkQualify(t, opt, "select x, y from other o, another a", "select x, y from other o, another a")
+
+	// 无目录：FROM 里只有一张表，或派生表 / CTE 的列已知时才归属
+	checkQualify(t, d.Options{Dialect: d.Postgres}, "with r as (select a, b from t) select * from r where a > 1",
+		"with r as (select t.a, t.b from t) select r.a, r.b from r where r.a > 1")
+	checkQualify(t, d.Options{Dialect: d.Postgres}, "select a, b.c from t, b", "select a, b.c from t, b")
+
+	// Oracle：PIVOT 不展开、不限定
+	ora := d.Options{Dialect: d.Oracle, Catalog: catalogOf(t, d.Oracle, "create table s (y number, q number, v number)")}
+	pivot := "select * from s pivot (sum(v) for q in (1 as q1, 2 as q2))"
+	checkQualify(t, ora, pivot, pivot)
+	checkQualify(t, ora, "select y, level from s connect by prior y = q", "select s.y, level from s connect by prior s.y = s.q")
+}
+
+func Test_Catalog_Lint(t *testing.T) {
+	cat := catalogOf(t, d.MySQL, catalogMyDDL)
+	rules := []d.LintRule{}
+	for _, r := range d.DefaultLintRules() {
+		switch r.Name() {
+		case d.LintUnknownTable, d.LintUnknownColumn, d.LintAmbiguousColumn:
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) != 3 {
+		t.Fatalf("schema rules missing from DefaultLintRules: %d", len(rules))
+	}
+	sql := "select id, nope, u.zzz from shop.users u join shop.orders o on o.user_id = u.id; " +
+		"insert into shop.archive (id, uid, totl) select id, user_id, amount from shop.orders; " +
+		"update shop.users set nam = 'x'; select * from shop.missing; " +
+		"with r as (select 1 as k) select k from r"
+	fs, err := d.Lint(sql, rules, d.Options{Dialect: d.MySQL, Catalog: cat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range fs {
+		got = append(got, fmt.Sprintf("#%d %s %s %s", f.Statement, f.Rule, f.Severity, sql[f.Start:f.End]))
+	}
+	want := []string{
+		"#1 ambiguous_column medium id",
+		"#1 unknown_column high nope",
+		"#1 unknown_column high u.zzz",
+		"#2 unknown_column high totl",
+		"#3 unknown_column high nam",
+		"#4 unknown_table high shop.missing",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("--- got\n%s\n--- want\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	// 无目录时不报
+	if fs, _ := d.Lint(sql, rules, d.Options{Dialect: d.MySQL}); len(fs) != 0 {
+		t.Fatalf("without catalog: %+v", fs)
+	}
+}
+
+func Test_Catalog_Lineage(t *testing.T) {
+	cat := catalogOf(t, d.MySQL, catalogMyDDL)
+	ls, err := d.Lineage("insert into shop.archive select o.id, u.id, amount from shop.orders o join shop.users u on u.id = user_id; "+
+		"insert into shop.archive (id, total) select * from (select id, amount from shop.orders) x",
+		d.Options{Dialect: d.MySQL, Catalog: cat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, l := range ls {
+		var src []string
+		for _, s := range l.Sources {
+			src = append(src, s.Table+"."+s.Column)
+		}
+		got = append(got, fmt.Sprintf("#%d %s.%s <- %s", l.Statement, l.Target.Table, l.Target.Column, strings.Join(src, ", ")))
+	}
+	want := []string{
+		"#1 shop.archive.id <- shop.orders.id",
+		"#1 shop.archive.uid <- shop.users.id",
+		"#1 shop.archive.total <- shop.orders.amount",
+		"#2 shop.archive.id <- shop.orders.id",
+		"#2 shop.archive.total <- shop.orders.amount",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("--- got\n%s\n--- want\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// 跨语料：Qualify 不报错，且去掉空白后只多出限定前缀或展开（语句数不变）
+func Test_Catalog_Corpus(t *testing.T) {
+	for _, c := range []struct {
+		dialect d.Dialect
+		sqls    []string
+	}{
+		{d.Postgres, corpusPG25}, {d.MySQL, corpusMy25}, {d.SQLServer, corpusMS25}, {d.Oracle, corpusOR25},
+		{d.Postgres, corpusPG}, {d.MySQL, corpusMySQL}, {d.SQLServer, corpusMSSQL}, {d.Oracle, corpusOracle},
+		{d.Postgres, seedDML_PG()}, {d.MySQL, seedDML_MySQL()}, {d.SQLServer, seedDML_MSSQL()}, {d.Oracle, seedDML_Oracle()},
+	} {
+		opt := d.Options{Dialect: c.dialect}
+		for i, s := range c.sqls {
+			out, err := d.Qualify(s, opt)
+			if err != nil {
+				t.Fatalf("%s #%d: %v", c.dialect, i, err)
+			}
+			a, _ := d.SplitSQL(s, opt)
+			b, _ := d.SplitSQL(out, opt)
+			if len(a) != len(b) {
+				t.Fatalf("%s #%d: statement count %d -> %d\n%s\n%s", c.dialect, i, len(a), len(b), s, out)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run Comments_|Hints_
+
+func Test_Comments_KindsAndSpans(t *testing.T) {
+	for _, dl := range []d.Dialect{d.MySQL, d.Postgres, d.SQLServer, d.Oracle} {
+		sql := "SELECT /*+ INDEX(t idx_a) */ a -- tail\nFROM t /* plain */"
+		res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: dl})
+		if err != nil {
+			t.Fatalf("[%s] build: %v", dl, err)
+		}
+		kinds := []string{}
+		for _, c := range res.Comments {
+			if sql[c.Start:c.End] != c.Text {
+				t.Fatalf("[%s] comment span mismatch: %+v", dl, c)
+			}
+			kinds = append(kinds, c.Kind)
+		}
+		if !reflect.DeepEqual(kinds, []string{"hint", "line", "block"}) {
+			t.Fatalf("[%s] kinds=%v comments=%+v", dl, kinds, res.Comments)
+		}
+		if res.Comments[1].Text != "-- tail" {
+			t.Fatalf("[%s] line comment should not include newline: %q", dl, res.Comments[1].Text)
+		}
+	}
+}
+
+func Test_Comments_SQLCommenterTags(t *testing.T) {
+	sql := `SELECT * FROM users /*action='list%20users',traceparent='00-4bf92f-00f067-01',framework='spring\'s'*/`
+	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.Postgres})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(res.Comments) != 1 {
+		t.Fatalf("comments: %+v", res.Comments)
+	}
+	want := map[string]string{"action": "list users", "traceparent": "00-4bf92f-00f067-01", "framework": "spring's"}
+	if !reflect.DeepEqual(res.Comments[0].Tags, want) {
+		t.Fatalf("tags: %v", res.Comments[0].Tags)
+	}
+	if res.Digest != "SELECT * FROM USERS" {
+		t.Fatalf("digest should still drop comments: %q", res.Digest)
+	}
+}
+
+func Test_Hints_CommentAndOption(t *testing.T) {
+	sql := `SELECT /*+ MAX_EXECUTION_TIME(1000) INDEX(t1 idx_a, idx_b) SET_VAR(sort_buffer_size = 16M) NO_ICP */ * FROM t1`
+	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	want := []d.Hint{
+		{Name: "MAX_EXECUTION_TIME", Args: []string{"1000"}},
+		{Name: "INDEX", Args: []string{"t1 idx_a", "idx_b"}},
+		{Name: "SET_VAR", Args: []string{"sort_buffer_size=16M"}},
+		{Name: "NO_ICP"},
+	}
+	assertHints(t, sql, res.Hints, want)
+
+	sql = `SELECT * FROM t WHERE a = @p OPTION (RECOMPILE, MAXDOP 4, OPTIMIZE FOR (@p = 5, @q UNKNOWN), HASH JOIN, USE HINT('DISABLE_OPTIMIZER_ROWGOAL'))`
+	res, err = d.BuildDigestANTLR(sql, d.Options{Dialect: d.SQLServer})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	want = []d.Hint{
+		{Name: "RECOMPILE"},
+		{Name: "MAXDOP", Args: []string{"4"}},
+		{Name: "OPTIMIZE FOR", Args: []string{"@p = 5", "@q UNKNOWN"}},
+		{Name: "HASH JOIN"},
+		{Name: "USE HINT", Args: []string{"'DISABLE_OPTIMIZER_ROWGOAL'"}},
+	}
+	assertHints(t, sql, res.Hints, want)
+}
+
+func assertHints(t *testing.T, sql string, got, want []d.Hint) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("hint count: got %+v", got)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || !reflect.DeepEqual(got[i].Args, want[i].Args) {
+			t.Fatalf("hint #%d: got %+v want %+v", i+1, got[i], want[i])
+		}
+		if got[i].Start < 0 || got[i].End > len(sql) || got[i].Start >= got[i].End {
+			t.Fatalf("hint #%d bad span: %+v", i+1, got[i])
+		}
+	}
+}
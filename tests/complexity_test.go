@@ -0,0 +1,142 @@
+package tests
+
+// go test -v -count=1 . -run Complexity_
+
+import (
+	"reflect"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+func complexityOf(t *testing.T, dialect d.Dialect, sql string) []d.Complexity {
+	t.Helper()
+	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: dialect, Complexity: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Complexity) != len(res.SQLType) {
+		t.Fatalf("complexity not aligned with sql_type: %d vs %d", len(res.Complexity), len(res.SQLType))
+	}
+	return res.Complexity
+}
+
+func Test_Complexity_PG(t *testing.T) {
+	sql := `with recursive t(n) as (select 1 union all select n + 1 from t where n < 10), x as (select * from y)
+select a.id, count(*), sum(b.v) over (partition by a.g), row_number() over (order by a.id)
+from a left outer join b on b.a_id = a.id and b.x <> 3 cross join t, x, lateral (select 1) l
+where a.s in (1, 2, 3) and a.k not in (select k from z where z.q is not null) and a.d between 1 and 2
+  and exists (select 1 from w where w.id = a.id and w.z in ('a', 'b'))
+group by a.id having count(*) >= 2
+union select 1, 2, 3, 4`
+	want := d.Complexity{
+		Joins:              4,
+		JoinTypes:          map[string]int{"LEFT": 1, "CROSS": 1, "COMMA": 2},
+		Subqueries:         3,
+		SubqueryDepth:      1,
+		CTEs:               2,
+		RecursiveCTEs:      1,
+		UnionBranches:      4, // CTE 里 2 个分支 + 外层 2 个分支
+		WindowFunctions:    2,
+		AggregateFunctions: 2,
+		InLists:            []int{3, 2},
+		MaxInList:          3,
+		Predicates:         11,
+		Tables:             []string{"y", "a", "b", "z", "w"},
+	}
+	got := complexityOf(t, d.Postgres, sql)
+	if len(got) != 1 || !reflect.DeepEqual(got[0], want) {
+		t.Fatalf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func Test_Complexity_MultiStatement(t *testing.T) {
+	sql := "update public.t set a = 1 where b like 'x%'; delete from s.t where id in (select id from u where u.x in (select x from v)); " +
+		"insert into q (a) select a from r join s on r.id = s.id"
+	got := complexityOf(t, d.Postgres, sql)
+	if len(got) != 3 {
+		t.Fatalf("want 3 statements, got %d", len(got))
+	}
+	if !reflect.DeepEqual(got[0].Tables, []string{"public.t"}) || got[0].Predicates != 1 {
+		t.Fatalf("update: %+v", got[0])
+	}
+	if got[1].Subqueries != 2 || got[1].SubqueryDepth != 2 || !reflect.DeepEqual(got[1].Tables, []string{"s.t", "u", "v"}) {
+		t.Fatalf("delete: %+v", got[1])
+	}
+	if got[2].Joins != 1 || got[2].JoinTypes["INNER"] != 1 || !reflect.DeepEqual(got[2].Tables, []string{"q", "r", "s"}) {
+		t.Fatalf("insert: %+v", got[2])
+	}
+}
+
+func Test_Complexity_Dialects(t *testing.T) {
+	// MySQL：STRAIGHT_JOIN 修饰词不算连接；<=> 算一个谓词；ON DUPLICATE KEY 不是条件
+	my := complexityOf(t, d.MySQL, "select straight_join a.x, group_concat(b.y) from db1.a inner join b using (id) natural join c where a.z = 1 and b.w <=> null; "+
+		"insert into t (a, b) values (1, 2) on duplicate key update b = values(b)")
+	if my[0].Joins != 2 || my[0].JoinTypes["NATURAL"] != 1 || my[0].AggregateFunctions != 1 || my[0].Predicates != 2 ||
+		!reflect.DeepEqual(my[0].Tables, []string{"db1.a", "b", "c"}) {
+		t.Fatalf("mysql select: %+v", my[0])
+	}
+	if my[1].Predicates != 0 || !reflect.DeepEqual(my[1].Tables, []string{"t"}) {
+		t.Fatalf("mysql insert: %+v", my[1])
+	}
+
+	// SQL Server：APPLY、联接提示、UPDATE TOP、MERGE ... USING
+	ms := complexityOf(t, d.SQLServer, "merge into dbo.t as tgt using src s on tgt.id = s.id when matched then update set a = 1; "+
+		"update top (5) x set a = 1 from x inner hash join y on x.id = y.id where x.b >= 2; "+
+		"select * from a cross apply f(a.id) outer apply (select top 1 * from b where b.a = a.id) z")
+	if ms[0].Predicates != 1 || !reflect.DeepEqual(ms[0].Tables, []string{"dbo.t", "src"}) {
+		t.Fatalf("mssql merge: %+v", ms[0])
+	}
+	if ms[1].JoinTypes["INNER"] != 1 || ms[1].Predicates != 2 || !reflect.DeepEqual(ms[1].Tables, []string{"x", "y"}) {
+		t.Fatalf("mssql update: %+v", ms[1])
+	}
+	if ms[2].JoinTypes["APPLY"] != 2 || ms[2].Subqueries != 1 || !reflect.DeepEqual(ms[2].Tables, []string{"a", "b"}) {
+		t.Fatalf("mssql apply: %+v", ms[2])
+	}
+
+	// Oracle：KEEP ... OVER 是窗口函数，LISTAGG ... WITHIN GROUP 是聚合；被拆开的 < = 只算一次；DUAL 不算表
+	or := complexityOf(t, d.Oracle, "select listagg(e.n, ',') within group (order by e.n), max(e.s) keep (dense_rank first order by e.d) over (partition by e.g) "+
+		"from emp@remote e, dept d where e.dept_id = d.id(+) and e.s <= :n connect by prior e.id = e.mgr start with e.mgr is null "+
+		"minus select 1, 2 from dual")
+	want := d.Complexity{
+		Joins: 1, JoinTypes: map[string]int{"COMMA": 1}, UnionBranches: 2, WindowFunctions: 1, AggregateFunctions: 1,
+		Predicates: 4, Tables: []string{"emp@remote", "dept"},
+	}
+	if !reflect.DeepEqual(or[0], want) {
+		t.Fatalf("oracle: got %+v\nwant %+v", or[0], want)
+	}
+}
+
+func Test_Complexity_Exceeds(t *testing.T) {
+	c := complexityOf(t, d.MySQL, "select * from a join b on a.id = b.id join c on c.id = b.id where a.x in (1, 2, 3, 4, 5, 6)")[0]
+	got := c.Exceeds(d.ComplexityLimits{Joins: 1, MaxInList: 10, SubqueryDepth: 3, Tables: 2})
+	if !reflect.DeepEqual(got, []string{"joins", "tables"}) {
+		t.Fatalf("got %v", got)
+	}
+	if got := c.Exceeds(d.ComplexityLimits{}); got != nil {
+		t.Fatalf("zero limits should not report: %v", got)
+	}
+}
+
+// 跨语料：不 panic，且与 SQLType 对齐
+func Test_Complexity_Corpus(t *testing.T) {
+	for _, c := range []struct {
+		dialect d.Dialect
+		sqls    []string
+	}{
+		{d.Postgres, corpusPG25}, {d.MySQL, corpusMy25}, {d.SQLServer, corpusMS25}, {d.Oracle, corpusOR25},
+		{d.Postgres, corpusPG}, {d.MySQL, corpusMySQL}, {d.SQLServer, corpusMSSQL}, {d.Oracle, corpusOracle},
+		{d.Postgres, pgEdgeSQL}, {d.MySQL, myEdgeSQL}, {d.SQLServer, msEdgeSQL}, {d.Oracle, orEdgeSQL},
+		{d.Oracle, oraComplexSQLs()},
+	} {
+		for _, s := range c.sqls {
+			complexityOf(t, c.dialect, s)
+		}
+	}
+	// 空输入：SQLType 为 ["UNKNOWN"]，对应一条全零指标
+	for _, s := range []string{"", " \n\t", "-- only a comment"} {
+		if got := complexityOf(t, d.Postgres, s); !reflect.DeepEqual(got, []d.Complexity{{}}) {
+			t.Errorf("%q: %+v", s, got)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package tests
+
+// go test -v -count=1 . -run DDL_
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+func parseDDL(t *testing.T, dialect d.Dialect, sql string) []d.DDLStatement {
+	t.Helper()
+	stmts, err := d.ParseDDL(sql, d.Options{Dialect: dialect})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stmts
+}
+
+// columnLine 把列写成 "name type [NOT NULL] [DEFAULT x] [AUTO] [AS (g)]" 便于比对
+func columnLine(c d.ColumnDef) string {
+	s := c.Name + " " + c.Type
+	if !c.Nullable {
+		s += " NOT NULL"
+	}
+	if c.Default != "" {
+		s += " DEFAULT " + c.Default
+	}
+	if c.AutoIncrement {
+		s += " AUTO"
+	}
+	if c.Generated != "" {
+		s += " AS (" + c.Generated + ")"
+	}
+	return s
+}
+
+func tableLines(tb *d.TableDef) []string {
+	var out []string
+	for _, c := range tb.Columns {
+		out = append(out, columnLine(c))
+	}
+	if len(tb.PrimaryKey) > 0 {
+		out = append(out, "PK "+strings.Join(tb.PrimaryKey, ","))
+	}
+	for _, c := range tb.Constraints {
+		s := c.Kind + " " + c.Name + " (" + strings.Join(c.Columns, ",") + ")"
+		if c.RefTable != "" {
+			s += " -> " + c.RefTable + "(" + strings.Join(c.RefColumns, ",") + ")"
+		}
+		if c.OnDelete != "" {
+			s += " ON DELETE " + c.OnDelete
+		}
+		if c.Check != "" {
+			s += " " + c.Check
+		}
+		out = append(out, s)
+	}
+	for _, ix := range tb.Indexes {
+		out = append(out, fmt.Sprintf("INDEX %s %s (%s)", ix.Name, ix.Kind, strings.Join(ix.Columns, ",")))
+	}
+	return out
+}
+
+func actionLines(acts []d.AlterAction) []string {
+	var out []string
+	for _, a := range acts {
+		s := a.Kind + " " + a.Name
+		if a.NewName != "" {
+			s += " -> " + a.NewName
+		}
+		if a.Column != nil {
+			switch a.Kind {
+			case d.AlterSetType:
+				s += ": " + a.Column.Type
+			case d.AlterSetDefault:
+				s += ": " + a.Column.Default
+			default:
+				s += ": " + columnLine(*a.Column)
+			}
+		}
+		if a.Constraint != nil {
+			s += ": " + a.Constraint.Kind + " (" + strings.Join(a.Constraint.Columns, ",") + ")"
+		}
+		if a.Index != nil {
+			s += ": (" + strings.Join(a.Index.Columns, ",") + ")"
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func checkLines(t *testing.T, what string, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("%s\n--- got\n%s\n--- want\n%s", what, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func Test_DDL_CreateTable(t *testing.T) {
+	st := parseDDL(t, d.MySQL, "CREATE TABLE IF NOT EXISTS `shop`.`users` (\n"+
+		"  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,\n"+
+		"  email VARCHAR(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL COMMENT 'login',\n"+
+		"  status ENUM('a','b') DEFAULT 'a',\n"+
+		"  balance DECIMAL(12,2) NOT NULL DEFAULT -1.5,\n"+
+		"  updated TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n"+
+		"  full_name VARCHAR(100) GENERATED ALWAYS AS (concat(a, ' ', b)) VIRTUAL,\n"+
+		"  org_id INT,\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  UNIQUE KEY `uk_email` (`email`),\n"+
+		"  KEY idx_status (status, updated DESC) USING BTREE,\n"+
+		"  FULLTEXT KEY ft (full_name),\n"+
+		"  CONSTRAINT fk_org FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE ON UPDATE NO ACTION,\n"+
+		"  CHECK (balance >= 0)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+	if len(st) != 1 || st[0].Kind != "CREATE TABLE" || st[0].Name != "shop.users" || !st[0].IfNotExists || st[0].IfExists {
+		t.Fatalf("%+v", st)
+	}
+	tb := st[0].Table
+	if tb.Schema != "shop" || tb.Name != "users" || tb.Columns[1].Collation != "utf8mb4_bin" || tb.Columns[1].Charset != "utf8mb4" || tb.Columns[1].Comment != "'login'" ||
+		tb.Columns[4].OnUpdate != "CURRENT_TIMESTAMP" || tb.Indexes[0].Method != "BTREE" || tb.Constraints[2].OnUpdate != "NO ACTION" {
+		t.Fatalf("%+v", tb)
+	}
+	checkLines(t, "mysql", tableLines(tb),
+		"id BIGINT UNSIGNED NOT NULL AUTO",
+		"email VARCHAR(255) NOT NULL",
+		"status ENUM('a','b') DEFAULT 'a'",
+		"balance DECIMAL(12,2) NOT NULL DEFAULT -1.5",
+		"updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP",
+		"full_name VARCHAR(100) AS (concat(a, ' ', b))",
+		"org_id INT",
+		"PK id",
+		"PRIMARY KEY  (id)",
+		"UNIQUE uk_email (email)",
+		"FOREIGN KEY fk_org (org_id) -> orgs(id) ON DELETE CASCADE",
+		"CHECK  () balance >= 0",
+		"INDEX idx_status  (status,updated DESC)",
+		"INDEX ft FULLTEXT (full_name)")
+
+	// PG：serial、数组、多词类型、列级约束、IDENTITY、NULLS NOT DISTINCT；EXCLUDE 不是列
+	st = parseDDL(t, d.Postgres, `create unlogged table public."Orders" (
+		id bigserial primary key,
+		cust_id integer not null references public.customers(id) on delete set null,
+		total numeric(12, 2) default 0 check (total >= 0),
+		tags text[] default '{}'::text[],
+		placed_at timestamp(3) with time zone not null default now(),
+		code varchar(10) collate "C" unique,
+		ident int generated by default as identity (start with 10),
+		constraint uq_c unique nulls not distinct (cust_id, code),
+		exclude using gist (placed_at with &&))`)
+	checkLines(t, "postgres", tableLines(st[0].Table),
+		"id bigserial NOT NULL AUTO",
+		"cust_id integer NOT NULL",
+		"total numeric(12,2) DEFAULT 0",
+		"tags text[] DEFAULT '{}'::text[]",
+		"placed_at timestamp(3) with time zone NOT NULL DEFAULT now()",
+		"code varchar(10)",
+		"ident int NOT NULL AUTO",
+		"PK id",
+		"PRIMARY KEY  (id)",
+		"FOREIGN KEY  (cust_id) -> public.customers(id) ON DELETE SET NULL",
+		"CHECK  () total >= 0",
+		"UNIQUE  (code)",
+		"UNIQUE uq_c (cust_id,code)")
+
+	// SQL Server：IDENTITY、命名默认值约束、计算列、列级外键、内联索引、前面的 GO
+	st = parseDDL(t, d.SQLServer, "GO\nCREATE TABLE [dbo].[Orders] (\n"+
+		"  [Id] INT IDENTITY(1,1),\n"+
+		"  [CustId] INT NOT NULL CONSTRAINT FK_Cust FOREIGN KEY REFERENCES dbo.Customers(Id),\n"+
+		"  Amount DECIMAL(12,2) NULL CONSTRAINT DF_Amount DEFAULT ((0)),\n"+
+		"  Note NVARCHAR(MAX) COLLATE Latin1_General_CI_AS SPARSE NULL,\n"+
+		"  Total AS (Amount * 2) PERSISTED,\n"+
+		"  CONSTRAINT PK_Orders PRIMARY KEY CLUSTERED ([Id] ASC),\n"+
+		"  INDEX IX_Cust NONCLUSTERED (CustId))")
+	if st[0].Table.Columns[2].DefaultName != "DF_Amount" {
+		t.Fatalf("%+v", st[0].Table.Columns[2])
+	}
+	checkLines(t, "sqlserver", tableLines(st[0].Table),
+		"Id INT NOT NULL AUTO",
+		"CustId INT NOT NULL",
+		"Amount DECIMAL(12,2) DEFAULT ((0))",
+		"Note NVARCHAR(MAX)",
+		"Total  AS (Amount * 2)",
+		"PK Id",
+		"FOREIGN KEY FK_Cust (CustId) -> dbo.Customers(Id)",
+		"PRIMARY KEY PK_Orders (Id)",
+		"INDEX IX_Cust NONCLUSTERED (CustId)")
+
+	// Oracle：GENERATED ... ON NULL AS IDENTITY、CHAR 语义长度、虚拟列、USING INDEX
+	st = parseDDL(t, d.Oracle, "CREATE TABLE hr.emp (\n"+
+		"  id NUMBER(10) GENERATED BY DEFAULT ON NULL AS IDENTITY,\n"+
+		"  name VARCHAR2(100 CHAR) NOT NULL,\n"+
+		"  sal NUMBER(12,2) DEFAULT 0 CONSTRAINT ck_sal CHECK (sal >= 0),\n"+
+		"  hired DATE DEFAULT SYSDATE NOT NULL,\n"+
+		"  bonus NUMBER AS (sal * 0.1) VIRTUAL,\n"+
+		"  CONSTRAINT pk_emp PRIMARY KEY (id) USING INDEX TABLESPACE idx\n"+
+		") TABLESPACE users")
+	checkLines(t, "oracle", tableLines(st[0].Table),
+		"id NUMBER(10) NOT NULL AUTO",
+		"name VARCHAR2(100 CHAR) NOT NULL",
+		"sal NUMBER(12,2) DEFAULT 0",
+		"hired DATE NOT NULL DEFAULT SYSDATE",
+		"bonus NUMBER AS (sal * 0.1)",
+		"PK id",
+		"CHECK ck_sal () sal >= 0",
+		"PRIMARY KEY pk_emp (id)")
+
+	// CTAS、PARTITION OF；临时表
+	st = parseDDL(t, d.Postgres, "create table ct as select 1; create table p1 partition of parent for values in (1); "+
+		"create temp table tt (a int); create view v as select 1")
+	if len(st) != 3 || !st[0].Table.AsQuery || !st[1].Unsupported || !st[2].Table.Temporary {
+		t.Fatalf("%+v", st)
+	}
+}
+
+func Test_DDL_Alter(t *testing.T) {
+	st := parseDDL(t, d.MySQL, "ALTER TABLE shop.users ADD COLUMN phone VARCHAR(20) NULL AFTER email, DROP COLUMN status, "+
+		"MODIFY balance DECIMAL(14,2) NOT NULL, CHANGE full_name display_name VARCHAR(120), ADD INDEX ix_phone (phone), "+
+		"DROP INDEX ft, ALTER COLUMN org_id SET DEFAULT 1, RENAME COLUMN phone TO mobile, DROP FOREIGN KEY fk_org, "+
+		"DROP PRIMARY KEY, ADD PRIMARY KEY (id, email), ADD UNIQUE KEY uk (mobile), ALGORITHM=INPLACE, LOCK=NONE")
+	if st[0].Options["ALGORITHM"] != "INPLACE" || st[0].Options["LOCK"] != "NONE" || st[0].Actions[0].Text != "ADD COLUMN phone VARCHAR(20) NULL AFTER email" {
+		t.Fatalf("%+v", st[0])
+	}
+	checkLines(t, "mysql", actionLines(st[0].Actions),
+		"ADD COLUMN phone: phone VARCHAR(20)",
+		"DROP COLUMN status",
+		"MODIFY COLUMN balance: balance DECIMAL(14,2) NOT NULL",
+		"MODIFY COLUMN full_name: display_name VARCHAR(120)",
+		"ADD INDEX ix_phone: (phone)",
+		"DROP INDEX ft",
+		"SET DEFAULT org_id: 1",
+		"RENAME COLUMN phone -> mobile",
+		"DROP CONSTRAINT fk_org",
+		"DROP CONSTRAINT : PRIMARY KEY ()",
+		"ADD CONSTRAINT : PRIMARY KEY (id,email)",
+		"ADD CONSTRAINT uk: UNIQUE (mobile)")
+
+	st = parseDDL(t, d.Postgres, `alter table if exists only public."Orders"
+		add column if not exists note text not null default 'x',
+		alter column total type numeric(14,2) using total::numeric(14,2),
+		alter total set not null, alter column tags drop default, alter code drop not null,
+		alter column code set data type varchar(20), drop column if exists gen cascade, rename code to sku,
+		add constraint fk_x foreign key (cust_id) references customers (id) not valid, validate constraint fk_y;
+		alter table t rename to t2`)
+	if st[0].Name != "public.Orders" || !st[0].IfExists {
+		t.Fatalf("%+v", st[0])
+	}
+	checkLines(t, "postgres", actionLines(st[0].Actions),
+		"ADD COLUMN note: note text NOT NULL DEFAULT 'x'",
+		"SET TYPE total: numeric(14,2)",
+		"SET NOT NULL total",
+		"DROP DEFAULT tags",
+		"DROP NOT NULL code",
+		"SET TYPE code: varchar(20)",
+		"DROP COLUMN gen",
+		"RENAME COLUMN code -> sku",
+		"ADD CONSTRAINT fk_x: FOREIGN KEY (cust_id)",
+		"OTHER ")
+	checkLines(t, "postgres rename", actionLines(st[1].Actions), "RENAME TABLE  -> t2")
+
+	// SQL Server：一个 ADD 后多列、DROP COLUMN a, b、ALTER COLUMN 整列重定义、WITH NOCHECK、默认值约束
+	st = parseDDL(t, d.SQLServer, "ALTER TABLE dbo.Orders ADD Flag BIT NOT NULL CONSTRAINT DF_Flag DEFAULT 0, Extra INT NULL; "+
+		"ALTER TABLE dbo.Orders ALTER COLUMN Note NVARCHAR(400) NOT NULL; ALTER TABLE dbo.Orders DROP COLUMN Extra, Flag; "+
+		"ALTER TABLE dbo.Orders WITH NOCHECK ADD CONSTRAINT CK_Amt CHECK (Amount >= 0); "+
+		"ALTER TABLE dbo.Orders ADD CONSTRAINT DF_Note DEFAULT N'' FOR Note")
+	var got []string
+	for _, s := range st {
+		got = append(got, actionLines(s.Actions)...)
+	}
+	checkLines(t, "sqlserver", got,
+		"ADD COLUMN Flag: Flag BIT NOT NULL DEFAULT 0",
+		"ADD COLUMN Extra: Extra INT",
+		"MODIFY COLUMN Note: Note NVARCHAR(400) NOT NULL",
+		"DROP COLUMN Extra",
+		"DROP COLUMN Flag",
+		"ADD CONSTRAINT CK_Amt: CHECK ()",
+		"SET DEFAULT Note: N''")
+
+	// Oracle：ADD (...)、MODIFY 只改写出的部分、DROP (...)
+	st = parseDDL(t, d.Oracle, "ALTER TABLE hr.emp ADD (email VARCHAR2(200), phone VARCHAR2(20) DEFAULT 'n/a' NOT NULL); "+
+		"ALTER TABLE hr.emp MODIFY (name VARCHAR2(200 CHAR) NULL, sal DEFAULT 1); ALTER TABLE hr.emp MODIFY email NOT NULL; "+
+		"ALTER TABLE hr.emp DROP (phone, fax); ALTER TABLE hr.emp RENAME COLUMN email TO mail")
+	got = nil
+	for _, s := range st {
+		got = append(got, actionLines(s.Actions)...)
+	}
+	checkLines(t, "oracle", got,
+		"ADD COLUMN email: email VARCHAR2(200)",
+		"ADD COLUMN phone: phone VARCHAR2(20) NOT NULL DEFAULT 'n/a'",
+		"SET TYPE name: VARCHAR2(200 CHAR)",
+		"DROP NOT NULL name",
+		"SET DEFAULT sal: 1",
+		"SET NOT NULL email",
+		"DROP COLUMN phone",
+		"DROP COLUMN fax",
+		"RENAME COLUMN email -> mail")
+	if st[0].Actions[1].Text != "phone VARCHAR2(20) DEFAULT 'n/a' NOT NULL" {
+		t.Fatalf("%q", st[0].Actions[1].Text)
+	}
+}
+
+func Test_DDL_IndexDrop(t *testing.T) {
+	st := parseDDL(t, d.Postgres, `create unique index concurrently if not exists ix on only public."Orders" using btree (cust_id, lower(code))
+		include (total) where total > 0; create index on t (a); drop index concurrently if exists public.ix, ix2;
+		drop table if exists a, b cascade; drop materialized view mv`)
+	ix := st[0].Index
+	if st[0].Kind != "CREATE INDEX" || !ix.Unique || ix.Name != "ix" || ix.Table != "public.Orders" || ix.Method != "btree" ||
+		strings.Join(ix.Columns, ";") != "cust_id;lower(code)" || strings.Join(ix.Include, ";") != "total" || ix.Where != "total > 0" {
+		t.Fatalf("%+v", ix)
+	}
+	if _, ok := st[0].Options["CONCURRENTLY"]; !ok || !st[0].IfNotExists || st[0].IfExists {
+		t.Fatalf("%+v", st[0])
+	}
+	if st[1].Index.Name != "" || st[1].Index.Table != "t" {
+		t.Fatalf("%+v", st[1].Index)
+	}
+	if st[2].Kind != "DROP INDEX" || strings.Join(st[2].Names, ";") != "public.ix;ix2" || !st[2].IfExists || st[2].IfNotExists {
+		t.Fatalf("%+v", st[2])
+	}
+	if st[3].Kind != "DROP TABLE" || len(st[3].Names) != 2 || !st[3].Cascade || st[4].Kind != "DROP MATERIALIZED VIEW" {
+		t.Fatalf("%+v", st[3:])
+	}
+
+	st = parseDDL(t, d.SQLServer, "CREATE NONCLUSTERED INDEX IX_Created ON dbo.Orders (Created DESC) INCLUDE (Amount) "+
+		"WHERE Amount > 0 WITH (ONLINE = ON, FILLFACTOR = 90); DROP INDEX IX_Created ON dbo.Orders WITH (ONLINE = ON)")
+	if ix := st[0].Index; ix.Kind != "NONCLUSTERED" || ix.Columns[0] != "Created DESC" || ix.Where != "Amount > 0" ||
+		st[0].Options["ONLINE"] != "ON" || st[0].Options["FILLFACTOR"] != "90" {
+		t.Fatalf("%+v %+v", ix, st[0].Options)
+	}
+	if st[1].Index.Table != "dbo.Orders" || st[1].Options["ONLINE"] != "ON" {
+		t.Fatalf("%+v", st[1])
+	}
+
+	st = parseDDL(t, d.MySQL, "CREATE UNIQUE INDEX ux ON shop.users (mobile(10), email) ALGORITHM=INPLACE LOCK=NONE; DROP INDEX ux ON shop.users")
+	if st[0].Index.Columns[0] != "mobile(10)" || st[0].Options["LOCK"] != "NONE" || st[1].Index.Table != "shop.users" {
+		t.Fatalf("%+v", st)
+	}
+	st = parseDDL(t, d.Oracle, "CREATE BITMAP INDEX ix ON hr.emp (dept_id) ONLINE;\n/\nDROP TABLE hr.old CASCADE CONSTRAINTS PURGE")
+	if st[0].Index.Kind != "BITMAP" || st[0].Options == nil || st[1].Kind != "DROP TABLE" || !st[1].Cascade {
+		t.Fatalf("%+v", st)
+	}
+}
+
+func Test_DDL_Schema(t *testing.T) {
+	s, err := d.SchemaFromDDL(`
+		CREATE TABLE shop.users (id BIGINT NOT NULL, email VARCHAR(255), status INT, full_name VARCHAR(100),
+		  PRIMARY KEY (id), UNIQUE KEY uk_email (email), KEY ix_status (status, email));
+		CREATE TABLE shop.tmp (a INT);
+		ALTER TABLE users ADD COLUMN phone VARCHAR(20), DROP COLUMN status, CHANGE full_name display_name VARCHAR(120),
+		  RENAME COLUMN email TO mail, ALTER COLUMN phone SET DEFAULT '-';
+		CREATE INDEX ix_phone ON shop.users (phone);
+		ALTER TABLE shop.users DROP PRIMARY KEY, ADD PRIMARY KEY (id, mail), DROP INDEX uk_email;
+		DROP TABLE shop.tmp;
+		CREATE TABLE IF NOT EXISTS shop.users (x INT);`, d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tables) != 1 || s.Table("SHOP.USERS") == nil || s.Table("other.users") != nil {
+		t.Fatalf("%+v", s)
+	}
+	checkLines(t, "mysql schema", tableLines(s.Table("users")),
+		"id BIGINT NOT NULL",
+		"mail VARCHAR(255) NOT NULL",
+		"display_name VARCHAR(120)",
+		"phone VARCHAR(20) DEFAULT '-'",
+		"PK id,mail",
+		"PRIMARY KEY  (id,mail)",
+		"INDEX ix_status  (mail)",
+		"INDEX ix_phone  (phone)")
+
+	// SQL Server：ALTER COLUMN 保留默认值；删除默认值约束
+	s, _ = d.SchemaFromDDL("CREATE TABLE t (a INT CONSTRAINT df_a DEFAULT 1, b INT DEFAULT 2);\nGO\n"+
+		"ALTER TABLE t ALTER COLUMN a BIGINT NOT NULL; ALTER TABLE t DROP CONSTRAINT df_a; EXEC sp_help 't'", d.Options{Dialect: d.SQLServer})
+	checkLines(t, "sqlserver schema", tableLines(s.Table("dbo.t")), "a BIGINT NOT NULL", "b INT DEFAULT 2")
+
+	// PG：改表名
+	s, _ = d.SchemaFromDDL("create table a (x int); alter table a rename to b; alter table b alter x type bigint", d.Options{Dialect: d.Postgres})
+	if s.Table("a") != nil || s.Table("b") == nil || s.Table("b").Columns[0].Type != "bigint" {
+		t.Fatalf("%+v", s)
+	}
+	// PG / Oracle / SQL Server：删列连带删除含该列的整个索引（MySQL 只从索引里去掉这一列，见上）
+	for _, dl := range []d.Dialect{d.Postgres, d.Oracle, d.SQLServer} {
+		s, _ = d.SchemaFromDDL("create table t (a int, b int, c int); create index ix_ab on t (a, b); create index ix_c on t (c);\n"+
+			"alter table t drop column b", d.Options{Dialect: dl})
+		checkLines(t, string(dl)+" drop column", tableLines(s.Table("t")), "a int", "c int", "INDEX ix_c  (c)")
+	}
+	// 目录由 Schema 构建：ALTER 之后的列
+	c, _ := d.CatalogFromDDL("create table a (x int); alter table a add y text", d.Options{Dialect: d.Postgres})
+	if tb := c.Table("a"); tb == nil || len(tb.Columns) != 2 || tb.Column("y").Type != "text" {
+		t.Fatalf("%+v", c)
+	}
+}
+
+// 跨语料：不 panic；语句序号与区间有效
+func Test_DDL_Corpus(t *testing.T) {
+	for _, c := range []struct {
+		dialect d.Dialect
+		sqls    []string
+	}{
+		{d.Postgres, corpusPG25}, {d.MySQL, corpusMy25}, {d.SQLServer, corpusMS25}, {d.Oracle, corpusOR25},
+		{d.Postgres, corpusPG}, {d.MySQL, corpusMySQL}, {d.SQLServer, corpusMSSQL}, {d.Oracle, corpusOracle},
+		{d.Postgres, pgEdgeSQL}, {d.MySQL, myEdgeSQL}, {d.SQLServer, msEdgeSQL}, {d.Oracle, orEdgeSQL},
+		{d.Oracle, oraComplexSQLs()},
+	} {
+		for i, s := range c.sqls {
+			stmts, err := d.ParseDDL(s, d.Options{Dialect: c.dialect})
+			if err != nil {
+				t.Fatalf("%s #%d: %v", c.dialect, i, err)
+			}
+			for _, st := range stmts {
+				if st.Statement < 1 || st.Kind == "" || st.Start >= st.End || st.End > len(s) {
+					t.Fatalf("%s #%d: bad entry %+v\n%s", c.dialect, i, st, s)
+				}
+			}
+			if _, err := d.SchemaFromDDL(s, d.Options{Dialect: c.dialect}); err != nil {
+				t.Fatalf("%s #%d: %v", c.dialect, i, err)
+			}
+		}
+	}
+}
@@ -35,7 +35,6 @@ func Test_Smoke_Postgres(t *testing.T) {
 }
 
 func Test_Smoke_SQLServer(t *testing.T) {
-	// 注意：不要用 N'...'，我们的字符串识别未特判 N 前缀；用普通 '...' 即可
 	sql := `SELECT TOP 3 * FROM t WHERE a = @p1 AND b = 'X'`
 	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.SQLServer})
 	fmt.Println(res.Digest)
@@ -161,7 +160,6 @@ func Test_Insert_Postgres_Multi(t *testing.T) {
 }
 
 func Test_Insert_SQLServer_Single(t *testing.T) {
-	// 注意：不用 N'...' 前缀，避免当前字符串识别不命中
 	sql := `INSERT INTO dbo.Orders (Id, UserId, Amount, Note)
 VALUES (101, @p1, 9.99, 'first');`
 	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.SQLServer})
@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+	"github.com/tensafe/sqlglot-go/sqlglot/digeststats"
+	"github.com/tensafe/sqlglot-go/sqlglot/sqldriver"
+)
+
+// go test -v -count=1 . -run DigestStats_
+
+func Test_DigestStats_Observe(t *testing.T) {
+	c := digeststats.New(digeststats.Config{Buckets: []float64{0.01, 0.1, 1}})
+	for i := 0; i < 9; i++ {
+		c.Observe("SELECT * FROM T WHERE ID = ?", 5*time.Millisecond, nil)
+	}
+	c.Observe("SELECT * FROM T WHERE ID = ?", 2*time.Second, errors.New("timeout"))
+	c.Observe("UPDATE T SET A = ?", 50*time.Millisecond, nil)
+
+	st := c.Snapshot()
+	if len(st) != 2 {
+		t.Fatalf("stats=%+v", st)
+	}
+	s := st[0]
+	if s.Digest != "SELECT * FROM T WHERE ID = ?" || s.Fingerprint != digeststats.Fingerprint(s.Digest) || len(s.Fingerprint) != 16 ||
+		s.Count != 10 || s.Errors != 1 || s.MaxTime != 2 {
+		t.Fatalf("select stat=%+v", s)
+	}
+	// 累计桶：<=0.01 有 9 个，+Inf 等于总数
+	if want := []uint64{9, 9, 9, 10}; !slices.Equal(s.Buckets, want) {
+		t.Fatalf("buckets=%v want %v", s.Buckets, want)
+	}
+	if s.P95Time <= 1 || s.P95Time > 2 {
+		t.Fatalf("p95=%v", s.P95Time)
+	}
+	if top := c.TopN(1, digeststats.SortMean); len(top) != 1 || top[0].Digest != "SELECT * FROM T WHERE ID = ?" {
+		t.Fatalf("top by mean=%+v", top)
+	}
+	if top := c.TopN(0, digeststats.SortErrors); len(top) != 2 || top[1].Errors != 0 {
+		t.Fatalf("top by errors=%+v", top)
+	}
+}
+
+func Test_DigestStats_OtherBucket(t *testing.T) {
+	c := digeststats.New(digeststats.Config{MaxDigests: 2})
+	for _, d := range []string{"A", "B", "C", "D", "A", ""} {
+		c.Observe(d, time.Millisecond, nil)
+	}
+	st := c.Snapshot()
+	if len(st) != 3 {
+		t.Fatalf("stats=%+v", st)
+	}
+	other := st[2]
+	if other.Fingerprint != digeststats.OtherFingerprint || other.Digest != "" || other.Count != 3 {
+		t.Fatalf("other=%+v", other)
+	}
+	if st[0].Digest != "A" || st[0].Count != 2 {
+		t.Fatalf("first=%+v", st[0])
+	}
+}
+
+func Test_DigestStats_HTTP(t *testing.T) {
+	c := digeststats.New(digeststats.Config{Buckets: []float64{0.5}, Namespace: "app"})
+	c.Observe(`SELECT "X" FROM T WHERE A = ?`, 250*time.Millisecond, nil)
+	c.Observe(`SELECT "X" FROM T WHERE A = ?`, 750*time.Millisecond, errors.New("x"))
+	fp := digeststats.Fingerprint(`SELECT "X" FROM T WHERE A = ?`)
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE app_query_duration_seconds histogram\n",
+		`app_query_duration_seconds_bucket{fingerprint="` + fp + `",le="0.5"} 1` + "\n",
+		`app_query_duration_seconds_bucket{fingerprint="` + fp + `",le="+Inf"} 2` + "\n",
+		`app_query_duration_seconds_sum{fingerprint="` + fp + `"} 1` + "\n",
+		`app_query_duration_seconds_count{fingerprint="` + fp + `"} 2` + "\n",
+		"# TYPE app_query_errors_total counter\n",
+		`app_query_errors_total{fingerprint="` + fp + `"} 1` + "\n",
+		`app_query_digest_info{fingerprint="` + fp + `",digest="SELECT \"X\" FROM T WHERE A = ?"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("content-type=%q", ct)
+	}
+
+	rec = httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics?format=json&top=5&sort=count", nil))
+	var top []digeststats.Stat
+	if err := json.Unmarshal(rec.Body.Bytes(), &top); err != nil {
+		t.Fatalf("json: %v\n%s", err, rec.Body.String())
+	}
+	if len(top) != 1 || top[0].Fingerprint != fp || top[0].Count != 2 || top[0].Errors != 1 {
+		t.Fatalf("top=%+v", top)
+	}
+
+	rec = httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics?format=json&top=x", nil))
+	if rec.Code != 400 {
+		t.Fatalf("bad top accepted: %d", rec.Code)
+	}
+}
+
+func Test_DigestStats_SQLDriverHook(t *testing.T) {
+	c := digeststats.New(digeststats.Config{})
+	db := sql.OpenDB(sqldriver.WrapConnector(fakeConnector{fakeDriver{}}, sqlglot.Options{Dialect: sqlglot.MySQL}, c.Hook()))
+	defer db.Close()
+	for _, id := range []int{1, 2, 3} {
+		if _, err := db.ExecContext(context.Background(), "DELETE FROM t WHERE id = ?", id); err != nil {
+			t.Fatalf("exec: %v", err)
+		}
+	}
+	// Prepare 事件不计数
+	if st := c.Snapshot(); len(st) != 1 || st[0].Count != 3 || st[0].Digest != "DELETE FROM T WHERE ID = ?" {
+		t.Fatalf("stats=%+v", st)
+	}
+}
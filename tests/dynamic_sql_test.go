@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run DynamicSQL_
+
+var dynamicSQLCases = []struct {
+	dialect d.Dialect
+	in      string
+	wrapper string
+	inner   string // 外层原文中内层文本的区间内容
+	digest  string
+	params  []string // 每个内层参数在外层原文中的区间内容
+}{
+	{d.SQLServer, "EXEC('SELECT * FROM t WHERE a = ''x'' AND b = 5')", "EXEC",
+		"SELECT * FROM t WHERE a = ''x'' AND b = 5", "SELECT * FROM T WHERE A = ? AND B = ?", []string{"''x''", "5"}},
+	{d.SQLServer, "EXEC sp_executesql N'SELECT name FROM t WHERE id = @p', N'@p int', @p = 1", "sp_executesql",
+		"SELECT name FROM t WHERE id = @p", "SELECT NAME FROM T WHERE ID = ?", []string{"@p"}},
+	{d.Oracle, "BEGIN EXECUTE IMMEDIATE 'DELETE FROM t WHERE id = :1 AND s = ''a''' USING v; END;", "EXECUTE IMMEDIATE",
+		"DELETE FROM t WHERE id = :1 AND s = ''a''", "DELETE FROM T WHERE ID = ? AND S = ?", []string{":1", "''a''"}},
+	{d.Postgres, "EXECUTE format('SELECT * FROM %I WHERE id = %L AND n > 10', 't', 5)", "format",
+		"SELECT * FROM %I WHERE id = %L AND n > 10", "SELECT * FROM ? WHERE ID = ? AND N > ?", []string{"%I", "%L", "10"}},
+	{d.MySQL, `PREPARE s FROM "SELECT * FROM t WHERE a = 'x\\n' AND b = ?"`, "PREPARE",
+		`SELECT * FROM t WHERE a = 'x\\n' AND b = ?`, "SELECT * FROM T WHERE A = ? AND B = ?", []string{`'x\\n'`, "?"}},
+}
+
+func Test_DynamicSQL_Wrappers(t *testing.T) {
+	for i, c := range dynamicSQLCases {
+		res, err := d.BuildDigestANTLR(c.in, d.Options{Dialect: c.dialect, UnwrapDynamicSQL: true})
+		if err != nil {
+			t.Fatalf("#%d build: %v", i, err)
+		}
+		if len(res.Nested) != 1 {
+			t.Fatalf("#%d nested=%+v", i, res.Nested)
+		}
+		n := res.Nested[0]
+		if n.Wrapper != c.wrapper || n.ParamIndex != 1 || c.in[n.Start:n.End] != c.inner || n.Digest != c.digest {
+			t.Fatalf("#%d wrapper=%q param=%d inner=%q digest=%q", i, n.Wrapper, n.ParamIndex, c.in[n.Start:n.End], n.Digest)
+		}
+		if len(n.Params) != len(c.params) {
+			t.Fatalf("#%d params=%+v", i, n.Params)
+		}
+		for j, p := range n.Params {
+			if got := c.in[p.Start:p.End]; got != c.params[j] {
+				t.Errorf("#%d P#%d outer span=%q want %q", i, p.Index, got, c.params[j])
+			}
+		}
+	}
+}
+
+func Test_DynamicSQL_Details(t *testing.T) {
+	// sp_executesql 的外层实参挂到内层命名绑定上
+	res, _ := d.BuildDigestANTLR("EXEC sp_executesql N'SELECT name FROM t WHERE id = @p', N'@p int', @p = 1",
+		d.Options{Dialect: d.SQLServer, UnwrapDynamicSQL: true})
+	if p := res.Nested[0].Params[0]; p.BindName != "p" || p.BoundValue != "1" {
+		t.Fatalf("bind=%+v", p)
+	}
+	// format 占位符单独标注
+	res, _ = d.BuildDigestANTLR("EXECUTE format('SELECT %s FROM t', 'a')", d.Options{Dialect: d.Postgres, UnwrapDynamicSQL: true})
+	if p := res.Nested[0].Params[0]; p.Type != "FormatSpec" || p.Value != "%s" || p.BindOrdinal != 0 {
+		t.Fatalf("format spec=%+v", p)
+	}
+	// 内层参数的 Value 为内层文本
+	res, _ = d.BuildDigestANTLR("EXEC('SELECT ''x''')", d.Options{Dialect: d.SQLServer, UnwrapDynamicSQL: true})
+	if p := res.Nested[0].Params[0]; p.Value != "'x'" || p.Decoded != "x" {
+		t.Fatalf("inner value=%+v", p)
+	}
+}
+
+func Test_DynamicSQL_Recursive(t *testing.T) {
+	in := "DO $$ BEGIN EXECUTE format('DELETE FROM %I WHERE id = %L', 't', 5); END $$"
+	res, err := d.BuildDigestANTLR(in, d.Options{Dialect: d.Postgres, UnwrapDynamicSQL: true})
+	if err != nil || len(res.Nested) != 1 || res.Nested[0].Wrapper != "DO" {
+		t.Fatalf("outer nested=%+v err=%v", res.Nested, err)
+	}
+	inner := res.Nested[0].Nested
+	if len(inner) != 1 || inner[0].Wrapper != "format" || inner[0].Digest != "DELETE FROM ? WHERE ID = ?" {
+		t.Fatalf("inner nested=%+v", inner)
+	}
+	if p := inner[0].Params[1]; in[p.Start:p.End] != "%L" {
+		t.Fatalf("inner offset=%q", in[p.Start:p.End])
+	}
+
+	in = "EXEC('EXEC(''SELECT * FROM t WHERE a = ''''x'''''')')"
+	res, _ = d.BuildDigestANTLR(in, d.Options{Dialect: d.SQLServer, UnwrapDynamicSQL: true})
+	n := res.Nested[0].Nested[0]
+	if n.Digest != "SELECT * FROM T WHERE A = ?" || in[n.Params[0].Start:n.Params[0].End] != "''''x''''" {
+		t.Fatalf("two-level nested=%+v", n)
+	}
+}
+
+func Test_DynamicSQL_Off(t *testing.T) {
+	res, _ := d.BuildDigestANTLR("EXEC('SELECT 1')", d.Options{Dialect: d.SQLServer})
+	if res.Nested != nil {
+		t.Fatalf("nested without option: %+v", res.Nested)
+	}
+	// 普通字符串参数不展开
+	res, _ = d.BuildDigestANTLR("SELECT * FROM t WHERE a = 'SELECT 1'", d.Options{Dialect: d.MySQL, UnwrapDynamicSQL: true})
+	if res.Nested != nil {
+		t.Fatalf("plain literal unwrapped: %+v", res.Nested)
+	}
+}
+
+// 长脚本：只看字面量前的一小段原文；包裹关键字前面的长标识符不会被截成半个词误判
+func Test_DynamicSQL_LongScript(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 2000; i++ {
+		sb.WriteString("EXEC('SELECT 1'); SELECT 'plain' FROM t;\n")
+	}
+	sb.WriteString("SELECT " + strings.Repeat("x", 300) + "EXEC('SELECT 2')")
+	res, err := d.BuildDigestANTLR(sb.String(), d.Options{Dialect: d.SQLServer, UnwrapDynamicSQL: true})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(res.Nested) != 2000 {
+		t.Fatalf("nested=%d", len(res.Nested))
+	}
+}
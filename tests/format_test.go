@@ -0,0 +1,154 @@
+package tests
+
+// go test -v -count=1 . -run Format_
+
+import (
+	"strings"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+func Test_Format_Golden(t *testing.T) {
+	cases := []struct {
+		dialect d.Dialect
+		fo      d.FormatOptions
+		in      string
+		want    string
+	}{
+		{d.Postgres, d.FormatOptions{},
+			"with recent as (select id, user_id from orders where created_at > now() - interval '7 days') " +
+				"select u.id, count(*) as n from users u left join recent r on r.user_id = u.id " +
+				"where u.status in ('active', 'pending') and u.age between 18 and 65 group by u.id order by n desc limit 10",
+			`WITH recent AS (
+  SELECT id, user_id
+  FROM orders
+  WHERE created_at > now() - INTERVAL '7 days'
+)
+SELECT u.id, count(*) AS n
+FROM users u
+LEFT JOIN recent r ON r.user_id = u.id
+WHERE u.status IN ('active', 'pending') AND u.age BETWEEN 18 AND 65
+GROUP BY u.id
+ORDER BY n DESC
+LIMIT 10`},
+		{d.MySQL, d.FormatOptions{LineWidth: 40},
+			"select a.id, a.name, b.total, case when b.total > 100 then 'big' else 'small' end as size from a join b on b.a_id = a.id and b.deleted = 0 where a.x = ? and exists (select 1 from c where c.a_id = a.id)",
+			`SELECT
+  a.id,
+  a.name,
+  b.total,
+  CASE
+    WHEN b.total > 100 THEN 'big'
+    ELSE 'small'
+  END AS size
+FROM a
+JOIN b
+  ON b.a_id = a.id AND b.deleted = 0
+WHERE
+  a.x = ?
+  AND EXISTS (
+    SELECT 1
+    FROM c
+    WHERE c.a_id = a.id
+  )`},
+		{d.MySQL, d.FormatOptions{KeywordCase: d.KeywordLower, Indent: "\t"},
+			"INSERT INTO t (a, b) VALUES (1, 'x'), (2, 'y') ON DUPLICATE KEY UPDATE b = VALUES(b); UPDATE t SET a = a + -1 WHERE id IN (SELECT id FROM u)",
+			"insert into t (a, b)\nvalues (1, 'x'), (2, 'y')\non duplicate key update b = values(b);\n\n" +
+				"update t\nset a = a + -1\nwhere id in (\n\tselect id\n\tfrom u\n)"},
+		// 与关键字同形的表名、别名保持原样（MySQL 表名区分大小写）
+		{d.MySQL, d.FormatOptions{},
+			"select last as l from last join view v on v.id = last.id; select * from function f; create table if not exists last (a int); update ignore last set a = 1",
+			"SELECT last AS l\nFROM last\nJOIN view v ON v.id = last.id;\n\nSELECT *\nFROM function f;\n\n" +
+				"CREATE TABLE IF NOT EXISTS last (a int);\n\nUPDATE IGNORE last\nSET a = 1"},
+		{d.Oracle, d.FormatOptions{KeywordCase: d.KeywordPreserve},
+			"select /*+ INDEX(e ix) */ e.id from emp@remote e, dept d where e.dept_id = d.id(+) and rownum <= :n\n/\nbegin null; end;",
+			"select /*+ INDEX(e ix) */ e.id\nfrom emp@remote e, dept d\nwhere e.dept_id = d.id(+) and rownum <= :n\n/\n\nbegin\n  null;\nend;"},
+		{d.SQLServer, d.FormatOptions{},
+			"select top (5) * from [dbo].[t] with (nolock) where a = @p1\nGO\nif @x > 1 begin select 1 end else begin select 2 end",
+			"SELECT TOP (5) *\nFROM [dbo].[t] WITH (nolock)\nWHERE a = @p1\nGO\n\nIF @x > 1\nBEGIN\n  SELECT 1\nEND ELSE\nBEGIN\n  SELECT 2\nEND"},
+	}
+	for i, c := range cases {
+		got, err := d.Format(c.in, d.Options{Dialect: c.dialect}, c.fo)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if got != c.want {
+			t.Fatalf("#%d mismatch\n--- got\n%s\n--- want\n%s", i, got, c.want)
+		}
+	}
+	if _, err := d.Format("select 1", d.Options{}, d.FormatOptions{KeywordCase: "title"}); err == nil {
+		t.Fatalf("unknown keyword case accepted")
+	}
+}
+
+func Test_Format_Comments(t *testing.T) {
+	in := "-- header\nselect a, -- first\n  /* second */ b\nfrom t where x = 1 -- trailing\n  and y = 2;\n-- footer"
+	got, err := d.Format(in, d.Options{Dialect: d.Postgres}, d.FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `-- header
+SELECT
+  a, -- first
+  /* second */
+  b
+FROM t
+WHERE
+  x = 1 -- trailing
+  AND y = 2;
+-- footer`
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+	// 行注释后紧跟的 token 不能被吞进注释；-- 不能由两个减号拼出
+	got, _ = d.Format("select a - -1, b -- c\n, d from t", d.Options{Dialect: d.MySQL}, d.FormatOptions{LineWidth: -1})
+	if !strings.Contains(got, "a - -1") || !strings.Contains(got, "-- c\n") {
+		t.Fatalf("unsafe output: %q", got)
+	}
+}
+
+// 跨语料：格式化前后摘要与参数一致，且再次格式化不变
+func Test_Format_Corpus(t *testing.T) {
+	for _, c := range []struct {
+		dialect d.Dialect
+		sqls    []string
+	}{
+		{d.Postgres, corpusPG25}, {d.MySQL, corpusMy25}, {d.SQLServer, corpusMS25}, {d.Oracle, corpusOR25},
+		{d.Postgres, corpusPG}, {d.MySQL, corpusMySQL}, {d.SQLServer, corpusMSSQL}, {d.Oracle, corpusOracle},
+		{d.Postgres, pgEdgeSQL}, {d.MySQL, myEdgeSQL}, {d.SQLServer, msEdgeSQL}, {d.Oracle, orEdgeSQL},
+		{d.Postgres, seedDML_PG()}, {d.MySQL, seedDML_MySQL()}, {d.SQLServer, seedDML_MSSQL()}, {d.Oracle, seedDML_Oracle()},
+		{d.Oracle, oraComplexSQLs()},
+	} {
+		// 关键字改写会改变 INTERVAL '1 day' 这类参数的原文，参数只在 preserve 下逐一比对
+		for _, fo := range []d.FormatOptions{{}, {LineWidth: 30, KeywordCase: d.KeywordPreserve}} {
+			opt := d.Options{Dialect: c.dialect}
+			for i, s := range c.sqls {
+				out, err := d.Format(s, opt, fo)
+				if err != nil {
+					t.Fatalf("%s #%d: %v", c.dialect, i, err)
+				}
+				again, _ := d.Format(out, opt, fo)
+				if again != out {
+					t.Fatalf("%s #%d (%+v) not idempotent\n--- first\n%s\n--- second\n%s", c.dialect, i, fo, out, again)
+				}
+				before, err1 := d.BuildDigestANTLR(s, opt)
+				after, err2 := d.BuildDigestANTLR(out, opt)
+				if err1 != nil || err2 != nil {
+					t.Fatalf("%s #%d: %v / %v", c.dialect, i, err1, err2)
+				}
+				if before.Digest != after.Digest || len(before.Params) != len(after.Params) {
+					t.Fatalf("%s #%d digest changed\nsql: %s\nout: %s\n%s\n%s", c.dialect, i, s, out, before.Digest, after.Digest)
+				}
+				if fo.KeywordCase != d.KeywordPreserve {
+					continue
+				}
+				for k := range before.Params {
+					if before.Params[k].Value != after.Params[k].Value {
+						t.Fatalf("%s #%d param %d: %q -> %q", c.dialect, i, k, before.Params[k].Value, after.Params[k].Value)
+					}
+				}
+			}
+		}
+	}
+}
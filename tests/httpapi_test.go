@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+	"github.com/tensafe/sqlglot-go/sqlglot/httpapi"
+)
+
+// go test -v -count=1 . -run HTTPAPI_
+
+func httpapiDo(t *testing.T, h http.Handler, method, path, body string, out any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: bad JSON %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func Test_HTTPAPI_Endpoints(t *testing.T) {
+	h := httpapi.NewHandler(httpapi.Config{Defaults: sqlglot.Options{Dialect: sqlglot.Postgres}})
+
+	var health map[string]string
+	if code := httpapiDo(t, h, "GET", "/healthz", "", &health); code != 200 || health["status"] != "ok" {
+		t.Fatalf("healthz %d %v", code, health)
+	}
+
+	var dr httpapi.DigestResult
+	code := httpapiDo(t, h, "POST", "/v1/digest", `{"sql":"select * from t where a = 1"}`, &dr)
+	if code != 200 || dr.Digest != "SELECT * FROM T WHERE A = ?" || dr.SQLType[0] != "SELECT" {
+		t.Fatalf("digest %d %+v", code, dr)
+	}
+
+	// params 的响应与 Result / ExParam 的 JSON 一致
+	var pr httpapi.ParamsResult
+	code = httpapiDo(t, h, "POST", "/v1/params", `{"sql":"SELECT * FROM t WHERE id = ? AND s = 'it''s'","dialect":"mysql"}`, &pr)
+	want, _ := sqlglot.ResultFor("SELECT * FROM t WHERE id = ? AND s = 'it''s'", sqlglot.Options{Dialect: sqlglot.MySQL})
+	if code != 200 || pr.Digest != want.Digest || len(pr.Params) != 2 || pr.Params[1] != want.Params[1] {
+		t.Fatalf("params %d %+v", code, pr)
+	}
+
+	var sr httpapi.SplitResult
+	code = httpapiDo(t, h, "POST", "/v1/split", `{"sql":"SELECT 1; DELETE FROM t"}`, &sr)
+	if code != 200 || len(sr.Statements) != 2 || sr.Statements[1].Type != "DELETE" || sr.Statements[1].Index != 2 {
+		t.Fatalf("split %d %+v", code, sr)
+	}
+
+	var vr httpapi.ValidateResult
+	code = httpapiDo(t, h, "POST", "/v1/validate", `{"sql":"SELECT (1"}`, &vr)
+	if code != 200 || vr.Valid || len(vr.Issues) != 1 {
+		t.Fatalf("validate %d %+v", code, vr)
+	}
+}
+
+func Test_HTTPAPI_Batch(t *testing.T) {
+	h := httpapi.NewHandler(httpapi.Config{})
+	var out struct {
+		Results []httpapi.DigestResult `json:"results"`
+	}
+	body := `{"dialect":"postgres","items":[
+		{"id":"a","sql":"SELECT 1"},
+		{"id":"b","sql":"SELECT [x] FROM t WHERE y = 2","dialect":"mssql"},
+		{"id":"c","sql":"SELECT 1","dialect":"db2"}]}`
+	if code := httpapiDo(t, h, "POST", "/v1/digest", body, &out); code != 200 || len(out.Results) != 3 {
+		t.Fatalf("batch %d %+v", code, out)
+	}
+	r := out.Results
+	if r[0].ID != "a" || r[0].Digest != "SELECT ?" || r[1].ID != "b" || r[1].Digest != "SELECT [X] FROM T WHERE Y = ?" {
+		t.Fatalf("results=%+v", r)
+	}
+	if r[2].ID != "c" || !strings.Contains(r[2].Error, "unknown dialect") {
+		t.Fatalf("bad item=%+v", r[2])
+	}
+}
+
+func Test_HTTPAPI_Limits(t *testing.T) {
+	h := httpapi.NewHandler(httpapi.Config{MaxBodyBytes: 64, MaxBatch: 2})
+	cases := []struct {
+		method, path, body string
+		code               int
+	}{
+		{"POST", "/v1/digest", `{"sql":"SELECT '` + strings.Repeat("x", 100) + `'"}`, http.StatusRequestEntityTooLarge},
+		{"POST", "/v1/digest", `{"items":[{"sql":"1"},{"sql":"2"},{"sql":"3"}]}`, http.StatusRequestEntityTooLarge},
+		{"POST", "/v1/digest", `{"sql":`, http.StatusBadRequest},
+		{"POST", "/v1/digest", `{"sq1":"SELECT 1"}`, http.StatusBadRequest},
+		{"POST", "/v1/digest", `{}`, http.StatusBadRequest},
+		{"POST", "/v1/digest", `{"sql":"SELECT 1","dialect":"db2"}`, http.StatusBadRequest},
+		{"GET", "/v1/digest", ``, http.StatusMethodNotAllowed},
+		{"POST", "/v1/nope", `{}`, http.StatusNotFound},
+	}
+	for i, c := range cases {
+		if code := httpapiDo(t, h, c.method, c.path, c.body, nil); code != c.code {
+			t.Errorf("#%d %s %s: code %d want %d", i, c.method, c.path, code, c.code)
+		}
+	}
+}
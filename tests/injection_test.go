@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run Injection_
+
+type injWant struct{ rule, severity, span string }
+
+var injectionCases = []struct {
+	dialect d.Dialect
+	in      string
+	want    []injWant
+}{
+	{d.MySQL, "SELECT * FROM users WHERE id = 1 OR 1=1", []injWant{{"tautology", "high", "OR 1=1"}}},
+	{d.Postgres, "SELECT * FROM users WHERE name = '' OR 'a'='a'", []injWant{{"tautology", "high", "OR 'a'='a'"}}},
+	{d.MySQL, "SELECT * FROM t WHERE id = 5 OR 2 > 1", []injWant{{"tautology", "high", "OR 2 > 1"}}},
+	{d.MySQL, "SELECT * FROM t WHERE id = 5 OR 1", []injWant{{"tautology", "high", "OR 1"}}},
+	{d.SQLServer, "SELECT * FROM t WHERE (id = 5 OR x = x)", []injWant{{"tautology", "high", "OR x = x"}}},
+	{d.MySQL, "SELECT * FROM users WHERE name = 'admin'#' AND pw = 'x'", []injWant{{"comment_truncation", "high", "#' AND pw = 'x'"}}},
+	{d.SQLServer, "SELECT * FROM users WHERE name = 'admin'--' AND pw = 'x'", []injWant{{"comment_truncation", "high", "--' AND pw = 'x'"}}},
+	{d.Oracle, "SELECT * FROM t WHERE b = 'x' -- lookup", []injWant{{"comment_truncation", "low", "-- lookup"}}},
+	{d.MySQL, "SELECT * FROM t WHERE id = 1; DROP TABLE users", []injWant{{"stacked_statement", "high", "DROP TABLE users"}}},
+	{d.Postgres, "SELECT a FROM t WHERE id = 1 UNION SELECT NULL, NULL", []injWant{{"union_select", "high", "UNION SELECT NULL, NULL"}}},
+	{d.MySQL, "SELECT a FROM t WHERE id = 1 UNION ALL SELECT table_name FROM information_schema.tables",
+		[]injWant{{"union_select", "high", "UNION ALL SELECT table_name FROM information_schema.tables"}}},
+	{d.MySQL, "SELECT a FROM t WHERE id = 1 UNION SELECT b FROM u", []injWant{{"union_select", "medium", "UNION SELECT b FROM u"}}},
+	{d.MySQL, "SELECT a FROM t WHERE id = 1 AND SLEEP(5)", []injWant{{"time_probe", "high", "SLEEP(5)"}}},
+	{d.MySQL, "SELECT BENCHMARK(1000000, MD5('x'))", []injWant{{"time_probe", "high", "BENCHMARK(1000000, MD5('x'))"}}},
+	{d.Postgres, "SELECT a FROM t WHERE id = 1; SELECT pg_sleep(10)",
+		[]injWant{{"stacked_statement", "high", "SELECT pg_sleep(10)"}, {"time_probe", "high", "pg_sleep(10)"}}},
+	{d.SQLServer, "SELECT a FROM t WHERE id = 1; WAITFOR DELAY '0:0:5'",
+		[]injWant{{"stacked_statement", "high", "WAITFOR DELAY '0:0:5'"}, {"time_probe", "high", "WAITFOR DELAY '0:0:5'"}}},
+	{d.Oracle, "SELECT a FROM t WHERE id = 1 AND 1 = DBMS_LOCK.SLEEP(5)", []injWant{{"time_probe", "high", "DBMS_LOCK.SLEEP(5)"}}},
+}
+
+// 正常查询不应命中
+var injectionClean = []struct {
+	dialect d.Dialect
+	in      string
+}{
+	{d.MySQL, "SELECT a FROM t WHERE x = 1 OR y = 2"},
+	{d.MySQL, "SELECT a FROM t WHERE name = 'O''Brien' AND id IN (1, 2)"},
+	{d.Postgres, "SELECT a FROM t UNION SELECT b FROM u"},
+	{d.Postgres, "SELECT a || b FROM t WHERE c = 'x' OR d = 'y'"},
+	{d.SQLServer, "SELECT TOP 10 * FROM t WHERE id = @id OR @id IS NULL"},
+	{d.Oracle, "SELECT * FROM t WHERE a = :1 OR b = :2"},
+	{d.MySQL, "SELECT * FROM t WHERE id = ?;"},
+}
+
+func Test_Injection_Findings(t *testing.T) {
+	for i, c := range injectionCases {
+		got, err := d.InspectInjection(c.in, d.Options{Dialect: c.dialect})
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("#%d %q\n got %+v\n want %+v", i, c.in, got, c.want)
+			continue
+		}
+		for j, w := range c.want {
+			g := got[j]
+			if g.Rule != w.rule || g.Severity != w.severity || c.in[g.Start:g.End] != w.span || g.Message == "" {
+				t.Errorf("#%d.%d %q\n got %s/%s %q\n want %s/%s %q", i, j, c.in, g.Rule, g.Severity, c.in[g.Start:g.End], w.rule, w.severity, w.span)
+			}
+		}
+	}
+}
+
+func Test_Injection_Clean(t *testing.T) {
+	for i, c := range injectionClean {
+		got, err := d.InspectInjection(c.in, d.Options{Dialect: c.dialect})
+		if err != nil || len(got) != 0 {
+			t.Errorf("#%d %q: findings=%+v err=%v", i, c.in, got, err)
+		}
+	}
+}
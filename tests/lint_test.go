@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+	"github.com/tensafe/sqlglot-go/sqlglot"
+)
+
+// go test -v -count=1 . -run Lint_
+
+// lintFlat 每条结果压成 "规则#语句 命中文本"
+func lintFlat(in string, fs []d.LintFinding) string {
+	var out []string
+	for _, f := range fs {
+		out = append(out, f.Rule+"#"+strconv.Itoa(f.Statement)+" "+in[f.Start:f.End])
+	}
+	return strings.Join(out, "\n")
+}
+
+var lintCases = []struct {
+	dialect d.Dialect
+	in      string
+	want    []string
+}{
+	{d.MySQL, "SELECT a FROM t1, t2 x, LATERAL (SELECT 1) y WHERE EXTRACT(YEAR FROM d) = 1 LIMIT 1",
+		[]string{"comma_join#1 , t2"}},
+	{d.Oracle, "SELECT e.ename, d.dname FROM emp e, dept d WHERE e.deptno = d.deptno(+)",
+		[]string{"comma_join#1 , dept", "oracle_outer_join#1 (+)"}},
+	{d.Postgres, "SELECT a FROM t WHERE id NOT IN (SELECT uid FROM u) AND k NOT IN (SELECT v FROM w WHERE v IS NOT NULL)",
+		[]string{"not_in_subquery#1 NOT IN (SELECT uid FROM u)"}},
+	{d.MySQL, "UPDATE t SET a = NULL, b = (SELECT c FROM u WHERE d = NULL) WHERE e = NULL OR NULL <> f",
+		[]string{"equals_null#1 = NULL", "equals_null#1 = NULL", "equals_null#1 NULL <>"}},
+	{d.MySQL, "INSERT INTO t (a) VALUES (1) ON DUPLICATE KEY UPDATE a = NULL", nil},
+	{d.SQLServer, "EXEC p @a = NULL; SET @x = NULL", nil},
+	{d.MySQL, "SELECT a FROM t WHERE DATE(created_at) = ? AND UPPER(email) LIKE ? AND LOWER(TRIM(user_email)) = 'x' AND COALESCE(x, 0) = 1",
+		[]string{"function_on_column#1 DATE(created_at)", "function_on_column#1 UPPER(email)", "function_on_column#1 LOWER(TRIM(user_email))"}},
+	{d.Postgres, "SELECT a FROM t WHERE a IN (SELECT b FROM u WHERE YEAR(u.order_date) = 2020)",
+		[]string{"function_on_column#1 YEAR(u.order_date)"}},
+	{d.MySQL, "SELECT a FROM t WHERE x = ? AND y = :name AND z = @uservar",
+		[]string{"mixed_bind_styles#1 :name"}},
+	{d.Postgres, "SELECT a FROM t WHERE x = $1 AND y = ? AND q::int = 1",
+		[]string{"mixed_bind_styles#1 ?"}},
+	{d.Oracle, "SELECT a FROM t WHERE x = :1 AND y = :name", nil},
+	{d.SQLServer, "SELECT a FROM t WHERE x = @p1 AND y = ?", []string{"mixed_bind_styles#1 ?"}},
+	{d.SQLServer, "SELECT a, b FROM t ORDER BY 2 DESC, a, 1; SELECT ROW_NUMBER() OVER (ORDER BY 1) FROM t ORDER BY a + 1",
+		[]string{"order_by_ordinal#1 2", "order_by_ordinal#1 1"}},
+	{d.MySQL, "SELECT a FROM t JOIN u ON u.id = t.uid WHERE t.x IS NULL ORDER BY a LIMIT 10", nil},
+}
+
+func Test_Lint_BuiltinRules(t *testing.T) {
+	for i, c := range lintCases {
+		got, err := d.Lint(c.in, nil, d.Options{Dialect: c.dialect})
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if g, w := lintFlat(c.in, got), strings.Join(c.want, "\n"); g != w {
+			t.Errorf("#%d %q\n got:\n%s\n want:\n%s", i, c.in, g, w)
+		}
+		for _, f := range got {
+			if f.Message == "" || f.Severity == "" {
+				t.Errorf("#%d incomplete finding %+v", i, f)
+			}
+		}
+	}
+}
+
+func Test_Lint_Suppression(t *testing.T) {
+	in := "DELETE FROM t WHERE a = NULL; -- sqlglot:ignore equals_null\n" +
+		"SELECT a FROM x, y WHERE b = NULL -- sqlglot:ignore\n;\n" +
+		"/* sqlglot:ignore comma_join */\n" +
+		"SELECT a FROM x, y WHERE b = NULL;\n" +
+		"SELECT a FROM x, y"
+	got, err := d.Lint(in, nil, d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := lintFlat(in, got), "equals_null#3 = NULL\ncomma_join#4 , y"; g != w {
+		t.Fatalf("got:\n%s\nwant:\n%s", g, w)
+	}
+
+	in = "# sqlglot:ignore-file comma_join, equals_null\nSELECT a FROM x, y WHERE b = NULL ORDER BY 1"
+	got, err = d.Lint(in, nil, d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := lintFlat(in, got), "order_by_ordinal#1 1"; g != w {
+		t.Fatalf("file-level: got:\n%s\nwant:\n%s", g, w)
+	}
+
+	// 规则名须与指令隔开；未知名字不抑制任何规则并单独报告
+	in = "SELECT a FROM x, y -- sqlglot:ignore because legacy\n;\n" +
+		"SELECT a FROM x, y -- sqlglot:ignore-comma_join\n;\n" +
+		"SELECT a FROM x, y /* sqlglot:ignore comma_join*/"
+	got, err = d.Lint(in, nil, d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := "comma_join#1 , y\n" +
+		"ignore_directive#1 -- sqlglot:ignore because legacy\n" +
+		"ignore_directive#1 -- sqlglot:ignore because legacy\n" +
+		"comma_join#2 , y"
+	if g := lintFlat(in, got); g != w {
+		t.Fatalf("unknown names: got:\n%s\nwant:\n%s", g, w)
+	}
+}
+
+func Test_Lint_CustomRule(t *testing.T) {
+	noSleep := sqlglot.NewLintRule("no_sleep", func(st *sqlglot.LintStatement) []sqlglot.LintFinding {
+		var out []sqlglot.LintFinding
+		for i, tk := range st.Tokens {
+			if tk.Upper == "SLEEP" {
+				out = append(out, st.FindingAt(i, i, "", "SLEEP in application SQL"))
+			}
+		}
+		return out
+	})
+	in := "SELECT SLEEP(1) FROM t ORDER BY 1; SELECT SLEEP(2) -- sqlglot:ignore no_sleep"
+	got, err := sqlglot.Lint(in, append(sqlglot.DefaultLintRules(), noSleep), sqlglot.Options{Dialect: sqlglot.MySQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Rule != "no_sleep" || got[0].Severity != sqlglot.SeverityMedium || in[got[0].Start:got[0].End] != "SLEEP" ||
+		got[1].Rule != sqlglot.LintOrderByOrdinal || got[1].Statement != 1 {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+// 语料回归：内置规则跑全部语料不出错
+func Test_Lint_Corpus(t *testing.T) {
+	for _, c := range []struct {
+		dialect d.Dialect
+		sqls    []string
+	}{
+		{d.Postgres, corpusPG25}, {d.MySQL, corpusMy25}, {d.SQLServer, corpusMS25}, {d.Oracle, corpusOR25},
+		{d.Oracle, oraComplexSQLs()},
+	} {
+		for i, s := range c.sqls {
+			fs, err := d.Lint(s, nil, d.Options{Dialect: c.dialect})
+			if err != nil {
+				t.Fatalf("%s #%d: %v", c.dialect, i, err)
+			}
+			for _, f := range fs {
+				if f.Start < 0 || f.End > len(s) || f.Start >= f.End {
+					t.Fatalf("%s #%d: bad span %+v", c.dialect, i, f)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run Decode
+
+func Test_Decode_Literals_PerDialect(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect d.Dialect
+		sql     string
+		want    []string // 每个参数的 Decoded
+	}{
+		{"mysql/quotes", d.MySQL, `SELECT 'it''s', 'a\nb', "d\"q", N'x'`, []string{"it's", "a\nb", `d"q`, "x"}},
+		{"mysql/like-escape", d.MySQL, `SELECT * FROM t WHERE a LIKE 'a\_b\%'`, []string{`a\_b\%`}},
+		{"mysql/hex", d.MySQL, `SELECT 0xFF, x'41', b'0101'`, []string{"\xff", "A", "\x05"}},
+		{"pg/escape", d.Postgres, `SELECT E'it\'s\n', 'a\nb', E'\x41\101é'`, []string{"it's\n", `a\nb`, "AAé"}},
+		{"pg/dollar", d.Postgres, `SELECT $$d;d$$, $t$x'y$t$`, []string{"d;d", "x'y"}},
+		{"pg/date", d.Postgres, `SELECT DATE '2020-01-02', INTERVAL '1 day'`, []string{"2020-01-02", "1 day"}},
+		{"tsql/nstring", d.SQLServer, `SELECT N'it''s', 'a\b'`, []string{"it's", `a\b`}},
+		{"pg/quoted-ident", d.Postgres, `SELECT "UserName", 'x' FROM t`, []string{"", "x"}},
+		{"oracle/q-quote", d.Oracle, `SELECT q'[hello;]', Q'{a'b}', q'!x!', 3f FROM dual`, []string{"hello;", "a'b", "x", "3"}},
+	}
+	for _, c := range cases {
+		res, err := d.BuildDigestANTLR(c.sql, d.Options{Dialect: c.dialect})
+		if err != nil {
+			t.Fatalf("[%s] build error: %v", c.name, err)
+		}
+		if len(res.Params) != len(c.want) {
+			t.Fatalf("[%s] param count: got %d want %d; params=%+v", c.name, len(res.Params), len(c.want), res.Params)
+		}
+		for i, p := range res.Params {
+			if p.Decoded != c.want[i] {
+				t.Fatalf("[%s] param #%d (%s) decoded=%q want %q", c.name, i+1, p.Value, p.Decoded, c.want[i])
+			}
+			if got := c.sql[p.Start:p.End]; got != p.Value {
+				t.Fatalf("[%s] param #%d Value must stay raw: slice=%q value=%q", c.name, i+1, got, p.Value)
+			}
+		}
+	}
+}
+
+func Test_Decode_NoBackslashEscapes(t *testing.T) {
+	sql := `SELECT 'a\nb'`
+	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.MySQL, NoBackslashEscapes: true})
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	if got := res.Params[0].Decoded; got != `a\nb` {
+		t.Fatalf("NO_BACKSLASH_ESCAPES should keep backslash: %q", got)
+	}
+}
+
+func Test_Decode_TypedAccessors(t *testing.T) {
+	sql := `SELECT 42, 1.5e3, 0x0102, 'txt', DATE '2020-01-02', TIMESTAMP '2020-01-02 03:04:05.5', ? FROM t`
+	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	ps := res.Params
+	if len(ps) != 7 {
+		t.Fatalf("param count: %d", len(ps))
+	}
+	if v, err := ps[0].Int64(); err != nil || v != 42 {
+		t.Fatalf("Int64: %v %v", v, err)
+	}
+	if v, err := ps[1].Float64(); err != nil || v != 1500 {
+		t.Fatalf("Float64: %v %v", v, err)
+	}
+	if v, err := ps[2].Bytes(); err != nil || !bytes.Equal(v, []byte{1, 2}) {
+		t.Fatalf("Bytes: %v %v", v, err)
+	}
+	if v, err := ps[2].Int64(); err != nil || v != 258 {
+		t.Fatalf("hex Int64: %v %v", v, err)
+	}
+	if v, err := ps[3].Text(); err != nil || v != "txt" {
+		t.Fatalf("Text: %v %v", v, err)
+	}
+	if v, err := ps[4].Time(); err != nil || !v.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Time(date): %v %v", v, err)
+	}
+	if v, err := ps[5].Typed(); err != nil || !v.(time.Time).Equal(time.Date(2020, 1, 2, 3, 4, 5, 5e8, time.UTC)) {
+		t.Fatalf("Typed(timestamp): %v %v", v, err)
+	}
+	if v, err := ps[0].Typed(); err != nil || v.(int64) != 42 {
+		t.Fatalf("Typed(int): %v %v", v, err)
+	}
+	if _, err := ps[6].Text(); !errors.Is(err, d.ErrNotLiteral) {
+		t.Fatalf("bind should be ErrNotLiteral, got %v", err)
+	}
+}
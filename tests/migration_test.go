@@ -0,0 +1,241 @@
+package tests
+
+// go test -v -count=1 . -run Migration_
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// migrationLines 每条语句一行 "#n risk lock [rewrite] [algorithm] [fails]: 第一条提示"
+func migrationLines(t *testing.T, dialect d.Dialect, version, schema, sql string) []string {
+	t.Helper()
+	mo := d.MigrationOptions{Version: version}
+	if schema != "" {
+		mo.Schema = schemaOf(t, dialect, schema)
+	}
+	checks, err := d.AnalyzeMigration(sql, d.Options{Dialect: dialect}, mo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, c := range checks {
+		s := fmt.Sprintf("#%d %s %s", c.Statement, c.Risk, c.Lock)
+		if c.Rewrite {
+			s += " rewrite"
+		}
+		if c.Algorithm != "" {
+			s += " " + c.Algorithm
+		}
+		if c.Fails {
+			s += " fails"
+		}
+		if len(c.Notes) > 0 {
+			s += ": " + c.Notes[len(c.Notes)-1].Reason
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func Test_Migration_MySQL(t *testing.T) {
+	schema := "CREATE TABLE users (id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY, email VARCHAR(60) NOT NULL, " +
+		"name VARCHAR(100), status ENUM('a','b'), bio TEXT, age INT)"
+	sql := `
+		ALTER TABLE users ADD COLUMN phone VARCHAR(20), ALGORITHM=INSTANT;
+		ALTER TABLE users ADD COLUMN nick VARCHAR(20) AFTER email;
+		ALTER TABLE users MODIFY email VARCHAR(50) NOT NULL;
+		ALTER TABLE users MODIFY name VARCHAR(120), ALGORITHM=INPLACE, LOCK=NONE;
+		ALTER TABLE users MODIFY status ENUM('a','b','c');
+		ALTER TABLE users MODIFY age INT NOT NULL;
+		ALTER TABLE users MODIFY email VARCHAR(80) NOT NULL;
+		CREATE FULLTEXT INDEX ft_bio ON users (bio);
+		ALTER TABLE users ADD CONSTRAINT fk_x FOREIGN KEY (age) REFERENCES t (id);
+		ALTER TABLE users MODIFY age BIGINT, ALGORITHM=INSTANT;
+		ALTER TABLE users ADD INDEX ix_age (age), LOCK=SHARED;
+		ALTER TABLE users DROP PRIMARY KEY, ADD PRIMARY KEY (id, email);
+		CREATE TABLE t2 (id INT);
+		CREATE INDEX ix ON t2 (id);
+		ALTER TABLE t2 MODIFY id BIGINT;`
+	checkLines(t, "mysql 8.0", migrationLines(t, d.MySQL, "", schema, sql),
+		"#1 low metadata INSTANT",
+		"#2 low metadata INSTANT",
+		"#3 high write rewrite COPY: changing the type of email from VARCHAR(60) to VARCHAR(50) copies the table and blocks writes",
+		"#4 low none INPLACE",
+		"#5 low metadata INSTANT",
+		"#6 medium none rewrite INPLACE: changing NULL / NOT NULL of age rebuilds the table",
+		"#7 high write rewrite COPY: widening email across the 255-byte length boundary copies the table",
+		"#8 medium write INPLACE: adding a FULLTEXT index blocks writes (the first FULLTEXT index also rebuilds the table)",
+		"#9 high write rewrite COPY: with foreign_key_checks=1 ADD FOREIGN KEY copies the table",
+		"#10 high none INSTANT fails: ALGORITHM=INSTANT is not supported for this change; the server rejects it",
+		"#11 medium write INPLACE",
+		"#12 medium none rewrite INPLACE: ADD PRIMARY KEY rebuilds the table",
+		"#13 low none",
+		"#14 low none",
+		"#15 low none")
+
+	// 8.0.29 之前 AFTER 与 DROP COLUMN 重建表；5.7 没有 INSTANT，ALGORITHM=INSTANT 被拒绝
+	checkLines(t, "mysql 8.0.20", migrationLines(t, d.MySQL, "8.0.20", schema,
+		"ALTER TABLE users ADD COLUMN nick VARCHAR(20) AFTER email; ALTER TABLE users ADD COLUMN x INT; ALTER TABLE users DROP COLUMN bio"),
+		"#1 medium none rewrite INPLACE: ADD COLUMN with AFTER / FIRST rebuilds the table before MySQL 8.0.29",
+		"#2 low metadata INSTANT",
+		"#3 medium none rewrite INPLACE: DROP COLUMN rebuilds the table before MySQL 8.0.29")
+	checkLines(t, "mysql 5.7", migrationLines(t, d.MySQL, "5.7.44", schema,
+		"ALTER TABLE users ADD COLUMN x INT, ALGORITHM=INSTANT; ALTER TABLE users ALTER COLUMN age SET DEFAULT 1"),
+		"#1 high none INSTANT fails: ALGORITHM=INSTANT is not supported for this change; the server rejects it",
+		"#2 low none INPLACE")
+
+	// 不知道旧定义时按复制表估计；LOCK=NONE 做不到被拒绝
+	checkLines(t, "mysql no schema", migrationLines(t, d.MySQL, "", "", "ALTER TABLE x MODIFY a INT, LOCK=NONE"),
+		"#1 high none fails: LOCK=NONE is not supported for this change; the server rejects it")
+}
+
+func Test_Migration_Postgres(t *testing.T) {
+	schema := "CREATE TABLE app.orders (id bigint PRIMARY KEY, code varchar(20), total numeric(10,2), cust_id int)"
+	sql := `
+		ALTER TABLE app.orders ADD COLUMN a int NOT NULL DEFAULT 0, ADD COLUMN b timestamptz DEFAULT now();
+		ALTER TABLE app.orders ADD COLUMN c uuid DEFAULT gen_random_uuid();
+		ALTER TABLE app.orders ADD COLUMN d int NOT NULL;
+		ALTER TABLE app.orders ALTER COLUMN code TYPE varchar(40), ALTER COLUMN total TYPE numeric(12,2);
+		ALTER TABLE app.orders ALTER COLUMN code TYPE text;
+		ALTER TABLE app.orders ALTER COLUMN cust_id TYPE bigint;
+		ALTER TABLE app.orders ALTER COLUMN cust_id SET NOT NULL;
+		ALTER TABLE app.orders ADD CONSTRAINT fk FOREIGN KEY (cust_id) REFERENCES c (id);
+		ALTER TABLE app.orders ADD CONSTRAINT fk2 FOREIGN KEY (cust_id) REFERENCES c (id) NOT VALID;
+		ALTER TABLE app.orders VALIDATE CONSTRAINT fk2;
+		ALTER TABLE app.orders ADD CONSTRAINT uq UNIQUE (code);
+		ALTER TABLE app.orders ADD CONSTRAINT uq2 UNIQUE USING INDEX ux;
+		CREATE INDEX ix ON app.orders (code);
+		CREATE INDEX CONCURRENTLY ix2 ON app.orders (code);
+		DROP INDEX app.ix;
+		ALTER TABLE app.orders SET LOGGED;`
+	checkLines(t, "postgres", migrationLines(t, d.Postgres, "", schema, sql),
+		"#1 low metadata",
+		"#2 high exclusive rewrite: adding c with a volatile default, identity or generated value rewrites the table",
+		"#3 low metadata: adding NOT NULL column d without a default fails if the table has rows",
+		"#4 low metadata",
+		"#5 low metadata",
+		"#6 high exclusive rewrite: changing the type of cust_id rewrites the table and its indexes under an ACCESS EXCLUSIVE lock",
+		"#7 high exclusive: SET NOT NULL scans the whole table under an ACCESS EXCLUSIVE lock",
+		"#8 medium write: ADD FOREIGN KEY checks every row while blocking writes to both tables",
+		"#9 low metadata",
+		"#10 low none",
+		"#11 high exclusive: ADD UNIQUE builds its index under an ACCESS EXCLUSIVE lock",
+		"#12 low metadata",
+		"#13 medium write: CREATE INDEX blocks writes to app.orders until the index is built",
+		"#14 low none",
+		"#15 high exclusive: DROP INDEX takes an ACCESS EXCLUSIVE lock on the table",
+		"#16 high exclusive rewrite: this rewrites the table under an ACCESS EXCLUSIVE lock")
+
+	// PG 10：带默认值的新列重写整表；SET NOT NULL 的建议不同
+	checks, _ := d.AnalyzeMigration("alter table t add column a int default 0; alter table t alter a set not null",
+		d.Options{Dialect: d.Postgres}, d.MigrationOptions{Version: "10.4"})
+	if len(checks) != 2 || !checks[0].Rewrite || checks[0].Risk != d.RiskHigh ||
+		!strings.HasPrefix(checks[1].Notes[0].Suggestion, "keep a CHECK (a IS NOT NULL) NOT VALID") {
+		t.Fatalf("%+v", checks)
+	}
+
+	// 脚本里新建的表（包括改名之后）不报风险
+	checkLines(t, "postgres new table", migrationLines(t, d.Postgres, "", "",
+		"create table n (a int); alter table n rename to m; create index on m (a); alter table m alter a type bigint; create index ix on other (a)"),
+		"#1 low none", "#2 low none", "#3 low none", "#4 low none",
+		"#5 medium write: CREATE INDEX blocks writes to other until the index is built")
+}
+
+func Test_Migration_SQLServer(t *testing.T) {
+	schema := "CREATE TABLE dbo.T (Id INT NOT NULL, Name NVARCHAR(50) NULL, Amt INT NULL)"
+	sql := `
+		ALTER TABLE dbo.T ADD A INT NULL, B INT NOT NULL CONSTRAINT DF_B DEFAULT 0;
+		ALTER TABLE dbo.T ADD G UNIQUEIDENTIFIER NOT NULL DEFAULT NEWID();
+		ALTER TABLE dbo.T ALTER COLUMN Name NVARCHAR(100) NULL;
+		ALTER TABLE dbo.T ALTER COLUMN Name NVARCHAR(100) NOT NULL;
+		ALTER TABLE dbo.T ALTER COLUMN Amt BIGINT NULL;
+		ALTER TABLE dbo.T ALTER COLUMN Amt DECIMAL(20,2) NULL WITH (ONLINE = ON);
+		ALTER TABLE dbo.T ADD CONSTRAINT FK_X FOREIGN KEY (Amt) REFERENCES dbo.X (Id);
+		ALTER TABLE dbo.T WITH NOCHECK ADD CONSTRAINT CK_A CHECK (Amt > 0);
+		ALTER TABLE dbo.T ADD CONSTRAINT PK_T PRIMARY KEY (Id);
+		CREATE INDEX IX_A ON dbo.T (Amt);
+		CREATE INDEX IX_B ON dbo.T (Amt) WITH (ONLINE = ON);
+		CREATE CLUSTERED INDEX IX_C ON dbo.T (Id);`
+	checkLines(t, "sqlserver", migrationLines(t, d.SQLServer, "", schema, sql),
+		"#1 low metadata: adding NOT NULL column B with a constant default is metadata only on Enterprise and Developer editions; other editions write every row",
+		"#2 high exclusive rewrite: adding NOT NULL column G with this default writes every row under a schema modification lock",
+		"#3 low metadata",
+		"#4 high exclusive: ALTER COLUMN ... NOT NULL checks every row under a schema modification lock",
+		"#5 high exclusive rewrite: ALTER COLUMN on Amt rewrites every row under a schema modification lock",
+		"#6 medium none rewrite: ALTER COLUMN on Amt rewrites every row under a schema modification lock",
+		"#7 high exclusive: ADD FOREIGN KEY checks every row under a schema modification lock",
+		"#8 low metadata",
+		"#9 high exclusive rewrite: ADD PRIMARY KEY builds its index while blocking reads and writes",
+		"#10 medium write: CREATE INDEX blocks writes to dbo.T until the index is built",
+		"#11 low none",
+		"#12 high exclusive rewrite: building a clustered index rewrites the table and blocks reads and writes")
+
+	// 2008（版本号 10）：带默认值的 NOT NULL 列逐行写入；ONLINE 建议只给 2016 起
+	checks, _ := d.AnalyzeMigration("ALTER TABLE dbo.T ADD B INT NOT NULL DEFAULT 0;\nGO\nALTER TABLE dbo.T ALTER COLUMN Amt BIGINT",
+		d.Options{Dialect: d.SQLServer}, d.MigrationOptions{Version: "10.50"})
+	if len(checks) != 2 || !checks[0].Rewrite || checks[1].Notes[0].Suggestion != "add a new column, backfill it in batches and switch over" {
+		t.Fatalf("%+v", checks)
+	}
+}
+
+func Test_Migration_Oracle(t *testing.T) {
+	schema := "CREATE TABLE hr.emp (id NUMBER(10) PRIMARY KEY, name VARCHAR2(100), sal NUMBER(10,2), email VARCHAR2(100))"
+	sql := `
+		ALTER TABLE hr.emp ADD (a NUMBER, b NUMBER DEFAULT 0);
+		ALTER TABLE hr.emp MODIFY (name VARCHAR2(200));
+		ALTER TABLE hr.emp MODIFY (sal NUMBER(8,2));
+		ALTER TABLE hr.emp MODIFY email NOT NULL;
+		ALTER TABLE hr.emp MODIFY email NOT NULL ENABLE NOVALIDATE;
+		ALTER TABLE hr.emp DROP COLUMN a;
+		ALTER TABLE hr.emp SET UNUSED (b);
+		ALTER TABLE hr.emp ADD CONSTRAINT uq UNIQUE (email);
+		ALTER TABLE hr.emp ADD CONSTRAINT fk FOREIGN KEY (id) REFERENCES hr.x (id) ENABLE NOVALIDATE;
+		ALTER TABLE hr.emp MOVE;
+		ALTER TABLE hr.emp MOVE ONLINE;
+		CREATE INDEX ix ON hr.emp (name);
+		CREATE INDEX ix2 ON hr.emp (name) ONLINE;`
+	checkLines(t, "oracle", migrationLines(t, d.Oracle, "19c", schema, sql),
+		"#1 low metadata",
+		"#2 low metadata",
+		"#3 high exclusive rewrite: changing the type of sal checks or converts every row while DML is blocked",
+		"#4 medium write: MODIFY ... NOT NULL checks every row while DML is blocked",
+		"#5 low metadata",
+		"#6 high exclusive rewrite: DROP COLUMN rewrites every block of the table",
+		"#7 low metadata",
+		"#8 medium write: ADD UNIQUE builds its index while DML is blocked",
+		"#9 low metadata",
+		"#10 high exclusive rewrite: MOVE rewrites the table and blocks DML; its indexes become unusable",
+		"#11 medium none rewrite",
+		"#12 medium write: CREATE INDEX blocks DML on hr.emp until the index is built",
+		"#13 low none")
+	checkLines(t, "oracle 11g", migrationLines(t, d.Oracle, "11g", schema, "ALTER TABLE hr.emp ADD (b NUMBER DEFAULT 0)"),
+		"#1 high exclusive rewrite: before Oracle 12c adding a nullable column with a default writes every row")
+}
+
+// 跨语料：不 panic；每条结论都有锁级别与风险等级
+func Test_Migration_Corpus(t *testing.T) {
+	for _, c := range []struct {
+		dialect d.Dialect
+		sqls    []string
+	}{
+		{d.Postgres, corpusPG25}, {d.MySQL, corpusMy25}, {d.SQLServer, corpusMS25}, {d.Oracle, corpusOR25},
+		{d.Postgres, corpusPG}, {d.MySQL, corpusMySQL}, {d.SQLServer, corpusMSSQL}, {d.Oracle, corpusOracle},
+		{d.Postgres, pgEdgeSQL}, {d.MySQL, myEdgeSQL}, {d.SQLServer, msEdgeSQL}, {d.Oracle, orEdgeSQL},
+	} {
+		for i, s := range c.sqls {
+			checks, err := d.AnalyzeMigration(s, d.Options{Dialect: c.dialect}, d.MigrationOptions{Version: "1"})
+			if err != nil {
+				t.Fatalf("%s #%d: %v", c.dialect, i, err)
+			}
+			for _, mc := range checks {
+				if mc.Lock == "" || mc.Risk == "" {
+					t.Fatalf("%s #%d: %+v", c.dialect, i, mc)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run MySQLSlowLog_|MySQLGeneralLog_|LogAggregator_
+
+const slowLogSample = `/usr/sbin/mysqld, Version: 8.0.34 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+# Time: 2024-01-02T03:04:05.123456Z
+# User@Host: app[app] @ web1 [10.0.0.5]  Id:    12
+# Query_time: 1.500000  Lock_time: 0.000100 Rows_sent: 1  Rows_examined: 1000
+use shop;
+SET timestamp=1704164644;
+SELECT * FROM orders
+WHERE id = 42;
+# User@Host: app[app] @  [10.0.0.6]  Id:    13
+# Query_time: 0.500000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 10
+SET timestamp=1704164646;
+SELECT * FROM orders WHERE id = 'x';
+# Time: 240102  3:05:00
+# User@Host: root[root] @ localhost []  Id:    14
+# Query_time: 3.000000  Lock_time: 0.000000 Rows_sent: 0  Rows_examined: 0
+SET timestamp=1704164700;
+UPDATE orders SET state = 'paid' WHERE id = 7;
+# User@Host: root[root] @ localhost []  Id:    14
+# Query_time: 0.000010  Lock_time: 0.000000 Rows_sent: 0  Rows_examined: 0
+SET timestamp=1704164701;
+# administrator command: Quit;
+`
+
+func readSlow(t *testing.T, s string) []d.LogEntry {
+	t.Helper()
+	var out []d.LogEntry
+	if err := d.ParseMySQLSlowLog(strings.NewReader(s), func(e d.LogEntry) error {
+		out = append(out, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return out
+}
+
+func Test_MySQLSlowLog_Entries(t *testing.T) {
+	es := readSlow(t, slowLogSample)
+	if len(es) != 3 {
+		t.Fatalf("want 3 entries, got %d: %+v", len(es), es)
+	}
+	e := es[0]
+	if e.SQL != "SELECT * FROM orders\nWHERE id = 42" {
+		t.Fatalf("sql=%q", e.SQL)
+	}
+	if e.User != "app" || e.Host != "web1" || e.DB != "shop" || e.ThreadID != 12 {
+		t.Fatalf("meta=%+v", e)
+	}
+	if e.QueryTime != 1500*time.Millisecond || e.LockTime != 100*time.Microsecond || e.RowsSent != 1 || e.RowsExamined != 1000 {
+		t.Fatalf("timing=%+v", e)
+	}
+	if !e.Time.Equal(time.Unix(1704164644, 0)) {
+		t.Fatalf("time=%v", e.Time)
+	}
+	if es[1].Host != "10.0.0.6" || es[1].DB != "shop" {
+		t.Fatalf("host/db should fall back to ip / previous use: %+v", es[1])
+	}
+	if !strings.HasPrefix(es[2].SQL, "UPDATE orders") || es[2].User != "root" {
+		t.Fatalf("entry 3=%+v", es[2])
+	}
+}
+
+func Test_MySQLSlowLog_TimeFallback(t *testing.T) {
+	es := readSlow(t, "# Time: 240102  3:04:05\n# User@Host: a[a] @ h []\n# Query_time: 0.1  Lock_time: 0 Rows_sent: 0  Rows_examined: 0\nSELECT 1;\n")
+	if len(es) != 1 {
+		t.Fatalf("entries=%+v", es)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !es[0].Time.Equal(want) {
+		t.Fatalf("time=%v want %v", es[0].Time, want)
+	}
+}
+
+func Test_MySQLGeneralLog_Entries(t *testing.T) {
+	log := "/usr/sbin/mysqld, Version: 8.0.34 (MySQL Community Server - GPL). started with:\n" +
+		"Tcp port: 3306  Unix socket: /tmp/mysql.sock\n" +
+		"Time                 Id Command    Argument\n" +
+		"2024-01-02T03:04:05.000001Z\t    8 Connect\tapp@web1 on shop using TCP/IP\n" +
+		"2024-01-02T03:04:05.000002Z\t    8 Query\tSELECT * FROM t\n" +
+		"WHERE a = 1\n" +
+		"2024-01-02T03:04:06.000000Z\t    8 Init DB\tother\n" +
+		"2024-01-02T03:04:07.000000Z\t    8 Query\tSELECT 2\n" +
+		"240102  3:04:08\t    9 Query\tSELECT 3\n" +
+		"\t\t    9 Query\tSELECT 4\n" +
+		"2024-01-02T03:04:09.000000Z\t    8 Quit\t\n"
+	var es []d.LogEntry
+	if err := d.ParseMySQLGeneralLog(strings.NewReader(log), func(e d.LogEntry) error {
+		es = append(es, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(es) != 4 {
+		t.Fatalf("want 4 entries, got %+v", es)
+	}
+	if es[0].SQL != "SELECT * FROM t\nWHERE a = 1" || es[0].User != "app" || es[0].Host != "web1" || es[0].DB != "shop" {
+		t.Fatalf("entry 1=%+v", es[0])
+	}
+	if es[1].DB != "other" {
+		t.Fatalf("Init DB not tracked: %+v", es[1])
+	}
+	if es[3].ThreadID != 9 || !es[3].Time.Equal(es[2].Time) {
+		t.Fatalf("time-less line should reuse last time: %+v", es[3])
+	}
+}
+
+func Test_LogAggregator_SlowLog(t *testing.T) {
+	agg := d.NewLogAggregator(d.Options{Dialect: d.MySQL})
+	if err := d.ParseMySQLSlowLog(strings.NewReader(slowLogSample), agg.Add); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	rs := agg.Results()
+	if len(rs) != 2 {
+		t.Fatalf("want 2 digests, got %+v", rs)
+	}
+	// UPDATE：总耗时 3s 排第一；两条 SELECT 归为同一 digest（1.5s + 0.5s）
+	if rs[0].SQLType[0] != "UPDATE" || rs[0].Count != 1 {
+		t.Fatalf("rs[0]=%+v", rs[0])
+	}
+	sel := rs[1]
+	if sel.Count != 2 || sel.TotalTime != 2*time.Second || sel.AvgTime != time.Second || sel.P95Time != 1500*time.Millisecond || sel.MaxTime != 1500*time.Millisecond {
+		t.Fatalf("select stats=%+v", sel)
+	}
+	if sel.RowsExamined != 1010 || sel.RowsSent != 2 {
+		t.Fatalf("rows=%+v", sel)
+	}
+	if !sel.FirstSeen.Equal(time.Unix(1704164644, 0)) || !sel.LastSeen.Equal(time.Unix(1704164646, 0)) {
+		t.Fatalf("seen=%v..%v", sel.FirstSeen, sel.LastSeen)
+	}
+	// 样本取最慢的一次，字面量脱敏
+	if sel.Sample != "SELECT * FROM orders\nWHERE id = ?" {
+		t.Fatalf("sample=%q", sel.Sample)
+	}
+}
+
+// 次数远超蓄水池容量：P95 仍落在真实分布附近
+func Test_LogAggregator_P95Bounded(t *testing.T) {
+	agg := d.NewLogAggregator(d.Options{Dialect: d.MySQL})
+	for i := 1; i <= 20000; i++ {
+		if err := agg.Add(d.LogEntry{SQL: "SELECT * FROM t WHERE id = 1", QueryTime: time.Duration(i%100+1) * time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	st := agg.Results()[0]
+	if st.Count != 20000 || st.MaxTime != 100*time.Millisecond {
+		t.Fatalf("stats=%+v", st)
+	}
+	if st.P95Time < 90*time.Millisecond || st.P95Time > 100*time.Millisecond {
+		t.Fatalf("p95=%v", st.P95Time)
+	}
+}
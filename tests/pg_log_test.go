@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run PostgresLog_
+
+func readPG(t *testing.T, log string, po d.PostgresLogOptions) []d.LogEntry {
+	t.Helper()
+	var out []d.LogEntry
+	if err := d.ParsePostgresLog(strings.NewReader(log), po, func(e d.LogEntry) error {
+		out = append(out, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return out
+}
+
+func Test_PostgresLog_StderrDefaultPrefix(t *testing.T) {
+	log := "2024-01-02 03:04:05.123 UTC [101] LOG:  duration: 12.500 ms  statement: SELECT *\n" +
+		"\tFROM orders WHERE id = 42\n" +
+		"2024-01-02 03:04:06.000 UTC [102] LOG:  duration: 0.100 ms  parse <unnamed>: SELECT * FROM t WHERE a = $1\n" +
+		"2024-01-02 03:04:06.001 UTC [102] LOG:  duration: 0.050 ms  bind <unnamed>: SELECT * FROM t WHERE a = $1\n" +
+		"2024-01-02 03:04:06.002 UTC [102] DETAIL:  parameters: $1 = '7'\n" +
+		"2024-01-02 03:04:06.003 UTC [102] LOG:  duration: 1.000 ms  execute <unnamed>: SELECT * FROM t WHERE a = $1 AND b = $2\n" +
+		"2024-01-02 03:04:06.003 UTC [102] DETAIL:  parameters: $1 = 'it''s, ok', $2 = NULL\n" +
+		"2024-01-02 03:04:07.000 UTC [103] ERROR:  relation \"x\" does not exist\n" +
+		"2024-01-02 03:04:07.000 UTC [103] STATEMENT:  SELECT * FROM x\n"
+	es := readPG(t, log, d.PostgresLogOptions{})
+	if len(es) != 2 {
+		t.Fatalf("want 2 entries (parse/bind skipped), got %+v", es)
+	}
+	if es[0].SQL != "SELECT *\nFROM orders WHERE id = 42" || es[0].QueryTime != 12500*time.Microsecond || es[0].ThreadID != 101 {
+		t.Fatalf("entry 1=%+v", es[0])
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 123e6, time.UTC); !es[0].Time.Equal(want) {
+		t.Fatalf("time=%v", es[0].Time)
+	}
+	if es[1].BindValues[1] != "'it''s, ok'" || es[1].BindValues[2] != "NULL" {
+		t.Fatalf("bind values=%#v", es[1].BindValues)
+	}
+
+	res, err := es[1].Result(d.Options{Dialect: d.Postgres})
+	if err != nil {
+		t.Fatalf("result: %v", err)
+	}
+	var bound []string
+	for _, p := range res.Params {
+		if p.Type == "Bind" {
+			bound = append(bound, p.Value+"="+p.BoundValue)
+		}
+	}
+	if strings.Join(bound, " ") != "$1='it''s, ok' $2=NULL" {
+		t.Fatalf("bound=%v params=%+v", bound, res.Params)
+	}
+}
+
+func Test_PostgresLog_StderrCustomPrefixAndLogDuration(t *testing.T) {
+	po := d.PostgresLogOptions{LinePrefix: "%t [%p]: [%l-1] user=%u,db=%d,app=%a,client=%h "}
+	log := "2024-01-02 03:04:05 UTC [200]: [1-1] user=app,db=shop,app=psql,client=10.0.0.9 LOG:  statement: UPDATE t SET a = 1\n" +
+		"2024-01-02 03:04:05 UTC [200]: [2-1] user=app,db=shop,app=psql,client=10.0.0.9 LOG:  duration: 3.000 ms\n" +
+		"2024-01-02 03:04:06 UTC [300]: [1-1] user=,db=,app=,client= LOG:  checkpoint starting: time\n"
+	es := readPG(t, log, po)
+	if len(es) != 1 {
+		t.Fatalf("entries=%+v", es)
+	}
+	e := es[0]
+	if e.User != "app" || e.DB != "shop" || e.Host != "10.0.0.9" || e.QueryTime != 3*time.Millisecond {
+		t.Fatalf("entry=%+v", e)
+	}
+}
+
+// 两个后端交错：各自的 duration / DETAIL 行归到各自的语句
+func Test_PostgresLog_InterleavedPIDs(t *testing.T) {
+	log := "2024-01-02 03:04:05.000 UTC [11] LOG:  statement: SELECT * FROM a\n" +
+		"2024-01-02 03:04:05.001 UTC [22] LOG:  execute <unnamed>: SELECT * FROM b WHERE id = $1\n" +
+		"2024-01-02 03:04:05.002 UTC [11] LOG:  duration: 7.000 ms\n" +
+		"2024-01-02 03:04:05.003 UTC [33] LOG:  statement: SELECT * FROM c\n" +
+		"2024-01-02 03:04:05.004 UTC [22] DETAIL:  parameters: $1 = '8'\n" +
+		"2024-01-02 03:04:05.005 UTC [22] LOG:  duration: 2.000 ms\n" +
+		"2024-01-02 03:04:05.006 UTC [11] LOG:  statement: SELECT * FROM a2\n" +
+		"2024-01-02 03:04:05.007 UTC [33] LOG:  disconnection: session time: 0:00:01.000 user=app database=shop host=[local]\n"
+	es := readPG(t, log, d.PostgresLogOptions{})
+	var got []string
+	for _, e := range es {
+		got = append(got, fmt.Sprintf("%d %s %v %v", e.ThreadID, e.SQL, e.QueryTime, e.BindValues))
+	}
+	want := []string{
+		"11 SELECT * FROM a 7ms map[]",
+		"33 SELECT * FROM c 0s map[]",
+		"22 SELECT * FROM b WHERE id = $1 2ms map[1:'8']",
+		"11 SELECT * FROM a2 0s map[]",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func Test_PostgresLog_CSVAndJSON(t *testing.T) {
+	csvLog := `2024-01-02 03:04:05.000 UTC,"app","shop",400,"10.0.0.1:5000",65940a1c.190,1,"SELECT",2024-01-02 03:00:00 UTC,3/7,0,LOG,00000,"duration: 2.000 ms  execute S_1: SELECT * FROM t
+WHERE id = $1","parameters: $1 = '5'",,,,,,,,"psql","client backend",,0
+`
+	es := readPG(t, csvLog, d.PostgresLogOptions{Format: "csvlog"})
+	if len(es) != 1 || es[0].SQL != "SELECT * FROM t\nWHERE id = $1" || es[0].BindValues[1] != "'5'" || es[0].User != "app" || es[0].ThreadID != 400 {
+		t.Fatalf("csv entries=%+v", es)
+	}
+
+	jsonLog := `{"timestamp":"2024-01-02 03:04:05.000 UTC","user":"app","dbname":"shop","pid":500,"error_severity":"LOG","message":"duration: 4.000 ms  statement: SELECT 1"}
+{"timestamp":"2024-01-02 03:04:06.000 UTC","user":"app","dbname":"shop","pid":500,"error_severity":"LOG","message":"duration: 1.000 ms  execute <unnamed>: SELECT $1","detail":"parameters: $1 = '9'"}
+`
+	es = readPG(t, jsonLog, d.PostgresLogOptions{Format: "jsonlog"})
+	if len(es) != 2 || es[0].QueryTime != 4*time.Millisecond || es[1].BindValues[1] != "'9'" {
+		t.Fatalf("json entries=%+v", es)
+	}
+}
+
+func Test_PostgresLog_Aggregate(t *testing.T) {
+	log := "2024-01-02 03:04:05.000 UTC [1] LOG:  duration: 10.000 ms  statement: SELECT * FROM t WHERE id = 1\n" +
+		"2024-01-02 03:04:06.000 UTC [1] LOG:  duration: 30.000 ms  execute <unnamed>: SELECT * FROM t WHERE id = $1\n" +
+		"2024-01-02 03:04:06.000 UTC [1] DETAIL:  parameters: $1 = '2'\n"
+	agg := d.NewLogAggregator(d.Options{Dialect: d.Postgres})
+	if err := d.ParsePostgresLog(strings.NewReader(log), d.PostgresLogOptions{}, agg.Add); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	rs := agg.Results()
+	if len(rs) != 1 || rs[0].Count != 2 || rs[0].TotalTime != 40*time.Millisecond {
+		t.Fatalf("results=%+v", rs)
+	}
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run PGStatStatements_
+
+// 期望值为 PostgreSQL 13–17 中 pg_stat_statements.query 的输出（DML 各版本相同），
+// 对照 contrib/pg_stat_statements 回归测试 expected/*.out 中的同类语句整理
+var pgssGolden = []struct{ in, want string }{
+	{"SELECT * FROM t WHERE id = 42", "SELECT * FROM t WHERE id = $1"},
+	{"select  a ,b from t where x='y'  and z in (1, 2,3);", "select  a ,b from t where x=$1  and z in ($2, $3,$4)"},
+	{"SELECT * FROM t WHERE a = $1 AND b = 5", "SELECT * FROM t WHERE a = $1 AND b = $2"},
+	{"SELECT * FROM t WHERE a = $2 AND b = 5 AND c = $1", "SELECT * FROM t WHERE a = $2 AND b = $3 AND c = $1"},
+	{"SELECT -1, a - 1, b * -2.5 FROM t", "SELECT $1, a - $2, b * $3 FROM t"},
+	{"SELECT a::numeric(10,2), 'x'::text, CAST(b AS varchar(20)) FROM t", "SELECT a::numeric(10,2), $1::text, CAST(b AS varchar(20)) FROM t"},
+	{"SELECT a, count(*) FROM t GROUP BY 1 ORDER BY 2 DESC, a LIMIT 10 OFFSET 5", "SELECT a, count(*) FROM t GROUP BY 1 ORDER BY 2 DESC, a LIMIT $1 OFFSET $2"},
+	{"UPDATE t SET a = NULL, b = true WHERE c IS NOT NULL AND d IS TRUE", "UPDATE t SET a = $1, b = $2 WHERE c IS NOT NULL AND d IS TRUE"},
+	{"INSERT INTO t (a, b, c) VALUES (1, E'x\\'y', $$z$$)", "INSERT INTO t (a, b, c) VALUES ($1, $2, $3)"},
+	{"SELECT DATE '2024-01-01', INTERVAL '1 day', B'101', X'ff'", "SELECT DATE $1, INTERVAL $2, $3, $4"},
+	{"/* app=web */ SELECT 1", "/* app=web */ SELECT $1"},
+	{"SELECT a\n  FROM t\n WHERE b = 'x'  -- trailing\n", "SELECT a\n  FROM t\n WHERE b = $1  -- trailing"},
+	{"SELECT * FROM t WHERE a = ANY(ARRAY[1, -2])", "SELECT * FROM t WHERE a = ANY(ARRAY[$1, $2])"},
+	{"WITH c AS (SELECT 1 AS x) SELECT x + 2 FROM c", "WITH c AS (SELECT $1 AS x) SELECT x + $2 FROM c"},
+	{"DELETE FROM t WHERE \"weird col\" = 'a' RETURNING 1", "DELETE FROM t WHERE \"weird col\" = $1 RETURNING $2"},
+	{"VALUES (1, 'a'), (2, 'b')", "VALUES ($1, $2), ($3, $4)"},
+	// 每条语句各自从 $1（或该语句已有的最大 $n 之后）编号
+	{"SELECT 1; SELECT 'a', $1; SELECT 2", "SELECT $1; SELECT $2, $1; SELECT $1"},
+}
+
+// 非 DML 语句：PG 16 起表达式里的常量也规范化（utility.out），之前原样保留；SET 见 pgssSetGolden
+var pgssUtilityGolden = []struct{ in, v15, v16 string }{
+	{"CREATE TABLE t (a varchar(10) DEFAULT 'x' NOT NULL)",
+		"CREATE TABLE t (a varchar(10) DEFAULT 'x' NOT NULL)", "CREATE TABLE t (a varchar(10) DEFAULT $1 NOT NULL)"},
+	{"CREATE TEMP TABLE tab_stats (a int, b char(20))",
+		"CREATE TEMP TABLE tab_stats (a int, b char(20))", "CREATE TEMP TABLE tab_stats (a int, b char(20))"},
+	{"CREATE INDEX index_stats ON tab_stats(b, (b || 'data1'), (b || 'data2')) WHERE a > 0",
+		"CREATE INDEX index_stats ON tab_stats(b, (b || 'data1'), (b || 'data2')) WHERE a > 0",
+		"CREATE INDEX index_stats ON tab_stats(b, (b || $1), (b || $2)) WHERE a > $3"},
+	{"ALTER TABLE tab_stats ALTER COLUMN b TYPE text USING 'data' || b",
+		"ALTER TABLE tab_stats ALTER COLUMN b TYPE text USING 'data' || b", "ALTER TABLE tab_stats ALTER COLUMN b TYPE text USING $1 || b"},
+	{"ALTER TABLE tab_stats ADD CONSTRAINT a_nonzero CHECK (a <> 0)",
+		"ALTER TABLE tab_stats ADD CONSTRAINT a_nonzero CHECK (a <> 0)", "ALTER TABLE tab_stats ADD CONSTRAINT a_nonzero CHECK (a <> $1)"},
+	{"CREATE TABLE p (a numeric(10, 2)) WITH (fillfactor = 70)",
+		"CREATE TABLE p (a numeric(10, 2)) WITH (fillfactor = 70)", "CREATE TABLE p (a numeric(10, 2)) WITH (fillfactor = 70)"},
+	{"ALTER TABLE t ADD b int NULL DEFAULT NULL, ADD c bool DEFAULT true",
+		"ALTER TABLE t ADD b int NULL DEFAULT NULL, ADD c bool DEFAULT true", "ALTER TABLE t ADD b int NULL DEFAULT $1, ADD c bool DEFAULT $2"},
+	{"COMMENT ON TABLE t IS 'x'", "COMMENT ON TABLE t IS 'x'", "COMMENT ON TABLE t IS 'x'"},
+	{"CREATE SEQUENCE s START 10 INCREMENT BY 2", "CREATE SEQUENCE s START 10 INCREMENT BY 2", "CREATE SEQUENCE s START 10 INCREMENT BY 2"},
+}
+
+// SET 的值：PG 17 及以前原样保留（REL_16/17 的 utility.out），PG 18 起替换
+var pgssSetGolden = []struct{ in, v17, v18 string }{
+	{"SET work_mem = '64MB';", "SET work_mem = '64MB'", "SET work_mem = $1"},
+	{"SET LOCAL statement_timeout TO 5000", "SET LOCAL statement_timeout TO 5000", "SET LOCAL statement_timeout TO $1"},
+	{"SET search_path TO app, public", "SET search_path TO app, public", "SET search_path TO app, public"},
+}
+
+func Test_PGStatStatements_Golden(t *testing.T) {
+	for i, c := range pgssGolden {
+		res, err := d.BuildDigestANTLR(c.in, d.Options{Dialect: d.Postgres, PGStatStatements: true})
+		if err != nil {
+			t.Fatalf("#%d build: %v", i, err)
+		}
+		if res.Digest != c.want {
+			t.Errorf("#%d\n in:   %q\n got:  %q\n want: %q", i, c.in, res.Digest, c.want)
+		}
+	}
+}
+
+func Test_PGStatStatements_UtilityByVersion(t *testing.T) {
+	for i, c := range pgssUtilityGolden {
+		for _, v := range []struct {
+			version int
+			want    string
+		}{{13, c.v15}, {15, c.v15}, {16, c.v16}, {17, c.v16}, {18, c.v16}, {0, c.v16}} {
+			res, err := d.BuildDigestANTLR(c.in, d.Options{Dialect: d.Postgres, PGStatStatements: true, PGVersion: v.version})
+			if err != nil {
+				t.Fatalf("#%d build: %v", i, err)
+			}
+			if res.Digest != v.want {
+				t.Errorf("#%d PG %d\n in:   %q\n got:  %q\n want: %q", i, v.version, c.in, res.Digest, v.want)
+			}
+		}
+	}
+}
+
+func Test_PGStatStatements_SetByVersion(t *testing.T) {
+	for i, c := range pgssSetGolden {
+		for _, v := range []struct {
+			version int
+			want    string
+		}{{15, c.v17}, {16, c.v17}, {17, c.v17}, {18, c.v18}, {0, c.v18}} {
+			res, err := d.BuildDigestANTLR(c.in, d.Options{Dialect: d.Postgres, PGStatStatements: true, PGVersion: v.version})
+			if err != nil {
+				t.Fatalf("#%d build: %v", i, err)
+			}
+			if res.Digest != v.want {
+				t.Errorf("#%d PG %d\n in:   %q\n got:  %q\n want: %q", i, v.version, c.in, res.Digest, v.want)
+			}
+		}
+	}
+}
+
+func Test_PGStatStatements_ParamsUnchanged(t *testing.T) {
+	sql := "SELECT * FROM t WHERE a = 1 AND b = 'x'"
+	plain, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.Postgres})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	pgss, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.Postgres, PGStatStatements: true})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(plain.Params) != len(pgss.Params) || plain.Params[1].Value != pgss.Params[1].Value {
+		t.Fatalf("params differ: %+v vs %+v", plain.Params, pgss.Params)
+	}
+	if plain.Digest == pgss.Digest {
+		t.Fatalf("option had no effect: %q", pgss.Digest)
+	}
+}
+
+func Test_PGStatStatements_OtherDialectsIgnored(t *testing.T) {
+	sql := "SELECT * FROM t WHERE a = 1"
+	a, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	b, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.MySQL, PGStatStatements: true})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if a.Digest != b.Digest {
+		t.Fatalf("MySQL digest changed: %q vs %q", a.Digest, b.Digest)
+	}
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+	"github.com/tensafe/sqlglot-go/sqlglot"
+	"github.com/tensafe/sqlglot-go/sqlglot/sqldriver"
+)
+
+// go test -v -count=1 . -run Policy_
+
+// 每条期望：规则 id、语句序号、命中文本
+type policyWant struct {
+	rule string
+	stmt int
+	span string
+}
+
+var policyCases = []struct {
+	dialect d.Dialect
+	in      string
+	want    []policyWant
+}{
+	{d.MySQL, "UPDATE t SET a = 1; DELETE FROM u WHERE id = 1; DELETE FROM v",
+		[]policyWant{{"write-without-where", 1, "UPDATE t SET a = 1"}, {"write-without-where", 3, "DELETE FROM v"}}},
+	{d.Postgres, "UPDATE t SET a = (SELECT b FROM u WHERE u.id = 1)",
+		[]policyWant{{"write-without-where", 1, "UPDATE t SET a = (SELECT b FROM u WHERE u.id = 1)"}}},
+	{d.MySQL, "TRUNCATE TABLE x; DROP TABLE y",
+		[]policyWant{{"truncate-or-drop", 1, "TRUNCATE TABLE x"}, {"truncate-or-drop", 2, "DROP TABLE y"}}},
+	{d.MySQL, "SELECT * FROM users WHERE id = 1 LIMIT 1", []policyWant{{"select-star", 1, "*"}}},
+	{d.Postgres, "SELECT u.*, o.id FROM users u JOIN o ON o.uid = u.id LIMIT 3", []policyWant{{"select-star", 1, "u.*"}}},
+	{d.SQLServer, "SELECT TOP 10 * FROM dbo.users", []policyWant{{"select-star", 1, "*"}}},
+	{d.MySQL, "SELECT a FROM t WHERE id > 5",
+		[]policyWant{{"unbounded-select", 1, "SELECT a FROM t WHERE id > 5"}}},
+	{d.MySQL, "SELECT a, COUNT(*) FROM t GROUP BY a",
+		[]policyWant{{"unbounded-select", 1, "SELECT a, COUNT(*) FROM t GROUP BY a"}}},
+	{d.MySQL, "GRANT ALL PRIVILEGES ON app.* TO 'u'@'%'", []policyWant{{"grant-all", 1, "GRANT ALL PRIVILEGES"}}},
+	{d.MySQL, "INSERT INTO other.t (a) VALUES (1); UPDATE `db2`.`t` SET a = 1 WHERE b = 2",
+		[]policyWant{{"cross-database-write", 1, "other.t"}, {"cross-database-write", 2, "`db2`.`t`"}}},
+	{d.SQLServer, "INSERT INTO db..t (a) VALUES (1); DELETE TOP (5) FROM [other].[dbo].[t] WHERE a = 1",
+		[]policyWant{{"cross-database-write", 1, "db..t"}, {"cross-database-write", 2, "[other].[dbo].[t]"}}},
+	{d.Oracle, "INSERT INTO hr.emp@remote VALUES (1)", []policyWant{{"cross-database-write", 1, "hr.emp@remote"}}},
+}
+
+// 默认规则下不应命中
+var policyClean = []struct {
+	dialect d.Dialect
+	in      string
+}{
+	{d.MySQL, "SELECT a FROM t WHERE id = 1 LIMIT 10"},
+	{d.MySQL, "SELECT COUNT(*) AS n, MAX(a) FROM t"},
+	{d.MySQL, "SELECT a FROM t WHERE EXISTS (SELECT * FROM u WHERE u.id = t.id) LIMIT 5"},
+	{d.Postgres, "WITH c AS (SELECT a FROM t LIMIT 3) SELECT * FROM c LIMIT 1"},
+	{d.Postgres, "SELECT a FROM t ORDER BY a OFFSET 10 ROWS FETCH NEXT 5 ROWS ONLY"},
+	{d.Postgres, "INSERT INTO public.t VALUES (1)"},
+	{d.Oracle, "SELECT * FROM dual"},
+	{d.Oracle, "UPDATE hr.emp SET a = 1 WHERE ROWNUM <= 10"},
+	{d.SQLServer, "INSERT INTO dbo.t VALUES (1)"},
+	{d.MySQL, "GRANT SELECT ON app.* TO 'u'@'%'"},
+}
+
+func Test_Policy_Default(t *testing.T) {
+	for i, c := range policyCases {
+		got, err := d.EvaluatePolicy(c.in, d.DefaultPolicy(), d.Options{Dialect: c.dialect})
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("#%d %q\n got %+v\n want %+v", i, c.in, got, c.want)
+			continue
+		}
+		for j, w := range c.want {
+			g := got[j]
+			if g.Rule != w.rule || g.Statement != w.stmt || c.in[g.Start:g.End] != w.span || g.Message == "" || g.Severity == "" {
+				t.Errorf("#%d.%d %q\n got %s #%d %q\n want %s #%d %q", i, j, c.in, g.Rule, g.Statement, c.in[g.Start:g.End], w.rule, w.stmt, w.span)
+			}
+		}
+	}
+	for i, c := range policyClean {
+		got, err := d.EvaluatePolicy(c.in, d.DefaultPolicy(), d.Options{Dialect: c.dialect})
+		if err != nil || len(got) != 0 {
+			t.Errorf("clean #%d %q: violations=%+v err=%v", i, c.in, got, err)
+		}
+	}
+}
+
+func Test_Policy_CustomRules(t *testing.T) {
+	p, err := sqlglot.ParsePolicy([]byte(`{"rules": [
+		{"id": "no-delete", "check": "statement_type", "types": ["delete"], "severity": "medium", "message": "use soft delete"},
+		{"id": "star-on-secrets", "check": "select_star", "tables": ["secrets"]},
+		{"id": "orders-db", "check": "cross_database_write", "databases": ["orders"]},
+		{"id": "update-where", "check": "missing_where", "types": ["UPDATE"]}
+	]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	in := "DELETE FROM t WHERE id = 1; SELECT * FROM users; SELECT * FROM app.secrets; INSERT INTO orders.o VALUES (1); INSERT INTO audit.o VALUES (1); DELETE FROM x; UPDATE t SET a = 1"
+	got, err := sqlglot.CheckPolicy(in, p, sqlglot.Options{Dialect: sqlglot.MySQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flat []string
+	for _, v := range got {
+		flat = append(flat, v.Rule+"|"+v.Severity+"|"+in[v.Start:v.End]+"|"+v.Message)
+	}
+	want := []string{
+		"no-delete|medium|DELETE FROM t WHERE id = 1|use soft delete",
+		"star-on-secrets|low|*|SELECT * on table secrets",
+		"orders-db|medium|audit.o|INSERT writes to o in database audit",
+		"no-delete|medium|DELETE FROM x|use soft delete",
+		"update-where|high|UPDATE t SET a = 1|UPDATE without WHERE affects every row",
+	}
+	if strings.Join(flat, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(flat, "\n"), strings.Join(want, "\n"))
+	}
+
+	for _, bad := range []string{
+		`{"rules": [{"check": "grant_all"}]}`,
+		`{"rules": [{"id": "a", "check": "grant_all"}, {"id": "a", "check": "grant_all"}]}`,
+		`{"rules": [{"id": "a", "check": "nope"}]}`,
+		`{"rules": [{"id": "a", "check": "grant_all", "severity": "fatal"}]}`,
+		`{"rules": [{"id": "a", "check": "statement_type"}]}`,
+		`{"rules": [{"id": "a", "check": "grant_all", "extra": 1}]}`,
+	} {
+		if _, err := sqlglot.ParsePolicy([]byte(bad)); err == nil {
+			t.Errorf("accepted %s", bad)
+		}
+	}
+}
+
+func Test_Policy_SQLDriverHook(t *testing.T) {
+	var seen []string
+	hook := sqldriver.PolicyHook(sqlglot.DefaultPolicy(), sqlglot.Options{Dialect: sqlglot.MySQL},
+		func(_ context.Context, ev sqldriver.Event, vs []sqlglot.PolicyViolation) {
+			for _, v := range vs {
+				seen = append(seen, string(ev.Op)+":"+v.Rule)
+			}
+		})
+	db := sql.OpenDB(sqldriver.WrapConnector(fakeConnector{fakeDriver{}}, sqlglot.Options{Dialect: sqlglot.MySQL}, hook))
+	defer db.Close()
+	if _, err := db.Exec("DELETE FROM t WHERE id = ?", 1); err != nil {
+		t.Fatal(err)
+	}
+	st, err := db.Prepare("UPDATE t SET a = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	for i := 0; i < 3; i++ {
+		if _, err := st.Exec(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 预编译语句只在 Prepare 时检查一次
+	if got := strings.Join(seen, ","); got != "prepare:write-without-where" {
+		t.Fatalf("seen=%s", got)
+	}
+}
+
+// 记录真正送到驱动的 SQL
+type recordingConnector struct {
+	fakeConnector
+	sent *[]string
+}
+
+func (c recordingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.fakeConnector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{Conn: conn, sent: c.sent}, nil
+}
+
+type recordingConn struct {
+	driver.Conn
+	sent *[]string
+}
+
+func (c *recordingConn) Prepare(q string) (driver.Stmt, error) {
+	*c.sent = append(*c.sent, q)
+	return c.Conn.Prepare(q)
+}
+
+func Test_Policy_SQLDriverEnforce(t *testing.T) {
+	var sent []string
+	log := &eventLog{}
+	opt := sqlglot.Options{Dialect: sqlglot.MySQL}
+	db := sql.OpenDB(sqldriver.WrapConnector(recordingConnector{fakeConnector{fakeDriver{}}, &sent}, opt, log.hook,
+		sqldriver.WithPolicy(sqlglot.DefaultPolicy())))
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM t")
+	var pe *sqldriver.PolicyError
+	if !errors.As(err, &pe) || len(pe.Violations) != 1 || pe.Violations[0].Rule != "write-without-where" {
+		t.Fatalf("exec: %v", err)
+	}
+	if _, err := db.Prepare("UPDATE t SET a = ?"); !errors.As(err, &pe) {
+		t.Fatalf("prepare: %v", err)
+	}
+	if _, err := db.Query("DELETE FROM t"); !errors.As(err, &pe) {
+		t.Fatalf("query: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM t WHERE id = ?", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(sent, "|"); got != "DELETE FROM t WHERE id = ?" {
+		t.Fatalf("sent to driver: %s", got)
+	}
+	var ops []string
+	for _, ev := range log.take() {
+		ops = append(ops, string(ev.Op)+":"+strconv.FormatBool(errors.As(ev.Err, &pe)))
+	}
+	if got := strings.Join(ops, ","); got != "exec:true,prepare:true,query:true,exec:false" {
+		t.Fatalf("events: %s", got)
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run Redact
+
+func Test_Redact_PreservesLayout(t *testing.T) {
+	sql := "select  id, name -- pick 'x'\nFROM users\n\tWHERE email = 'a@b.c' AND age > 30 AND id = ? AND d = DATE '2020-01-01';"
+	out, ps, err := d.Redact(sql, d.RedactOptions{Options: d.Options{Dialect: d.MySQL}})
+	if err != nil {
+		t.Fatalf("redact: %v", err)
+	}
+	want := "select  id, name -- pick 'x'\nFROM users\n\tWHERE email = ? AND age > ? AND id = ? AND d = ?;"
+	if out != want {
+		t.Fatalf("redact:\n got=%q\nwant=%q", out, want)
+	}
+	if len(ps) != 3 {
+		t.Fatalf("redacted literal count: %d", len(ps))
+	}
+}
+
+func Test_Redact_ModesAndFuncs(t *testing.T) {
+	sql := `SELECT UPPER('secret'), 'secret', 42 FROM t WHERE x = $1`
+
+	out, _, err := d.Redact(sql, d.RedactOptions{
+		Options: d.Options{Dialect: d.Postgres, ParamizeTimeFuncs: true},
+		Mode:    d.RedactTypeTag,
+	})
+	if err != nil {
+		t.Fatalf("redact: %v", err)
+	}
+	if out != `SELECT UPPER(<String>), <String>, <Number> FROM t WHERE x = $1` {
+		t.Fatalf("type tag: %q", out)
+	}
+
+	opt := d.RedactOptions{Options: d.Options{Dialect: d.Postgres}, Mode: d.RedactHMAC, HMACKey: []byte("k")}
+	out, _, err = d.Redact(sql, opt)
+	if err != nil {
+		t.Fatalf("redact hmac: %v", err)
+	}
+	if strings.Contains(out, "secret") {
+		t.Fatalf("hmac leaked value: %q", out)
+	}
+	parts := strings.Split(out, "hmac:")
+	if len(parts) != 4 || parts[1][:16] != parts[2][:16] {
+		t.Fatalf("same value should hash the same: %q", out)
+	}
+
+	if _, _, err := d.Redact(sql, d.RedactOptions{Mode: d.RedactHMAC}); err != d.ErrHMACKeyRequired {
+		t.Fatalf("missing key: %v", err)
+	}
+
+	out, _, err = d.Redact(`SELECT N'x', 'y'`, d.RedactOptions{
+		Options: d.Options{Dialect: d.SQLServer},
+		Replace: func(p d.ExParam) string { return strings.Repeat("*", len(p.Decoded)) },
+	})
+	if err != nil || out != `SELECT *, *` {
+		t.Fatalf("custom replace: %q %v", out, err)
+	}
+}
+
+func Test_Redact_QuotedIdentifiers(t *testing.T) {
+	// PG/Oracle 的 ".." 是标识符，原样保留；MySQL 下是字符串
+	sql := `SELECT "UserName" FROM "Accounts" WHERE pw = 'secret'`
+	for _, dl := range []d.Dialect{d.Postgres, d.Oracle} {
+		out, ps, err := d.Redact(sql, d.RedactOptions{Options: d.Options{Dialect: dl}})
+		if err != nil {
+			t.Fatalf("[%s] redact: %v", dl, err)
+		}
+		if want := `SELECT "UserName" FROM "Accounts" WHERE pw = ?`; out != want || len(ps) != 1 {
+			t.Fatalf("[%s] redact:\n got=%q\nwant=%q (%d literals)", dl, out, want, len(ps))
+		}
+	}
+	out, _, err := d.Redact(`SELECT "secret"`, d.RedactOptions{Options: d.Options{Dialect: d.MySQL}})
+	if err != nil || out != `SELECT ?` {
+		t.Fatalf("mysql double-quoted string: %q %v", out, err)
+	}
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run Render_|Bind_Values
+
+func Test_Render_RoundTrip(t *testing.T) {
+	cases := []struct {
+		dialect d.Dialect
+		sql     string
+	}{
+		{d.MySQL, `SELECT a, 'it''s' FROM t WHERE id = ? AND b IN (1, 2) AND c = 0xFF`},
+		{d.Postgres, `SELECT $$x?y$$, $1::text, DATE '2020-01-01' FROM t WHERE arr[2] = E'a\'b' LIMIT 10`},
+		{d.SQLServer, `SELECT TOP 3 [a?b] FROM t WHERE a = @p1 AND b = N'X'`},
+		{d.Oracle, `SELECT q'[abc]', :p FROM dual FETCH FIRST 2 ROWS ONLY`},
+	}
+	for i, c := range cases {
+		opt := d.Options{Dialect: c.dialect}
+		res, err := d.BuildDigestANTLR(c.sql, opt)
+		if err != nil {
+			t.Fatalf("[#%d] build: %v", i, err)
+		}
+		out, err := d.RenderDigest(res.Digest, res.Params, c.dialect)
+		if err != nil {
+			t.Fatalf("[#%d] render: %v", i, err)
+		}
+		again, err := d.BuildDigestANTLR(out, opt)
+		if err != nil {
+			t.Fatalf("[#%d] rebuild: %v", i, err)
+		}
+		if again.Digest != res.Digest {
+			t.Fatalf("[#%d] digest changed after render:\n sql=%s\n out=%s\n %q\n %q", i, c.sql, out, res.Digest, again.Digest)
+		}
+		for k, p := range again.Params {
+			if p.Value != res.Params[k].Value {
+				t.Fatalf("[#%d] param #%d: %q vs %q", i, k+1, p.Value, res.Params[k].Value)
+			}
+		}
+	}
+}
+
+func Test_Render_IdentifierCase(t *testing.T) {
+	cases := []struct {
+		dialect d.Dialect
+		sql     string
+		want    string
+	}{
+		{d.MySQL, "select userName, t.`Key` from Users t where t.Id = 5 and t.key = 'a'",
+			"SELECT username, t.`KEY` FROM users t WHERE t.id = 5 AND t.key = 'a'"},
+		{d.Postgres, `select count(*) from Orders where status in (1, 2)`,
+			`SELECT count(*) FROM orders WHERE status IN (1, 2)`},
+		{d.Oracle, `select Id from Emp where rownum = 3`,
+			`SELECT ID FROM EMP WHERE ROWNUM = 3`},
+	}
+	for i, c := range cases {
+		res, err := d.BuildDigestANTLR(c.sql, d.Options{Dialect: c.dialect})
+		if err != nil {
+			t.Fatalf("[#%d] build: %v", i, err)
+		}
+		out, err := d.RenderDigest(res.Digest, res.Params, c.dialect)
+		if err != nil || out != c.want {
+			t.Fatalf("[#%d] render:\n got=%s\nwant=%s %v", i, out, c.want, err)
+		}
+	}
+}
+
+func Test_Render_CollapsedMismatch(t *testing.T) {
+	sql := `INSERT INTO t(a) VALUES (1), (2)`
+	res, err := d.BuildDigestANTLR(sql, d.Options{Dialect: d.MySQL, CollapseValuesInDigest: true})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if _, err := d.RenderDigest(res.Digest, res.Params, d.MySQL); err == nil {
+		t.Fatalf("collapsed digest should not re-inflate: %q", res.Digest)
+	}
+}
+
+func Test_Bind_Values_PerDialect(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	cases := []struct {
+		dialect d.Dialect
+		sql     string
+		values  []any
+		want    string
+	}{
+		{d.MySQL, `SELECT * FROM t WHERE a = ? AND b = ? AND c = ?`, []any{1, `it's \x`, nil},
+			`SELECT * FROM t WHERE a = 1 AND b = 'it''s \\x' AND c = NULL`},
+		{d.Postgres, `SELECT * FROM t WHERE a = $2 AND b = $1 AND c = $2`, []any{"x'y", []byte{0xde, 0xad}},
+			`SELECT * FROM t WHERE a = '\xdead'::bytea AND b = 'x''y' AND c = '\xdead'::bytea`},
+		{d.SQLServer, `SELECT * FROM t WHERE a = @p1 AND b = @p2`, []any{true, "名"},
+			`SELECT * FROM t WHERE a = 1 AND b = N'名'`},
+		{d.Oracle, `SELECT * FROM t WHERE a = :id AND b = :ts AND c = :id`, []any{7, ts},
+			`SELECT * FROM t WHERE a = 7 AND b = TIMESTAMP '2024-05-06 07:08:09 +00:00' AND c = 7`},
+		{d.Oracle, `SELECT * FROM t WHERE a = :id AND b = :name`, []any{sql.Named("NAME", "n"), sql.Named("id", 3.5)},
+			`SELECT * FROM t WHERE a = 3.5 AND b = 'n'`},
+	}
+	// MySQL DATETIME 没有时区：换算成 UTC
+	cst := time.FixedZone("CST", 8*3600)
+	cases = append(cases, struct {
+		dialect d.Dialect
+		sql     string
+		values  []any
+		want    string
+	}{d.MySQL, `SELECT ?`, []any{time.Date(2024, 5, 6, 15, 8, 9, 0, cst)}, `SELECT '2024-05-06 07:08:09'`})
+	for i, c := range cases {
+		got, err := d.BindValues(c.sql, c.values, d.Options{Dialect: c.dialect})
+		if err != nil {
+			t.Fatalf("[#%d %s] bind: %v", i, c.dialect, err)
+		}
+		if got != c.want {
+			t.Fatalf("[#%d %s] bind:\n got=%s\nwant=%s", i, c.dialect, got, c.want)
+		}
+	}
+}
+
+func Test_Bind_Values_Errors(t *testing.T) {
+	if _, err := d.BindValues(`SELECT ?, ?`, []any{1}, d.Options{Dialect: d.MySQL}); err == nil {
+		t.Fatalf("missing value should fail")
+	}
+	if _, err := d.BindValues(`SELECT ?`, []any{struct{}{}}, d.Options{Dialect: d.MySQL}); err == nil ||
+		!strings.Contains(err.Error(), "unsupported") {
+		t.Fatalf("unsupported type should fail: %v", err)
+	}
+	if _, err := d.BindValues(`SELECT ?`, []any{1, 2}, d.Options{Dialect: d.MySQL}); err == nil {
+		t.Fatalf("extra value should fail")
+	}
+	if _, err := d.BindValues(`SELECT * FROM t WHERE a = :a`, []any{sql.Named("a", 1), sql.Named("b", 2)},
+		d.Options{Dialect: d.Oracle}); err == nil {
+		t.Fatalf("unused named value should fail")
+	}
+	got, err := d.BindValues(`SELECT ?`, []any{`a\b`}, d.Options{Dialect: d.MySQL, NoBackslashEscapes: true})
+	if err != nil || got != `SELECT 'a\b'` {
+		t.Fatalf("NoBackslashEscapes: %q %v", got, err)
+	}
+}
@@ -0,0 +1,321 @@
+package tests
+
+// go test -v -count=1 . -run SchemaDiff_
+
+import (
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+func schemaOf(t *testing.T, dialect d.Dialect, sql string) *d.Schema {
+	t.Helper()
+	s, err := d.SchemaFromDDL(sql, d.Options{Dialect: dialect})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// diffLines 变更的 SQL，破坏性的前面加一行 "!! reason"
+func diffLines(changes []d.SchemaChange) []string {
+	var out []string
+	for _, c := range changes {
+		if c.Destructive {
+			out = append(out, "!! "+c.Reason)
+		}
+		out = append(out, c.SQL...)
+	}
+	return out
+}
+
+const diffOldMySQL = `
+CREATE TABLE users (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  email VARCHAR(255) NOT NULL COMMENT 'login',
+  name VARCHAR(100),
+  status INT DEFAULT 0,
+  org_id INT,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_email (email),
+  KEY ix_status (status),
+  CONSTRAINT fk_org FOREIGN KEY (org_id) REFERENCES orgs (id)
+);
+CREATE TABLE orgs (id INT PRIMARY KEY, name VARCHAR(50));
+CREATE TABLE legacy (id INT);`
+
+const diffNewMySQL = `
+CREATE TABLE users (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  email VARCHAR(128) NOT NULL COMMENT 'login',
+  name VARCHAR(200) NOT NULL DEFAULT '',
+  org_id INT,
+  created DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_email (email, org_id),
+  KEY ix_created (created),
+  CONSTRAINT fk_org FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE
+);
+CREATE TABLE orgs (id INT PRIMARY KEY, name VARCHAR(50));
+CREATE TABLE audit (id BIGINT PRIMARY KEY, user_id BIGINT UNSIGNED REFERENCES users (id), INDEX ix_user (user_id));`
+
+func Test_SchemaDiff_MySQL(t *testing.T) {
+	changes := d.DiffSchemas(schemaOf(t, d.MySQL, diffOldMySQL), schemaOf(t, d.MySQL, diffNewMySQL))
+	checkLines(t, "mysql", diffLines(changes),
+		"ALTER TABLE users DROP FOREIGN KEY fk_org",
+		"ALTER TABLE users DROP INDEX uk_email",
+		"DROP INDEX ix_status ON users",
+		"CREATE TABLE audit (\n  id BIGINT NOT NULL,\n  user_id BIGINT UNSIGNED,\n  PRIMARY KEY (id)\n)",
+		"ALTER TABLE users ADD COLUMN created DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP",
+		"!! narrows VARCHAR(255) to VARCHAR(128)",
+		"ALTER TABLE users MODIFY COLUMN email VARCHAR(128) NOT NULL COMMENT 'login'",
+		"ALTER TABLE users MODIFY COLUMN name VARCHAR(200) NOT NULL DEFAULT ''",
+		"!! drops column status",
+		"ALTER TABLE users DROP COLUMN status",
+		"ALTER TABLE users ADD CONSTRAINT uk_email UNIQUE (email, org_id)",
+		"CREATE INDEX ix_created ON users (created)",
+		"CREATE INDEX ix_user ON audit (user_id)",
+		"ALTER TABLE users ADD CONSTRAINT fk_org FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE",
+		"ALTER TABLE audit ADD FOREIGN KEY (user_id) REFERENCES users (id)",
+		"!! drops table legacy",
+		"DROP TABLE legacy")
+	var alter *d.SchemaChange
+	for i := range changes {
+		if changes[i].Kind == d.ChangeAlterColumn && changes[i].Name == "name" {
+			alter = &changes[i]
+		}
+	}
+	if alter == nil || alter.From.Type != "VARCHAR(100)" || alter.To.Default != "''" ||
+		checkSame(alter.Changed, "type", "nullable", "default") != "" {
+		t.Fatalf("%+v", alter)
+	}
+
+	// 相同的 Schema（大小写、空白、类型同义词不同）没有变更
+	same := d.DiffSchemas(schemaOf(t, d.Postgres, "create table t (a integer not null, b varchar(10) default 'x', primary key (a))"),
+		schemaOf(t, d.Postgres, "CREATE TABLE T (A INT4 NOT NULL PRIMARY KEY, B CHARACTER VARYING ( 10 ) DEFAULT 'x')"))
+	if len(same) != 0 {
+		t.Fatalf("%+v", same)
+	}
+	// 字符集与排序规则
+	changes = d.DiffSchemas(schemaOf(t, d.MySQL, "CREATE TABLE t (c VARCHAR(10) CHARACTER SET latin1, e VARCHAR(5) CHARSET utf8mb4)"),
+		schemaOf(t, d.MySQL, "CREATE TABLE t (c VARCHAR(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin, e VARCHAR(5) CHARSET UTF8MB4)"))
+	checkLines(t, "mysql", diffLines(changes),
+		"ALTER TABLE t MODIFY COLUMN c VARCHAR(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin")
+	if checkSame(changes[0].Changed, "charset", "collation") != "" {
+		t.Fatalf("%+v", changes[0])
+	}
+	// 带引号参数的类型名同样不分大小写
+	same = d.DiffSchemas(schemaOf(t, d.MySQL, "CREATE TABLE t (s ENUM('on','off'), z SET('A'))"),
+		schemaOf(t, d.MySQL, "create table t (s enum('on','off'), z set('A'))"))
+	if len(same) != 0 {
+		t.Fatalf("%+v", same)
+	}
+}
+
+func checkSame(got []string, want ...string) string {
+	if len(got) != len(want) {
+		return "len"
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return got[i]
+		}
+	}
+	return ""
+}
+
+// 同一组变更按四种方言渲染
+func Test_SchemaDiff_Render(t *testing.T) {
+	changes := d.DiffSchemas(
+		schemaOf(t, d.MySQL, "CREATE TABLE shop.t (id INT NOT NULL, qty INT, note VARCHAR(50) NOT NULL, old INT, PRIMARY KEY (id))"),
+		schemaOf(t, d.MySQL, "CREATE TABLE shop.t (id INT NOT NULL, qty BIGINT NOT NULL DEFAULT 1, note VARCHAR(80), added INT DEFAULT 0, PRIMARY KEY (id), KEY ix_qty (qty))"))
+	render := func(dialect d.Dialect) []string {
+		var out []string
+		for _, c := range changes {
+			out = append(out, c.Render(dialect)...)
+		}
+		return out
+	}
+	checkLines(t, "mysql", render(d.MySQL),
+		"ALTER TABLE shop.t ADD COLUMN added INT DEFAULT 0",
+		"ALTER TABLE shop.t MODIFY COLUMN qty BIGINT NOT NULL DEFAULT 1",
+		"ALTER TABLE shop.t MODIFY COLUMN note VARCHAR(80)",
+		"ALTER TABLE shop.t DROP COLUMN old",
+		"CREATE INDEX ix_qty ON shop.t (qty)")
+	checkLines(t, "postgres", render(d.Postgres),
+		"ALTER TABLE shop.t ADD COLUMN added INT DEFAULT 0",
+		"ALTER TABLE shop.t ALTER COLUMN qty TYPE BIGINT, ALTER COLUMN qty SET DEFAULT 1, ALTER COLUMN qty SET NOT NULL",
+		"ALTER TABLE shop.t ALTER COLUMN note TYPE VARCHAR(80), ALTER COLUMN note DROP NOT NULL",
+		"ALTER TABLE shop.t DROP COLUMN old",
+		"CREATE INDEX ix_qty ON shop.t (qty)")
+	checkLines(t, "sqlserver", render(d.SQLServer),
+		"ALTER TABLE shop.t ADD added INT DEFAULT 0 NULL",
+		"ALTER TABLE shop.t ALTER COLUMN qty BIGINT NOT NULL",
+		"ALTER TABLE shop.t ADD DEFAULT 1 FOR qty",
+		"ALTER TABLE shop.t ALTER COLUMN note VARCHAR(80) NULL",
+		"ALTER TABLE shop.t DROP COLUMN old",
+		"CREATE INDEX ix_qty ON shop.t (qty)")
+	checkLines(t, "oracle", render(d.Oracle),
+		"ALTER TABLE shop.t ADD (added INT DEFAULT 0)",
+		"ALTER TABLE shop.t MODIFY (qty BIGINT DEFAULT 1 NOT NULL)",
+		"ALTER TABLE shop.t MODIFY (note VARCHAR(80) NULL)",
+		"ALTER TABLE shop.t DROP COLUMN old",
+		"CREATE INDEX ix_qty ON shop.t (qty)")
+}
+
+func Test_SchemaDiff_Dialects(t *testing.T) {
+	// PG：没名字的约束按默认命名删除；换类型族加 USING；索引跟表在同一 schema
+	changes := d.DiffSchemas(
+		schemaOf(t, d.Postgres, `create table app.orders (id serial primary key, code varchar(20) unique, note text, created timestamp default now());
+			create index ix_code on app.orders (lower(code))`),
+		schemaOf(t, d.Postgres, `create table app.orders (id serial primary key, code int, note varchar(100), created date, tags text[]);`))
+	checkLines(t, "postgres", diffLines(changes),
+		"ALTER TABLE app.orders DROP CONSTRAINT orders_code_key",
+		"DROP INDEX app.ix_code",
+		`ALTER TABLE app.orders ADD COLUMN tags text[]`,
+		"!! converts varchar(20) to int",
+		"ALTER TABLE app.orders ALTER COLUMN code TYPE int USING code::int",
+		"!! narrows text to varchar(100)",
+		"ALTER TABLE app.orders ALTER COLUMN note TYPE varchar(100)",
+		"!! narrows timestamp to date",
+		"ALTER TABLE app.orders ALTER COLUMN created TYPE date, ALTER COLUMN created DROP DEFAULT")
+
+	// SQL Server：默认值约束先删后加，删列前先删它的默认值约束
+	changes = d.DiffSchemas(
+		schemaOf(t, d.SQLServer, "CREATE TABLE dbo.T (Id INT IDENTITY(1,1) NOT NULL CONSTRAINT PK_T PRIMARY KEY, "+
+			"Amt DECIMAL(12,2) NULL CONSTRAINT DF_Amt DEFAULT ((0)), Flag BIT NOT NULL CONSTRAINT DF_Flag DEFAULT 0, Note NVARCHAR(MAX) NULL, Qty INT DEFAULT 0)"),
+		schemaOf(t, d.SQLServer, "CREATE TABLE dbo.T (Id INT IDENTITY(1,1) NOT NULL CONSTRAINT PK_T PRIMARY KEY, "+
+			"Amt DECIMAL(12,2) NULL CONSTRAINT DF_Amt2 DEFAULT 1, Note NVARCHAR(400) NOT NULL, Qty INT DEFAULT (0), [Order] INT)"))
+	checkLines(t, "sqlserver", diffLines(changes),
+		"ALTER TABLE dbo.T ADD [Order] INT NULL",
+		"ALTER TABLE dbo.T DROP CONSTRAINT DF_Amt",
+		"ALTER TABLE dbo.T ADD CONSTRAINT DF_Amt2 DEFAULT 1 FOR Amt",
+		"!! narrows NVARCHAR(MAX) to NVARCHAR(400)",
+		"ALTER TABLE dbo.T ALTER COLUMN Note NVARCHAR(400) NOT NULL",
+		"!! drops column Flag",
+		"ALTER TABLE dbo.T DROP CONSTRAINT DF_Flag",
+		"ALTER TABLE dbo.T DROP COLUMN Flag")
+
+	// Oracle：MODIFY 只写变了的部分
+	changes = d.DiffSchemas(
+		schemaOf(t, d.Oracle, "CREATE TABLE hr.emp (id NUMBER(10) PRIMARY KEY, name VARCHAR2(100 CHAR) NOT NULL, sal NUMBER(12,2) DEFAULT 0, hired DATE)"),
+		schemaOf(t, d.Oracle, "CREATE TABLE hr.emp (id NUMBER(10) PRIMARY KEY, name VARCHAR2(200 CHAR), sal NUMBER(12,2), hired TIMESTAMP, dept NUMBER);"+
+			"CREATE UNIQUE INDEX ux_emp ON hr.emp (name)"))
+	checkLines(t, "oracle", diffLines(changes),
+		"ALTER TABLE hr.emp ADD (dept NUMBER)",
+		"ALTER TABLE hr.emp MODIFY (name VARCHAR2(200 CHAR) NULL)",
+		"ALTER TABLE hr.emp MODIFY (sal DEFAULT NULL)",
+		"ALTER TABLE hr.emp MODIFY (hired TIMESTAMP)",
+		"CREATE UNIQUE INDEX ux_emp ON hr.emp (name)")
+}
+
+// 删表顺序：引用别的表的先删；互相引用时先删外键
+func Test_SchemaDiff_DropOrder(t *testing.T) {
+	old := schemaOf(t, d.MySQL, `CREATE TABLE parent (id INT PRIMARY KEY);
+		CREATE TABLE child (id INT PRIMARY KEY, p INT, CONSTRAINT fk_p FOREIGN KEY (p) REFERENCES parent (id));
+		CREATE TABLE grandchild (id INT, c INT, CONSTRAINT fk_c FOREIGN KEY (c) REFERENCES child (id));
+		CREATE TABLE a (id INT PRIMARY KEY, b_id INT);
+		CREATE TABLE b (id INT PRIMARY KEY, a_id INT, CONSTRAINT fk_ba FOREIGN KEY (a_id) REFERENCES a (id));
+		ALTER TABLE a ADD CONSTRAINT fk_ab FOREIGN KEY (b_id) REFERENCES b (id);
+		CREATE TABLE keep (id INT)`)
+	checkLines(t, "mysql", diffLines(d.DiffSchemas(old, schemaOf(t, d.MySQL, "CREATE TABLE keep (id INT)"))),
+		"ALTER TABLE b DROP FOREIGN KEY fk_ba",
+		"!! drops table grandchild",
+		"DROP TABLE grandchild",
+		"!! drops table child",
+		"DROP TABLE child",
+		"!! drops table parent",
+		"DROP TABLE parent",
+		"!! drops table a",
+		"DROP TABLE a",
+		"!! drops table b",
+		"DROP TABLE b")
+}
+
+// PG 改生成列表达式：SET EXPRESSION 要 17，DROP EXPRESSION 要 13
+func Test_SchemaDiff_PGGenerated(t *testing.T) {
+	a := schemaOf(t, d.Postgres, "create table t (a int, b int generated always as (a * 2) stored, c int generated always as (a + 1) stored)")
+	b := schemaOf(t, d.Postgres, "create table t (a int, b int generated always as (a * 3) stored, c int)")
+	checkLines(t, "latest", diffLines(d.DiffSchemas(a, b)),
+		"ALTER TABLE t ALTER COLUMN b SET EXPRESSION AS (a * 3)",
+		"ALTER TABLE t ALTER COLUMN c DROP EXPRESSION")
+	b.Version = "16.4"
+	checkLines(t, "16", diffLines(d.DiffSchemas(a, b)),
+		"-- PostgreSQL before 17 cannot change the expression of t.b in place; drop and re-add it",
+		"ALTER TABLE t ALTER COLUMN c DROP EXPRESSION")
+	changes := d.DiffSchemas(a, b)
+	checkLines(t, "12", changes[1].RenderVersion(d.Postgres, "12"),
+		"-- PostgreSQL before 13 cannot turn t.c into a plain column in place; drop and re-add it")
+}
+
+// 临时表按方言写法建表
+func Test_SchemaDiff_Temporary(t *testing.T) {
+	for _, c := range []struct {
+		dialect d.Dialect
+		ddl     string
+		want    string
+	}{
+		{d.MySQL, "CREATE TEMPORARY TABLE app.tmp (id INT)", "CREATE TEMPORARY TABLE app.tmp (\n  id INT\n)"},
+		{d.Postgres, "CREATE TEMPORARY TABLE app.tmp (id INT)", "CREATE TEMPORARY TABLE tmp (\n  id INT\n)"},
+		{d.SQLServer, "CREATE TEMPORARY TABLE app.tmp (id INT)", "CREATE TABLE #tmp (\n  id INT NULL\n)"},
+		{d.Oracle, "CREATE TEMPORARY TABLE app.tmp (id INT)", "CREATE GLOBAL TEMPORARY TABLE app.tmp (\n  id INT\n) ON COMMIT PRESERVE ROWS"},
+	} {
+		changes := d.DiffSchemas(nil, schemaOf(t, d.MySQL, c.ddl))
+		if len(changes) != 1 || len(changes[0].Render(c.dialect)) != 1 || changes[0].Render(c.dialect)[0] != c.want {
+			t.Fatalf("%s: %+v", c.dialect, changes)
+		}
+	}
+	// SQL Server 的 #tmp 在其它方言里去掉 #
+	changes := d.DiffSchemas(nil, schemaOf(t, d.SQLServer, "CREATE TABLE ##g (id INT)"))
+	checkLines(t, "sqlserver", changes[0].Render(d.SQLServer), "CREATE TABLE ##g (\n  id INT NULL\n)")
+	checkLines(t, "postgres", changes[0].Render(d.Postgres), "CREATE TEMPORARY TABLE g (\n  id INT\n)")
+}
+
+// 类型收窄的判断
+func Test_SchemaDiff_Narrowing(t *testing.T) {
+	for _, c := range []struct {
+		dialect  d.Dialect
+		from, to string
+		want     string // 空为不破坏
+	}{
+		{d.MySQL, "INT", "BIGINT", ""},
+		{d.MySQL, "BIGINT", "INT", "narrows BIGINT to INT"},
+		{d.MySQL, "INT UNSIGNED", "INT", "narrows INT UNSIGNED to INT"},
+		{d.MySQL, "VARCHAR(20)", "TEXT", ""},
+		{d.MySQL, "VARCHAR(100000)", "TEXT", "narrows VARCHAR(100000) to TEXT"},
+		{d.MySQL, "LONGTEXT", "MEDIUMTEXT", "narrows LONGTEXT to MEDIUMTEXT"},
+		{d.MySQL, "DECIMAL(10,2)", "DECIMAL(12,2)", ""},
+		{d.MySQL, "DECIMAL(10,2)", "DECIMAL(10,1)", "narrows DECIMAL(10,2) to DECIMAL(10,1)"},
+		{d.MySQL, "INT", "DECIMAL(12,2)", ""},
+		{d.MySQL, "BIGINT", "DECIMAL(12,2)", "narrows BIGINT to DECIMAL(12,2)"},
+		{d.MySQL, "INT", "VARCHAR(64)", ""},
+		{d.MySQL, "DATETIME", "DATE", "narrows DATETIME to DATE"},
+		{d.MySQL, "DATE", "DATETIME", ""},
+		{d.MySQL, "DATETIME(6)", "DATETIME(3)", "narrows DATETIME(6) to DATETIME(3)"},
+		{d.MySQL, "VARCHAR(10)", "INT", "converts VARCHAR(10) to INT"},
+		{d.MySQL, "FLOAT", "DOUBLE", ""},
+		{d.MySQL, "DOUBLE", "FLOAT", "narrows DOUBLE to FLOAT"},
+		{d.MySQL, "INT", "DOUBLE", ""},
+		{d.MySQL, "BIGINT", "FLOAT", ""},
+		{d.MySQL, "ENUM('a','b')", "ENUM('a','b','c')", ""},
+		{d.MySQL, "ENUM('a','b')", "ENUM('b','a')", "narrows ENUM('a','b') to ENUM('b','a')"},
+		{d.MySQL, "SET('x','y')", "SET('x')", "narrows SET('x','y') to SET('x')"},
+		{d.Postgres, "real", "double precision", ""},
+		{d.Postgres, "float8", "float4", "narrows float8 to float4"},
+		{d.SQLServer, "REAL", "FLOAT", ""},
+		{d.SQLServer, "FLOAT(53)", "FLOAT(24)", "narrows FLOAT(53) to FLOAT(24)"},
+		{d.Oracle, "BINARY_FLOAT", "BINARY_DOUBLE", ""},
+		{d.Postgres, "varchar(20)", "varchar", ""},
+		{d.Postgres, "text", "varchar(20)", "narrows text to varchar(20)"},
+		{d.Postgres, "numeric", "numeric(10,2)", "narrows numeric to numeric(10,2)"},
+		{d.Postgres, "timestamp", "timestamptz", ""},
+		{d.SQLServer, "NVARCHAR(MAX)", "NVARCHAR(4000)", "narrows NVARCHAR(MAX) to NVARCHAR(4000)"},
+		{d.Oracle, "VARCHAR2(100 CHAR)", "VARCHAR2(50 CHAR)", "narrows VARCHAR2(100 CHAR) to VARCHAR2(50 CHAR)"},
+		{d.Oracle, "NUMBER(10)", "NUMBER", ""},
+	} {
+		changes := d.DiffSchemas(schemaOf(t, c.dialect, "CREATE TABLE t (c "+c.from+")"), schemaOf(t, c.dialect, "CREATE TABLE t (c "+c.to+")"))
+		if len(changes) != 1 || changes[0].Kind != d.ChangeAlterColumn || changes[0].Reason != c.want || changes[0].Destructive != (c.want != "") {
+			t.Fatalf("%s %s -> %s: %+v", c.dialect, c.from, c.to, changes)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tensafe/sqlglot-go/sqlglot"
+	"github.com/tensafe/sqlglot-go/sqlglot/sqldriver"
+)
+
+// go test -v -count=1 . -run SQLDriver_
+
+// 内存假驱动：direct=true 的连接实现 ExecerContext/QueryerContext，否则 database/sql 走 Prepare
+type fakeDriver struct{ direct bool }
+
+var errFake = errors.New("fake: boom")
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	if d.direct {
+		return &fakeDirectConn{}, nil
+	}
+	return &fakeConn{}, nil
+}
+
+type fakeConnector struct{ d fakeDriver }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c fakeConnector) Driver() driver.Driver                        { return c.d }
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(q string) (driver.Stmt, error) {
+	if strings.Contains(q, "BROKEN") {
+		return nil, errFake
+	}
+	return &fakeStmt{q: q}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeDirectConn struct{ fakeConn }
+
+func (c *fakeDirectConn) ExecContext(_ context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	return (&fakeStmt{q: q}).exec(len(args))
+}
+func (c *fakeDirectConn) QueryContext(_ context.Context, q string, _ []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct{ q string }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.exec(len(args))
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) { return &fakeRows{}, nil }
+func (s *fakeStmt) exec(n int) (driver.Result, error) {
+	if strings.Contains(s.q, "FAIL") {
+		return nil, errFake
+	}
+	return driver.RowsAffected(n + 1), nil
+}
+
+type fakeRows struct{ done bool }
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(7)
+	return nil
+}
+
+type eventLog struct {
+	mu sync.Mutex
+	ev []sqldriver.Event
+}
+
+func (l *eventLog) hook(_ context.Context, ev sqldriver.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ev = append(l.ev, ev)
+}
+
+func (l *eventLog) take() []sqldriver.Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ev := l.ev
+	l.ev = nil
+	return ev
+}
+
+func Test_SQLDriver_DirectConn(t *testing.T) {
+	var log eventLog
+	db := sql.OpenDB(sqldriver.WrapConnector(fakeConnector{fakeDriver{direct: true}}, sqlglot.Options{Dialect: sqlglot.MySQL}, log.hook))
+	defer db.Close()
+
+	if _, err := db.Exec("UPDATE t SET a = 'x' WHERE id = ?", 5); err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	var n int
+	if err := db.QueryRow("SELECT n FROM t WHERE id = 42").Scan(&n); err != nil || n != 7 {
+		t.Fatalf("query: n=%d err=%v", n, err)
+	}
+	ev := log.take()
+	if len(ev) != 2 {
+		t.Fatalf("events=%+v", ev)
+	}
+	if e := ev[0]; e.Op != sqldriver.OpExec || e.Digest != "UPDATE T SET A = ? WHERE ID = ?" || len(e.Params) != 2 ||
+		e.SQLType[0] != "UPDATE" || e.RowsAffected != 2 || len(e.Args) != 1 || e.Args[0].Value != int64(5) || e.Err != nil {
+		t.Fatalf("exec event=%+v", e)
+	}
+	if e := ev[1]; e.Op != sqldriver.OpQuery || e.Digest != "SELECT N FROM T WHERE ID = ?" || e.RowsAffected != -1 {
+		t.Fatalf("query event=%+v", e)
+	}
+}
+
+func Test_SQLDriver_PreparePath(t *testing.T) {
+	var log eventLog
+	sql.Register("sqlglot-fake-prepare", sqldriver.Wrap(fakeDriver{}, sqlglot.Options{Dialect: sqlglot.Postgres}, log.hook))
+	db, err := sql.Open("sqlglot-fake-prepare", "")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	// 连接不支持直接执行：database/sql 先 Prepare 再执行，仍只上报一次 exec
+	if _, err := db.Exec("DELETE FROM t WHERE id = $1", 9); err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	ev := log.take()
+	if len(ev) != 1 || ev[0].Op != sqldriver.OpExec {
+		t.Fatalf("events=%+v", ev)
+	}
+	if ev[0].Digest != "DELETE FROM T WHERE ID = ?" || ev[0].RowsAffected != 2 || len(ev[0].Args) != 1 {
+		t.Fatalf("exec event=%+v", ev[0])
+	}
+	rows, err := db.Query("SELECT n FROM t")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	rows.Close()
+	if _, err := db.Exec("SELECT BROKEN"); !errors.Is(err, errFake) {
+		t.Fatalf("err=%v", err)
+	}
+	ev = log.take()
+	if len(ev) != 2 || ev[0].Op != sqldriver.OpQuery || ev[1].Op != sqldriver.OpExec || !errors.Is(ev[1].Err, errFake) {
+		t.Fatalf("events=%+v", ev)
+	}
+
+	// 预编译语句多次执行共用同一 digest
+	st, err := db.Prepare("SELECT n FROM t WHERE a > 10")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		rows, err := st.Query()
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		rows.Close()
+	}
+	st.Close()
+	ev = log.take()
+	if len(ev) != 3 || ev[1].Op != sqldriver.OpStmtQuery || ev[2].Digest != "SELECT N FROM T WHERE A > ?" {
+		t.Fatalf("events=%+v", ev)
+	}
+}
+
+type closingConnector struct {
+	fakeConnector
+	closed bool
+}
+
+func (c *closingConnector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func Test_SQLDriver_ConnectorClose(t *testing.T) {
+	c := &closingConnector{fakeConnector: fakeConnector{fakeDriver{direct: true}}}
+	db := sql.OpenDB(sqldriver.WrapConnector(c, sqlglot.Options{Dialect: sqlglot.MySQL}, nil))
+	if err := db.Close(); err != nil || !c.closed {
+		t.Fatalf("close err=%v closed=%v", err, c.closed)
+	}
+}
+
+func Test_SQLDriver_Errors(t *testing.T) {
+	var log eventLog
+	db := sql.OpenDB(sqldriver.WrapConnector(fakeConnector{fakeDriver{direct: true}}, sqlglot.Options{Dialect: sqlglot.MySQL}, log.hook))
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO FAIL VALUES (1)"); !errors.Is(err, errFake) {
+		t.Fatalf("err=%v", err)
+	}
+	if _, err := db.Prepare("SELECT BROKEN"); !errors.Is(err, errFake) {
+		t.Fatalf("err=%v", err)
+	}
+	ev := log.take()
+	if len(ev) != 2 || !errors.Is(ev[0].Err, errFake) || ev[0].RowsAffected != -1 || ev[0].Digest != "INSERT INTO FAIL VALUES(?)" {
+		t.Fatalf("events=%+v", ev)
+	}
+	if ev[1].Op != sqldriver.OpPrepare || !errors.Is(ev[1].Err, errFake) {
+		t.Fatalf("prepare event=%+v", ev[1])
+	}
+
+	// 事务内的语句同样上报
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec("UPDATE t SET a = 1"); err != nil {
+		t.Fatalf("tx exec: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if ev := log.take(); len(ev) != 1 || ev[0].Digest != "UPDATE T SET A = ?" {
+		t.Fatalf("tx events=%+v", ev)
+	}
+}
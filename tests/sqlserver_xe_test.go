@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run SQLServerXEL_|SPExecuteSQL_|QueryStore_
+
+const xelSample = `<?xml version="1.0" encoding="utf-8"?>
+<events>
+  <event name="sql_batch_completed" package="sqlserver" timestamp="2024-01-02T03:04:05.123Z">
+    <data name="duration"><type name="uint64" package="package0"/><value>1500</value></data>
+    <data name="row_count"><value>1</value></data>
+    <data name="batch_text"><value>SELECT * FROM dbo.orders WHERE id = 42 AND note &lt;&gt; 'x'</value></data>
+    <action name="database_name" package="sqlserver"><value>shop</value></action>
+    <action name="username" package="sqlserver"><value>app</value></action>
+    <action name="client_hostname" package="sqlserver"><value>web1</value></action>
+    <action name="session_id" package="sqlserver"><value>55</value></action>
+  </event>
+  <event name="rpc_completed" package="sqlserver" timestamp="2024-01-02T03:04:06Z">
+    <data name="duration"><value>2500</value></data>
+    <data name="object_name"><value>sp_executesql</value></data>
+    <data name="statement"><value>exec sp_executesql N'SELECT * FROM dbo.orders WHERE id = @p0 AND note &lt;&gt; @p1',N'@p0 int,@p1 nvarchar(4000)',@p0=7,@p1=N'it''s'</value></data>
+  </event>
+  <event name="sql_statement_completed" package="sqlserver" timestamp="2024-01-02T03:04:07Z">
+    <data name="statement"><value>SELECT 1</value></data>
+  </event>
+</events>`
+
+func Test_SQLServerXEL_Events(t *testing.T) {
+	var es []d.LogEntry
+	if err := d.ParseSQLServerXEL(strings.NewReader(xelSample), func(e d.LogEntry) error {
+		es = append(es, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(es) != 2 {
+		t.Fatalf("want 2 entries, got %+v", es)
+	}
+	b := es[0]
+	if b.SQL != "SELECT * FROM dbo.orders WHERE id = 42 AND note <> 'x'" || b.QueryTime != 1500*time.Microsecond ||
+		b.DB != "shop" || b.User != "app" || b.Host != "web1" || b.ThreadID != 55 || b.RowsSent != 1 {
+		t.Fatalf("batch entry=%+v", b)
+	}
+	r := es[1]
+	if r.SQL != "SELECT * FROM dbo.orders WHERE id = @p0 AND note <> @p1" {
+		t.Fatalf("rpc not unwrapped: %q", r.SQL)
+	}
+	res, err := r.Result(d.Options{Dialect: d.SQLServer})
+	if err != nil {
+		t.Fatalf("result: %v", err)
+	}
+	got := map[string]string{}
+	for _, p := range res.Params {
+		if p.BindName != "" {
+			got[p.BindName] = p.BoundValue
+		}
+	}
+	if got["p0"] != "7" || got["p1"] != "N'it''s'" {
+		t.Fatalf("bound values=%v params=%+v", got, res.Params)
+	}
+}
+
+func Test_SQLServerXEL_Aggregate(t *testing.T) {
+	agg := d.NewLogAggregator(d.Options{Dialect: d.SQLServer})
+	if err := d.ParseSQLServerXEL(strings.NewReader(xelSample), agg.Add); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	// 字面量版本与 sp_executesql 展开后的版本归为同一 digest
+	rs := agg.Results()
+	if len(rs) != 1 || rs[0].Count != 2 || rs[0].TotalTime != 4*time.Millisecond {
+		t.Fatalf("results=%+v", rs)
+	}
+}
+
+func Test_SPExecuteSQL_Forms(t *testing.T) {
+	cases := []struct {
+		in   string
+		stmt string
+		vals map[string]string
+	}{
+		{"EXEC sys.sp_executesql N'UPDATE t SET a = @A WHERE b = @b', N'@a int, @b varchar(10)', 1, 'x'",
+			"UPDATE t SET a = @A WHERE b = @b", map[string]string{"a": "1", "b": "'x'"}},
+		{"sp_executesql N'SELECT @n', N'@n int OUTPUT', @n = @out OUTPUT;",
+			"SELECT @n", map[string]string{"n": "@out"}},
+		{"EXECUTE [sp_executesql] N'SELECT 1'", "SELECT 1", map[string]string{}},
+	}
+	for i, c := range cases {
+		stmt, vals, ok := d.UnwrapSPExecuteSQL(c.in)
+		if !ok || stmt != c.stmt || len(vals) != len(c.vals) {
+			t.Fatalf("#%d ok=%v stmt=%q vals=%v", i, ok, stmt, vals)
+		}
+		for k, v := range c.vals {
+			if vals[k] != v {
+				t.Fatalf("#%d %s=%q want %q", i, k, vals[k], v)
+			}
+		}
+	}
+	if _, _, ok := d.UnwrapSPExecuteSQL("EXEC dbo.my_proc @a = 1"); ok {
+		t.Fatalf("plain proc call should not unwrap")
+	}
+}
+
+func Test_QueryStore_SplitText(t *testing.T) {
+	stmt, params := d.SplitQueryStoreText("(@p0 int,@p1 nvarchar(4000))SELECT * FROM t WHERE a = @p0 AND b = @p1")
+	if stmt != "SELECT * FROM t WHERE a = @p0 AND b = @p1" || params != "@p0 int,@p1 nvarchar(4000)" {
+		t.Fatalf("stmt=%q params=%q", stmt, params)
+	}
+	if stmt, params := d.SplitQueryStoreText("(SELECT 1)"); stmt != "(SELECT 1)" || params != "" {
+		t.Fatalf("non-declaration prefix touched: %q %q", stmt, params)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	d "github.com/tensafe/sqlglot-go/internal/sqldigest_antlr"
+)
+
+// go test -v -count=1 . -run Validate_|SplitSQL_
+
+func Test_SplitSQL_Ranges(t *testing.T) {
+	sql := "SELECT 'a;b' FROM t; UPDATE t SET a = 1 /* ; */;\nDELETE FROM t"
+	stmts, err := d.SplitSQL(sql, d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	want := []string{"SELECT", "UPDATE", "DELETE"}
+	if len(stmts) != len(want) {
+		t.Fatalf("want %d statements, got %+v", len(want), stmts)
+	}
+	for i, st := range stmts {
+		if st.Type != want[i] {
+			t.Fatalf("#%d type=%s want %s", i, st.Type, want[i])
+		}
+		if got := strings.TrimSpace(sql[st.StartByte:st.EndByte]); !strings.HasPrefix(got, want[i]) {
+			t.Fatalf("#%d range text %q", i, got)
+		}
+	}
+}
+
+func Test_Validate_OK(t *testing.T) {
+	for _, dl := range []d.Dialect{d.MySQL, d.Postgres, d.SQLServer, d.Oracle} {
+		issues, err := d.Validate("SELECT a, (b + 1) FROM t WHERE c IN (1, 2)", d.Options{Dialect: dl})
+		if err != nil {
+			t.Fatalf("[%s] validate: %v", dl, err)
+		}
+		if len(issues) != 0 {
+			t.Fatalf("[%s] unexpected issues: %v", dl, issues)
+		}
+	}
+}
+
+func Test_Validate_Parens(t *testing.T) {
+	issues, err := d.Validate("SELECT (1 FROM t;\nSELECT 1) FROM t", d.Options{Dialect: d.MySQL})
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("want 2 issues, got %v", issues)
+	}
+	if issues[0].Message != "unclosed '('" || issues[0].Offset != 7 {
+		t.Fatalf("issue[0]=%+v", issues[0])
+	}
+	if issues[1].Message != "unmatched ')'" || issues[1].Line != 2 {
+		t.Fatalf("issue[1]=%+v", issues[1])
+	}
+}
+
+func Test_Validate_UnterminatedString(t *testing.T) {
+	for _, dl := range []d.Dialect{d.MySQL, d.Postgres, d.SQLServer, d.Oracle} {
+		issues, err := d.Validate("SELECT 'abc FROM t", d.Options{Dialect: dl})
+		if err != nil {
+			t.Fatalf("[%s] validate: %v", dl, err)
+		}
+		if len(issues) == 0 {
+			t.Fatalf("[%s] unterminated string not reported", dl)
+		}
+	}
+}